@@ -0,0 +1,94 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"github.com/pkg/errors"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Most times one unit of work is rolled back on purpose before it's let through, so --abort-fraction 1 still ends
+const maxAbortsPerUnit = 10
+
+// Transactions rolled back on purpose with --abort-fraction, and what the retries cost the units of work they were
+// part of. Only units of work that write in a single transaction are aborted; read-only, auto-commit and segmented
+// units of work run as usual.
+type AbortResult struct {
+	// Transactions that could have been aborted, including the ones that were
+	Transactions int64
+	// Transactions rolled back once their statements had run
+	Aborts int64
+	// How long the rollbacks took, in microseconds
+	Rollbacks *hdrhistogram.Histogram
+	// Units of work that succeeded after one or more of their transactions were rolled back, and their latency
+	// including the rolled back attempts
+	Retried          int64
+	RetriedLatencies *hdrhistogram.Histogram
+}
+
+func newAbortResult() *AbortResult {
+	return &AbortResult{
+		Rollbacks:        hdrhistogram.New(0, 60*60*1000000, 3),
+		RetriedLatencies: hdrhistogram.New(0, 60*60*1000000, 3),
+	}
+}
+
+func (r *AbortResult) record(latency time.Duration, outcome uowOutcome) error {
+	r.Transactions += 1 + int64(len(outcome.rollbacks))
+	r.Aborts += int64(len(outcome.rollbacks))
+	for _, rollback := range outcome.rollbacks {
+		if err := r.Rollbacks.RecordValue(rollback.Microseconds()); err != nil {
+			return errors.Wrapf(err, "failed to record rollback latency: %s", rollback)
+		}
+	}
+	if outcome.succeeded && len(outcome.rollbacks) > 0 {
+		r.Retried++
+		if err := r.RetriedLatencies.RecordValue(latency.Microseconds()); err != nil {
+			return errors.Wrapf(err, "failed to record latency: %s", latency)
+		}
+	}
+	return nil
+}
+
+func (r *AbortResult) merge(other *AbortResult) {
+	r.Transactions += other.Transactions
+	r.Aborts += other.Aborts
+	r.Rollbacks.Merge(other.Rollbacks)
+	r.Retried += other.Retried
+	r.RetriedLatencies.Merge(other.RetriedLatencies)
+}
+
+// Whether to roll back the next attempt of a unit of work that has been rolled back the given number of times
+func (w *Worker) abortNext(aborts int) bool {
+	return w.abortFraction > 0 && aborts < maxAbortsPerUnit && rand.Float64() < w.abortFraction
+}
+
+// Rolled back transactions and how long their retries made units of work take; only written with --abort-fraction
+func writeAbortReport(result Result, s *strings.Builder) {
+	aborts := result.Aborts
+	if aborts == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Injected aborts --\n\n"))
+	if aborts.Transactions == 0 {
+		s.WriteString("  No transactions could be aborted; only units of work that write in a single transaction are\n\n")
+		return
+	}
+	s.WriteString(fmt.Sprintf("  Rolled back %d of %d transactions (%.2f%%) once their statements had run\n",
+		aborts.Aborts, aborts.Transactions, float64(aborts.Aborts)/float64(aborts.Transactions)*100))
+	if aborts.Rollbacks.TotalCount() > 0 {
+		unit := result.LatencyUnit
+		s.WriteString(fmt.Sprintf("  Rollback: P50: %s, P99: %s, P99.9: %s, Max: %s\n",
+			unit.Format(float64(aborts.Rollbacks.ValueAtQuantile(50))), unit.Format(float64(aborts.Rollbacks.ValueAtQuantile(99))),
+			unit.Format(float64(aborts.Rollbacks.ValueAtQuantile(99.9))), unit.Format(float64(aborts.Rollbacks.Max()))))
+	}
+	if aborts.Retried > 0 {
+		retried := aborts.RetriedLatencies
+		s.WriteString(fmt.Sprintf("  %d units of work succeeded after being retried; including the retries, they took "+
+			"P50: %s, P99: %s, Max: %s\n", aborts.Retried, result.LatencyUnit.Format(float64(retried.ValueAtQuantile(50))),
+			result.LatencyUnit.Format(float64(retried.ValueAtQuantile(99))), result.LatencyUnit.Format(float64(retried.Max()))))
+	}
+	s.WriteString("\n")
+}
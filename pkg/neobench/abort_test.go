@@ -0,0 +1,58 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAbortedTransactionsAreRolledBackAndRetried(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &statementDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetAbortFraction(0.5)
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 100, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(100), result.Scripts["workertest"].Succeeded)
+	assert.Equal(t, int64(0), result.Scripts["workertest"].Failed)
+
+	rolledBack := int64(0)
+	for _, tx := range driver.transactions {
+		if tx.rolledBack {
+			rolledBack++
+		}
+	}
+	aborts := result.Aborts
+	assert.Equal(t, rolledBack, aborts.Aborts)
+	assert.Equal(t, int64(len(driver.transactions)), aborts.Transactions)
+	assert.InDelta(t, 100, aborts.Aborts, 40)
+	// Each rolled back attempt ran the statement once before the retry
+	assert.InDelta(t, 2000, aborts.RetriedLatencies.Min(), 10)
+	assert.True(t, aborts.Retried > 0 && aborts.Retried < 100)
+
+	total := NewResult("", "")
+	total.Add(result)
+	s := strings.Builder{}
+	writeAbortReport(total, &s)
+	assert.Contains(t, s.String(), "-- Injected aborts --")
+	assert.Contains(t, s.String(), "units of work succeeded after being retried")
+}
+
+func TestAbortsGiveUpAfterTheLimit(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &statementDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetAbortFraction(1)
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 3, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(3), result.Scripts["workertest"].Succeeded)
+	assert.Equal(t, int64(3*maxAbortsPerUnit), result.Aborts.Aborts)
+}
@@ -0,0 +1,109 @@
+package neobench
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Number of checkpoints we need to see for a script before we start judging new ones; the first few
+// checkpoints are noisy and we have nothing to compare them to
+const anomalyMinHistory = 3
+
+// Something notable that happened during the run, like a latency spike or a sudden drop in throughput. These
+// are recorded with a timestamp, so users can correlate them with server logs.
+type Anomaly struct {
+	At         time.Time
+	ScriptName string
+	// Human-readable description of what was odd about this checkpoint
+	Description string
+}
+
+// Looks at progress checkpoints as they come in and flags ones that stand out from the checkpoints before them.
+// A checkpoint is flagged if its p99 is more than LatencyFactor times the median p99 of prior checkpoints, or
+// if its rate is less than half the median rate of prior checkpoints.
+type AnomalyDetector struct {
+	LatencyFactor float64
+	Anomalies     []Anomaly
+
+	// p99 and rate of each prior checkpoint, by script name
+	p99s  map[string][]float64
+	rates map[string][]float64
+}
+
+func NewAnomalyDetector(latencyFactor float64) *AnomalyDetector {
+	return &AnomalyDetector{
+		LatencyFactor: latencyFactor,
+		p99s:          make(map[string][]float64),
+		rates:         make(map[string][]float64),
+	}
+}
+
+// Compare the given checkpoint to the ones seen before it; returns any anomalies found in this checkpoint
+func (d *AnomalyDetector) Observe(at time.Time, checkpoint Result) []Anomaly {
+	var found []Anomaly
+
+	// Scripts that ran before but are missing from this checkpoint had zero throughput
+	scriptNames := make(map[string]bool)
+	for name := range d.rates {
+		scriptNames[name] = true
+	}
+	for name := range checkpoint.Scripts {
+		scriptNames[name] = true
+	}
+
+	for name := range scriptNames {
+		rate, p99 := 0.0, 0.0
+		if script, ok := checkpoint.Scripts[name]; ok {
+			rate = script.Rate
+			if script.Succeeded > 0 {
+				p99 = float64(script.Latencies.ValueAtQuantile(99)) / 1000.0
+			}
+		}
+
+		if priorRates := d.rates[name]; len(priorRates) >= anomalyMinHistory {
+			medianRate := median(priorRates)
+			if rate < medianRate*0.5 {
+				found = append(found, Anomaly{
+					At:         at,
+					ScriptName: name,
+					Description: fmt.Sprintf("throughput dropped to %.3f/s, median before was %.3f/s",
+						rate, medianRate),
+				})
+			}
+		}
+		if priorP99s := d.p99s[name]; p99 > 0 && d.LatencyFactor > 0 && len(priorP99s) >= anomalyMinHistory {
+			medianP99 := median(priorP99s)
+			if medianP99 > 0 && p99 > medianP99*d.LatencyFactor {
+				found = append(found, Anomaly{
+					At:         at,
+					ScriptName: name,
+					Description: fmt.Sprintf("p99 latency was %s, %.1fx the median p99 of %s before",
+						checkpoint.LatencyUnit.Format(p99*1000.0), p99/medianP99, checkpoint.LatencyUnit.Format(medianP99*1000.0)),
+				})
+			}
+		}
+
+		d.rates[name] = append(d.rates[name], rate)
+		if p99 > 0 {
+			d.p99s[name] = append(d.p99s[name], p99)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].ScriptName < found[j].ScriptName
+	})
+	d.Anomalies = append(d.Anomalies, found...)
+	return found
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
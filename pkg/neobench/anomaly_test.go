@@ -0,0 +1,48 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFlagsLatencySpikesAndThroughputDrops(t *testing.T) {
+	d := NewAnomalyDetector(3)
+	start := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+
+	// A few normal checkpoints to establish a baseline
+	for i := 0; i < 4; i++ {
+		found := d.Observe(start.Add(time.Duration(i)*time.Second), checkpointWith("s", 100, 10*time.Millisecond))
+		assert.Empty(t, found)
+	}
+
+	spikeAt := start.Add(10 * time.Second)
+	found := d.Observe(spikeAt, checkpointWith("s", 100, 50*time.Millisecond))
+	assert.Len(t, found, 1)
+	assert.Equal(t, spikeAt, found[0].At)
+	assert.Contains(t, found[0].Description, "p99 latency")
+
+	found = d.Observe(start.Add(11*time.Second), checkpointWith("s", 20, 10*time.Millisecond))
+	assert.Len(t, found, 1)
+	assert.Contains(t, found[0].Description, "throughput dropped")
+
+	// Script missing entirely from a checkpoint means it did no work at all
+	found = d.Observe(start.Add(12*time.Second), NewResult("", ""))
+	assert.Len(t, found, 1)
+	assert.Contains(t, found[0].Description, "throughput dropped")
+
+	assert.Len(t, d.Anomalies, 3)
+}
+
+func checkpointWith(scriptName string, rate float64, latency time.Duration) Result {
+	res := NewWorkerResult(0)
+	for i := 0; i < 10; i++ {
+		if err := res.record(scriptName, "", latency, uowOutcome{succeeded: true}); err != nil {
+			panic(err)
+		}
+	}
+	res.Scripts[scriptName].Rate = rate
+	checkpoint := NewResult("", "")
+	checkpoint.Add(res)
+	return checkpoint
+}
@@ -0,0 +1,150 @@
+package neobench
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lag below this is scheduling noise rather than the database falling behind
+const backlogThreshold = 100 * time.Millisecond
+
+const (
+	// Run every unit of work however late, and report the wait as latency; as without a limit
+	LagPolicyQueue = "queue"
+	// Skip units of work as long as they are too late, which keeps the backlog at the limit
+	LagPolicyDrop = "drop"
+	// Skip everything due once units of work are too late, which clears the backlog
+	LagPolicyShed = "shed"
+)
+
+// How far behind schedule workers may fall in latency mode before they skip units of work, and how. Against a
+// server that can't keep up, the backlog otherwise grows for the whole run, and latencies along with it; skipped
+// units of work are counted as missed instead.
+type ScheduleLimit struct {
+	MaxLag time.Duration
+	Policy string
+}
+
+func ParseLagPolicy(policy string) (string, error) {
+	switch policy {
+	case LagPolicyQueue, LagPolicyDrop, LagPolicyShed:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown schedule lag policy: %s, supported policies are 'queue', 'drop' and 'shed'", policy)
+}
+
+// Follows how far behind schedule the workers are in latency mode. When the database can't keep up, workers start
+// each transaction later than it was due, and the backlog grows silently; this makes it visible in progress reports
+// and the result, along with when it started.
+type BacklogTracker struct {
+	limit   ScheduleLimit
+	samples []BacklogSample
+	// Total rate asked for at the time of each sample, see SetRequestedRate
+	requested func() float64
+}
+
+// How far behind the furthest behind worker was, at one progress report
+type BacklogSample struct {
+	Elapsed time.Duration
+	// Largest lag of any transaction that started since the previous report
+	Lag time.Duration
+	// Total rate asked for at the time of the report; 0 if not known
+	Requested float64
+}
+
+type BacklogResult struct {
+	Samples []BacklogSample
+	// Elapsed time at the first report where workers were behind by backlogThreshold or more, or -1 if they never were
+	BehindAt time.Duration
+	Peak     time.Duration
+	PeakAt   time.Duration
+	// Lag of the last transaction each worker started, the largest of them
+	End time.Duration
+	// Transactions skipped for being too far behind, and the limit that decided it
+	Missed int64
+	Limit  ScheduleLimit
+	// Rate asked for, averaged over the reports, against the rate transactions started at over the whole run; the
+	// requested rate is 0 if not known
+	RequestedRate float64
+	AchievedRate  float64
+	// Progress reports where workers were less than backlogThreshold behind
+	OnSchedule int
+}
+
+func NewBacklogTracker(limit ScheduleLimit) *BacklogTracker {
+	return &BacklogTracker{limit: limit}
+}
+
+// Sets where the rate asked for comes from, which changes over the run with --target-latency or --phase
+func (b *BacklogTracker) SetRequestedRate(requested func() float64) {
+	b.requested = requested
+}
+
+func (b *BacklogTracker) Sample(elapsed time.Duration, checkpoint Result) {
+	sample := BacklogSample{Elapsed: elapsed, Lag: checkpoint.MaxScheduleLag}
+	if b.requested != nil {
+		sample.Requested = b.requested()
+	}
+	b.samples = append(b.samples, sample)
+}
+
+// Summarizes the samples so far; final is the result of the whole run
+func (b *BacklogTracker) Result(final Result) *BacklogResult {
+	result := &BacklogResult{Samples: b.samples, BehindAt: -1, End: final.ScheduleLag, Missed: final.Missed, Limit: b.limit,
+		AchievedRate: final.TotalRate()}
+	requested := 0.0
+	for _, sample := range b.samples {
+		requested += sample.Requested
+		if sample.Lag < backlogThreshold {
+			result.OnSchedule++
+		}
+		if result.BehindAt < 0 && sample.Lag >= backlogThreshold {
+			result.BehindAt = sample.Elapsed
+		}
+		if sample.Lag > result.Peak {
+			result.Peak, result.PeakAt = sample.Lag, sample.Elapsed
+		}
+	}
+	if len(b.samples) > 0 {
+		result.RequestedRate = requested / float64(len(b.samples))
+	}
+	return result
+}
+
+func formatLag(lag time.Duration) string {
+	return fmt.Sprintf("%.3fs", lag.Seconds())
+}
+
+// When and by how much the workers fell behind schedule
+func writeBacklogReport(result Result, s *strings.Builder) {
+	backlog := result.Backlog
+	if backlog == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Schedule --\n\n"))
+	writeEfficiency(backlog, s)
+	if backlog.BehindAt < 0 {
+		s.WriteString(fmt.Sprintf("  Kept up with the rate; transactions started at most %s after they were due\n\n", formatLag(backlog.Peak)))
+		return
+	}
+	s.WriteString(fmt.Sprintf("  Fell behind schedule %s into the run\n", backlog.BehindAt.Round(time.Second)))
+	s.WriteString(fmt.Sprintf("  Peak backlog: %s, %s into the run\n", formatLag(backlog.Peak), backlog.PeakAt.Round(time.Second)))
+	s.WriteString(fmt.Sprintf("  Backlog at the end: %s\n", formatLag(backlog.End)))
+	if backlog.Missed > 0 {
+		s.WriteString(fmt.Sprintf("  Missed: %d transactions were not run, having fallen more than %s behind (--schedule-lag-policy %s)\n",
+			backlog.Missed, backlog.Limit.MaxLag, backlog.Limit.Policy))
+	}
+	s.WriteString(fmt.Sprintf("  Latencies include the time transactions spent waiting in the backlog; the database did not keep up with the rate\n\n"))
+}
+
+// Whether the rate asked for was one the database could take, at a glance
+func writeEfficiency(backlog *BacklogResult, s *strings.Builder) {
+	if backlog.RequestedRate <= 0 {
+		return
+	}
+	s.WriteString(fmt.Sprintf("  Achieved rate: %.2f of %.2f transactions per second requested (%.1f%%)\n", backlog.AchievedRate,
+		backlog.RequestedRate, backlog.AchievedRate/backlog.RequestedRate*100))
+	s.WriteString(fmt.Sprintf("  On schedule: %d of %d progress intervals (%.1f%%), %d transactions missed\n", backlog.OnSchedule,
+		len(backlog.Samples), float64(backlog.OnSchedule)/float64(len(backlog.Samples))*100, backlog.Missed))
+}
@@ -0,0 +1,105 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerRecordsScheduleLag(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	// Each transaction takes three seconds, at one a second
+	driver := &fakeDriver{clock: clock, r: r, minLatency: 3 * time.Second, maxLatency: 3 * time.Second}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+
+	result := w.RunBenchmark(newTestWorkload(r), "", time.Second, Burst{}, 5, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	// The fifth transaction was due at 4s, and started at 12s
+	assert.Equal(t, 8*time.Second, result.ScheduleLag)
+	assert.Equal(t, 8*time.Second, result.MaxScheduleLag)
+}
+
+func TestWorkerSkipsUnitsTooFarBehindSchedule(t *testing.T) {
+	for policy, expected := range map[string]struct {
+		missed int64
+		end    time.Time
+	}{
+		// Transactions due at 0, 1, 4, 7 and 10 seconds run; the ones in between are dropped one at a time
+		LagPolicyDrop: {missed: 6, end: time.Date(2020, 1, 1, 1, 1, 16, 0, time.UTC)},
+		// Transactions due at 0, 1, 7, 8 and 14 seconds run; the backlog is shed whenever it's over the limit
+		LagPolicyShed: {missed: 10, end: time.Date(2020, 1, 1, 1, 1, 18, 0, time.UTC)},
+	} {
+		r := rand.New(rand.NewSource(1337))
+		clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+		driver := &fakeDriver{clock: clock, r: r, minLatency: 3 * time.Second, maxLatency: 3 * time.Second}
+		w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+		w.SetScheduleLimit(ScheduleLimit{MaxLag: 2 * time.Second, Policy: policy})
+
+		result := w.RunBenchmark(newTestWorkload(r), "", time.Second, Burst{}, 5, make(chan struct{}), NewResultRecorder(0))
+
+		assert.NoError(t, result.Error, policy)
+		assert.Equal(t, int64(5), result.Scripts["workertest"].Succeeded, policy)
+		assert.Equal(t, expected.missed, result.Missed, policy)
+		assert.Equal(t, 2*time.Second, result.MaxScheduleLag, policy)
+		assert.Equal(t, expected.end, clock.now(), policy)
+	}
+}
+
+func TestWriteBacklogReport(t *testing.T) {
+	backlog := NewBacklogTracker(ScheduleLimit{})
+	for i, lag := range []time.Duration{time.Millisecond, 2 * time.Second, 5 * time.Second, 4 * time.Second} {
+		backlog.Sample(time.Duration(i+1)*10*time.Second, Result{MaxScheduleLag: lag})
+	}
+	result := Result{ScheduleLag: 3500 * time.Millisecond, Missed: 12}
+	backlog.limit = ScheduleLimit{MaxLag: 5 * time.Second, Policy: LagPolicyDrop}
+	result.Backlog = backlog.Result(result)
+
+	s := strings.Builder{}
+	writeBacklogReport(result, &s)
+	assert.Equal(t, `-- Schedule --
+
+  Fell behind schedule 20s into the run
+  Peak backlog: 5.000s, 30s into the run
+  Backlog at the end: 3.500s
+  Missed: 12 transactions were not run, having fallen more than 5s behind (--schedule-lag-policy drop)
+  Latencies include the time transactions spent waiting in the backlog; the database did not keep up with the rate
+
+`, s.String())
+
+	keptUp := NewBacklogTracker(ScheduleLimit{})
+	keptUp.Sample(10*time.Second, Result{MaxScheduleLag: 2 * time.Millisecond})
+	result = Result{Backlog: keptUp.Result(Result{})}
+	s.Reset()
+	writeBacklogReport(result, &s)
+	assert.Equal(t, "-- Schedule --\n\n  Kept up with the rate; transactions started at most 0.002s after they were due\n\n", s.String())
+}
+
+func TestBacklogReportSaysHowMuchOfTheRequestedRateWasAchieved(t *testing.T) {
+	backlog := NewBacklogTracker(ScheduleLimit{})
+	requested := 100.0
+	backlog.SetRequestedRate(func() float64 { return requested })
+	for i, lag := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 2 * time.Second, 3 * time.Second} {
+		if i == 2 {
+			requested = 200
+		}
+		backlog.Sample(time.Duration(i+1)*10*time.Second, Result{MaxScheduleLag: lag})
+	}
+	final := NewResult("neo4j", "")
+	final.Scripts["writes"] = &ScriptResult{ScriptName: "writes", Rate: 120}
+	final.Missed = 7
+	final.Backlog = backlog.Result(final)
+
+	assert.Equal(t, 150.0, final.Backlog.RequestedRate)
+	assert.Equal(t, 120.0, final.Backlog.AchievedRate)
+	assert.Equal(t, 2, final.Backlog.OnSchedule)
+
+	s := strings.Builder{}
+	writeEfficiency(final.Backlog, &s)
+	assert.Equal(t, `  Achieved rate: 120.00 of 150.00 transactions per second requested (80.0%)
+  On schedule: 2 of 4 progress intervals (50.0%), 7 transactions missed
+`, s.String())
+}
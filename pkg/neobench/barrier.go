@@ -0,0 +1,90 @@
+package neobench
+
+import (
+	"time"
+)
+
+// Holds workers back until all of them are set up, then releases them at once. Setting up, mostly connecting to the
+// database, takes a while with many clients; without this, the run would be measured from before the last client
+// got going, and short runs would come out slower than they are. See Worker#SetReadyBarrier.
+type ReadyBarrier struct {
+	workers  int
+	ready    chan struct{}
+	released chan struct{}
+	// When the run started, set right before released is closed
+	start time.Time
+	// Measuring starts this long after the release, see SetWarmup
+	warmup time.Duration
+
+	// With a ramp, workers take turns connecting, see SetRamp
+	ramp *ConnectPacer
+}
+
+func NewReadyBarrier(workers int) *ReadyBarrier {
+	return &ReadyBarrier{
+		workers:  workers,
+		ready:    make(chan struct{}, workers),
+		released: make(chan struct{}),
+	}
+}
+
+// Spreads connecting out to the given number of workers per second, rather than all at once, so thousands of clients
+// don't storm the database with connections and logins; the workers are still released together
+func (b *ReadyBarrier) SetRamp(perSecond float64) {
+	b.ramp = NewConnectPacer(perSecond)
+}
+
+// Has the workers measure phases and ramp joins from the end of the given warmup after the release, rather than from
+// the release itself; the warmup runs the workload like the rest of the run, it just isn't measured
+func (b *ReadyBarrier) SetWarmup(warmup time.Duration) {
+	b.warmup = warmup
+}
+
+// Waits for the calling worker's turn to connect, with a ramp; false if stopCh closed first
+func (b *ReadyBarrier) AwaitTurn(stopCh <-chan struct{}) bool {
+	if b.ramp == nil {
+		return true
+	}
+	return b.ramp.Await(stopCh)
+}
+
+// Called by each worker once it is set up; waits for the barrier to be released, returning when the run started,
+// or false if stopCh closed first
+func (b *ReadyBarrier) Ready(stopCh <-chan struct{}) (time.Time, bool) {
+	b.ready <- struct{}{}
+	return b.Wait(stopCh)
+}
+
+// Waits for the barrier to be released, without counting as a worker that is ready
+func (b *ReadyBarrier) Wait(stopCh <-chan struct{}) (time.Time, bool) {
+	select {
+	case <-b.released:
+		return b.start, true
+	case <-stopCh:
+		return time.Time{}, false
+	}
+}
+
+// Waits for every worker to be ready; false if stopCh closed first
+func (b *ReadyBarrier) AwaitReady(stopCh <-chan struct{}) bool {
+	for i := 0; i < b.workers; i++ {
+		select {
+		case <-b.ready:
+		case <-stopCh:
+			return false
+		}
+	}
+	return true
+}
+
+// Releases the workers, with the given time as the start of the run
+func (b *ReadyBarrier) Release(start time.Time) {
+	b.start = start
+	close(b.released)
+}
+
+// When measuring starts, once released: the start of the run plus the warmup. Phases of the workload, steps of a
+// --rate schedule and joins of a --ramp all count from here, not from when the workers were set up.
+func (b *ReadyBarrier) MeasureStart() time.Time {
+	return b.start.Add(b.warmup)
+}
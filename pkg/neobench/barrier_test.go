@@ -0,0 +1,130 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestReadyBarrierReleasesWorkersTogether(t *testing.T) {
+	barrier := NewReadyBarrier(3)
+	stopCh := make(chan struct{})
+	starts := make(chan time.Time, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			start, started := barrier.Ready(stopCh)
+			assert.True(t, started)
+			starts <- start
+		}()
+	}
+	assert.True(t, barrier.AwaitReady(stopCh))
+	select {
+	case <-starts:
+		t.Fatal("worker started before the barrier was released")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	barrier.SetWarmup(30 * time.Second)
+	barrier.Release(start)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, start, <-starts)
+	}
+	assert.Equal(t, start.Add(30*time.Second), barrier.MeasureStart())
+}
+
+func TestReadyBarrierGivesUpWhenStopped(t *testing.T) {
+	barrier := NewReadyBarrier(2)
+	stopCh := make(chan struct{})
+	go barrier.Ready(stopCh)
+	close(stopCh)
+	assert.False(t, barrier.AwaitReady(stopCh))
+	_, started := barrier.Wait(stopCh)
+	assert.False(t, started)
+}
+
+func TestWorkerConnectsBeforeItIsReady(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &warmingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	barrier := NewReadyBarrier(1)
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetReadyBarrier(barrier)
+
+	results := make(chan WorkerResult)
+	go func() {
+		results <- w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+	}()
+	assert.True(t, barrier.AwaitReady(nil))
+	assert.Equal(t, []string{"RETURN 1"}, driver.queries)
+
+	// Setting up took a while, which isn't counted
+	clock.sleep(time.Minute)
+	barrier.Release(clock.now())
+	result := <-results
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.Scripts["workertest"].Succeeded)
+	assert.Greater(t, result.Scripts["workertest"].Rate, 100.0)
+}
+
+func TestPhasesCountFromTheReleaseRatherThanFromSetup(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &warmingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Second, maxLatency: time.Second}}
+	first, err := Parse("first", `RETURN 1;`, 1)
+	assert.NoError(t, err)
+	second, err := Parse("second", `RETURN 2;`, 1)
+	assert.NoError(t, err)
+	scripts := NewScripts(first, second)
+	scripts.Phases, err = ParseWeightPhases([]string{"0-5s:first=1", "5s-10s:second=1"}, scripts.Scripts)
+	assert.NoError(t, err)
+	// Clients are made before they connect, so the start they're made with is from before setup
+	wrk := ClientWorkload{Scripts: scripts, Rand: r, Start: clock.now()}
+	barrier := NewReadyBarrier(1)
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetReadyBarrier(barrier)
+
+	results := make(chan WorkerResult)
+	go func() {
+		results <- w.RunBenchmark(wrk, "", 0, Burst{}, 10, make(chan struct{}), NewResultRecorder(0))
+	}()
+	assert.True(t, barrier.AwaitReady(nil))
+	// Counted from before setup, this would put the whole run past both phases
+	clock.sleep(time.Minute)
+	barrier.Release(clock.now())
+	result := <-results
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(5), result.Phases["0-5s"]["first"].Succeeded)
+	assert.Equal(t, int64(5), result.Phases["5s-10s"]["second"].Succeeded)
+	assert.NotContains(t, result.Phases, "")
+}
+
+// Answers the auto-commit statements workers connect with
+type warmingDriver struct {
+	*fakeDriver
+	queries []string
+}
+
+func (d *warmingDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d
+}
+
+func (d *warmingDriver) Run(cypher string, params map[string]interface{}, configurers ...func(*neo4j.TransactionConfig)) (neo4j.Result, error) {
+	d.queries = append(d.queries, cypher)
+	return &fakeResult{}, nil
+}
+
+func TestReadyBarrierRampSpreadsConnecting(t *testing.T) {
+	barrier := NewReadyBarrier(3)
+	barrier.SetRamp(10)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, barrier.ramp.reserve(now))
+	assert.Equal(t, now.Add(100*time.Millisecond), barrier.ramp.reserve(now))
+	assert.Equal(t, now.Add(200*time.Millisecond), barrier.ramp.reserve(now.Add(50*time.Millisecond)))
+	// Turns don't pile up while nobody connects
+	later := now.Add(time.Minute)
+	assert.Equal(t, later, barrier.ramp.reserve(later))
+}
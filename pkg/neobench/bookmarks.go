@@ -0,0 +1,62 @@
+package neobench
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Bookmark modes of --bookmarks, which sets how units of work wait for the writes of those before them; scripts can
+// still opt out with `:bookmark none`
+const (
+	// BookmarkChain and BookmarkNone, as for `:bookmark`, apply to every script; BookmarkShared also makes each
+	// unit of work wait for what every worker has written so far, see SharedBookmarks
+	BookmarkShared = "shared"
+)
+
+func ValidateBookmarkMode(mode string) error {
+	switch mode {
+	case BookmarkChain, BookmarkNone, BookmarkShared:
+		return nil
+	}
+	return fmt.Errorf("invalid --bookmarks '%s', expected %s, %s or %s", mode, BookmarkChain, BookmarkNone, BookmarkShared)
+}
+
+// The latest bookmark of each worker, by database, with --bookmarks shared; each unit of work starts a session from
+// all of them, so it sees every write that completed before it started, as if the workers were one causal session.
+// This costs each unit of work the wait for the server it lands on to catch up with all workers rather than one.
+type SharedBookmarks struct {
+	mut    sync.Mutex
+	latest map[string]map[*workerSessions]string
+}
+
+func NewSharedBookmarks() *SharedBookmarks {
+	return &SharedBookmarks{latest: make(map[string]map[*workerSessions]string)}
+}
+
+// Bookmarks to start a session against the given database from
+func (b *SharedBookmarks) For(databaseName string) []string {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	bookmarks := make([]string, 0, len(b.latest[databaseName]))
+	for _, bookmark := range b.latest[databaseName] {
+		bookmarks = append(bookmarks, bookmark)
+	}
+	sort.Strings(bookmarks)
+	return bookmarks
+}
+
+// Records the bookmark the given worker's session against databaseName ended with, replacing its one before
+func (b *SharedBookmarks) publish(owner *workerSessions, databaseName, bookmark string) {
+	if bookmark == "" {
+		return
+	}
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	byOwner, found := b.latest[databaseName]
+	if !found {
+		byOwner = make(map[*workerSessions]string)
+		b.latest[databaseName] = byOwner
+	}
+	byOwner[owner] = bookmark
+}
@@ -0,0 +1,61 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestSharedBookmarksStartEachUnitOfWorkFromEveryWorkersWrites(t *testing.T) {
+	driver, first := newRoutingWorker()
+	second := &Worker{workerId: 1, driver: driver, now: first.now, sleep: first.sleep}
+	shared := NewSharedBookmarks()
+	first.SetBookmarks(BookmarkShared, shared)
+	second.SetBookmarks(BookmarkShared, shared)
+	_, write := newRoutingScripts(t)
+	firstSessions := newWorkerSessions(driver, "", &acquisitionTimer{now: first.now})
+	secondSessions := newWorkerSessions(driver, "", &acquisitionTimer{now: second.now})
+
+	run := func(w *Worker, sessions *workerSessions) {
+		uow := write.NewUnitOfWork(ScriptContext{Script: write, Rand: rand.New(rand.NewSource(1))})
+		_, err := w.runAttempt(sessions, &uow)
+		assert.NoError(t, err)
+	}
+	run(first, firstSessions)
+	run(second, secondSessions)
+	run(first, firstSessions)
+
+	assert.Equal(t, [][]string{{}, {"bookmark-1"}, {"bookmark-1", "bookmark-2"}}, sessionBookmarks(driver.sessions))
+	assert.Equal(t, []string{"bookmark-2", "bookmark-3"}, shared.For(""))
+}
+
+func TestNoBookmarksRunsEachUnitOfWorkInAFreshSession(t *testing.T) {
+	driver, w := newRoutingWorker()
+	w.SetBookmarks(BookmarkNone, nil)
+	_, write := newRoutingScripts(t)
+	sessions := newWorkerSessions(driver, "", &acquisitionTimer{now: w.now})
+
+	for i := 0; i < 3; i++ {
+		uow := write.NewUnitOfWork(ScriptContext{Script: write, Rand: rand.New(rand.NewSource(1))})
+		_, err := w.runAttempt(sessions, &uow)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, [][]string{nil, nil, nil}, sessionBookmarks(driver.sessions))
+}
+
+func TestValidateBookmarkMode(t *testing.T) {
+	assert.NoError(t, ValidateBookmarkMode(BookmarkChain))
+	assert.NoError(t, ValidateBookmarkMode(BookmarkNone))
+	assert.NoError(t, ValidateBookmarkMode(BookmarkShared))
+	assert.EqualError(t, ValidateBookmarkMode("causal"), "invalid --bookmarks 'causal', expected chain, none or shared")
+}
+
+func sessionBookmarks(sessions []neo4j.SessionConfig) [][]string {
+	out := make([][]string, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, session.Bookmarks)
+	}
+	return out
+}
@@ -0,0 +1,191 @@
+package builtin
+
+import (
+	"fmt"
+	"neobench/pkg/neobench"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
+)
+
+// The composite-like workload spreads a product catalog across a fixed number of shard databases, and queries them
+// through a composite database. Composite databases were introduced in Neo4j 5, and require Enterprise Edition.
+const (
+	CompositeDatabase  = "neobenchcomposite"
+	compositeNumShards = 3
+	// Products per unit of scale, across all shards; product n lives in shard (n % compositeNumShards) + 1
+	compositeProductsPerScale = 10000
+	compositeMaxPrice         = 1000
+	// Bump this whenever the generator changes the data it creates, see DatasetFingerprint
+	compositeLikeGeneratorVersion = 1
+	// Bump this whenever the workload scripts change in ways that affect results, see workloadVersions
+	compositeLikeWorkloadVersion = 1
+
+	// Size of the dataset at scale 1 across all shards, used to translate size targets into a scale; the store
+	// size is a rough estimate
+	CompositeLikeNodesPerScale      = compositeProductsPerScale
+	CompositeLikeStoreBytesPerScale = 2 * 1024 * 1024
+)
+
+// Point lookup of a product by id; the product is only in one of the shards, but the client does not know which,
+// so the query has to ask all of them. This measures the overhead of the composite query fan-out and UNION.
+const CompositeLookup = `
+:set id random(1, greatest(1, int(10000 * $scale)))
+
+:use neobenchcomposite
+CALL {
+  USE neobenchcomposite.shard1 MATCH (p:Product {id: $id}) RETURN p.name AS name, p.price AS price
+  UNION ALL
+  USE neobenchcomposite.shard2 MATCH (p:Product {id: $id}) RETURN p.name AS name, p.price AS price
+  UNION ALL
+  USE neobenchcomposite.shard3 MATCH (p:Product {id: $id}) RETURN p.name AS name, p.price AS price
+}
+RETURN name, price;
+`
+
+// Aggregation over every shard, with the composite database combining the partial counts from each shard
+const CompositeAggregate = `
+:set minPrice random(1, 990)
+
+:use neobenchcomposite
+UNWIND graph.names() AS g
+CALL {
+  USE graph.byName(g)
+  MATCH (p:Product) WHERE p.price >= $minPrice AND p.price < $minPrice + 10
+  RETURN count(p) AS n
+}
+RETURN sum(n) AS products;
+`
+
+// Describes what InitCompositeLike creates at the given scale
+func DescribeCompositeLikeDataset(scale float64) string {
+	return fmt.Sprintf("%d products spread across %d new shard databases, %s to %s, and the composite database %s over them",
+		scaled(compositeProductsPerScale, scale), compositeNumShards, compositeShardDatabase(1), compositeShardDatabase(compositeNumShards), CompositeDatabase)
+}
+
+func InitCompositeLike(scale float64, driver neo4j.Driver, out neobench.Output, version string) error {
+	if !strings.HasPrefix(version, "5") {
+		return fmt.Errorf("the composite-like workload needs composite databases, which require Neo4j 5 Enterprise Edition, found Neo4j %s", version)
+	}
+
+	out.ReportInitProgress(neobench.ProgressReport{
+		Section:      "init",
+		Step:         "create databases",
+		Completeness: 0,
+	})
+	err := createCompositeDatabases(driver)
+	if err != nil {
+		return err
+	}
+
+	for shard := int64(1); shard <= compositeNumShards; shard++ {
+		err = populateCompositeShard(driver, shard, scale, version, out)
+		if err != nil {
+			return errors.Wrapf(err, "failed to populate shard%d", shard)
+		}
+	}
+	return nil
+}
+
+// Databases InitCompositeLike populates; the composite database itself only holds aliases to these
+func CompositeShardDatabases() []string {
+	var databases []string
+	for shard := int64(1); shard <= compositeNumShards; shard++ {
+		databases = append(databases, compositeShardDatabase(shard))
+	}
+	return databases
+}
+
+func compositeShardDatabase(shard int64) string {
+	return fmt.Sprintf("neobenchshard%d", shard)
+}
+
+func createCompositeDatabases(driver neo4j.Driver) error {
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeWrite,
+		DatabaseName: "system",
+	})
+	defer session.Close()
+
+	for shard := int64(1); shard <= compositeNumShards; shard++ {
+		err := runQ(session, fmt.Sprintf("CREATE DATABASE %s IF NOT EXISTS WAIT", compositeShardDatabase(shard)), nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create shard database")
+		}
+	}
+	err := runQ(session, fmt.Sprintf("CREATE COMPOSITE DATABASE %s IF NOT EXISTS WAIT", CompositeDatabase), nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create composite database")
+	}
+	for shard := int64(1); shard <= compositeNumShards; shard++ {
+		err = runQ(session, fmt.Sprintf("CREATE ALIAS %s.shard%d IF NOT EXISTS FOR DATABASE %s",
+			CompositeDatabase, shard, compositeShardDatabase(shard)), nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add shard to composite database")
+		}
+	}
+	return nil
+}
+
+func populateCompositeShard(driver neo4j.Driver, shard int64, scale float64, version string, out neobench.Output) error {
+	numProducts := scaled(compositeProductsPerScale, scale)
+	batchSize := int64(5000)
+	step := fmt.Sprintf("populate shard%d", shard)
+
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeWrite,
+		DatabaseName: compositeShardDatabase(shard),
+	})
+	defer session.Close()
+
+	meta, done, err := beginInit(session, DatasetFingerprint{
+		Dataset:          fmt.Sprintf("composite-like/shard%d", shard),
+		GeneratorVersion: compositeLikeGeneratorVersion,
+		Scale:            scale,
+	}, out)
+	if err != nil || done {
+		return err
+	}
+
+	err = ensureSchema(session, []schemaEntry{
+		{Label: "Product", Property: "id", Unique: true},
+		{Label: "Product", Property: "price", Unique: false},
+	}, version)
+	if err != nil {
+		return err
+	}
+
+	result, err := session.Run("MATCH (p:Product) RETURN coalesce(max(p.id), 0) AS n", nil)
+	if err != nil {
+		return err
+	}
+	record, err := result.Single()
+	if err != nil {
+		return err
+	}
+	highestExisting := record.GetByIndex(0).(int64)
+
+	for start := highestExisting + 1; start <= numProducts; start += batchSize {
+		end := min(numProducts, start+batchSize-1)
+		err = runQ(session, `UNWIND range($start, $end) AS id
+WITH id WHERE id % $numShards = $shard - 1
+CREATE (:Product {id: id, name: "product-" + id, price: 1 + (id * 7919) % $maxPrice})
+`, map[string]interface{}{
+			"start":     start,
+			"end":       end,
+			"numShards": int64(compositeNumShards),
+			"shard":     shard,
+			"maxPrice":  int64(compositeMaxPrice),
+		})
+		if err != nil {
+			return err
+		}
+		out.ReportInitProgress(neobench.ProgressReport{
+			Section:      "init",
+			Step:         step,
+			Completeness: float64(end) / float64(numProducts),
+		})
+	}
+	return completeInit(session, meta)
+}
@@ -0,0 +1,23 @@
+package builtin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"neobench/pkg/neobench"
+	"testing"
+)
+
+func TestCompositeLikeTargetsCompositeDatabase(t *testing.T) {
+	vars := map[string]interface{}{"scale": int64(1)}
+	for name, src := range map[string]string{"lookup": CompositeLookup, "aggregate": CompositeAggregate} {
+		script, err := neobench.Parse("builtin:composite-like/"+name, src, 1)
+		assert.NoError(t, err)
+		uow, err := script.Eval(neobench.ScriptContext{
+			Vars: vars,
+			Rand: rand.New(rand.NewSource(1337)),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, uow.Statements, 1)
+		assert.Equal(t, CompositeDatabase, uow.Statements[0].Database)
+	}
+}
@@ -0,0 +1,265 @@
+package builtin
+
+import (
+	"fmt"
+	"neobench/pkg/neobench"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// Identifies a generated dataset. This is recorded in a meta node in the database while the dataset is populated,
+// so later runs of --init can tell if the database already holds the dataset they'd generate.
+type DatasetFingerprint struct {
+	// Name of the builtin dataset, ex: ldbc-like
+	Dataset string
+	// Bumped whenever a generator changes the data it creates, so datasets from older generators are not reused
+	GeneratorVersion int64
+	Scale            float64
+	// Seed the dataset was generated with; resumed populations continue with the seed they started with
+	Seed int64
+	// Knobs set to something other than their defaults, see describeOverriddenKnobs
+	Knobs string
+}
+
+// Version of the workload scripts that run against each builtin dataset. Unlike the generator version, this doesn't
+// stop a dataset from being used; it's recorded so runs can warn that results from different workload versions
+// may not be comparable.
+var workloadVersions = map[string]int64{
+	"tpcb-like":      tpcbLikeWorkloadVersion,
+	"ldbc-like":      ldbcLikeWorkloadVersion,
+	"composite-like": compositeLikeWorkloadVersion,
+}
+
+// Generator version of each builtin dataset
+var generatorVersions = map[string]int64{
+	"tpcb-like":      tpcbLikeGeneratorVersion,
+	"ldbc-like":      ldbcLikeGeneratorVersion,
+	"composite-like": compositeLikeGeneratorVersion,
+}
+
+// Fingerprint plus population progress, as recorded in the meta node
+type datasetMeta struct {
+	DatasetFingerprint
+	// Version of the workloads current when the dataset was populated, see workloadVersions
+	WorkloadVersion int64
+	Completed       bool
+	// Generator-specific progress marker, used to resume population
+	LastAction int64
+}
+
+type initAction int
+
+const (
+	initPopulate initAction = iota
+	initResume
+	initSkip
+)
+
+// Decides what --init should do, given what the database already holds; returns an error with guidance on how
+// to proceed if the existing dataset can't be used or resumed
+func planInit(existing *datasetMeta, want DatasetFingerprint) (initAction, error) {
+	if existing == nil {
+		return initPopulate, nil
+	}
+	state := "partially populated"
+	if existing.Completed {
+		state = "fully populated"
+	}
+	if existing.GeneratorVersion != want.GeneratorVersion {
+		return 0, fmt.Errorf("target database contains a %s %s dataset from generator version %d, but this version "+
+			"of neobench generates version %d. Please clear the database to re-populate it, or use a neobench release "+
+			"with generator version %d", state, existing.Dataset, existing.GeneratorVersion, want.GeneratorVersion, existing.GeneratorVersion)
+	}
+	if existing.Scale != want.Scale {
+		action := "run the workload"
+		if !existing.Completed {
+			action = "resume population"
+		}
+		return 0, fmt.Errorf("target database contains a %s %s dataset with --scale %g. Please either clear the "+
+			"database or re-run with --scale set to %g to %s", state, existing.Dataset, existing.Scale, existing.Scale, action)
+	}
+	if existing.Knobs != want.Knobs {
+		action := "run the workload"
+		if !existing.Completed {
+			action = "resume population"
+		}
+		return 0, fmt.Errorf("target database contains a %s %s dataset populated with %s, but this run has %s. "+
+			"Please either clear the database or re-run with the same -D options to %s", state, existing.Dataset,
+			knobsAsOptions(existing.Knobs), knobsAsOptions(want.Knobs), action)
+	}
+	if existing.Completed {
+		return initSkip, nil
+	}
+	return initResume, nil
+}
+
+// Reads the meta node for the given dataset, nil if there is none
+func readDatasetMeta(session neo4j.Session, dataset string) (*datasetMeta, error) {
+	// Meta nodes from before datasets were fingerprinted were only ever written by ldbc-like
+	if dataset == "ldbc-like" {
+		if err := runQ(session, "MATCH (meta:__NEOBENCH_META__) WHERE meta.dataset IS NULL SET meta.dataset = 'ldbc-like'", nil); err != nil {
+			return nil, err
+		}
+	}
+	return queryDatasetMeta(session, dataset)
+}
+
+// Like readDatasetMeta, but without migrating older meta nodes, so it can run in read-only sessions
+func queryDatasetMeta(session neo4j.Session, dataset string) (*datasetMeta, error) {
+	result, err := session.Run(`MATCH (meta:__NEOBENCH_META__ {dataset: $dataset})
+RETURN coalesce(meta.completed, false), coalesce(meta.lastAction, 0), coalesce(meta.seed, 0), coalesce(meta.scale, 0),
+       coalesce(meta.generatorVersion, 1), coalesce(meta.workloadVersion, 1),
+       coalesce(meta.knobs, '')`, map[string]interface{}{"dataset": dataset})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Next() {
+		return nil, result.Err()
+	}
+	values := result.Record().Values
+	return &datasetMeta{
+		DatasetFingerprint: DatasetFingerprint{
+			Dataset:          dataset,
+			GeneratorVersion: values[4].(int64),
+			Scale:            asFloat(values[3]),
+			Seed:             values[2].(int64),
+			Knobs:            values[6].(string),
+		},
+		WorkloadVersion: values[5].(int64),
+		Completed:       values[0].(bool),
+		LastAction:      values[1].(int64),
+	}, nil
+}
+
+// Scale used to be an integer, so older meta nodes have it stored as one
+func asFloat(v interface{}) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+// Number of entities to create for the given scale; this must match the `greatest(1, int(perScale * $scale))`
+// expressions the workload scripts use to pick random ids, so scripts only draw ids that exist
+func scaled(perScale int64, scale float64) int64 {
+	n := int64(float64(perScale) * scale)
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+func writeDatasetMeta(session neo4j.Session, meta datasetMeta) error {
+	return runQ(session, `MERGE (meta:__NEOBENCH_META__ {dataset: $dataset})
+SET meta += {completed: $completed, lastAction: $lastAction, seed: $seed, scale: $scale, generatorVersion: $generatorVersion,
+             workloadVersion: $workloadVersion, knobs: $knobs}`,
+		datasetMetaParams(meta))
+}
+
+func datasetMetaParams(meta datasetMeta) map[string]interface{} {
+	return map[string]interface{}{
+		"dataset":          meta.Dataset,
+		"completed":        meta.Completed,
+		"lastAction":       meta.LastAction,
+		"seed":             meta.Seed,
+		"scale":            meta.Scale,
+		"generatorVersion": meta.GeneratorVersion,
+		"workloadVersion":  meta.WorkloadVersion,
+		"knobs":            meta.Knobs,
+	}
+}
+
+// Checks the meta node against the dataset we want, and reports if population can be skipped. Otherwise, the
+// returned meta is what population should continue from; the seed in it is the one to use.
+func beginInit(session neo4j.Session, want DatasetFingerprint, out neobench.Output) (datasetMeta, bool, error) {
+	existing, err := readDatasetMeta(session, want.Dataset)
+	if err != nil {
+		return datasetMeta{}, false, err
+	}
+	action, err := planInit(existing, want)
+	if err != nil {
+		return datasetMeta{}, false, err
+	}
+	switch action {
+	case initSkip:
+		out.ReportInitProgress(neobench.ProgressReport{
+			Section:      "init",
+			Step:         "dataset already populated",
+			Completeness: 1,
+		})
+		return *existing, true, nil
+	case initResume:
+		out.ReportInitProgress(neobench.ProgressReport{
+			Section:      "init",
+			Step:         "resuming partially populated dataset",
+			Completeness: 0,
+		})
+		return *existing, false, nil
+	default:
+		meta := datasetMeta{DatasetFingerprint: want, WorkloadVersion: workloadVersions[baseDataset(want.Dataset)]}
+		return meta, false, writeDatasetMeta(session, meta)
+	}
+}
+
+func completeInit(session neo4j.Session, meta datasetMeta) error {
+	meta.Completed = true
+	return writeDatasetMeta(session, meta)
+}
+
+// Datasets spread across databases have one meta node per database, named like composite-like/shard1
+func baseDataset(dataset string) string {
+	return strings.SplitN(dataset, "/", 2)[0]
+}
+
+// Checks the given builtin dataset in the database against the versions this neobench generates and runs, and
+// describes anything that may make results incomparable with runs against a freshly populated dataset
+func DatasetVersionWarnings(driver neo4j.Driver, dbName, dataset string) ([]string, error) {
+	type location struct{ database, metaDataset string }
+	locations := []location{{dbName, dataset}}
+	if dataset == "composite-like" {
+		locations = nil
+		for shard := int64(1); shard <= compositeNumShards; shard++ {
+			locations = append(locations, location{compositeShardDatabase(shard), fmt.Sprintf("composite-like/shard%d", shard)})
+		}
+	}
+
+	var warnings []string
+	for _, loc := range locations {
+		session := driver.NewSession(neo4j.SessionConfig{
+			AccessMode:   neo4j.AccessModeRead,
+			DatabaseName: loc.database,
+		})
+		meta, err := queryDatasetMeta(session, loc.metaDataset)
+		session.Close()
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			// Not populated by neobench, or by a version from before datasets were fingerprinted; nothing to compare
+			continue
+		}
+		warnings = append(warnings, versionWarnings(*meta)...)
+	}
+	return warnings, nil
+}
+
+func versionWarnings(meta datasetMeta) []string {
+	var warnings []string
+	dataset := baseDataset(meta.Dataset)
+	if !meta.Completed {
+		warnings = append(warnings, fmt.Sprintf("the %s dataset is only partially populated, "+
+			"run with --init to finish populating it", meta.Dataset))
+	}
+	if current := generatorVersions[dataset]; meta.GeneratorVersion != current {
+		warnings = append(warnings, fmt.Sprintf("the %s dataset was created by generator version %d, but this "+
+			"version of neobench generates version %d; results may not be comparable to runs against a dataset "+
+			"populated by this version", meta.Dataset, meta.GeneratorVersion, current))
+	}
+	if current := workloadVersions[dataset]; meta.WorkloadVersion != current {
+		warnings = append(warnings, fmt.Sprintf("the %s dataset was populated for version %d of its workloads, "+
+			"but this is version %d of them; results may not be comparable to runs from the version the dataset "+
+			"was populated for", meta.Dataset, meta.WorkloadVersion, current))
+	}
+	return warnings
+}
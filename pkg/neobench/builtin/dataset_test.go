@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPlanInit(t *testing.T) {
+	want := DatasetFingerprint{Dataset: "tpcb-like", GeneratorVersion: 2, Scale: 10, Seed: 1}
+	existing := func(completed bool, version int64, scale float64) *datasetMeta {
+		return &datasetMeta{
+			DatasetFingerprint: DatasetFingerprint{Dataset: "tpcb-like", GeneratorVersion: version, Scale: scale, Seed: 7},
+			Completed:          completed,
+		}
+	}
+
+	withKnobs := func(meta *datasetMeta, knobs string) *datasetMeta {
+		meta.Knobs = knobs
+		return meta
+	}
+
+	tests := map[string]struct {
+		existing    *datasetMeta
+		expect      initAction
+		expectError string
+	}{
+		"empty database":       {existing: nil, expect: initPopulate},
+		"completed, same":      {existing: existing(true, 2, 10), expect: initSkip},
+		"partial, same":        {existing: existing(false, 2, 10), expect: initResume},
+		"completed, scale":     {existing: existing(true, 2, 5), expectError: "fully populated tpcb-like dataset with --scale 5. Please either clear the database or re-run with --scale set to 5 to run the workload"},
+		"partial, scale":       {existing: existing(false, 2, 5), expectError: "partially populated tpcb-like dataset with --scale 5. Please either clear the database or re-run with --scale set to 5 to resume population"},
+		"old generator":        {existing: existing(true, 1, 10), expectError: "from generator version 1, but this version of neobench generates version 2"},
+		"old generator, scale": {existing: existing(false, 1, 5), expectError: "from generator version 1"},
+		"completed, knobs":     {existing: withKnobs(existing(true, 2, 10), "tellers_per_branch=5"), expectError: "fully populated tpcb-like dataset populated with -D tellers_per_branch=5, but this run has no -D options for dataset knobs"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			action, err := planInit(tc.existing, want)
+			if tc.expectError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, action)
+		})
+	}
+}
+
+func TestVersionWarnings(t *testing.T) {
+	meta := func(completed bool, generatorVersion, workloadVersion int64) datasetMeta {
+		return datasetMeta{
+			DatasetFingerprint: DatasetFingerprint{Dataset: "tpcb-like", GeneratorVersion: generatorVersion, Scale: 1, Seed: 1},
+			WorkloadVersion:    workloadVersion,
+			Completed:          completed,
+		}
+	}
+
+	assert.Empty(t, versionWarnings(meta(true, tpcbLikeGeneratorVersion, tpcbLikeWorkloadVersion)))
+
+	warnings := versionWarnings(meta(true, tpcbLikeGeneratorVersion-1, tpcbLikeWorkloadVersion))
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "created by generator version 1")
+
+	warnings = versionWarnings(meta(true, tpcbLikeGeneratorVersion, tpcbLikeWorkloadVersion+1))
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "populated for version 2 of its workloads")
+
+	warnings = versionWarnings(meta(false, tpcbLikeGeneratorVersion, tpcbLikeWorkloadVersion))
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "only partially populated")
+}
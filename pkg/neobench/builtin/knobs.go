@@ -0,0 +1,95 @@
+package builtin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A constant of a builtin dataset that can be overridden with -D, for adjusting the shape of the data without
+// forking neobench. Both the generator and the workload scripts read knobs, the scripts as variables, so they agree
+// on things like how many accounts there are to pick from.
+type Knob struct {
+	Name        string
+	Default     float64
+	Description string
+	// Counts and ids must be whole numbers; these are passed to scripts as integers
+	Integer bool
+}
+
+var TPCBLikeKnobs = []Knob{
+	{Name: "accounts_per_branch", Default: 100000, Integer: true, Description: "accounts created per branch, there is one branch per scale"},
+	{Name: "tellers_per_branch", Default: 10, Integer: true, Description: "tellers created per branch"},
+}
+
+var LDBCLikeKnobs = []Knob{
+	{Name: "ldbc_tags", Default: float64(ldbcNumTags), Integer: true, Description: "number of tags that people are interested in and messages are tagged with"},
+	{Name: "ldbc_message_length_skew", Default: 10, Description: "exponential parameter of the message length distribution, higher values give more short messages"},
+}
+
+// Knob values, by name
+type Knobs map[string]float64
+
+// Picks the values of the given knobs from -D variables, using the defaults for knobs that aren't set
+func ResolveKnobs(knobs []Knob, variables map[string]interface{}) (Knobs, error) {
+	out := make(Knobs, len(knobs))
+	for _, knob := range knobs {
+		value := knob.Default
+		switch v := variables[knob.Name].(type) {
+		case nil:
+		case int64:
+			value = float64(v)
+		case float64:
+			value = v
+		default:
+			return nil, fmt.Errorf("-D %s must be a number, got %v", knob.Name, v)
+		}
+		if value <= 0 {
+			return nil, fmt.Errorf("-D %s must be greater than zero, got %g", knob.Name, value)
+		}
+		if knob.Integer && value != float64(int64(value)) {
+			return nil, fmt.Errorf("-D %s must be a whole number, got %g", knob.Name, value)
+		}
+		out[knob.Name] = value
+	}
+	return out, nil
+}
+
+// Adds the default values of the given knobs to variables that don't set them, so builtin scripts can use them
+func DefineKnobDefaults(knobs []Knob, variables map[string]interface{}) {
+	for _, knob := range knobs {
+		if _, defined := variables[knob.Name]; defined {
+			continue
+		}
+		if knob.Integer {
+			variables[knob.Name] = int64(knob.Default)
+		} else {
+			variables[knob.Name] = knob.Default
+		}
+	}
+}
+
+func (k Knobs) int(name string) int64 {
+	return int64(k[name])
+}
+
+// The knobs that differ from their defaults, as a stable string for the dataset fingerprint, ex: "tellers_per_branch=5";
+// datasets populated with the defaults get an empty string, which is also what datasets from before knobs existed have
+func describeOverriddenKnobs(knobs []Knob, values Knobs) string {
+	var overridden []string
+	for _, knob := range knobs {
+		if value, found := values[knob.Name]; found && value != knob.Default {
+			overridden = append(overridden, fmt.Sprintf("%s=%g", knob.Name, value))
+		}
+	}
+	sort.Strings(overridden)
+	return strings.Join(overridden, ",")
+}
+
+// Formats a knob fingerprint as -D options, for error messages
+func knobsAsOptions(knobs string) string {
+	if knobs == "" {
+		return "no -D options for dataset knobs"
+	}
+	return "-D " + strings.ReplaceAll(knobs, ",", " -D ")
+}
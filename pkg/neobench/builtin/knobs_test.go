@@ -0,0 +1,23 @@
+package builtin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolveKnobs(t *testing.T) {
+	knobs, err := ResolveKnobs(LDBCLikeKnobs, map[string]interface{}{"ldbc_tags": int64(100), "scale": int64(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, Knobs{"ldbc_tags": 100, "ldbc_message_length_skew": 10}, knobs)
+	assert.Equal(t, "ldbc_tags=100", describeOverriddenKnobs(LDBCLikeKnobs, knobs))
+
+	knobs, err = ResolveKnobs(LDBCLikeKnobs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", describeOverriddenKnobs(LDBCLikeKnobs, knobs))
+
+	_, err = ResolveKnobs(LDBCLikeKnobs, map[string]interface{}{"ldbc_tags": 10.5})
+	assert.EqualError(t, err, "-D ldbc_tags must be a whole number, got 10.5")
+
+	_, err = ResolveKnobs(LDBCLikeKnobs, map[string]interface{}{"ldbc_message_length_skew": int64(0)})
+	assert.EqualError(t, err, "-D ldbc_message_length_skew must be greater than zero, got 0")
+}
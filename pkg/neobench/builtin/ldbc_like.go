@@ -12,7 +12,7 @@ import (
 )
 
 const LDBCIC2 = `
-:set personId random(1, 9892 * $scale)
+:set personId random(1, greatest(1, int(9892 * $scale)))
 
 MATCH (:Person {id: $personId})-[:KNOWS]-(friend),
       (friend)<-[:HAS_CREATOR]-(message)
@@ -28,8 +28,8 @@ LIMIT 20
 `
 
 const LDBCIC6 = `
-:set personId random(1, 9892 * $scale)
-:set tagId random(1, 16080)
+:set personId random(1, greatest(1, int(9892 * $scale)))
+:set tagId random(1, $ldbc_tags)
 
 MATCH (knownTag:Tag {name: "Tag-" + $tagId})
 MATCH (person:Person {id:$personId})-[:KNOWS*1..2]-(friend)
@@ -47,7 +47,7 @@ LIMIT 10;
 `
 
 const LDBCIC10 = `
-:set personId random(1, 9892 * $scale)
+:set personId random(1, greatest(1, int(9892 * $scale)))
 :set birthdayMonth random(1, 13)
 
 MATCH (person:Person {id:$personId})-[:KNOWS*2..2]-(friend),
@@ -74,8 +74,8 @@ LIMIT 10;
 `
 
 const LDBCIC14 = `
-:set personOne random(1, 9892 * $scale)
-:set personTwo random(1, 9892 * $scale)
+:set personOne random(1, greatest(1, int(9892 * $scale)))
+:set personTwo random(1, greatest(1, int(9892 * $scale)))
 
 MATCH path = allShortestPaths((person1:Person {id:$personOne})-[:KNOWS*0..]-(person2:Person {id:$personTwo}))
 RETURN
@@ -91,6 +91,20 @@ ORDER BY weight DESC;
 
 const ldbcStartYear = 2002
 
+// Bump this whenever the generator changes the data it creates, or how it tracks progress, see DatasetFingerprint
+const ldbcLikeGeneratorVersion = 5
+
+// Bump this whenever the ldbc-like scripts change in ways that affect results, see workloadVersions
+const ldbcLikeWorkloadVersion = 1
+
+// Approximate size of the dataset at scale 1, used to translate size targets into a scale. Most nodes are the
+// forums, posts and comments created while simulating activity, so these are estimates from the action weights
+// in InitLDBCLike rather than exact numbers.
+const (
+	LDBCLikeNodesPerScale      = 3300000
+	LDBCLikeStoreBytesPerScale = 1536 * 1024 * 1024
+)
+
 const ldbcNumContinents = int64(6)
 const ldbcNumCountries = int64(111)
 const ldbcNumCities = int64(1343)
@@ -98,9 +112,16 @@ const ldbcNumCities = int64(1343)
 const ldbcNumUniversities = int64(6380)
 const ldbcNumCompanies = int64(1575)
 
+// Default for the ldbc_tags knob
 const ldbcNumTags = int64(16080)
 const ldbcNumTagClasses = int64(71)
 
+// Describes what InitLDBCLike creates at the given scale
+func DescribeLDBCLikeDataset(scale float64) string {
+	return fmt.Sprintf("%d people, with places, organisations and tags, and the friendships, forums and messages "+
+		"from ten years of simulated activity", neobench.LDBCPeople(scale))
+}
+
 // This populates a dataset that follows the LDBC SNB schema and attempts to achieve superficially similar
 // distributions. It is *not* LDBC, but it is intended as a proxy for it. Ideally, if you have a setup that
 // works well with this benchmark, it'd also do well in the real LDBC benchmark.
@@ -113,8 +134,13 @@ const ldbcNumTagClasses = int64(71)
 //
 // - Was populated "naturally", with data fragmented and inserted piecewise the same a real dataset is
 // - Has deterministic identifiers, allowing the load gen portion to generate random load without lookups in the db
-func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neobench.Output, version string) error {
-	numPeople := 9892 * scale
+//
+// Simulated actions are written in waves of independent batches, with up to `concurrency` batches of a wave
+// written at the same time from separate sessions; see ldbcWavePlanner.
+func InitLDBCLike(scale float64, seed int64, knobs Knobs, concurrency int, dbName string, driver neo4j.Driver, out neobench.Output, version string) error {
+	numPeople := neobench.LDBCPeople(scale)
+	numTags := knobs.int("ldbc_tags")
+	messageLengthSkew := knobs["ldbc_message_length_skew"]
 
 	now := time.Date(ldbcStartYear, 1, 1, 0, 0, 0, 0, time.UTC)
 	daysOfActivity := 365 * 10
@@ -125,39 +151,22 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 	})
 	defer session.Close()
 
-	// Make sure we're working against a db with no ldbc data in it; we are not (yet!) reentrant
-	result, err := session.Run("MATCH (meta:__NEOBENCH_META__) RETURN meta.completed as completed, meta.lastAction as lastAction, meta.seed as seed, meta.scale as scale", nil)
-	if err != nil {
+	meta, done, err := beginInit(session, DatasetFingerprint{
+		Dataset:          "ldbc-like",
+		GeneratorVersion: ldbcLikeGeneratorVersion,
+		Scale:            scale,
+		Seed:             seed,
+		Knobs:            describeOverriddenKnobs(LDBCLikeKnobs, knobs),
+	}, out)
+	if err != nil || done {
 		return err
 	}
-	preExistingActions := 0
-	if result.Next() == true {
-		existingCompleted := result.Record().Values[0].(bool)
-		preExistingActions = int(result.Record().Values[1].(int64))
-		existingSeed := result.Record().Values[2].(int64)
-		existingScale := result.Record().Values[3].(int64)
-
-		if existingScale == scale && existingCompleted {
-			out.ReportInitProgress(neobench.ProgressReport{
-				Section:      "init",
-				Step:         "dataset already populated",
-				Completeness: 1,
-			})
-			return nil
-		}
-
-		// The target database already has a partially populated dataset; if scale is the same, we can pick up where
-		// the prior job stopped
-		if existingScale != scale {
-			return fmt.Errorf("target database contains a partially populated dataset with --scale %d. Please either clear the database or re-run with --scale set to %d to resume population", existingScale, existingScale)
-		}
-
-		seed = existingSeed
-	}
+	// If we're resuming, we need to continue with the seed population started with, to generate the same data
+	seed = meta.Seed
 
-	if preExistingActions == 0 {
+	if meta.LastAction == 0 {
 		initRandom := rand.New(rand.NewSource(seed + 1337))
-		if err := ldbcInitStaticData(initRandom, session, out, version); err != nil {
+		if err := ldbcInitStaticData(initRandom, numTags, session, out, version); err != nil {
 			return err
 		}
 	}
@@ -179,29 +188,20 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 	// We populate the dynamic data by simulating user activity; this is meant to try to
 	// bring the initial dataset to the same state it'd be in in a real world social network
 
-	signupsPerDay := float64(numPeople) / float64(daysOfActivity)
-
 	// These structures help shape the simulated activity such that activity skews to be between friends
-	friends := &choiceMatrix32{
-		entries: make([][]int32, numPeople),
-		random:  random,
-	}
-	memberships := &choiceMatrix32{
-		entries: make([][]int32, numPeople),
-		random:  random,
-	}
+	attachment := newLDBCAttachment(random, numPeople)
+	friends, memberships := attachment.friends, attachment.memberships
 
 	// Helps us pick recent posts to act on
 	messageCountsPerForum := make([]int, 1, 32*1024)
 
-	signupCumulator := 0.0
 	peopleCreated := 0
 	forumsCreated := 0
 	messagesCreated := 0
 	actionsTaken := 0
 
 	// Message ids encode their forum and an incrementing sequence; this lets us pick recent messages in a
-	// given forum without coordinating with the state of the database
+	// given forum without coordinating with the state of the database, see neobench.LDBCMessageId
 	newMessageId := func(forumId int) int64 {
 		for len(messageCountsPerForum) <= forumId {
 			messageCountsPerForum = append(messageCountsPerForum, 0)
@@ -210,17 +210,13 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 		messageCountsPerForum[forumId] += 1
 		messagesCreated += 1
 
-		msgId := ldbcMessageId{
-			forumId:      forumId,
-			messageIndex: nextMessageIndex,
-		}.serialize()
-		return msgId
+		return neobench.LDBCMessageId(int64(forumId), int64(nextMessageIndex))
 	}
 
 	actionCreatePost := func(actor int, now time.Time) map[string]interface{} {
 		forumId := memberships.pickExponential(actor)
 		messageId := newMessageId(forumId)
-		content := randLDBCMessageContent(random)
+		content := randLDBCMessageContent(random, messageLengthSkew)
 		return map[string]interface{}{
 			"type":        "p",
 			"personId":    actor,
@@ -233,7 +229,7 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 			"length":      len(content),
 			"language":    "uz",
 			"imageFile":   "photo1374389534791.jpg",
-			"tags":        randLDBCTags(random, ldbcNumTags),
+			"tags":        randLDBCTags(random, numTags),
 		}
 	}
 
@@ -243,14 +239,11 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 		if lastMessage < 1 {
 			return nil
 		}
-		parentIndex, _ := neobench.ExponentialRand(random, 1, int64(lastMessage), 10.0)
-		parentId := ldbcMessageId{
-			forumId:      forumId,
-			messageIndex: int(parentIndex),
-		}.serialize()
+		parentIndex, _ := neobench.ExponentialRand(random, 0, int64(lastMessage)-1, 10.0)
+		parentId := neobench.LDBCMessageId(int64(forumId), parentIndex)
 		messageId := newMessageId(forumId)
 
-		content := randLDBCMessageContent(random)
+		content := randLDBCMessageContent(random, messageLengthSkew)
 		return map[string]interface{}{
 			"type":        "c",
 			"personId":    actor,
@@ -261,7 +254,7 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 			"locationIP":  "127.0.0.1",
 			"content":     content,
 			"length":      len(content),
-			"tags":        randLDBCTags(random, ldbcNumTags),
+			"tags":        randLDBCTags(random, numTags),
 		}
 	}
 
@@ -271,11 +264,8 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 		if lastMessage < 1 {
 			return nil
 		}
-		messageIndex, _ := neobench.ExponentialRand(random, 1, int64(lastMessage), 10.0)
-		messageId := ldbcMessageId{
-			forumId:      forumId,
-			messageIndex: int(messageIndex),
-		}.serialize()
+		messageIndex, _ := neobench.ExponentialRand(random, 0, int64(lastMessage)-1, 10.0)
+		messageId := neobench.LDBCMessageId(int64(forumId), messageIndex)
 
 		return map[string]interface{}{
 			"type":      "l",
@@ -287,10 +277,9 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 
 	actionAddFriend := func(actor int, now time.Time) map[string]interface{} {
 		friendId := 0
-		// TODO: Weight this to favor friend-of-friends
 		tries := 0
 		for {
-			friendId = random.Intn(peopleCreated) + 1
+			friendId = attachment.pickFriend(actor, peopleCreated)
 			// Stop searching if we find another :Person that we're not yet friends with
 			if friendId != actor && !friends.contains(actor, friendId) {
 				break
@@ -301,8 +290,7 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 				return nil
 			}
 		}
-		friends.insert(actor, friendId)
-		friends.insert(friendId, actor)
+		attachment.befriend(actor, friendId)
 		return map[string]interface{}{
 			"type":     "af",
 			"personId": actor,
@@ -316,10 +304,9 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 			return nil
 		}
 		forumId := 0
-		// TODO: Weight this to favor friends' forums
 		tries := 0
 		for {
-			forumId = random.Intn(forumsCreated) + 1
+			forumId = attachment.pickForum(actor, forumsCreated)
 			if !memberships.contains(actor, forumId) {
 				break
 			}
@@ -329,7 +316,7 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 				return nil
 			}
 		}
-		memberships.insert(actor, forumId)
+		attachment.join(actor, forumId)
 		return map[string]interface{}{
 			"type":     "jf",
 			"personId": actor,
@@ -344,15 +331,14 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 
 		messageCountsPerForum = append(messageCountsPerForum, 0)
 
-		memberships.insert(actor, forumId)
-		memberships.insert(actor, forumId)
+		attachment.join(actor, forumId)
 		return map[string]interface{}{
 			"type":     "cf",
 			"personId": actor,
 			"forumId":  forumId,
 			"now":      now,
 			"title":    fmt.Sprintf("Forum %d created by Person-%d", forumId, actor),
-			"tags":     randLDBCTags(random, ldbcNumTags),
+			"tags":     randLDBCTags(random, numTags),
 		}
 	}
 
@@ -379,192 +365,22 @@ func InitLDBCLike(scale, seed int64, dbName string, driver neo4j.Driver, out neo
 	// and to try to excercise similar choke points.
 	actionsPerDayPerPerson := 0.4
 	estTotalActions := int64(daysOfActivity)*int64(float64(numPeople)*actionsPerDayPerPerson/2) + numPeople
-	actions := make([]map[string]interface{}, 0, 1024)
-
-	performedActions := 0
-	performActions := func() error {
-		// All this stuff about performedActions and preExistingActions is about resumability; being able to start
-		// populating again after population fails for some reason; we store in the db what the last action inserted
-		// was, and fast-forward through stuff here if need be
-		performedActions += len(actions)
 
-		if preExistingActions >= performedActions {
+	planner := newLDBCWavePlanner(ldbcBatchesPerWave, ldbcBatchSize)
+	waveNo := int64(0)
+	performActions := func(wave [][]map[string]interface{}) error {
+		// Resuming population means fast-forwarding through the waves recorded as done in the meta node; the
+		// action stream and how it's split into waves is deterministic, so we end up where we left off
+		defer func() { waveNo += 1 }()
+		if waveNo < meta.LastAction {
 			return nil
 		}
-
-		// This is the primary query we use for inserts; it's engineered to allow large batches to be sent over
-		// and committed in bulk. Each action *type* has a CALL block, and inside each such block we go through
-		// all the actions, filter by the action type the current CALL block knows about, and performs it.
-		// There are issues with this approach - most notably that the actions lose their ordering within the batch,
-		// as they end up executed by type rather than sequence.
-		// We might look at improving this query to try to work around that. Also it's still a major bottleneck for
-		// dataset population, even SF001 takes several minutes to do.
-		_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-			q := `
-MERGE (meta:__NEOBENCH_META__)
-SET meta = {completed: false, lastAction: $lastAction, seed: $seed, scale: $scale }
-WITH 1 AS row LIMIT 1
-
-UNWIND $actions as action
-
-// Do CreatePerson action
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'cp' THEN [1] ELSE [] END AS i
-
-  CREATE (p:Person {
-    id: action.personNo,
-    creationDate: action.creationDate,
-    firstName: action.firstName,
-    lastName: action.lastName,
-    gender: action.gender,
-    birthday: action.birthday,
-    email: action.personNo + "@persons.com",
-    speaks: action.speaks,
-    browserUsed: action.browserUsed,
-    locationIP: action.locationIP
-  })
-  WITH action, p 
-  MATCH (city:City {name: action.city})
-  CREATE (p)-[:IS_LOCATED_IN]->(city)
-  
-  WITH action, p LIMIT 1
-  UNWIND action.interests as interest 
-  MATCH (t:Tag {name: interest})
-  CREATE (p)-[:HAS_INTEREST]->(t)
-  
-  WITH action, p LIMIT 1
-  UNWIND action.companies as company
-  MATCH (c:Company {name: company.name})
-  CREATE (p)-[:WORK_AT {workFrom: company.workFrom}]->(c)
-  
-  WITH action, p LIMIT 1
-  UNWIND action.universities as university
-  MATCH (u:University {name: university.name})
-  CREATE (p)-[:STUDY_AT {classYear: university.classYear}]->(u)
-
-  RETURN COUNT(*) AS createPersonCount
-}
-
-// Do AddFriend actions
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'af' THEN [1] ELSE [] END AS i
-
-  MATCH (p:Person {id: action.personId}), (f:Person {id: action.friendId})
-  MERGE (p)<-[:KNOWS {creationDate: action.now}]-(f)
-  RETURN COUNT(*) AS addFriendCount
-}
-
-// Do CreateForum actions
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'cf' THEN [1] ELSE [] END AS i
-
-  MATCH (p:Person {id: action.personId})
-  MERGE (f:Forum {id: action.forumId})
-  ON CREATE SET f.title = action.title, f.creationDate = action.now
-  MERGE (f)-[:HAS_MODERATOR]->(p)
-  MERGE (f)-[:HAS_MEMBER {joinDate: action.now}]->(p)
-  WITH action, f
-  UNWIND action.tags as tag 
-  MATCH (t:Tag {name:tag})
-  MERGE (f)-[:HAS_TAG]->(t)
-  RETURN COUNT(*) AS createForumCount
-}
-
-// Do Post actions
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'p' THEN [1] ELSE [] END AS i
-
-  MATCH (p:Person {id: action.personId}), (f:Forum {id: action.forumId})
-  CREATE (m:Message:Post {
-    id: action.messageId,
-    creationDate: action.now,
-    browserUsed: action.browserUsed,
-    locationIP: action.locationIP,
-    content: action.content,
-    length: action.length,
-    language: action.language,
-    imageFile: action.imageFile
-  })
-  CREATE (f)-[:CONTAINER_OF]->(m)
-  CREATE (m)-[:HAS_CREATOR]->(p)
-  WITH action, m
-  UNWIND action.tags as tag 
-  MATCH (t:Tag {name:tag})
-  CREATE (m)-[:HAS_TAG]->(t)
-
-  RETURN COUNT(*) AS createPostCount
-}
-
-// Do JoinForum actions
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'jf' THEN [1] ELSE [] END AS i
-
-  MATCH (p:Person {id: action.personId}), (f:Forum {id: action.forumId})
-  MERGE (p)<-[:HAS_MEMBER {joinDate: action.now}]-(f)
-
-  RETURN COUNT(*) AS joinForumCount
-}
-
-// Do Comment Action
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'c' THEN [1] ELSE [] END AS i
-
-  MATCH (p:Person {id: action.personId}), (parent:Message {id: action.parentId})
-  CREATE (c:Message:Comment {
-    id: action.messageId,
-    creationDate: action.now,
-    browserUsed: action.browserUsed,
-    locationIP: action.locationIP,
-    content: action.content,
-    length: action.length
-  })
-  CREATE (c)-[:REPLY_OF]->(parent)
-  CREATE (c)-[:HAS_CREATOR]->(p)
-  WITH action, c
-  UNWIND action.tags as tag 
-  MATCH (t:Tag {name:tag})
-  CREATE (c)-[:HAS_TAG]->(t)
-
-  RETURN COUNT(*) AS commentCount
-}
-
-// Do Like action
-CALL {
-  WITH action
-  UNWIND CASE action.type WHEN 'l' THEN [1] ELSE [] END AS i
-
-  MATCH (p:Person {id: action.personId}), (msg:Message {id: action.messageId})
-  CREATE (p)-[:LIKES {creationDate: action.now}]->(msg)
-
-  RETURN COUNT(*) AS likeCount
-}
-
-RETURN COUNT(*) AS i
-`
-
-			res, err := tx.Run(q, map[string]interface{}{
-				"actions":    actions,
-				"lastAction": performedActions,
-				"seed":       seed,
-				"scale":      scale,
-			})
-			if err != nil {
-				return nil, errors.Wrap(err, "..")
-			}
-			_, err = res.Consume() // Need to call this to avoid bug in driver
-			if err != nil {
-				return nil, errors.Wrap(err, "..")
-			}
-			return nil, nil
-		})
-		if err != nil {
-			return err
+		meta, err = writeLDBCWave(driver, dbName, concurrency, meta, wave)
+		return err
+	}
+	addAction := func(action map[string]interface{}) error {
+		if wave := planner.add(action); wave != nil {
+			return performActions(wave)
 		}
 		return nil
 	}
@@ -575,10 +391,11 @@ RETURN COUNT(*) AS i
 		now = now.AddDate(0, 0, 1)
 		realDelta := int(time.Now().Sub(startTime).Seconds())
 		fmt.Printf("%s (day %d, %d people, %d actions taken in %d seconds)\n", now, dayNo, peopleCreated, actionsTaken, realDelta)
-		signupCumulator += signupsPerDay
-		for signupCumulator > 1 {
-			signupCumulator -= 1
-			actions = append(actions, createLDBCPerson(random, peopleCreated+1, now, ldbcNumCities, ldbcNumUniversities, ldbcNumCompanies, ldbcNumTags))
+		// People sign up at an even pace, so that all numPeople have joined by the last day
+		for int64(peopleCreated) < numPeople*int64(dayNo+1)/int64(daysOfActivity) {
+			if err := addAction(createLDBCPerson(random, peopleCreated+1, now, ldbcNumCities, ldbcNumUniversities, ldbcNumCompanies, numTags)); err != nil {
+				return err
+			}
 			peopleCreated += 1
 		}
 
@@ -587,7 +404,9 @@ RETURN COUNT(*) AS i
 		}
 
 		if forumsCreated < 5 {
-			actions = append(actions, actionCreateForum(1, now))
+			if err := addAction(actionCreateForum(1, now)); err != nil {
+				return err
+			}
 		}
 
 		actionsToday := max(1, int64(float64(peopleCreated)*actionsPerDayPerPerson))
@@ -603,37 +422,67 @@ RETURN COUNT(*) AS i
 			if action == nil {
 				continue
 			}
-			actions = append(actions, action)
-			actionsTaken += 1
-			if len(actions) > 1000 {
-				if err := performActions(); err != nil {
-					return err
-				}
-				actions = actions[:0]
+			if err := addAction(action); err != nil {
+				return err
 			}
+			actionsTaken += 1
 			out.ReportInitProgress(neobench.ProgressReport{
 				Section:      "init",
 				Step:         "simulating dynamic content creation",
 				Completeness: float64(actionsTaken) / float64(estTotalActions),
 			})
 		}
-
-		if len(actions) > 1000 {
-			if err := performActions(); err != nil {
-				return err
-			}
-			actions = actions[:0]
-		}
 	}
 
-	if len(actions) > 0 {
-		if err := performActions(); err != nil {
+	if wave := planner.flush(); wave != nil {
+		if err := performActions(wave); err != nil {
 			return err
 		}
 	}
 
-	return runQ(session, `MERGE (meta:__NEOBENCH_META__)
-SET meta.completed = true`, nil)
+	// Which messages exist depends on how the simulation played out, so record it for ldbc_random_message
+	messageCounts := make([]int64, len(messageCountsPerForum))
+	for forumId, count := range messageCountsPerForum {
+		messageCounts[forumId] = int64(count)
+	}
+	err = runQ(session, "MATCH (meta:__NEOBENCH_META__ {dataset: $dataset}) SET meta.messageCounts = $messageCounts",
+		map[string]interface{}{"dataset": meta.Dataset, "messageCounts": messageCounts})
+	if err != nil {
+		return errors.Wrapf(err, "failed to record message counts")
+	}
+
+	return completeInit(session, meta)
+}
+
+// Reads which messages exist in the ldbc-like dataset in the given database, as recorded by InitLDBCLike
+func ReadLDBCIds(driver neo4j.Driver, dbName string) (*neobench.LDBCIds, error) {
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	result, err := session.Run(`MATCH (meta:__NEOBENCH_META__ {dataset: "ldbc-like"})
+RETURN coalesce(meta.completed, false), meta.scale, meta.messageCounts`, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read ldbc-like dataset meta node")
+	}
+	if !result.Next() {
+		if result.Err() != nil {
+			return nil, result.Err()
+		}
+		return nil, fmt.Errorf("database %s has no ldbc-like dataset, please populate it with --builtin ldbc-like --init", dbName)
+	}
+	values := result.Record().Values
+	if !values[0].(bool) || values[2] == nil {
+		return nil, fmt.Errorf("the ldbc-like dataset in database %s is not fully populated by this version of neobench, "+
+			"please re-run with --builtin ldbc-like --init", dbName)
+	}
+	rawCounts := values[2].([]interface{})
+	messageCounts := make([]int64, len(rawCounts))
+	for i, count := range rawCounts {
+		messageCounts[i] = count.(int64)
+	}
+	return neobench.NewLDBCIds(asFloat(values[1]), messageCounts), nil
 }
 
 type choiceMatrix32 struct {
@@ -645,6 +494,12 @@ func (c *choiceMatrix32) insert(key, val int) {
 	c.entries[key] = append(c.entries[key], int32(val))
 }
 
+// Uniform random choice among entries for given key; the key must have entries
+func (c *choiceMatrix32) pickUniform(key int) int {
+	entries := c.entries[key]
+	return int(entries[c.random.Intn(len(entries))])
+}
+
 // Exponential random distribution choice among entries for given key
 func (c *choiceMatrix32) pickExponential(key int) int {
 	entries := c.entries[key]
@@ -681,15 +536,6 @@ func runQ(session neo4j.Session, query string, params map[string]interface{}) er
 	return err
 }
 
-type ldbcMessageId struct {
-	forumId      int
-	messageIndex int
-}
-
-func (m ldbcMessageId) serialize() int64 {
-	return int64(m.forumId)<<(8*4) | int64(m.messageIndex)
-}
-
 // List of 3-tuples; each tuple is (continent, country, city); countries are distributed exponentially
 // across continents, cities exponentially across countries.
 func generateLDBCPlaces(random *rand.Rand, numContinents, numCountries, numCities int64) (out [][]string) {
@@ -750,6 +596,7 @@ func generateLDBCUniversities(random *rand.Rand, numCities, numUniversities int6
 // out of band and have no parent
 func generateLDBCTagClasses(random *rand.Rand, numTagClasses int64) (out [][]string) {
 	for i := int64(1); i < numTagClasses; i++ {
+		// Parents always have lower numbers, so the class hierarchy is a tree rooted in TagClass-0
 		out = append(out, []string{fmt.Sprintf("TagClass-%d", i), randLDBCTagClass(random, i)})
 	}
 	return
@@ -804,7 +651,7 @@ func createLDBCPerson(random *rand.Rand, personNo int, creationDate time.Time, n
 	}
 }
 
-func ldbcInitStaticData(random *rand.Rand, session neo4j.Session, out neobench.Output, version string) error {
+func ldbcInitStaticData(random *rand.Rand, numTags int64, session neo4j.Session, out neobench.Output, version string) error {
 	// Schema
 	out.ReportInitProgress(neobench.ProgressReport{
 		Section:      "init",
@@ -839,7 +686,7 @@ func ldbcInitStaticData(random *rand.Rand, session neo4j.Session, out neobench.O
 WITH place[0] as continentName, place[1] as countryName, place[2] as cityName
 MERGE (continent:Continent {name: continentName, uri: "https://continents.com/" + continentName})
 MERGE (country:Country {name: countryName, uri: "https://countries.com/" + countryName})
-MERGE (country)-[:IS_PART_OF]-(continent)
+MERGE (country)-[:IS_PART_OF]->(continent)
 MERGE (city:City {name: cityName, uri: "https://cities.com/" + cityName})
 MERGE (city)-[:IS_PART_OF]->(country)
 `, map[string]interface{}{
@@ -865,10 +712,10 @@ MERGE (uni)-[:IS_LOCATED_IN]->(city)
 	err = runQ(session, `UNWIND $companies AS row
 WITH row[0] as countryName, row[1] as corpName
 MATCH (country:Country {name: countryName})
-MERGE (corp:Country {name: corpName, url: "https://corp.com/" + corpName})
+MERGE (corp:Company {name: corpName, url: "https://corp.com/" + corpName})
 MERGE (corp)-[:IS_LOCATED_IN]->(country)
 `, map[string]interface{}{
-		"companies": generateLDBCCompanies(random, ldbcNumCities, ldbcNumCompanies),
+		"companies": generateLDBCCompanies(random, ldbcNumCountries, ldbcNumCompanies),
 	})
 	if err != nil {
 		return err
@@ -899,7 +746,7 @@ WITH c, className
 MATCH (p:TagClass {name: className})
 MERGE (c)-[:HAS_TYPE]->(p)
 `, map[string]interface{}{
-		"tags": generateLDBCTags(random, ldbcNumTags, ldbcNumTagClasses),
+		"tags": generateLDBCTags(random, numTags, ldbcNumTagClasses),
 	})
 	if err != nil {
 		return err
@@ -909,34 +756,34 @@ MERGE (c)-[:HAS_TYPE]->(p)
 
 // Return 2-tuples of (tagname, tagclass)
 func generateLDBCTags(random *rand.Rand, numTags, numTagClasses int64) (out [][]string) {
-	for i := int64(1); i < numTags; i++ {
+	for i := int64(1); i <= numTags; i++ {
 		out = append(out, []string{fmt.Sprintf("Tag-%d", i), randLDBCTagClass(random, numTagClasses)})
 	}
 	return
 }
 
 func randLDBCContinent(r *rand.Rand, numContinents int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numContinents, 5.0)
+	i, _ := neobench.ExponentialRand(r, 0, numContinents-1, 5.0)
 	return fmt.Sprintf("Continent-%d", i)
 }
 
 func randLDBCCountry(r *rand.Rand, numCountries int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numCountries, 5.0)
+	i, _ := neobench.ExponentialRand(r, 0, numCountries-1, 5.0)
 	return fmt.Sprintf("Country-%d", i)
 }
 
 func randLDBCCity(r *rand.Rand, numCities int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numCities, 5.0)
+	i, _ := neobench.ExponentialRand(r, 0, numCities-1, 5.0)
 	return fmt.Sprintf("City-%d", i)
 }
 
 func randLDBCTagClass(r *rand.Rand, numTagClasses int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numTagClasses, 5.0)
+	i, _ := neobench.ExponentialRand(r, 0, numTagClasses-1, 5.0)
 	return fmt.Sprintf("TagClass-%d", i)
 }
 
 func randLDBCTag(r *rand.Rand, numTags int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numTags, 5.0)
+	i, _ := neobench.ExponentialRand(r, 1, numTags, 5.0)
 	return fmt.Sprintf("Tag-%d", i)
 }
 
@@ -950,17 +797,17 @@ func randLDBCTags(r *rand.Rand, numTags int64) []string {
 }
 
 func randLDBCUniversity(r *rand.Rand, numUniversities int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numUniversities, 5.0)
+	i, _ := neobench.ExponentialRand(r, 0, numUniversities-1, 5.0)
 	return fmt.Sprintf("University-%d", i)
 }
 
 func randLDBCCompany(r *rand.Rand, numCompanies int64) string {
-	i, _ := neobench.ExponentialRand(r, 0, numCompanies, 5.0)
+	i, _ := neobench.ExponentialRand(r, 0, numCompanies-1, 5.0)
 	return fmt.Sprintf("Company-%d", i)
 }
 
 func randLDBCPersonId(r *rand.Rand, numPeople int64) int {
-	i, _ := neobench.ExponentialRand(r, 1, numPeople+1, 5.0)
+	i, _ := neobench.ExponentialRand(r, 1, numPeople, 5.0)
 	return int(i)
 }
 
@@ -982,12 +829,12 @@ func randBrowser(random *rand.Rand) string {
 	return "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/79.0.3945.88 Safari/537.36"
 }
 
-func randLDBCMessageContent(random *rand.Rand) string {
+func randLDBCMessageContent(random *rand.Rand, lengthSkew float64) string {
 	// Sampling an LDBC store said there's a strong bias towards very short - 2-7 characters - message contents, so
 	// we do exponential spread from there; sampling 1000 entries we saw no string longer than 183 chars, which is
 	// pretty weird TBH, it's like they are tweets rather than comments and posts? Making ours a bit larger.
 	lorem := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur. Excepteur sint occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim id est laborum."
-	msgLen, _ := neobench.ExponentialRand(random, 2, int64(len(lorem)), 10.0)
+	msgLen, _ := neobench.ExponentialRand(random, 2, int64(len(lorem)), lengthSkew)
 	// This will give skewed results once you start looking at compression..
 	return lorem[:msgLen]
 }
@@ -1121,3 +968,164 @@ func min(a, b int64) int64 {
 	}
 	return b
 }
+
+// Applies a batch of simulated actions. This is engineered to allow large batches to be sent over and committed
+// in bulk. Each action *type* has a CALL block, and inside each such block we go through all the actions, filter
+// by the action type the current CALL block knows about, and performs it. There are issues with this approach -
+// most notably that the actions lose their ordering within the batch, as they end up executed by type rather than
+// sequence; the blocks are ordered so that things are created before the blocks that refer to them.
+const ldbcBatchQuery = `
+CREATE (:__NEOBENCH_BATCH__ {dataset: $dataset, wave: $wave, batch: $batch})
+WITH 1 AS row LIMIT 1
+
+UNWIND $actions as action
+
+// Do CreatePerson action
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'cp' THEN [1] ELSE [] END AS i
+
+  CREATE (p:Person {
+    id: action.personNo,
+    creationDate: action.creationDate,
+    firstName: action.firstName,
+    lastName: action.lastName,
+    gender: action.gender,
+    birthday: action.birthday,
+    email: action.personNo + "@persons.com",
+    speaks: action.speaks,
+    browserUsed: action.browserUsed,
+    locationIP: action.locationIP
+  })
+  WITH action, p
+  MATCH (city:City {name: action.city})
+  CREATE (p)-[:IS_LOCATED_IN]->(city)
+
+  // Each list gets its own block, so people with no interests still get their companies and universities
+  WITH action, p
+  CALL {
+    WITH action, p
+    UNWIND action.interests as interest
+    MATCH (t:Tag {name: interest})
+    CREATE (p)-[:HAS_INTEREST]->(t)
+    RETURN COUNT(*) AS interestCount
+  }
+  CALL {
+    WITH action, p
+    UNWIND action.companies as company
+    MATCH (c:Company {name: company.name})
+    CREATE (p)-[:WORK_AT {workFrom: company.workFrom}]->(c)
+    RETURN COUNT(*) AS companyCount
+  }
+  CALL {
+    WITH action, p
+    UNWIND action.universities as university
+    MATCH (u:University {name: university.name})
+    CREATE (p)-[:STUDY_AT {classYear: university.classYear}]->(u)
+    RETURN COUNT(*) AS universityCount
+  }
+
+  RETURN COUNT(*) AS createPersonCount
+}
+
+// Do AddFriend actions
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'af' THEN [1] ELSE [] END AS i
+
+  MATCH (p:Person {id: action.personId}), (f:Person {id: action.friendId})
+  MERGE (p)<-[:KNOWS {creationDate: action.now}]-(f)
+  RETURN COUNT(*) AS addFriendCount
+}
+
+// Do CreateForum actions
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'cf' THEN [1] ELSE [] END AS i
+
+  MATCH (p:Person {id: action.personId})
+  MERGE (f:Forum {id: action.forumId})
+  ON CREATE SET f.title = action.title, f.creationDate = action.now
+  MERGE (f)-[:HAS_MODERATOR]->(p)
+  MERGE (f)-[:HAS_MEMBER {joinDate: action.now}]->(p)
+  WITH action, f
+  UNWIND action.tags as tag 
+  MATCH (t:Tag {name:tag})
+  MERGE (f)-[:HAS_TAG]->(t)
+  RETURN COUNT(*) AS createForumCount
+}
+
+// Do Post actions
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'p' THEN [1] ELSE [] END AS i
+
+  MATCH (p:Person {id: action.personId}), (f:Forum {id: action.forumId})
+  CREATE (m:Message:Post {
+    id: action.messageId,
+    creationDate: action.now,
+    browserUsed: action.browserUsed,
+    locationIP: action.locationIP,
+    content: action.content,
+    length: action.length,
+    language: action.language,
+    imageFile: action.imageFile
+  })
+  CREATE (f)-[:CONTAINER_OF]->(m)
+  CREATE (m)-[:HAS_CREATOR]->(p)
+  WITH action, m
+  UNWIND action.tags as tag 
+  MATCH (t:Tag {name:tag})
+  CREATE (m)-[:HAS_TAG]->(t)
+
+  RETURN COUNT(*) AS createPostCount
+}
+
+// Do JoinForum actions
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'jf' THEN [1] ELSE [] END AS i
+
+  MATCH (p:Person {id: action.personId}), (f:Forum {id: action.forumId})
+  MERGE (p)<-[:HAS_MEMBER {joinDate: action.now}]-(f)
+
+  RETURN COUNT(*) AS joinForumCount
+}
+
+// Do Comment Action
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'c' THEN [1] ELSE [] END AS i
+
+  MATCH (p:Person {id: action.personId}), (parent:Message {id: action.parentId})
+  CREATE (c:Message:Comment {
+    id: action.messageId,
+    creationDate: action.now,
+    browserUsed: action.browserUsed,
+    locationIP: action.locationIP,
+    content: action.content,
+    length: action.length
+  })
+  CREATE (c)-[:REPLY_OF]->(parent)
+  CREATE (c)-[:HAS_CREATOR]->(p)
+  WITH action, c
+  UNWIND action.tags as tag 
+  MATCH (t:Tag {name:tag})
+  CREATE (c)-[:HAS_TAG]->(t)
+
+  RETURN COUNT(*) AS commentCount
+}
+
+// Do Like action
+CALL {
+  WITH action
+  UNWIND CASE action.type WHEN 'l' THEN [1] ELSE [] END AS i
+
+  MATCH (p:Person {id: action.personId}), (msg:Message {id: action.messageId})
+  CREATE (p)-[:LIKES {creationDate: action.now}]->(msg)
+
+  RETURN COUNT(*) AS likeCount
+}
+
+RETURN COUNT(*) AS i
+`
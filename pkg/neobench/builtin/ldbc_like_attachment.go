@@ -0,0 +1,86 @@
+package builtin
+
+import "math/rand"
+
+// Shares of friend picks that go to friends-of-friends and to people in proportion to how many friends they
+// already have; the rest are uniform, which is how newcomers get their first friends
+const (
+	ldbcFriendOfFriendShare = 0.45
+	ldbcPopularPersonShare  = 0.35
+)
+
+// Shares of forum picks that go to forums friends are in and to forums in proportion to their member count; the
+// rest are uniform, which is how new forums get their first members
+const (
+	ldbcFriendsForumShare = 0.5
+	ldbcPopularForumShare = 0.3
+)
+
+// Decides who befriends whom and who joins which forum in the ldbc-like simulation. Real social networks have
+// power-law degree distributions: friendships cluster among friends-of-friends, and a few people and forums
+// attract a large share of the connections. Uniform picks give a flat distribution without those hotspots, so
+// this biases picks using preferential attachment instead.
+type ldbcAttachment struct {
+	random      *rand.Rand
+	friends     *choiceMatrix32
+	memberships *choiceMatrix32
+	// Each friendship adds both people here, and each membership adds its forum, so a uniform pick from these
+	// picks people in proportion to their number of friends, and forums in proportion to their number of members
+	friendshipEnds []int32
+	membershipEnds []int32
+}
+
+func newLDBCAttachment(random *rand.Rand, numPeople int64) *ldbcAttachment {
+	// Indexed by person id, which starts at 1
+	return &ldbcAttachment{
+		random: random,
+		friends: &choiceMatrix32{
+			entries: make([][]int32, numPeople+1),
+			random:  random,
+		},
+		memberships: &choiceMatrix32{
+			entries: make([][]int32, numPeople+1),
+			random:  random,
+		},
+	}
+}
+
+// Proposes someone for the actor to befriend; this may be the actor or someone they already know, callers need
+// to check for that
+func (a *ldbcAttachment) pickFriend(actor, peopleCreated int) int {
+	r := a.random.Float64()
+	if r < ldbcFriendOfFriendShare && a.friends.count(actor) > 0 {
+		via := a.friends.pickUniform(actor)
+		return a.friends.pickUniform(via)
+	}
+	if r < ldbcFriendOfFriendShare+ldbcPopularPersonShare && len(a.friendshipEnds) > 0 {
+		return int(a.friendshipEnds[a.random.Intn(len(a.friendshipEnds))])
+	}
+	return a.random.Intn(peopleCreated) + 1
+}
+
+// Proposes a forum for the actor to join; this may be one they're already a member of, callers need to check for that
+func (a *ldbcAttachment) pickForum(actor, forumsCreated int) int {
+	r := a.random.Float64()
+	if r < ldbcFriendsForumShare && a.friends.count(actor) > 0 {
+		via := a.friends.pickUniform(actor)
+		if a.memberships.count(via) > 0 {
+			return a.memberships.pickUniform(via)
+		}
+	}
+	if r < ldbcFriendsForumShare+ldbcPopularForumShare && len(a.membershipEnds) > 0 {
+		return int(a.membershipEnds[a.random.Intn(len(a.membershipEnds))])
+	}
+	return a.random.Intn(forumsCreated) + 1
+}
+
+func (a *ldbcAttachment) befriend(person, friend int) {
+	a.friends.insert(person, friend)
+	a.friends.insert(friend, person)
+	a.friendshipEnds = append(a.friendshipEnds, int32(person), int32(friend))
+}
+
+func (a *ldbcAttachment) join(person, forum int) {
+	a.memberships.insert(person, forum)
+	a.membershipEnds = append(a.membershipEnds, int32(forum))
+}
@@ -0,0 +1,64 @@
+package builtin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestLDBCAttachmentSkewsFriendships(t *testing.T) {
+	numPeople, numFriendships := 2000, 20000
+	attachment := newLDBCAttachment(rand.New(rand.NewSource(1337)), int64(numPeople))
+
+	for i := 0; i < numFriendships; {
+		actor := attachment.random.Intn(numPeople) + 1
+		friend := attachment.pickFriend(actor, numPeople)
+		if friend == actor || attachment.friends.contains(actor, friend) {
+			continue
+		}
+		attachment.befriend(actor, friend)
+		i++
+	}
+
+	// With uniform picks, nobody would get much past twice the mean of 20 friends; preferential attachment
+	// makes hubs with several times that
+	maxFriends := 0
+	for person := 1; person <= numPeople; person++ {
+		if attachment.friends.count(person) > maxFriends {
+			maxFriends = attachment.friends.count(person)
+		}
+	}
+	meanFriends := 2 * numFriendships / numPeople
+	assert.Greater(t, maxFriends, 4*meanFriends)
+}
+
+func TestLDBCAttachmentSkewsForumMembership(t *testing.T) {
+	numPeople, numForums, numJoins := 2000, 200, 20000
+	attachment := newLDBCAttachment(rand.New(rand.NewSource(1337)), int64(numPeople))
+	for person := 2; person <= numPeople; person++ {
+		attachment.befriend(person-1, person)
+	}
+
+	for i := 0; i < numJoins; {
+		actor := attachment.random.Intn(numPeople) + 1
+		forum := attachment.pickForum(actor, numForums)
+		if attachment.memberships.contains(actor, forum) {
+			continue
+		}
+		attachment.join(actor, forum)
+		i++
+	}
+
+	members := make(map[int32]int)
+	for _, forum := range attachment.membershipEnds {
+		members[forum] += 1
+	}
+	maxMembers := 0
+	for _, count := range members {
+		if count > maxMembers {
+			maxMembers = count
+		}
+	}
+	meanMembers := numJoins / numForums
+	assert.Greater(t, maxMembers, 3*meanMembers)
+}
@@ -0,0 +1,237 @@
+package builtin
+
+import (
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
+)
+
+// Number of actions sent to the database in each transaction while simulating activity
+const ldbcBatchSize = 1000
+
+// Number of batches in each wave, see ldbcWavePlanner. This is fixed rather than following the init concurrency,
+// so a population can be resumed with a different concurrency than it was started with.
+const ldbcBatchesPerWave = 16
+
+// Something created by an action in the simulation, that later actions may refer to
+type ldbcEntity struct {
+	kind byte // 'p' for person, 'f' for forum, 'm' for message
+	id   int64
+}
+
+// Entities the given action creates
+func ldbcActionCreates(action map[string]interface{}) []ldbcEntity {
+	switch action["type"] {
+	case "cp":
+		return []ldbcEntity{{'p', ldbcId(action["personNo"])}}
+	case "cf":
+		return []ldbcEntity{{'f', ldbcId(action["forumId"])}}
+	case "p", "c":
+		return []ldbcEntity{{'m', ldbcId(action["messageId"])}}
+	}
+	return nil
+}
+
+// Entities the given action requires to exist before it can be applied
+func ldbcActionDependencies(action map[string]interface{}) []ldbcEntity {
+	if action["type"] == "cp" {
+		return nil
+	}
+	person := ldbcEntity{'p', ldbcId(action["personId"])}
+	switch action["type"] {
+	case "cf":
+		return []ldbcEntity{person}
+	case "p", "jf":
+		return []ldbcEntity{person, {'f', ldbcId(action["forumId"])}}
+	case "c":
+		return []ldbcEntity{person, {'m', ldbcId(action["parentId"])}}
+	case "l":
+		return []ldbcEntity{person, {'m', ldbcId(action["messageId"])}}
+	case "af":
+		return []ldbcEntity{person, {'p', ldbcId(action["friendId"])}}
+	}
+	return nil
+}
+
+func ldbcId(v interface{}) int64 {
+	switch id := v.(type) {
+	case int:
+		return int64(id)
+	case int64:
+		return id
+	}
+	panic(errors.Errorf("unexpected id type in ldbc action: %T", v))
+}
+
+// Partitions the simulated action stream into waves of batches. Waves are applied one after the other, while all
+// batches in a wave are applied concurrently; so no batch may depend on something another batch in the same wave
+// creates. To guarantee that, an action that depends on something created earlier in the current wave goes into
+// the batch that created it, and if it depends on things from two different batches, the wave is closed and the
+// action starts the next one. Everything else goes to the emptiest batch.
+//
+// Placement only depends on the order actions are added in, so the same action stream always yields the same
+// waves; this is what lets population resume after it's been interrupted.
+type ldbcWavePlanner struct {
+	batchSize int
+	batches   [][]map[string]interface{}
+	createdIn map[ldbcEntity]int
+}
+
+func newLDBCWavePlanner(batchesPerWave, batchSize int) *ldbcWavePlanner {
+	return &ldbcWavePlanner{
+		batchSize: batchSize,
+		batches:   make([][]map[string]interface{}, batchesPerWave),
+		createdIn: make(map[ldbcEntity]int),
+	}
+}
+
+// Adds an action to the current wave. If the action can't go in the current wave, the wave is closed and returned,
+// and the action starts the next wave; otherwise this returns nil.
+func (p *ldbcWavePlanner) add(action map[string]interface{}) [][]map[string]interface{} {
+	var closed [][]map[string]interface{}
+	target, ok := p.place(action)
+	if !ok {
+		closed = p.flush()
+		target, _ = p.place(action)
+	}
+	p.batches[target] = append(p.batches[target], action)
+	for _, entity := range ldbcActionCreates(action) {
+		p.createdIn[entity] = target
+	}
+	return closed
+}
+
+// Closes the current wave and returns its batches, nil if the wave is empty
+func (p *ldbcWavePlanner) flush() [][]map[string]interface{} {
+	empty := true
+	for _, batch := range p.batches {
+		if len(batch) > 0 {
+			empty = false
+		}
+	}
+	if empty {
+		return nil
+	}
+	wave := p.batches
+	p.batches = make([][]map[string]interface{}, len(wave))
+	p.createdIn = make(map[ldbcEntity]int)
+	return wave
+}
+
+// Picks the batch in the current wave the action should go in; false if it can't go in the current wave
+func (p *ldbcWavePlanner) place(action map[string]interface{}) (int, bool) {
+	target := -1
+	for _, dependency := range ldbcActionDependencies(action) {
+		batch, found := p.createdIn[dependency]
+		if !found {
+			continue
+		}
+		if target != -1 && target != batch {
+			return 0, false
+		}
+		target = batch
+	}
+	if target == -1 {
+		target = 0
+		for i, batch := range p.batches {
+			if len(batch) < len(p.batches[target]) {
+				target = i
+			}
+		}
+	}
+	return target, len(p.batches[target]) < p.batchSize
+}
+
+// Applies one wave of batches, each in its own session and transaction, up to concurrency at a time. Each batch
+// transaction leaves a marker node behind, and once all batches are in, the meta node is moved on to the next wave
+// and the markers removed. If population is interrupted mid-wave, the markers tell which batches to skip on resume.
+func writeLDBCWave(driver neo4j.Driver, dbName string, concurrency int, meta datasetMeta, wave [][]map[string]interface{}) (datasetMeta, error) {
+	waveNo := meta.LastAction
+	completed, err := readLDBCBatchMarkers(driver, dbName, meta.Dataset, waveNo)
+	if err != nil {
+		return meta, err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	errs := make([]error, len(wave))
+	for batchNo, batch := range wave {
+		if len(batch) == 0 || completed[int64(batchNo)] {
+			continue
+		}
+		wg.Add(1)
+		go func(batchNo int, batch []map[string]interface{}) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			errs[batchNo] = writeLDBCBatch(driver, dbName, meta.Dataset, waveNo, int64(batchNo), batch)
+		}(batchNo, batch)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return meta, err
+		}
+	}
+
+	meta.LastAction = waveNo + 1
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeWrite,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	return meta, runQ(session, `MERGE (meta:__NEOBENCH_META__ {dataset: $dataset})
+SET meta += {completed: $completed, lastAction: $lastAction, seed: $seed, scale: $scale, generatorVersion: $generatorVersion,
+             workloadVersion: $workloadVersion, knobs: $knobs}
+WITH meta
+MATCH (marker:__NEOBENCH_BATCH__ {dataset: $dataset})
+DELETE marker`, datasetMetaParams(meta))
+}
+
+// Batches of the given wave that have already been applied
+func readLDBCBatchMarkers(driver neo4j.Driver, dbName, dataset string, waveNo int64) (map[int64]bool, error) {
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	result, err := session.Run("MATCH (marker:__NEOBENCH_BATCH__ {dataset: $dataset, wave: $wave}) RETURN marker.batch",
+		map[string]interface{}{"dataset": dataset, "wave": waveNo})
+	if err != nil {
+		return nil, err
+	}
+	completed := make(map[int64]bool)
+	for result.Next() {
+		completed[result.Record().Values[0].(int64)] = true
+	}
+	return completed, result.Err()
+}
+
+func writeLDBCBatch(driver neo4j.Driver, dbName, dataset string, waveNo, batchNo int64, actions []map[string]interface{}) error {
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeWrite,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		res, err := tx.Run(ldbcBatchQuery, map[string]interface{}{
+			"dataset": dataset,
+			"wave":    waveNo,
+			"batch":   batchNo,
+			"actions": actions,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply batch %d of wave %d", batchNo, waveNo)
+		}
+		_, err = res.Consume() // Need to call this to avoid bug in driver
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply batch %d of wave %d", batchNo, waveNo)
+		}
+		return nil, nil
+	})
+	return err
+}
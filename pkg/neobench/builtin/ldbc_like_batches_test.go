@@ -0,0 +1,92 @@
+package builtin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestLDBCWavePlannerKeepsBatchesIndependent(t *testing.T) {
+	stream := randomLDBCActionStream(rand.New(rand.NewSource(1337)), 20000)
+
+	waves := planLDBCWaves(stream, 4, 100)
+
+	seen := 0
+	for _, wave := range waves {
+		assert.Len(t, wave, 4)
+		createdIn := make(map[ldbcEntity]int)
+		for batchNo, batch := range wave {
+			assert.LessOrEqual(t, len(batch), 100)
+			for _, action := range batch {
+				for _, entity := range ldbcActionCreates(action) {
+					createdIn[entity] = batchNo
+				}
+			}
+			seen += len(batch)
+		}
+		for batchNo, batch := range wave {
+			for _, action := range batch {
+				for _, dependency := range ldbcActionDependencies(action) {
+					if creator, found := createdIn[dependency]; found {
+						assert.Equal(t, batchNo, creator, "action %v depends on %v from another batch in the same wave", action, dependency)
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, len(stream), seen)
+	assert.Greater(t, len(waves), 1)
+
+	// Same stream, same waves; resuming population relies on this
+	assert.Equal(t, waves, planLDBCWaves(stream, 4, 100))
+}
+
+func planLDBCWaves(stream []map[string]interface{}, batchesPerWave, batchSize int) [][][]map[string]interface{} {
+	planner := newLDBCWavePlanner(batchesPerWave, batchSize)
+	var waves [][][]map[string]interface{}
+	for _, action := range stream {
+		if wave := planner.add(action); wave != nil {
+			waves = append(waves, wave)
+		}
+	}
+	if wave := planner.flush(); wave != nil {
+		waves = append(waves, wave)
+	}
+	return waves
+}
+
+// A stream of actions with the same shape of dependencies as the ldbc-like simulation, minus the payloads
+func randomLDBCActionStream(random *rand.Rand, n int) []map[string]interface{} {
+	people, forums, messages := 2, 1, 0
+	stream := []map[string]interface{}{
+		{"type": "cp", "personNo": 1},
+		{"type": "cp", "personNo": 2},
+		{"type": "cf", "personId": 1, "forumId": 1},
+	}
+	for len(stream) < n {
+		actor := random.Intn(people) + 1
+		switch random.Intn(6) {
+		case 0:
+			people += 1
+			stream = append(stream, map[string]interface{}{"type": "cp", "personNo": people})
+		case 1:
+			forums += 1
+			stream = append(stream, map[string]interface{}{"type": "cf", "personId": actor, "forumId": forums})
+		case 2:
+			stream = append(stream, map[string]interface{}{"type": "af", "personId": actor, "friendId": random.Intn(people) + 1})
+		case 3:
+			messages += 1
+			stream = append(stream, map[string]interface{}{"type": "p", "personId": actor, "forumId": random.Intn(forums) + 1, "messageId": int64(messages)})
+		case 4:
+			if messages > 0 {
+				messages += 1
+				stream = append(stream, map[string]interface{}{"type": "c", "personId": actor, "parentId": int64(random.Intn(messages-1) + 1), "messageId": int64(messages)})
+			}
+		case 5:
+			if messages > 0 {
+				stream = append(stream, map[string]interface{}{"type": "l", "personId": actor, "messageId": int64(random.Intn(messages) + 1)})
+			}
+		}
+	}
+	return stream
+}
@@ -1,7 +1,9 @@
 package builtin
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"math/rand"
 	"neobench/pkg/neobench"
 	"testing"
@@ -38,3 +40,72 @@ LIMIT 20
 		},
 	}, uow.Statements)
 }
+
+// Populates a small ldbc-like dataset in a real database and checks the graph has the structure each simulated
+// action is meant to create
+func TestLDBCLikeGeneratedGraphStructure(t *testing.T) {
+	db := startTestNeo4j(t)
+	scale := 0.01
+	out := &neobench.InteractiveOutput{ErrStream: ioutil.Discard, OutStream: ioutil.Discard}
+
+	knobs, _ := ResolveKnobs(LDBCLikeKnobs, nil)
+	err := InitLDBCLike(scale, 1337, knobs, 4, "neo4j", db.driver, out, db.version)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, neobench.LDBCPeople(scale), db.count(t, "MATCH (p:Person) RETURN count(p)"))
+
+	// Each of these counts things that should not exist
+	violations := map[string]string{
+		"nodes without labels":              "MATCH (n) WHERE size(labels(n)) = 0 RETURN count(n)",
+		"leftover batch markers":            "MATCH (n:__NEOBENCH_BATCH__) RETURN count(n)",
+		"countries not in one continent":    "MATCH (c:Country) WHERE size([(c)-[:IS_PART_OF]->(x:Continent) | x]) <> 1 RETURN count(c)",
+		"cities not in one country":         "MATCH (c:City) WHERE size([(c)-[:IS_PART_OF]->(x:Country) | x]) <> 1 RETURN count(c)",
+		"companies not in one country":      "MATCH (c:Company) WHERE size([(c)-[:IS_LOCATED_IN]->(x:Country) | x]) <> 1 RETURN count(c)",
+		"universities not in one city":      "MATCH (u:University) WHERE size([(u)-[:IS_LOCATED_IN]->(x:City) | x]) <> 1 RETURN count(u)",
+		"tags without one class":            "MATCH (t:Tag) WHERE size([(t)-[:HAS_TYPE]->(x:TagClass) | x]) <> 1 RETURN count(t)",
+		"tag classes that are their parent": "MATCH (c:TagClass)-[:IS_SUBCLASS_OF]->(c) RETURN count(c)",
+		"people not in one city":            "MATCH (p:Person) WHERE size([(p)-[:IS_LOCATED_IN]->(x:City) | x]) <> 1 RETURN count(p)",
+		"friendships not between people":    "MATCH (a)-[:KNOWS]->(b) WHERE NOT a:Person OR NOT b:Person OR a = b RETURN count(a)",
+		"forums without one moderator":      "MATCH (f:Forum) WHERE size([(f)-[:HAS_MODERATOR]->(x:Person) | x]) <> 1 RETURN count(f)",
+		"forums without members":            "MATCH (f:Forum) WHERE NOT (f)-[:HAS_MEMBER]->(:Person) RETURN count(f)",
+		"members that are not people":       "MATCH (:Forum)-[:HAS_MEMBER]->(x) WHERE NOT x:Person RETURN count(x)",
+		"posts not in one forum":            "MATCH (m:Post) WHERE size([(f:Forum)-[:CONTAINER_OF]->(m) | f]) <> 1 RETURN count(m)",
+		"messages without one creator":      "MATCH (m:Message) WHERE size([(m)-[:HAS_CREATOR]->(x:Person) | x]) <> 1 RETURN count(m)",
+		"comments without one parent":       "MATCH (c:Comment) WHERE size([(c)-[:REPLY_OF]->(x:Message) | x]) <> 1 RETURN count(c)",
+		"likes not from people to messages": "MATCH (a)-[:LIKES]->(b) WHERE NOT a:Person OR NOT b:Message RETURN count(a)",
+	}
+	for name, query := range violations {
+		assert.Equal(t, int64(0), db.count(t, query), name)
+	}
+
+	// And each of these counts things every action type should have created some of
+	created := map[string]string{
+		"interests":    "MATCH (:Person)-[r:HAS_INTEREST]->(:Tag) RETURN count(r)",
+		"employments":  "MATCH (:Person)-[r:WORK_AT]->(:Company) RETURN count(r)",
+		"studies":      "MATCH (:Person)-[r:STUDY_AT]->(:University) RETURN count(r)",
+		"friendships":  "MATCH (:Person)-[r:KNOWS]->(:Person) RETURN count(r)",
+		"forums":       "MATCH (f:Forum) RETURN count(f)",
+		"memberships":  "MATCH (:Forum)-[r:HAS_MEMBER]->(:Person) RETURN count(r)",
+		"posts":        "MATCH (m:Post) RETURN count(m)",
+		"comments":     "MATCH (m:Comment) RETURN count(m)",
+		"likes":        "MATCH (:Person)-[r:LIKES]->(:Message) RETURN count(r)",
+		"message tags": "MATCH (:Message)-[r:HAS_TAG]->(:Tag) RETURN count(r)",
+	}
+	for name, query := range created {
+		assert.Greater(t, db.count(t, query), int64(0), name)
+	}
+
+	// Ids handed out by ldbc_random_message should all exist
+	ids, err := ReadLDBCIds(db.driver, "neo4j")
+	if !assert.NoError(t, err) {
+		return
+	}
+	random := rand.New(rand.NewSource(1337))
+	for i := 0; i < 20; i++ {
+		id, err := ids.RandomMessage(random)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), db.count(t, fmt.Sprintf("MATCH (m:Message {id: %d}) RETURN count(m)", id)))
+	}
+}
@@ -0,0 +1,104 @@
+package builtin
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"neobench/pkg/neobench"
+)
+
+const testNeo4jPassword = "neobench-test"
+
+// Harness for tests that need a real database, like the dataset generator tests.
+//
+// If NEOBENCH_TEST_NEO4J_URL is set, tests run against that database, authenticating with NEOBENCH_TEST_NEO4J_USER
+// and NEOBENCH_TEST_NEO4J_PASSWORD; note that they write to it. Otherwise a throwaway Neo4j container is started with
+// docker, using the image in NEOBENCH_TEST_NEO4J_IMAGE or neo4j:5. Tests are skipped with -short, or if neither is
+// available.
+type testNeo4j struct {
+	driver  neo4j.Driver
+	version string
+}
+
+func startTestNeo4j(t *testing.T) testNeo4j {
+	if testing.Short() {
+		t.Skip("skipping test against Neo4j in short mode")
+	}
+
+	url := os.Getenv("NEOBENCH_TEST_NEO4J_URL")
+	user, password := os.Getenv("NEOBENCH_TEST_NEO4J_USER"), os.Getenv("NEOBENCH_TEST_NEO4J_PASSWORD")
+	if url == "" {
+		url = startNeo4jContainer(t)
+		user, password = "neo4j", testNeo4jPassword
+	}
+	if user == "" {
+		user = "neo4j"
+	}
+
+	if err := neobench.AwaitNeo4j(url, user, password, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	driver, err := neo4j.NewDriver(url, neo4j.BasicAuth(user, password, ""))
+	if err != nil {
+		t.Fatalf("failed to create driver for %s: %s", url, err)
+	}
+	t.Cleanup(func() { driver.Close() })
+
+	session := driver.NewSession(neo4j.SessionConfig{})
+	defer session.Close()
+	version, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		res, err := tx.Run("CALL dbms.components() YIELD name, versions WHERE name = 'Neo4j Kernel' RETURN versions[0]", nil)
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single()
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[0], nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read Neo4j version: %s", err)
+	}
+	return testNeo4j{driver: driver, version: version.(string)}
+}
+
+func startNeo4jContainer(t *testing.T) string {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("skipping test against Neo4j, set NEOBENCH_TEST_NEO4J_URL or install docker to run it")
+	}
+	image := os.Getenv("NEOBENCH_TEST_NEO4J_IMAGE")
+	if image == "" {
+		image = "neo4j:5"
+	}
+	url, stop, err := neobench.StartNeo4jContainer(image, testNeo4jPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+	return url
+}
+
+// Runs a query that returns a single integer
+func (n testNeo4j) count(t *testing.T, query string) int64 {
+	session := n.driver.NewSession(neo4j.SessionConfig{})
+	defer session.Close()
+	value, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		res, err := tx.Run(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single()
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[0], nil
+	})
+	if err != nil {
+		t.Fatalf("failed to run %s: %s", query, err)
+	}
+	return value.(int64)
+}
@@ -0,0 +1,49 @@
+package builtin
+
+import (
+	"neobench/pkg/neobench"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
+)
+
+// Writes a new value to a key and reads it back through a read session, which in a cluster is routed to a
+// secondary or follower. Each worker has keys of its own, so reading anything but the value just written means
+// the reader was behind the writer. The keys are created by the workload itself; $scale sets how many each
+// worker has.
+const ReadYourWrites = `
+:set key $nbWorkerId * 1000000 + random(1, greatest(1, int(1000 * $scale)))
+:set value random(1, 1000000000000)
+
+MERGE (n:ReadYourWrites {key: $key}) SET n.value = $value;
+
+:readback
+OPTIONAL MATCH (n:ReadYourWrites {key: $key}) RETURN n.value = $value AS fresh;
+`
+
+// Creates the index the read-your-writes workload looks keys up by; the workload writes the nodes itself
+func InitReadYourWrites(driver neo4j.Driver, dbName string, out neobench.Output) error {
+	out.ReportInitProgress(neobench.ProgressReport{
+		Section:      "init",
+		Step:         "create index",
+		Completeness: 0,
+	})
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeWrite,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	res, err := session.Run("CREATE INDEX readYourWritesKey IF NOT EXISTS FOR (n:ReadYourWrites) ON (n.key)", nil)
+	if err == nil {
+		_, err = res.Consume()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to create index for read-your-writes")
+	}
+	out.ReportInitProgress(neobench.ProgressReport{
+		Section:      "init",
+		Step:         "create index",
+		Completeness: 1,
+	})
+	return nil
+}
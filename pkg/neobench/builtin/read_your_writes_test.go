@@ -0,0 +1,28 @@
+package builtin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"neobench/pkg/neobench"
+	"testing"
+)
+
+func TestReadYourWritesKeysArePerWorker(t *testing.T) {
+	script, err := neobench.Parse("builtin:read-your-writes", ReadYourWrites, 1)
+	assert.NoError(t, err)
+	for workerId := int64(0); workerId < 3; workerId++ {
+		uow, err := script.Eval(neobench.ScriptContext{
+			Vars: map[string]interface{}{"scale": int64(1), neobench.WorkerIdVar: workerId},
+			Rand: rand.New(rand.NewSource(1337)),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, uow.Statements, 2)
+		assert.False(t, uow.Statements[0].Readback)
+		assert.True(t, uow.Statements[1].Readback)
+
+		key := uow.Statements[0].Params["key"].(int64)
+		assert.Greater(t, key, workerId*1000000)
+		assert.LessOrEqual(t, key, workerId*1000000+1000)
+		assert.Equal(t, uow.Statements[0].Params, uow.Statements[1].Params)
+	}
+}
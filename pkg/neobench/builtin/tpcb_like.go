@@ -1,16 +1,17 @@
 package builtin
 
 import (
-	"math"
+	"fmt"
 	"neobench/pkg/neobench"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
 )
 
 const TPCBLike = `
-:set aid random(1, 100000 * $scale)
-:set bid random(1, 1 * $scale)
-:set tid random(1, 10 * $scale)
+:set aid random(1, greatest(1, int($accounts_per_branch * $scale)))
+:set bid random(1, greatest(1, int(1 * $scale)))
+:set tid random(1, greatest(1, int($tellers_per_branch * $scale)))
 :set delta random(-5000, 5000)
 
 MATCH (account:Account {aid:$aid}) 
@@ -23,27 +24,56 @@ CREATE (:History { tid: $tid, bid: $bid, aid: $aid, delta: $delta, mtime: timest
 `
 
 const MatchOnly = `
-:set aid random(1, 100000 * $scale)
+:set aid random(1, greatest(1, int($accounts_per_branch * $scale)))
 MATCH (account:Account {aid:$aid}) RETURN account.balance;
 `
 
-func InitTPCBLike(scale int64, dbName string, driver neo4j.Driver, out neobench.Output, version string) error {
-	numBranches := 1 * scale
-	numTellers := 10 * scale
-	numAccounts := 100000 * scale
+// Bump this whenever the generator changes the data it creates, see DatasetFingerprint
+const tpcbLikeGeneratorVersion = 2
+
+// Bump this whenever the tpcb-like or match-only scripts change in ways that affect results, see workloadVersions
+const tpcbLikeWorkloadVersion = 1
+
+// Size of the dataset at scale 1 with the default knobs, used to translate size targets into a scale; the store size
+// is a rough estimate
+const (
+	TPCBLikeNodesPerScale      = 1 + 10 + 100000
+	TPCBLikeStoreBytesPerScale = 12 * 1024 * 1024
+)
+
+// Describes what InitTPCBLike creates at the given scale
+func DescribeTPCBLikeDataset(scale float64, knobs Knobs) string {
+	return fmt.Sprintf("%d branches, %d tellers and %d accounts", scaled(1, scale),
+		scaled(knobs.int("tellers_per_branch"), scale), scaled(knobs.int("accounts_per_branch"), scale))
+}
+
+func InitTPCBLike(scale float64, knobs Knobs, concurrency int, batchSize int64, dbName string, driver neo4j.Driver, out neobench.Output, version string) error {
+	numBranches := scaled(1, scale)
+	numTellers := scaled(knobs.int("tellers_per_branch"), scale)
+	numAccounts := scaled(knobs.int("accounts_per_branch"), scale)
 	session := driver.NewSession(neo4j.SessionConfig{
 		AccessMode:   neo4j.AccessModeWrite,
 		DatabaseName: dbName,
 	})
 	defer session.Close()
 
+	meta, done, err := beginInit(session, DatasetFingerprint{
+		Dataset:          "tpcb-like",
+		GeneratorVersion: tpcbLikeGeneratorVersion,
+		Scale:            scale,
+		Knobs:            describeOverriddenKnobs(TPCBLikeKnobs, knobs),
+	}, out)
+	if err != nil || done {
+		return err
+	}
+
 	out.ReportInitProgress(neobench.ProgressReport{
 		Section:      "init",
 		Step:         "create schema",
 		Completeness: 0,
 	})
 
-	var err = ensureSchema(session, []schemaEntry{
+	err = ensureSchema(session, []schemaEntry{
 		{Label: "Branch", Property: "bid", Unique: true},
 		{Label: "Teller", Property: "tid", Unique: true},
 		{Label: "Account", Property: "aid", Unique: true},
@@ -80,36 +110,119 @@ MERGE (t:Teller {tid: tellerId}) SET t.balance = 0
 		Step:         "create accounts",
 		Completeness: 0,
 	})
-	result, err := session.Run("MATCH (:Account) RETURN COUNT(*) AS n", nil)
+	err = createTPCBAccounts(driver, dbName, numAccounts, concurrency, batchSize, version, out)
 	if err != nil {
 		return err
 	}
-	result.Next()
-	existingAccountNum := result.Record().GetByIndex(0).(int64)
-
-	batchSize := int64(5000)
-	startAtBatch := int64(math.Floor(float64(existingAccountNum) / float64(batchSize)))
-	numBatches := numAccounts / batchSize
-	for batchNo := int64(startAtBatch); batchNo <= numBatches; batchNo++ {
-		startAccount := max(existingAccountNum, batchSize*batchNo) + 1
-		endAccount := min(numAccounts, startAccount+batchSize) - 1
-		if endAccount <= startAccount {
-			continue
-		}
-		err = runQ(session, `UNWIND range($startAccount, $endAccount) AS accountId 
-CREATE (a:Account {aid: accountId, balance: 0})
-`, map[string]interface{}{
-			"startAccount": startAccount,
-			"endAccount":   endAccount,
-		})
-		if err != nil {
+	return completeInit(session, meta)
+}
+
+// Number of transactions in each chunk of accounts handed to a session, when the server supports
+// CALL {} IN TRANSACTIONS; otherwise each chunk is a single transaction
+const tpcbBatchesPerChunk = 10
+
+// Creates accounts 1 through numAccounts. The accounts are split into chunks, and up to concurrency sessions
+// create chunks at the same time. On servers that support it, each chunk is a single CALL {} IN TRANSACTIONS
+// query, committing every batchSize accounts, which saves a round trip per transaction.
+//
+// This is safe to resume: chunks that are already fully populated are skipped, and partially populated ones
+// are completed with MERGE rather than CREATE.
+func createTPCBAccounts(driver neo4j.Driver, dbName string, numAccounts int64, concurrency int, batchSize int64, version string, out neobench.Output) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	chunkSize := batchSize
+	inTransactions := supportsCallInTransactions(version)
+	if inTransactions {
+		chunkSize = batchSize * tpcbBatchesPerChunk
+	}
+	numChunks := (numAccounts + chunkSize - 1) / chunkSize
+
+	chunks := make(chan int64, numChunks)
+	for chunkNo := int64(0); chunkNo < numChunks; chunkNo++ {
+		chunks <- chunkNo
+	}
+	close(chunks)
+
+	// Buffered so workers never block on reporting, even if we've stopped listening after an error
+	done := make(chan error, numChunks)
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			session := driver.NewSession(neo4j.SessionConfig{
+				AccessMode:   neo4j.AccessModeWrite,
+				DatabaseName: dbName,
+			})
+			defer session.Close()
+			for chunkNo := range chunks {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				first := chunkNo*chunkSize + 1
+				last := min(numAccounts, first+chunkSize-1)
+				done <- createTPCBAccountChunk(session, first, last, batchSize, inTransactions)
+			}
+		}()
+	}
+
+	// Progress is reported from here rather than the workers, outputs are not safe for concurrent use
+	for chunksDone := int64(1); chunksDone <= numChunks; chunksDone++ {
+		if err := <-done; err != nil {
 			return err
 		}
 		out.ReportInitProgress(neobench.ProgressReport{
 			Section:      "init",
 			Step:         "create accounts",
-			Completeness: float64(batchNo) / float64(numBatches),
+			Completeness: float64(chunksDone) / float64(numChunks),
 		})
 	}
 	return nil
 }
+
+func createTPCBAccountChunk(session neo4j.Session, first, last, batchSize int64, inTransactions bool) error {
+	result, err := session.Run("MATCH (a:Account) WHERE $first <= a.aid <= $last RETURN count(a)",
+		map[string]interface{}{"first": first, "last": last})
+	if err != nil {
+		return errors.Wrapf(err, "failed to count existing accounts %d to %d", first, last)
+	}
+	record, err := result.Single()
+	if err != nil {
+		return errors.Wrapf(err, "failed to count existing accounts %d to %d", first, last)
+	}
+	existing := record.Values[0].(int64)
+	if existing == last-first+1 {
+		return nil
+	}
+	create := "CREATE (a:Account {aid: accountId, balance: 0})"
+	if existing > 0 {
+		create = "MERGE (a:Account {aid: accountId}) ON CREATE SET a.balance = 0"
+	}
+
+	params := map[string]interface{}{"first": first, "last": last, "batchSize": batchSize}
+	if inTransactions {
+		// CALL {} IN TRANSACTIONS only works in auto-commit transactions
+		result, err = session.Run(fmt.Sprintf(`UNWIND range($first, $last) AS accountId
+CALL { WITH accountId %s } IN TRANSACTIONS OF $batchSize ROWS`, create), params)
+		if err == nil {
+			_, err = result.Consume()
+		}
+	} else {
+		err = runQ(session, fmt.Sprintf("UNWIND range($first, $last) AS accountId %s", create), params)
+	}
+	return errors.Wrapf(err, "failed to create accounts %d to %d", first, last)
+}
+
+// CALL {} IN TRANSACTIONS was added in Neo4j 4.4
+func supportsCallInTransactions(version string) bool {
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	return major > 4 || (major == 4 && minor >= 4)
+}
@@ -1,7 +1,9 @@
 package builtin
 
 import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"math/rand"
 	"neobench/pkg/neobench"
 	"testing"
@@ -9,6 +11,7 @@ import (
 
 func TestParseTpcBLike(t *testing.T) {
 	vars := map[string]interface{}{"scale": int64(1)}
+	DefineKnobDefaults(TPCBLikeKnobs, vars)
 	script, err := neobench.Parse("builtin:tpcb-like", TPCBLike, 1)
 
 	assert.NoError(t, err)
@@ -43,3 +46,73 @@ func TestParseTpcBLike(t *testing.T) {
 		},
 	}, uow.Statements)
 }
+
+func TestTpcBLikeFractionalScale(t *testing.T) {
+	script, err := neobench.Parse("builtin:tpcb-like", TPCBLike, 1)
+	assert.NoError(t, err)
+	vars := map[string]interface{}{"scale": 0.1}
+	DefineKnobDefaults(TPCBLikeKnobs, vars)
+	for i := int64(0); i < 100; i++ {
+		uow, err := script.Eval(neobench.ScriptContext{
+			Vars: vars,
+			Rand: rand.New(rand.NewSource(i)),
+		})
+		assert.NoError(t, err)
+		params := uow.Statements[len(uow.Statements)-1].Params
+		assert.LessOrEqual(t, params["aid"].(int64), scaled(100000, 0.1))
+		assert.Equal(t, int64(1), params["bid"])
+		assert.LessOrEqual(t, params["tid"].(int64), scaled(10, 0.1))
+	}
+}
+
+func TestTpcBLikeAccountsPerBranchKnob(t *testing.T) {
+	script, err := neobench.Parse("builtin:tpcb-like", TPCBLike, 1)
+	assert.NoError(t, err)
+	vars := map[string]interface{}{"scale": int64(2), "accounts_per_branch": int64(50)}
+	DefineKnobDefaults(TPCBLikeKnobs, vars)
+	knobs, err := ResolveKnobs(TPCBLikeKnobs, vars)
+	assert.NoError(t, err)
+	for i := int64(0); i < 100; i++ {
+		uow, err := script.Eval(neobench.ScriptContext{
+			Vars: vars,
+			Rand: rand.New(rand.NewSource(i)),
+		})
+		assert.NoError(t, err)
+		params := uow.Statements[len(uow.Statements)-1].Params
+		assert.LessOrEqual(t, params["aid"].(int64), scaled(knobs.int("accounts_per_branch"), 2))
+	}
+	assert.Equal(t, "2 branches, 20 tellers and 100 accounts", DescribeTPCBLikeDataset(2, knobs))
+}
+
+func TestSupportsCallInTransactions(t *testing.T) {
+	assert.False(t, supportsCallInTransactions("4.3.7"))
+	assert.True(t, supportsCallInTransactions("4.4.12"))
+	assert.True(t, supportsCallInTransactions("5.1.0"))
+	assert.False(t, supportsCallInTransactions("dev"))
+}
+
+func TestInitTPCBLikeCreatesAndResumesAccounts(t *testing.T) {
+	db := startTestNeo4j(t)
+	out := &neobench.InteractiveOutput{ErrStream: ioutil.Discard, OutStream: ioutil.Discard}
+
+	knobs, _ := ResolveKnobs(TPCBLikeKnobs, nil)
+	err := InitTPCBLike(0.2, knobs, 4, 1000, "neo4j", db.driver, out, db.version)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(20000), db.count(t, "MATCH (a:Account) RETURN count(DISTINCT a.aid)"))
+	assert.Equal(t, int64(20000), db.count(t, "MATCH (a:Account) RETURN max(a.aid)"))
+
+	// Pretend population was interrupted part-way through a chunk
+	session := db.driver.NewSession(neo4j.SessionConfig{})
+	defer session.Close()
+	assert.NoError(t, runQ(session, "MATCH (meta:__NEOBENCH_META__ {dataset: 'tpcb-like'}) SET meta.completed = false", nil))
+	assert.NoError(t, runQ(session, "MATCH (a:Account) WHERE a.aid >= 15500 DETACH DELETE a", nil))
+
+	err = InitTPCBLike(0.2, knobs, 4, 1000, "neo4j", db.driver, out, db.version)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(20000), db.count(t, "MATCH (a:Account) RETURN count(a)"))
+	assert.Equal(t, int64(20000), db.count(t, "MATCH (a:Account) RETURN count(DISTINCT a.aid)"))
+}
@@ -0,0 +1,62 @@
+package neobench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Synchronized bursts of transactions on top of the steady latency-mode rate, set with --burst. Every interval,
+// Size extra transactions arrive at the same instant, spread across the workers, to see how the database copes
+// with a thundering herd rather than with smooth arrivals.
+type Burst struct {
+	// Transactions per burst; when split with ForWorker, the number this worker runs per burst
+	Size     int64
+	Interval time.Duration
+}
+
+// Parses a burst spec like "size=100,interval=10s"
+func ParseBurst(spec string) (Burst, error) {
+	burst := Burst{}
+	for _, entry := range strings.Split(spec, ",") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return Burst{}, fmt.Errorf("invalid --burst '%s', expected options like size=100,interval=10s", spec)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 1 {
+				return Burst{}, fmt.Errorf("invalid --burst size '%s', must be a whole number above zero", value)
+			}
+			burst.Size = size
+		case "interval":
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval <= 0 {
+				return Burst{}, fmt.Errorf("invalid --burst interval '%s', must be a duration like 10s", value)
+			}
+			burst.Interval = interval
+		default:
+			return Burst{}, fmt.Errorf("unknown --burst option '%s', expected size and interval", key)
+		}
+	}
+	if burst.Size == 0 || burst.Interval == 0 {
+		return Burst{}, fmt.Errorf("invalid --burst '%s', both size and interval must be set, ex: size=100,interval=10s", spec)
+	}
+	return burst, nil
+}
+
+func (b Burst) String() string {
+	return fmt.Sprintf("size=%d,interval=%s", b.Size, b.Interval)
+}
+
+// The share of each burst the given worker runs; the remainder of an uneven split goes to the lowest worker ids
+func (b Burst) ForWorker(workerId int64, numWorkers int) Burst {
+	size := b.Size / int64(numWorkers)
+	if workerId < b.Size%int64(numWorkers) {
+		size++
+	}
+	return Burst{Size: size, Interval: b.Interval}
+}
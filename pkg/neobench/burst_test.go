@@ -0,0 +1,46 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseBurst(t *testing.T) {
+	burst, err := ParseBurst("size=100, interval=10s")
+	assert.NoError(t, err)
+	assert.Equal(t, Burst{Size: 100, Interval: 10 * time.Second}, burst)
+
+	for _, spec := range []string{"", "size=100", "interval=10s", "size=0,interval=1s", "size=1,interval=-1s", "size=1,interval=1s,jitter=2"} {
+		_, err := ParseBurst(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestBurstForWorkerSplitsSize(t *testing.T) {
+	burst := Burst{Size: 10, Interval: time.Second}
+	total := int64(0)
+	for worker := int64(0); worker < 4; worker++ {
+		total += burst.ForWorker(worker, 4).Size
+	}
+	assert.Equal(t, int64(10), total)
+	assert.Equal(t, int64(3), burst.ForWorker(0, 4).Size)
+	assert.Equal(t, int64(2), burst.ForWorker(3, 4).Size)
+}
+
+func TestWorkerRunsBurstsOnTopOfRate(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &fakeDriver{clock: clock, r: r, minLatency: 1 * time.Millisecond, maxLatency: 2 * time.Millisecond}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+
+	// One transaction a second, plus three at once every ten seconds: by the 30th transaction, two bursts have run
+	result := w.RunBenchmark(newTestWorkload(r), "", time.Second, Burst{Size: 3, Interval: 10 * time.Second}, 30, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(30), result.Scripts["workertest"].Succeeded)
+	assert.Equal(t, int64(6), result.Bursts["workertest"].Succeeded)
+	// Burst transactions queue up behind each other, and are measured from when the burst was due
+	assert.Greater(t, result.Bursts["workertest"].Latencies.Max(), 2*time.Millisecond.Microseconds())
+}
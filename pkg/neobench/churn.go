@@ -0,0 +1,110 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Drops the connection of each worker at a fixed interval and opens a new one, to measure what connecting costs
+// the units of work that have to, as with proxies and load balancers that churn connections. Unlike
+// --max-conn-lifetime, which the pool applies to idle connections, the connection, driver and routing table are
+// all new, so the unit of work that follows pays for all of it. See Worker#SetConnectionChurn.
+type ConnectionChurn struct {
+	Interval time.Duration
+	// Creates the driver each reconnect gets its connection from; the worker closes it on the next reconnect
+	NewDriver func() (neo4j.Driver, error)
+}
+
+// Units of work that ran on a connection opened right before them, with --reconnect-every, against the rest
+type ChurnResult struct {
+	Reconnects int64
+	// Latency of the units of work that reconnected, and of those that didn't, in microseconds
+	Cold *hdrhistogram.Histogram
+	Warm *hdrhistogram.Histogram
+}
+
+func newChurnResult() *ChurnResult {
+	return &ChurnResult{
+		Cold: hdrhistogram.New(0, 60*60*1000000, 3),
+		Warm: hdrhistogram.New(0, 60*60*1000000, 3),
+	}
+}
+
+func (r *ChurnResult) record(latency time.Duration, outcome uowOutcome) error {
+	if outcome.reconnected {
+		r.Reconnects++
+	}
+	if !outcome.succeeded {
+		return nil
+	}
+	latencies := r.Warm
+	if outcome.reconnected {
+		latencies = r.Cold
+	}
+	if err := latencies.RecordValue(latency.Microseconds()); err != nil {
+		return errors.Wrapf(err, "failed to record latency: %s", latency)
+	}
+	return nil
+}
+
+func (r *ChurnResult) merge(other *ChurnResult) {
+	r.Reconnects += other.Reconnects
+	r.Cold.Merge(other.Cold)
+	r.Warm.Merge(other.Warm)
+}
+
+// Drops the worker's connection and sets up a new one if it's due to; returns whether it did, so the unit of work
+// that follows is counted as cold
+func (w *Worker) reconnectIfDue(sessions *workerSessions) (bool, error) {
+	if w.churn.Interval <= 0 {
+		return false, nil
+	}
+	now := w.now()
+	if w.nextReconnect.IsZero() {
+		// Spread the first reconnects out over the interval, so the workers don't all reconnect at once
+		w.nextReconnect = now.Add(time.Duration(rand.Int63n(int64(w.churn.Interval))))
+		return false, nil
+	}
+	if now.Before(w.nextReconnect) {
+		return false, nil
+	}
+	w.nextReconnect = now.Add(w.churn.Interval)
+	driver, err := w.churn.NewDriver()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create driver to reconnect with")
+	}
+	sessions.replaceDriver(driver)
+	return true, nil
+}
+
+// Cold against warm latencies; only written with --reconnect-every
+func writeChurnReport(result Result, s *strings.Builder) {
+	churn := result.Churn
+	if churn == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Connection churn --\n\n"))
+	s.WriteString(fmt.Sprintf("  Reconnected %d times\n", churn.Reconnects))
+	unit := result.LatencyUnit
+	for _, kind := range []struct {
+		name      string
+		latencies *hdrhistogram.Histogram
+	}{{"Cold", churn.Cold}, {"Warm", churn.Warm}} {
+		if kind.latencies.TotalCount() == 0 {
+			continue
+		}
+		s.WriteString(fmt.Sprintf("  %s: P50: %s, P99: %s, P99.9: %s, Max: %s, over %d units of work\n", kind.name,
+			unit.Format(float64(kind.latencies.ValueAtQuantile(50))), unit.Format(float64(kind.latencies.ValueAtQuantile(99))),
+			unit.Format(float64(kind.latencies.ValueAtQuantile(99.9))), unit.Format(float64(kind.latencies.Max())), kind.latencies.TotalCount()))
+	}
+	if churn.Cold.TotalCount() > 0 && churn.Warm.TotalCount() > 0 {
+		s.WriteString(fmt.Sprintf("  Reconnecting added %s at P50\n",
+			unit.Format(float64(churn.Cold.ValueAtQuantile(50)-churn.Warm.ValueAtQuantile(50)))))
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,72 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerReconnectsAtTheChurnInterval(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	newFake := func() *closingDriver {
+		return &closingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	}
+	var drivers []*closingDriver
+	w := Worker{workerId: 0, driver: newFake(), now: clock.now, sleep: clock.sleep}
+	w.SetConnectionChurn(ConnectionChurn{Interval: 10 * time.Millisecond, NewDriver: func() (neo4j.Driver, error) {
+		driver := newFake()
+		drivers = append(drivers, driver)
+		return driver, nil
+	}})
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 100, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	churn := result.Churn
+	// 100 units of work of 1ms each, reconnecting every 10ms after a first reconnect within the first 10ms
+	assert.InDelta(t, 9, churn.Reconnects, 1)
+	assert.Equal(t, int64(len(drivers)), churn.Reconnects)
+	assert.Equal(t, churn.Reconnects, churn.Cold.TotalCount())
+	assert.Equal(t, 100-churn.Reconnects, churn.Warm.TotalCount())
+	// Every driver but the last was closed by the reconnect after it, and the last when the worker stopped
+	for _, driver := range drivers {
+		assert.True(t, driver.closed)
+	}
+
+	total := NewResult("", "")
+	total.Add(result)
+	s := strings.Builder{}
+	writeChurnReport(total, &s)
+	assert.Contains(t, s.String(), "-- Connection churn --")
+	assert.Contains(t, s.String(), "Cold: P50: 1.000ms")
+}
+
+func TestWorkerWithoutChurnDoesNotReconnect(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	w := Worker{workerId: 0, driver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}, now: clock.now, sleep: clock.sleep}
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 10, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Nil(t, result.Churn)
+}
+
+// Remembers being closed; its sessions are closed separately
+type closingDriver struct {
+	*fakeDriver
+	closed bool
+}
+
+func (d *closingDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d.fakeDriver
+}
+
+func (d *closingDriver) Close() error {
+	d.closed = true
+	return nil
+}
@@ -0,0 +1,290 @@
+package neobench
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// A result written with --output json, read back to compare it with another, see WriteComparisonHtml
+type SavedResult struct {
+	Name   string
+	result jsonResult
+}
+
+func ReadSavedResult(path string) (SavedResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SavedResult{}, errors.Wrapf(err, "failed to read result")
+	}
+	defer file.Close()
+	return parseSavedResult(path, file)
+}
+
+func parseSavedResult(name string, r io.Reader) (SavedResult, error) {
+	saved := SavedResult{Name: name}
+	if err := json.NewDecoder(r).Decode(&saved.result); err != nil {
+		return SavedResult{}, errors.Wrapf(err, "failed to read result '%s', expected the output of --output json", name)
+	}
+	return saved, nil
+}
+
+// The percentiles the latency charts plot, from left to right
+var comparedPercentiles = []struct {
+	label string
+	value func(l *jsonLatency) float64
+}{
+	{"p25", func(l *jsonLatency) float64 { return l.P25 }},
+	{"p50", func(l *jsonLatency) float64 { return l.P50 }},
+	{"p75", func(l *jsonLatency) float64 { return l.P75 }},
+	{"p95", func(l *jsonLatency) float64 { return l.P95 }},
+	{"p99", func(l *jsonLatency) float64 { return l.P99 }},
+	{"p99.9", func(l *jsonLatency) float64 { return l.P999 }},
+	{"p99.999", func(l *jsonLatency) float64 { return l.P99999 }},
+	{"max", func(l *jsonLatency) float64 { return l.Max }},
+}
+
+// Writes a page that sets two results side by side, for before and after comparisons: a table per script of
+// throughput, failures and latency percentiles with the change between the runs, and a chart per script with the
+// latency percentiles of both runs overlaid. Scripts in only one of the runs are shown with the other side empty.
+// The page has no outside dependencies, so it can be mailed or attached as is.
+func WriteComparisonHtml(before, after SavedResult, out io.Writer) error {
+	page := comparisonPage{Before: before.Name, After: after.Name}
+	page.Total = comparisonRow{
+		Name:       "total",
+		Throughput: compareValues(before.result.TransactionsPerSecond, after.result.TransactionsPerSecond, true, formatRate),
+		Failed:     compareValues(float64(before.result.Failed), float64(after.result.Failed), false, formatCount),
+	}
+	for _, name := range comparedScriptNames(before.result.Scripts, after.result.Scripts) {
+		page.Scripts = append(page.Scripts, newComparisonScript(name, findScript(before.result.Scripts, name), findScript(after.result.Scripts, name)))
+	}
+	return comparisonTemplate.Execute(out, page)
+}
+
+// The script names of both runs, in the order of the first run, followed by those only in the second
+func comparedScriptNames(before, after []jsonScript) []string {
+	seen := map[string]bool{}
+	names := make([]string, 0, len(before))
+	for _, scripts := range [][]jsonScript{before, after} {
+		for _, script := range scripts {
+			if !seen[script.Name] {
+				seen[script.Name] = true
+				names = append(names, script.Name)
+			}
+		}
+	}
+	return names
+}
+
+func findScript(scripts []jsonScript, name string) *jsonScript {
+	for i := range scripts {
+		if scripts[i].Name == name {
+			return &scripts[i]
+		}
+	}
+	return nil
+}
+
+type comparisonPage struct {
+	Before  string
+	After   string
+	Total   comparisonRow
+	Scripts []comparisonScript
+}
+
+type comparisonScript struct {
+	comparisonRow
+	Latencies []comparisonLatency
+	Chart     *latencyChart
+}
+
+type comparisonRow struct {
+	Name       string
+	Throughput comparedValue
+	Failed     comparedValue
+}
+
+type comparisonLatency struct {
+	Label string
+	Value comparedValue
+}
+
+// One figure from both runs; empty strings where a run doesn't have it
+type comparedValue struct {
+	Before string
+	After  string
+	Delta  string
+	// "better", "worse" or empty, for the colour of the delta
+	Verdict string
+}
+
+func newComparisonScript(name string, before, after *jsonScript) comparisonScript {
+	script := comparisonScript{comparisonRow: comparisonRow{Name: name}}
+	var beforeLatency, afterLatency *jsonLatency
+	if before != nil {
+		script.Throughput.Before = formatRate(before.TransactionsPerSecond)
+		script.Failed.Before = formatCount(float64(before.Failed))
+		beforeLatency = before.Latency
+	}
+	if after != nil {
+		script.Throughput.After = formatRate(after.TransactionsPerSecond)
+		script.Failed.After = formatCount(float64(after.Failed))
+		afterLatency = after.Latency
+	}
+	if before != nil && after != nil {
+		script.Throughput = compareValues(before.TransactionsPerSecond, after.TransactionsPerSecond, true, formatRate)
+		script.Failed = compareValues(float64(before.Failed), float64(after.Failed), false, formatCount)
+	}
+	for _, percentile := range comparedPercentiles {
+		latency := comparisonLatency{Label: percentile.label}
+		if beforeLatency != nil {
+			latency.Value.Before = LatencyAuto.Format(beforeLatency.micros(percentile.value(beforeLatency)))
+		}
+		if afterLatency != nil {
+			latency.Value.After = LatencyAuto.Format(afterLatency.micros(percentile.value(afterLatency)))
+		}
+		if beforeLatency != nil && afterLatency != nil {
+			latency.Value = compareValues(beforeLatency.micros(percentile.value(beforeLatency)),
+				afterLatency.micros(percentile.value(afterLatency)), false, LatencyAuto.Format)
+		}
+		script.Latencies = append(script.Latencies, latency)
+	}
+	if beforeLatency != nil || afterLatency != nil {
+		script.Chart = newLatencyChart(beforeLatency, afterLatency)
+	}
+	return script
+}
+
+// Ex: 120.00 -> 150.00, +25.0%; higherIsBetter says which way the change is an improvement
+func compareValues(before, after float64, higherIsBetter bool, format func(float64) string) comparedValue {
+	value := comparedValue{Before: format(before), After: format(after)}
+	switch {
+	case before == after:
+		value.Delta = "0%"
+		return value
+	case before == 0:
+		value.Delta = "new"
+	default:
+		value.Delta = fmt.Sprintf("%+.1f%%", (after-before)/before*100)
+	}
+	if (after > before) == higherIsBetter {
+		value.Verdict = "better"
+	} else {
+		value.Verdict = "worse"
+	}
+	return value
+}
+
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.2f/s", rate)
+}
+
+func formatCount(count float64) string {
+	return fmt.Sprintf("%.0f", count)
+}
+
+const (
+	chartWidth  = 560
+	chartHeight = 220
+	chartMargin = 40
+)
+
+// An SVG line chart of the latency percentiles of both runs, with the percentiles spread evenly along the x axis, so
+// the tail gets as much room as the median
+type latencyChart struct {
+	Width  int
+	Height int
+	Before string
+	After  string
+	Ticks  []chartTick
+	Max    string
+}
+
+type chartTick struct {
+	Label string
+	X     float64
+}
+
+func newLatencyChart(before, after *jsonLatency) *latencyChart {
+	chart := &latencyChart{Width: chartWidth + 2*chartMargin, Height: chartHeight + 2*chartMargin}
+	top := 0.0
+	for _, latency := range []*jsonLatency{before, after} {
+		if latency != nil {
+			top = math.Max(top, latency.micros(latency.Max))
+		}
+	}
+	if top == 0 {
+		top = 1
+	}
+	chart.Max = LatencyAuto.Format(top)
+	step := float64(chartWidth) / float64(len(comparedPercentiles)-1)
+	for i, percentile := range comparedPercentiles {
+		chart.Ticks = append(chart.Ticks, chartTick{Label: percentile.label, X: chartMargin + float64(i)*step})
+	}
+	points := func(latency *jsonLatency) string {
+		if latency == nil {
+			return ""
+		}
+		s := strings.Builder{}
+		for i, percentile := range comparedPercentiles {
+			y := chartMargin + chartHeight - latency.micros(percentile.value(latency))/top*chartHeight
+			s.WriteString(fmt.Sprintf("%.1f,%.1f ", chartMargin+float64(i)*step, y))
+		}
+		return strings.TrimSpace(s.String())
+	}
+	chart.Before = points(before)
+	chart.After = points(after)
+	return chart
+}
+
+var comparisonTemplate = template.Must(template.New("compare").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>neobench: {{.Before}} vs {{.After}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { padding: 4px 10px; text-align: right; border-bottom: 1px solid #ddd; }
+th:first-child, td:first-child { text-align: left; }
+.better { color: #1a7f37; }
+.worse { color: #cf222e; }
+.before { stroke: #0969da; }
+.after { stroke: #bc4c00; }
+</style>
+</head>
+<body>
+<h1>neobench comparison</h1>
+<p>Before: <b>{{.Before}}</b><br>After: <b>{{.After}}</b></p>
+
+<h2>Throughput</h2>
+<table>
+<tr><th>script</th><th>before</th><th>after</th><th>change</th><th>failed before</th><th>failed after</th><th>change</th></tr>
+{{range .Scripts}}{{template "row" .}}{{end}}{{template "row" .Total}}
+</table>
+{{range .Scripts}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>latency</th><th>before</th><th>after</th><th>change</th></tr>
+{{range .Latencies}}<tr><td>{{.Label}}</td><td>{{.Value.Before}}</td><td>{{.Value.After}}</td><td class="{{.Value.Verdict}}">{{.Value.Delta}}</td></tr>
+{{end}}</table>
+{{with .Chart}}<svg width="{{.Width}}" height="{{.Height}}" xmlns="http://www.w3.org/2000/svg" font-size="11">
+<line x1="40" y1="260" x2="600" y2="260" stroke="#999"/>
+<line x1="40" y1="40" x2="40" y2="260" stroke="#999"/>
+<text x="44" y="34">{{.Max}}</text>
+{{range .Ticks}}<text x="{{.X}}" y="276" text-anchor="middle">{{.Label}}</text>
+{{end}}{{if .Before}}<polyline class="before" fill="none" stroke-width="2" points="{{.Before}}"/>
+{{end}}{{if .After}}<polyline class="after" fill="none" stroke-width="2" points="{{.After}}"/>
+{{end}}<line class="before" x1="440" y1="20" x2="460" y2="20" stroke-width="2"/><text x="464" y="24">before</text>
+<line class="after" x1="520" y1="20" x2="540" y2="20" stroke-width="2"/><text x="544" y="24">after</text>
+</svg>{{end}}
+{{end}}
+</body>
+</html>
+{{define "row"}}<tr><td>{{.Name}}</td><td>{{.Throughput.Before}}</td><td>{{.Throughput.After}}</td><td class="{{.Throughput.Verdict}}">{{.Throughput.Delta}}</td><td>{{.Failed.Before}}</td><td>{{.Failed.After}}</td><td class="{{.Failed.Verdict}}">{{.Failed.Delta}}</td></tr>
+{{end}}`))
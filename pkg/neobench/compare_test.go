@@ -0,0 +1,70 @@
+package neobench
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestComparisonSetsScriptsOfBothRunsSideBySide(t *testing.T) {
+	// The runs were made with different --latency-unit, which the comparison has to see past
+	before := savedResult(t, "before.json", jsonResult{
+		TransactionsPerSecond: 100,
+		Scripts: []jsonScript{
+			{Name: "reads", TransactionsPerSecond: 100, Latency: &jsonLatency{unit: LatencyMilliseconds, P50: 2, P99: 10, Max: 20}},
+			{Name: "dropped", TransactionsPerSecond: 5, Latency: &jsonLatency{unit: LatencyMilliseconds, P50: 1, Max: 1}},
+		},
+		Failures: []jsonFailure{},
+	})
+	after := savedResult(t, "after.json", jsonResult{
+		TransactionsPerSecond: 150,
+		Failed:                3,
+		Scripts: []jsonScript{
+			{Name: "reads", TransactionsPerSecond: 150, Failed: 3, Latency: &jsonLatency{unit: LatencyMicroseconds, P50: 2000, P99: 5000, Max: 30000}},
+		},
+		Failures: []jsonFailure{},
+	})
+
+	out := bytes.Buffer{}
+	assert.NoError(t, WriteComparisonHtml(before, after, &out))
+	page := out.String()
+
+	assert.Contains(t, page, "<b>before.json</b>")
+	// html/template writes the + of increases as &#43;
+	assert.Contains(t, page, `<tr><td>reads</td><td>100.00/s</td><td>150.00/s</td><td class="better">&#43;50.0%</td><td>0</td><td>3</td><td class="worse">new</td></tr>`)
+	assert.Contains(t, page, `<tr><td>dropped</td><td>5.00/s</td><td></td><td class=""></td><td>0</td><td></td><td class=""></td></tr>`)
+	assert.Contains(t, page, `<tr><td>p50</td><td>2.000ms</td><td>2.000ms</td><td class="">0%</td></tr>`)
+	assert.Contains(t, page, `<tr><td>p99</td><td>10.000ms</td><td>5.000ms</td><td class="better">-50.0%</td></tr>`)
+	assert.Contains(t, page, `<tr><td>max</td><td>20.000ms</td><td>30.000ms</td><td class="worse">&#43;50.0%</td></tr>`)
+	// Both runs of reads are overlaid in one chart, the script in only one run has a line of its own
+	assert.Equal(t, 2, strings.Count(page, `<polyline class="before"`))
+	assert.Equal(t, 1, strings.Count(page, `<polyline class="after"`))
+}
+
+func TestLatencyReadsBackInTheUnitItWasWrittenIn(t *testing.T) {
+	written := &jsonLatency{unit: LatencySeconds, P50: 0.25, P99: 1.5, Max: 2}
+	data, err := json.Marshal(written)
+	assert.NoError(t, err)
+
+	read := &jsonLatency{}
+	assert.NoError(t, json.Unmarshal(data, read))
+
+	assert.Equal(t, written, read)
+	assert.Equal(t, 1500000.0, read.micros(read.P99))
+}
+
+func TestComparisonRejectsFilesThatAreNotJsonResults(t *testing.T) {
+	_, err := parseSavedResult("run.csv", strings.NewReader("script,rate\nreads,100\n"))
+
+	assert.EqualError(t, err, "failed to read result 'run.csv', expected the output of --output json: invalid character 's' looking for beginning of value")
+}
+
+func savedResult(t *testing.T, name string, result jsonResult) SavedResult {
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	saved, err := parseSavedResult(name, bytes.NewReader(data))
+	assert.NoError(t, err)
+	return saved
+}
@@ -0,0 +1,97 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Paces how fast the workers open sessions, and with them connections, to the database, and times how long the
+// connections they start out with take to set up. Shared by all the workers of a run, see Worker#SetConnectPacer.
+type ConnectPacer struct {
+	mut sync.Mutex
+	// Time between one session being opened and the next; 0 doesn't pace them
+	interval time.Duration
+	// When the next session may be opened
+	next time.Time
+	// How long setting up each connection took, in microseconds
+	latencies *hdrhistogram.Histogram
+}
+
+// Paces opening sessions to the given number a second; 0 doesn't pace them, and only times connecting
+func NewConnectPacer(perSecond float64) *ConnectPacer {
+	p := &ConnectPacer{latencies: hdrhistogram.New(0, 60*60*1000000, 3)}
+	if perSecond > 0 {
+		p.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return p
+}
+
+// Waits for the caller's turn to open a session; false if stopCh closed first
+func (p *ConnectPacer) Await(stopCh <-chan struct{}) bool {
+	if p.interval <= 0 {
+		return true
+	}
+	now := time.Now()
+	wait := p.reserve(now).Sub(now)
+	if wait <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(wait):
+		return true
+	case <-stopCh:
+		return false
+	}
+}
+
+// Reserves the next time a session may be opened, at the earliest now
+func (p *ConnectPacer) reserve(now time.Time) time.Time {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	turn := p.next
+	if turn.Before(now) {
+		turn = now
+	}
+	p.next = turn.Add(p.interval)
+	return turn
+}
+
+// Records how long setting up a connection took
+func (p *ConnectPacer) record(latency time.Duration) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	// Connecting for longer than the histogram goes only happens with a broken network, and the run fails anyway
+	_ = p.latencies.RecordValue(latency.Microseconds())
+}
+
+// How long setting up each connection took so far, in microseconds
+func (p *ConnectPacer) Latencies() *hdrhistogram.Histogram {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return hdrhistogram.Import(p.latencies.Export())
+}
+
+// Parses connection rates like "10/s", "600/m" or "10", the latter per second, into connections per second
+func ParseConnectionRate(s string) (float64, error) {
+	count, unit := strings.TrimSpace(s), time.Second
+	if i := strings.Index(count, "/"); i >= 0 {
+		switch strings.TrimSpace(count[i+1:]) {
+		case "s":
+			unit = time.Second
+		case "m":
+			unit = time.Minute
+		default:
+			return 0, fmt.Errorf("invalid connection rate '%s', expected connections per second or minute, ex: 10/s or 600/m", s)
+		}
+		count = strings.TrimSpace(count[:i])
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid connection rate '%s', expected connections per second or minute, ex: 10/s or 600/m", s)
+	}
+	return n / unit.Seconds(), nil
+}
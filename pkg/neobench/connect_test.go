@@ -0,0 +1,58 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestConnectPacerSpacesOutSessions(t *testing.T) {
+	pacer := NewConnectPacer(4)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, pacer.reserve(now))
+	assert.Equal(t, now.Add(250*time.Millisecond), pacer.reserve(now))
+	assert.Equal(t, now.Add(500*time.Millisecond), pacer.reserve(now))
+}
+
+func TestConnectPacerWithoutRateOnlyTimes(t *testing.T) {
+	pacer := NewConnectPacer(0)
+	assert.True(t, pacer.Await(nil))
+	pacer.record(3 * time.Millisecond)
+	pacer.record(5 * time.Millisecond)
+	latencies := pacer.Latencies()
+	assert.Equal(t, int64(2), latencies.TotalCount())
+	assert.InDelta(t, 5000, latencies.Max(), 10)
+}
+
+func TestWorkerTimesConnecting(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &warmingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	barrier := NewReadyBarrier(1)
+	pacer := NewConnectPacer(0)
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetReadyBarrier(barrier)
+	w.SetConnectPacer(pacer)
+
+	results := make(chan WorkerResult)
+	go func() {
+		results <- w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+	}()
+	assert.True(t, barrier.AwaitReady(nil))
+	barrier.Release(clock.now())
+	assert.NoError(t, (<-results).Error)
+	assert.Equal(t, int64(1), pacer.Latencies().TotalCount())
+}
+
+func TestParseConnectionRate(t *testing.T) {
+	for input, expected := range map[string]float64{"10/s": 10, "600/m": 10, "2.5": 2.5, " 30 / m ": 0.5} {
+		actual, err := ParseConnectionRate(input)
+		assert.NoError(t, err, input)
+		assert.InDelta(t, expected, actual, 0.0001, input)
+	}
+	for _, input := range []string{"", "0/s", "ten/s", "10/h", "-1"} {
+		_, err := ParseConnectionRate(input)
+		assert.Error(t, err, input)
+	}
+}
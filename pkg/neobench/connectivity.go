@@ -0,0 +1,79 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"strings"
+)
+
+// Runs a trivial query against the database the benchmark targets, so connection problems stop neobench before
+// any workers start, with an explanation of what to do about them rather than a driver error from every worker
+func VerifyConnectivity(driver neo4j.Driver, address, user, dbName string) error {
+	if err := checkConnectivity(driver, dbName); err != nil {
+		return diagnoseConnectionError(err, address, user, dbName)
+	}
+	return nil
+}
+
+func checkConnectivity(driver neo4j.Driver, dbName string) error {
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	res, err := session.Run("RETURN 1", nil)
+	if err != nil {
+		return err
+	}
+	_, err = res.Consume()
+	return err
+}
+
+// Translates common driver errors into what's likely wrong and how to fix it; errors it doesn't recognize are
+// returned with just the address added
+func diagnoseConnectionError(err error, address, user, dbName string) error {
+	if dbName == "" {
+		dbName = "(default)"
+	}
+	if neo4jErr, ok := err.(*neo4j.Neo4jError); ok {
+		switch neo4jErr.Code {
+		case "Neo.ClientError.Security.Unauthorized":
+			return fmt.Errorf("%s rejected the username and password for user '%s', check --user and --password", address, user)
+		case "Neo.ClientError.Security.AuthenticationRateLimit":
+			return fmt.Errorf("%s is refusing logins after too many failed attempts, wait a bit and check --user and --password", address)
+		case "Neo.ClientError.Database.DatabaseNotFound":
+			return fmt.Errorf("database %s does not exist on %s, check the DBNAME argument", dbName, address)
+		case "Neo.ClientError.Security.Forbidden":
+			return fmt.Errorf("user '%s' is not allowed to access database %s on %s: %s", user, dbName, address, neo4jErr.Msg)
+		}
+		return fmt.Errorf("failed to connect to %s: %s", address, err)
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "URI scheme"):
+		return fmt.Errorf("%s, use an address like neo4j://host:7687", msg)
+	case strings.Contains(msg, "Bolt versions") || strings.Contains(msg, "unsupported version"):
+		return fmt.Errorf("the server at %s does not speak Bolt, check that --address points to the Bolt port, usually 7687, "+
+			"rather than to the HTTP port, usually 7474: %s", address, msg)
+	case strings.Contains(msg, "x509:"):
+		return fmt.Errorf("the certificate of %s was not accepted: %s. If it's for a different name, like that of a load balancer, "+
+			"use --tls-server-name; --no-check-certificates turns checks off, exposing your credentials to anyone on the network",
+			address, msg)
+	case strings.Contains(msg, "check that TLS is enabled") || strings.Contains(msg, "does not look like a TLS handshake"):
+		return fmt.Errorf("%s does not have TLS enabled, use -e false to connect without encryption: %s", address, msg)
+	case strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host") || strings.Contains(msg, "i/o timeout"):
+		return fmt.Errorf("could not reach %s, check --address and that the server is running and reachable from here: %s", address, msg)
+	case strings.Contains(msg, "Unable to retrieve routing table"):
+		return fmt.Errorf("%s did not provide a routing table for database %s: %s. Check that the database is online "+
+			"and that --address is a server of the cluster, not a load balancer in front of it", address, dbName, msg)
+	case strings.Contains(msg, "No readers") || strings.Contains(msg, "No writers"):
+		return fmt.Errorf("the routing table from %s has no servers for database %s right now, check that the cluster is healthy: %s",
+			address, dbName, msg)
+	case strings.Contains(msg, "No server connection available"):
+		return fmt.Errorf("could not connect to the servers %s says the cluster has: %s. If their advertised addresses are "+
+			"not reachable from here, like when connecting through a port forward, --resolver connects to just the "+
+			"server of --address, through an address that is reachable", address, msg)
+	}
+	return fmt.Errorf("failed to connect to %s: %s", address, msg)
+}
@@ -0,0 +1,28 @@
+package neobench
+
+import (
+	"errors"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiagnoseConnectionErrors(t *testing.T) {
+	for _, c := range []struct {
+		err      error
+		expected string
+	}{
+		{&neo4j.Neo4jError{Code: "Neo.ClientError.Security.Unauthorized", Msg: "The client is unauthorized"}, "check --user and --password"},
+		{&neo4j.Neo4jError{Code: "Neo.ClientError.Database.DatabaseNotFound", Msg: "no such db"}, "database mydb does not exist"},
+		{errors.New("Server responded with unsupported version 72.84"), "does not speak Bolt"},
+		{errors.New("ConnectivityError: x509: certificate is valid for lb.internal, not db.example.com"), "use --tls-server-name"},
+		{errors.New("ConnectivityError: Remote end closed the connection, check that TLS is enabled on the server"), "use -e false"},
+		{errors.New("Unable to retrieve routing table from localhost:7687: dial tcp 127.0.0.1:7687: connect: connection refused"), "check --address"},
+		{errors.New("Unable to retrieve routing table from localhost:7687: Server error"), "Check that the database is online"},
+		{errors.New("No server connection available to any of [core1:7687 core2:7687]"), "--resolver"},
+		{errors.New("something else"), "failed to connect to localhost:7687: something else"},
+	} {
+		err := diagnoseConnectionError(c.err, "localhost:7687", "neo4j", "mydb")
+		assert.Contains(t, err.Error(), c.expected)
+	}
+}
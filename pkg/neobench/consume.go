@@ -0,0 +1,55 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// How much of the results of its statements a script reads, set with `:consume`
+const (
+	// Read every record, the default
+	ConsumeAll = "all"
+	// Read the first record and discard the rest, as an application showing the first page of results would. With
+	// `:fetch-size`, the server is told to stop streaming the rest rather than sending them to be thrown away.
+	ConsumeFirst = "first"
+	// Don't read the results at all; the driver discards them when the transaction commits or the next statement runs
+	ConsumeNone = "none"
+)
+
+// Parses the rest of a `:consume` line
+func parseConsume(s *Script, c *parseContext) {
+	switch mode := ident(c); mode {
+	case ConsumeAll, ConsumeFirst, ConsumeNone:
+		s.Consume = mode
+	default:
+		c.fail(fmt.Errorf("unexpected :consume '%s', expected %s, %s or %s", mode, ConsumeAll, ConsumeFirst, ConsumeNone))
+	}
+}
+
+// Consumes as much of the given result as the mode says, one of the Consume constants; empty is the same as
+// ConsumeAll. When measuring payload sizes, the records read are counted and sized first, and when measuring time to
+// first record, the first of them is timed. There's no summary with ConsumeNone.
+func (w *Worker) consume(res neo4j.Result, mode string, size *payloadSize, first *firstRecordTimer) (neo4j.ResultSummary, error) {
+	if mode == ConsumeNone {
+		return nil, nil
+	}
+	if w.payloads || w.firstRecords || mode == ConsumeFirst {
+		for res.Next() {
+			if w.firstRecords && !first.seen {
+				first.seen = true
+				first.latency = w.now().Sub(first.start)
+			}
+			if w.payloads {
+				size.rows++
+				for _, value := range res.Record().Values {
+					size.received += valueSize(value)
+				}
+			}
+			// Only payload sizes need more than the first record
+			if !w.payloads || mode == ConsumeFirst {
+				break
+			}
+		}
+	}
+	return res.Consume()
+}
@@ -0,0 +1,45 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseConsume(t *testing.T) {
+	script, err := Parse("consume", ":consume first\nMATCH (n) RETURN n;", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ConsumeFirst, script.Consume)
+	assert.Len(t, script.Commands, 1)
+
+	_, err = Parse("invalid", ":consume some", 1)
+	assert.Error(t, err)
+}
+
+func TestConsumeModes(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	w := &Worker{now: clock.now, sleep: clock.sleep, payloads: true}
+	results := func() *streamingResult {
+		return &streamingResult{clock: clock, records: 5, interval: time.Millisecond}
+	}
+
+	for _, c := range []struct {
+		mode     string
+		read     int64
+		consumed bool
+	}{{ConsumeAll, 5, true}, {"", 5, true}, {ConsumeFirst, 1, true}, {ConsumeNone, 0, false}} {
+		res := results()
+		size := payloadSize{}
+		_, err := w.consume(res, c.mode, &size, &firstRecordTimer{})
+		assert.NoError(t, err)
+		assert.Equal(t, c.read, size.rows, c.mode)
+		assert.Equal(t, c.consumed, res.consumed, c.mode)
+	}
+
+	// Without measuring anything, the first record is still read
+	w = &Worker{now: clock.now, sleep: clock.sleep}
+	res := results()
+	_, err := w.consume(res, ConsumeFirst, &payloadSize{}, &firstRecordTimer{})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, res.records)
+}
@@ -0,0 +1,160 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// The lowest total rate the controller will back off to; below this, checkpoints have too few transactions
+// to say anything about the percentile
+const minControlledRate = 0.1
+
+// How much the controller raises the rate after a checkpoint that met the target, at most, and how much it
+// lowers it after one that missed, at most
+const (
+	controllerMaxIncrease = 1.2
+	controllerMaxDecrease = 0.5
+)
+
+// A latency percentile to hold, set with --target-latency, ex: p99=100ms
+type LatencyTarget struct {
+	// Between 0 and 100, ex: 99.9
+	Percentile float64
+	Latency    time.Duration
+}
+
+func ParseLatencyTarget(spec string) (LatencyTarget, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || !strings.HasPrefix(strings.TrimSpace(parts[0]), "p") {
+		return LatencyTarget{}, fmt.Errorf("invalid --target-latency '%s', expected a percentile and a latency, ex: p99=100ms", spec)
+	}
+	percentile, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(parts[0]), "p"), 64)
+	if err != nil || percentile <= 0 || percentile >= 100 {
+		return LatencyTarget{}, fmt.Errorf("invalid --target-latency percentile '%s', must be between p0 and p100, ex: p99.9", parts[0])
+	}
+	latency, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || latency <= 0 {
+		return LatencyTarget{}, fmt.Errorf("invalid --target-latency latency '%s', must be a duration like 100ms", parts[1])
+	}
+	return LatencyTarget{Percentile: percentile, Latency: latency}, nil
+}
+
+func (t LatencyTarget) String() string {
+	return fmt.Sprintf("p%g=%s", t.Percentile, t.Latency)
+}
+
+// Decides the latency mode rate as the run goes, rather than it staying at --rate; see RateController and PhaseRates
+type RateSource interface {
+	// Time between transactions for each client at the current rate; safe to call from any worker
+	ClientInterval() time.Duration
+}
+
+// Adjusts the latency mode rate after each progress checkpoint, to hold a latency percentile at a target, for
+// finding how much load the database can take while meeting an SLO. Checkpoints that met the target raise the
+// rate, checkpoints that missed lower it, each in proportion to how far off the target they were.
+//
+// Latencies are measured from when transactions should have started, so once the database falls behind the
+// backlog keeps latencies high until the lowered rate lets it catch up; the controller backs off hard for that.
+type RateController struct {
+	// Current total rate, as float64 bits, read by all workers; first in the struct, to be 64-bit aligned for atomics
+	rateBits uint64
+
+	Target     LatencyTarget
+	numClients int
+
+	// What each checkpoint the controller has seen looked like, in order
+	Windows []ControlWindow
+}
+
+// One progress checkpoint, as seen by the RateController
+type ControlWindow struct {
+	// Total rate the workers were asked for during this window
+	OfferedRate float64
+	// Total rate the workers achieved
+	AchievedRate float64
+	// The target percentile of latency in this window, across all scripts
+	Latency   time.Duration
+	MetTarget bool
+}
+
+func NewRateController(target LatencyTarget, initialRate float64, numClients int) *RateController {
+	c := &RateController{Target: target, numClients: numClients}
+	c.setRate(initialRate)
+	return c
+}
+
+// Total rate the workers should currently run at
+func (c *RateController) Rate() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.rateBits))
+}
+
+// Time between transactions for each client at the current rate; safe to call from any worker
+func (c *RateController) ClientInterval() time.Duration {
+	return TotalRatePerSecondToDurationPerClient(c.numClients, c.Rate())
+}
+
+func (c *RateController) setRate(rate float64) {
+	atomic.StoreUint64(&c.rateBits, math.Float64bits(math.Max(rate, minControlledRate)))
+}
+
+// Adjusts the rate based on the given progress checkpoint; checkpoints without any successful transactions
+// count as missing the target, since the database didn't get anything done in time
+func (c *RateController) Observe(checkpoint Result) {
+	offered := c.Rate()
+	latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+	for _, script := range checkpoint.Scripts {
+		latencies.Merge(script.Latencies)
+	}
+
+	window := ControlWindow{OfferedRate: offered, AchievedRate: checkpoint.TotalRate()}
+	factor := controllerMaxDecrease
+	if latencies.TotalCount() > 0 {
+		window.Latency = time.Duration(latencies.ValueAtQuantile(c.Target.Percentile)) * time.Microsecond
+		window.MetTarget = window.Latency <= c.Target.Latency
+		if window.Latency > 0 {
+			factor = float64(c.Target.Latency) / float64(window.Latency)
+		} else {
+			factor = controllerMaxIncrease
+		}
+	}
+	factor = math.Min(math.Max(factor, controllerMaxDecrease), controllerMaxIncrease)
+	c.Windows = append(c.Windows, window)
+	c.setRate(offered * factor)
+}
+
+// The highest rate the database achieved during a checkpoint that met the target; 0 if none did
+func (c *RateController) SustainedRate() float64 {
+	sustained := 0.0
+	for _, window := range c.Windows {
+		if window.MetTarget && window.AchievedRate > sustained {
+			sustained = window.AchievedRate
+		}
+	}
+	return sustained
+}
+
+// Outcome of a run with --target-latency
+type ControlResult struct {
+	Target LatencyTarget
+	// See RateController#SustainedRate
+	SustainedRate float64
+	// The rate the controller had settled on when the run ended
+	FinalRate float64
+	Windows   []ControlWindow
+}
+
+func (c *RateController) Result() *ControlResult {
+	return &ControlResult{
+		Target:        c.Target,
+		SustainedRate: c.SustainedRate(),
+		FinalRate:     c.Rate(),
+		Windows:       c.Windows,
+	}
+}
+
+var _ RateSource = &RateController{}
@@ -0,0 +1,58 @@
+package neobench
+
+import (
+	"github.com/codahale/hdrhistogram"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseLatencyTarget(t *testing.T) {
+	target, err := ParseLatencyTarget("p99.9=100ms")
+	assert.NoError(t, err)
+	assert.Equal(t, LatencyTarget{Percentile: 99.9, Latency: 100 * time.Millisecond}, target)
+
+	for _, spec := range []string{"", "p99", "99=100ms", "p100=1s", "p0=1s", "p99=fast", "p99=0s"} {
+		_, err := ParseLatencyTarget(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRateControllerHoldsTarget(t *testing.T) {
+	controller := NewRateController(LatencyTarget{Percentile: 99, Latency: 100 * time.Millisecond}, 100, 4)
+	assert.Equal(t, 40*time.Millisecond, controller.ClientInterval())
+
+	// Well under the target, so the rate goes up, but only by so much at a time
+	controller.Observe(controlCheckpoint(100, 10*time.Millisecond))
+	assert.InDelta(t, 120, controller.Rate(), 0.001)
+
+	// Twice the target, so the rate is halved
+	controller.Observe(controlCheckpoint(110, 200*time.Millisecond))
+	assert.InDelta(t, 60, controller.Rate(), 0.001)
+
+	// Slightly under the target, so the rate goes up slightly
+	controller.Observe(controlCheckpoint(60, 90*time.Millisecond))
+	assert.InDelta(t, 66.667, controller.Rate(), 0.1)
+
+	// Nothing got done at all
+	controller.Observe(NewResult("", ""))
+	assert.InDelta(t, 33.333, controller.Rate(), 0.1)
+
+	result := controller.Result()
+	assert.Equal(t, 4, len(result.Windows))
+	assert.Equal(t, []bool{true, false, true, false}, []bool{
+		result.Windows[0].MetTarget, result.Windows[1].MetTarget, result.Windows[2].MetTarget, result.Windows[3].MetTarget})
+	// The highest achieved rate that met the target; the 110tps checkpoint missed it
+	assert.Equal(t, 100.0, result.SustainedRate)
+	assert.InDelta(t, 33.333, result.FinalRate, 0.1)
+}
+
+func controlCheckpoint(rate float64, latency time.Duration) Result {
+	latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+	for i := 0; i < 100; i++ {
+		_ = latencies.RecordValue(latency.Microseconds())
+	}
+	result := NewResult("", "")
+	result.Scripts["a"] = &ScriptResult{ScriptName: "a", Rate: rate, Succeeded: 100, Latencies: latencies}
+	return result
+}
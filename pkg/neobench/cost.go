@@ -0,0 +1,123 @@
+package neobench
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name of the server metric costs take page faults from, see serverMetrics
+const pageFaultsMetric = "Page faults"
+
+// What the transactions of one script cost the server, on average, so capacity planning can go from transactions
+// per second to the load on the hardware. Db hits come from the units of work profiled with --profile-sample. Page
+// faults come from --server-metrics, which only has them for the server as a whole; they are split over the
+// scripts by their share of the db hits, so they are an estimate, and only there when every script was profiled.
+type CostEstimate struct {
+	ScriptName string
+	// Transactions per second the script ran at
+	Rate float64
+	// Per transaction; negative if not known
+	DbHits     float64
+	PageFaults float64
+}
+
+// Estimates the cost of each script in the result, and of all of them together under the name ""; nil if neither
+// profiles nor page faults were measured
+func EstimateCosts(result Result) []CostEstimate {
+	faults, faultsMeasured := sampledPageFaults(result)
+	if len(result.Profiles) == 0 && !faultsMeasured {
+		return nil
+	}
+
+	scripts := sortedScripts(result.Scripts)
+	estimates := make([]CostEstimate, 0, len(scripts)+1)
+	total := CostEstimate{Rate: result.TotalRate(), DbHits: -1, PageFaults: -1}
+	totalHitRate, profiledAll := 0.0, true
+	for _, script := range scripts {
+		estimate := CostEstimate{ScriptName: script.ScriptName, Rate: script.Rate, DbHits: -1, PageFaults: -1}
+		if profile, found := result.Profiles[script.ScriptName]; found && profile.Units > 0 {
+			estimate.DbHits = profile.DbHitsPerUnit()
+			totalHitRate += estimate.DbHits * script.Rate
+		} else {
+			profiledAll = false
+		}
+		estimates = append(estimates, estimate)
+	}
+	if profiledAll && total.Rate > 0 {
+		total.DbHits = totalHitRate / total.Rate
+	}
+	if faultsMeasured && total.Rate > 0 {
+		// Faults per second over the sampled part of the run, spread over the transactions of the whole run
+		total.PageFaults = faults / total.Rate
+		if profiledAll && totalHitRate > 0 {
+			for i := range estimates {
+				estimates[i].PageFaults = faults * estimates[i].DbHits / totalHitRate
+			}
+		}
+	}
+	return append(estimates, total)
+}
+
+// Page faults per second over the part of the run the server metrics were sampled over; false if the server
+// didn't expose them, or they weren't scraped
+func sampledPageFaults(result Result) (float64, bool) {
+	if result.ServerMetrics == nil || len(result.ServerMetrics.Samples) == 0 {
+		return 0, false
+	}
+	samples := result.ServerMetrics.Samples
+	total, found := 0.0, false
+	for _, sample := range samples {
+		if faults, ok := sample.Values[pageFaultsMetric]; ok {
+			total += faults
+			found = true
+		}
+	}
+	elapsed := samples[len(samples)-1].Elapsed.Seconds()
+	if !found || elapsed <= 0 {
+		return 0, false
+	}
+	return total / elapsed, true
+}
+
+// Cost per transaction by script, and what the rate of the run adds up to; only written with --profile-sample or
+// --server-metrics
+func writeCostReport(result Result, s *strings.Builder) {
+	estimates := EstimateCosts(result)
+	if estimates == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Cost per transaction --\n\n"))
+	for _, estimate := range estimates {
+		name := "Overall"
+		if estimate.ScriptName != "" {
+			name = fmt.Sprintf("[%s]", estimate.ScriptName)
+		}
+		costs := describeCosts(estimate, 1)
+		if len(costs) == 0 {
+			s.WriteString(fmt.Sprintf("  %s: not profiled\n", name))
+			continue
+		}
+		line := fmt.Sprintf("  %s: %s per transaction", name, strings.Join(costs, ", "))
+		if estimate.ScriptName == "" {
+			line += fmt.Sprintf("; at %.3f transactions per second, %s per second", estimate.Rate,
+				strings.Join(describeCosts(estimate, estimate.Rate), ", "))
+		}
+		s.WriteString(line + "\n")
+	}
+	if estimates[0].PageFaults >= 0 {
+		s.WriteString("  Page faults are measured for the whole server, and split over scripts by their share of db hits\n")
+	}
+	s.WriteString("\n")
+}
+
+// The known costs of the estimate, times the given factor, ex: 12.5 db hits, 0.3 page faults
+func describeCosts(estimate CostEstimate, factor float64) []string {
+	costs := make([]string, 0, 2)
+	if estimate.DbHits >= 0 {
+		costs = append(costs, fmt.Sprintf("%.1f db hits", estimate.DbHits*factor))
+	}
+	if estimate.PageFaults >= 0 {
+		costs = append(costs, fmt.Sprintf("%.1f page faults", estimate.PageFaults*factor))
+	}
+	return costs
+}
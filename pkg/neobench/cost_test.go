@@ -0,0 +1,63 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateCostsSplitsPageFaultsByDbHits(t *testing.T) {
+	result := NewResult("neo4j", "")
+	result.Scripts["reads"] = &ScriptResult{ScriptName: "reads", Rate: 100}
+	result.Scripts["writes"] = &ScriptResult{ScriptName: "writes", Rate: 50}
+	result.Profiles["reads"] = &ProfileResult{ScriptName: "reads", Units: 2, Operators: map[string]*OperatorProfile{"NodeIndexSeek": {DbHits: 20}}}
+	result.Profiles["writes"] = &ProfileResult{ScriptName: "writes", Units: 1, Operators: map[string]*OperatorProfile{"Create": {DbHits: 40}}}
+	// 3000 faults over 10 seconds
+	result.ServerMetrics = &ServerMetricsResult{Samples: []ServerMetricsSample{
+		{Elapsed: 5 * time.Second, Values: map[string]float64{pageFaultsMetric: 1000}},
+		{Elapsed: 10 * time.Second, Values: map[string]float64{pageFaultsMetric: 2000}},
+	}}
+
+	estimates := EstimateCosts(result)
+
+	assert.Len(t, estimates, 3)
+	reads, writes, total := estimates[0], estimates[1], estimates[2]
+	assert.Equal(t, 10.0, reads.DbHits)
+	assert.Equal(t, 40.0, writes.DbHits)
+	// 1000 db hits a second for reads, 2000 for writes; 300 faults a second split the same way
+	assert.InDelta(t, 1.0, reads.PageFaults, 0.0001)
+	assert.InDelta(t, 4.0, writes.PageFaults, 0.0001)
+	assert.InDelta(t, 20.0, total.DbHits, 0.0001)
+	assert.InDelta(t, 2.0, total.PageFaults, 0.0001)
+
+	s := strings.Builder{}
+	writeCostReport(result, &s)
+	assert.Equal(t, `-- Cost per transaction --
+
+  [reads]: 10.0 db hits, 1.0 page faults per transaction
+  [writes]: 40.0 db hits, 4.0 page faults per transaction
+  Overall: 20.0 db hits, 2.0 page faults per transaction; at 150.000 transactions per second, 3000.0 db hits, 300.0 page faults per second
+  Page faults are measured for the whole server, and split over scripts by their share of db hits
+
+`, s.String())
+}
+
+func TestEstimateCostsWithoutProfilingEveryScript(t *testing.T) {
+	result := NewResult("neo4j", "")
+	result.Scripts["reads"] = &ScriptResult{ScriptName: "reads", Rate: 100}
+	result.Scripts["writes"] = &ScriptResult{ScriptName: "writes", Rate: 50}
+	result.Profiles["reads"] = &ProfileResult{ScriptName: "reads", Units: 1, Operators: map[string]*OperatorProfile{"NodeIndexSeek": {DbHits: 10}}}
+
+	s := strings.Builder{}
+	writeCostReport(result, &s)
+	assert.Equal(t, `-- Cost per transaction --
+
+  [reads]: 10.0 db hits per transaction
+  [writes]: not profiled
+  Overall: not profiled
+
+`, s.String())
+
+	assert.Nil(t, EstimateCosts(NewResult("neo4j", "")))
+}
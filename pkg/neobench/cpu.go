@@ -0,0 +1,141 @@
+package neobench
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	CpuGuardOff   = "off"
+	CpuGuardWarn  = "warn"
+	CpuGuardAbort = "abort"
+)
+
+// Exit code when --cpu-guard abort stopped the run
+const CpuGuardExitCode = 4
+
+// Share of the CPU available to neobench above which the load generator, rather than the database, is likely what
+// limits throughput and adds latency
+const cpuGuardThreshold = 0.9
+
+func ParseCpuGuard(mode string) (string, error) {
+	switch mode {
+	case CpuGuardOff, CpuGuardWarn, CpuGuardAbort:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown --cpu-guard mode: %s, supported modes are 'off', 'warn' and 'abort'", mode)
+}
+
+// Follows the CPU use of neobench itself. A load generator that is out of CPU queues transactions up on its own
+// side, so the throughput and latency it reports are its own, not the database's; this makes that visible, and can
+// stop the run rather than let it produce numbers that would be misread.
+type CpuGuard struct {
+	Mode string
+	// CPUs neobench can use, see runtime.GOMAXPROCS
+	cpus int
+	// CPU time the process has used, user and system; see processCpuTime
+	cpuTime func() (time.Duration, error)
+
+	start     time.Time
+	lastAt    time.Time
+	lastUsage time.Duration
+	samples   []CpuSample
+	aborted   bool
+}
+
+// CPU use of neobench over one progress interval
+type CpuSample struct {
+	Elapsed time.Duration
+	// Of the CPUs available to neobench, 1 being all of them
+	Usage float64
+}
+
+type CpuResult struct {
+	Cpus    int
+	Samples []CpuSample
+	// Whether the run was stopped for saturating the CPU, with --cpu-guard abort
+	Aborted bool
+}
+
+func NewCpuGuard(mode string) *CpuGuard {
+	return &CpuGuard{Mode: mode, cpus: runtime.GOMAXPROCS(0), cpuTime: processCpuTime}
+}
+
+func (g *CpuGuard) Begin(now time.Time) error {
+	usage, err := g.cpuTime()
+	if err != nil {
+		return err
+	}
+	g.start, g.lastAt, g.lastUsage = now, now, usage
+	return nil
+}
+
+// Records CPU use since the previous sample; returns it, and whether it is over cpuGuardThreshold
+func (g *CpuGuard) Sample(now time.Time) (CpuSample, bool, error) {
+	usage, err := g.cpuTime()
+	if err != nil {
+		return CpuSample{}, false, err
+	}
+	wall := now.Sub(g.lastAt)
+	if wall <= 0 {
+		return CpuSample{}, false, nil
+	}
+	sample := CpuSample{
+		Elapsed: now.Sub(g.start),
+		Usage:   (usage - g.lastUsage).Seconds() / (wall.Seconds() * float64(g.cpus)),
+	}
+	g.lastAt, g.lastUsage = now, usage
+	g.samples = append(g.samples, sample)
+	return sample, sample.Usage > cpuGuardThreshold, nil
+}
+
+// Marks the run as stopped by the guard
+func (g *CpuGuard) Abort() {
+	g.aborted = true
+}
+
+func (g *CpuGuard) Result() *CpuResult {
+	return &CpuResult{Cpus: g.cpus, Samples: g.samples, Aborted: g.aborted}
+}
+
+// Whether any progress interval had neobench over cpuGuardThreshold
+func (r *CpuResult) Saturated() bool {
+	for _, sample := range r.Samples {
+		if sample.Usage > cpuGuardThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// CPU use of neobench itself; only written when the load generator was at risk of being the bottleneck, since
+// otherwise it's noise in the report
+func writeCpuReport(result Result, s *strings.Builder) {
+	cpu := result.Cpu
+	if cpu == nil || len(cpu.Samples) == 0 || !cpu.Saturated() {
+		return
+	}
+	mean, peak := 0.0, cpu.Samples[0]
+	saturated := 0
+	for _, sample := range cpu.Samples {
+		mean += sample.Usage
+		if sample.Usage > peak.Usage {
+			peak = sample
+		}
+		if sample.Usage > cpuGuardThreshold {
+			saturated++
+		}
+	}
+	mean /= float64(len(cpu.Samples))
+	s.WriteString(fmt.Sprintf("-- Load generator --\n\n"))
+	s.WriteString(fmt.Sprintf("  CPU use of neobench: mean %.0f%%, peak %.0f%% %s into the run, of %d CPUs\n",
+		mean*100, peak.Usage*100, peak.Elapsed.Round(time.Second), cpu.Cpus))
+	s.WriteString(fmt.Sprintf("  neobench used over %.0f%% of its CPU in %d of %d progress intervals; it may have been the bottleneck "+
+		"rather than the database, which makes these results unreliable\n", cpuGuardThreshold*100, saturated, len(cpu.Samples)))
+	if cpu.Aborted {
+		s.WriteString(fmt.Sprintf("  The run was stopped early by --cpu-guard abort\n"))
+	}
+	s.WriteString("\n")
+}
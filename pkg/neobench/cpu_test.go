@@ -0,0 +1,52 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCpuGuardFlagsSaturatedIntervals(t *testing.T) {
+	used := time.Duration(0)
+	guard := &CpuGuard{Mode: CpuGuardWarn, cpus: 2, cpuTime: func() (time.Duration, error) { return used, nil }}
+	start := time.Unix(0, 0)
+	assert.NoError(t, guard.Begin(start))
+
+	// One of two CPUs busy over 10 seconds
+	used += 10 * time.Second
+	sample, saturated, err := guard.Sample(start.Add(10 * time.Second))
+	assert.NoError(t, err)
+	assert.False(t, saturated)
+	assert.InDelta(t, 0.5, sample.Usage, 0.001)
+
+	// Both, nearly
+	used += 19 * time.Second
+	sample, saturated, err = guard.Sample(start.Add(20 * time.Second))
+	assert.NoError(t, err)
+	assert.True(t, saturated)
+	assert.InDelta(t, 0.95, sample.Usage, 0.001)
+
+	guard.Abort()
+	s := strings.Builder{}
+	writeCpuReport(Result{Cpu: guard.Result()}, &s)
+	assert.Equal(t, `-- Load generator --
+
+  CPU use of neobench: mean 72%, peak 95% 20s into the run, of 2 CPUs
+  neobench used over 90% of its CPU in 1 of 2 progress intervals; it may have been the bottleneck rather than the database, which makes these results unreliable
+  The run was stopped early by --cpu-guard abort
+
+`, s.String())
+}
+
+func TestCpuReportLeftOutWhenNotSaturated(t *testing.T) {
+	s := strings.Builder{}
+	writeCpuReport(Result{Cpu: &CpuResult{Cpus: 4, Samples: []CpuSample{{Elapsed: time.Second, Usage: 0.3}}}}, &s)
+	assert.Equal(t, "", s.String())
+}
+
+func TestProcessCpuTime(t *testing.T) {
+	used, err := processCpuTime()
+	assert.NoError(t, err)
+	assert.True(t, used > 0)
+}
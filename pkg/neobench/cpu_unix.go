@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package neobench
+
+import (
+	"syscall"
+	"time"
+)
+
+// User and system CPU time used by this process so far
+func processCpuTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, err
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
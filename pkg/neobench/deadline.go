@@ -0,0 +1,67 @@
+package neobench
+
+import (
+	"sync"
+	"time"
+)
+
+// Exit code when the run was ended by --hard-deadline, as opposed to 1 for failed transactions
+const HardDeadlineExitCode = 3
+
+// Ends the process if the run goes on for longer than it should, see --hard-deadline. A driver that hangs on a dead
+// connection can otherwise keep workers from ever returning, and neobench from ever exiting, which stalls whatever
+// automation runs it. Before exiting, this tries to write out what was recorded so far, but gives up on that too after
+// FlushTimeout, since whatever hung the run may hang that as well.
+type HardDeadline struct {
+	Grace        time.Duration
+	FlushTimeout time.Duration
+
+	mut   sync.Mutex
+	timer *time.Timer
+	exit  func(code int)
+}
+
+func NewHardDeadline(grace time.Duration, exit func(code int)) *HardDeadline {
+	return &HardDeadline{
+		Grace:        grace,
+		FlushTimeout: 10 * time.Second,
+		exit:         exit,
+	}
+}
+
+// Arms the deadline for a run due to end at the given time; if it hasn't been disarmed Grace after that, flush is
+// called to write out partial results and the process exits. Arming again moves the deadline, ex: from the end of
+// setting up to the end of the run.
+func (h *HardDeadline) Arm(end time.Time, flush func()) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	h.timer = time.AfterFunc(time.Until(end.Add(h.Grace)), func() {
+		h.expire(flush)
+	})
+}
+
+// Called once the run has completed
+func (h *HardDeadline) Disarm() {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+}
+
+func (h *HardDeadline) expire(flush func()) {
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		flush()
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(h.FlushTimeout):
+	}
+	h.exit(HardDeadlineExitCode)
+}
@@ -0,0 +1,70 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHardDeadlineExitsAfterGrace(t *testing.T) {
+	exited := make(chan int, 1)
+	flushed := make(chan struct{}, 1)
+	deadline := NewHardDeadline(10*time.Millisecond, func(code int) { exited <- code })
+
+	deadline.Arm(time.Now(), func() { flushed <- struct{}{} })
+
+	select {
+	case code := <-exited:
+		assert.Equal(t, HardDeadlineExitCode, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the hard deadline to exit")
+	}
+	assert.Len(t, flushed, 1)
+}
+
+func TestHardDeadlineExitsEvenIfFlushHangs(t *testing.T) {
+	exited := make(chan int, 1)
+	deadline := NewHardDeadline(0, func(code int) { exited <- code })
+	deadline.FlushTimeout = 10 * time.Millisecond
+
+	hang := make(chan struct{})
+	defer close(hang)
+	deadline.Arm(time.Now(), func() { <-hang })
+
+	select {
+	case code := <-exited:
+		assert.Equal(t, HardDeadlineExitCode, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the hard deadline to exit, not wait for the flush")
+	}
+}
+
+func TestDisarmedHardDeadlineDoesNotExit(t *testing.T) {
+	exited := make(chan int, 1)
+	deadline := NewHardDeadline(20*time.Millisecond, func(code int) { exited <- code })
+
+	deadline.Arm(time.Now(), func() {})
+	deadline.Disarm()
+
+	select {
+	case <-exited:
+		t.Fatal("expected a disarmed hard deadline not to exit")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRearmedHardDeadlineMovesTheDeadline(t *testing.T) {
+	exited := make(chan int, 1)
+	deadline := NewHardDeadline(0, func(code int) { exited <- code })
+
+	// Setting up was due to end now, but finished; the run ends well after
+	deadline.Arm(time.Now().Add(20*time.Millisecond), func() {})
+	deadline.Arm(time.Now().Add(time.Hour), func() {})
+	defer deadline.Disarm()
+
+	select {
+	case <-exited:
+		t.Fatal("expected the first deadline to be replaced by the second")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
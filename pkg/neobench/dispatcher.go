@@ -0,0 +1,175 @@
+package neobench
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Runs the clients of latency mode on a bounded pool of workers, see --worker-pool, rather than on a worker each.
+// Modelling thousands of users, each sending a transaction now and then, otherwise takes thousands of goroutines
+// and sessions that mostly sleep; here, whichever worker is free runs the transaction that is due first, of
+// whichever client it belongs to. Clients keep their own schedule, workload state and results, so the report is
+// the same as without a pool; if the pool is too small to keep up, that shows as clients falling behind schedule.
+type Dispatcher struct {
+	workers []*Worker
+	// Time between the transactions of each client; see Worker#RunBenchmark
+	interval time.Duration
+	rates    RateSource
+
+	mut sync.Mutex
+	// Signalled when a client is released
+	released *sync.Cond
+	clients  dispatchQueue
+	// Clients that haven't stopped or crashed, whether queued or running
+	active int
+}
+
+// A logical client, run by whichever worker of the pool is free when its next transaction is due
+type DispatchedClient struct {
+	Id       int64
+	Workload ClientWorkload
+	Recorder *ResultRecorder
+
+	nextStart time.Time
+	err       error
+	// Stopped after a failure during shutdown, see Worker#runNext
+	stopped bool
+}
+
+func NewDispatcher(workers []*Worker, interval time.Duration, rates RateSource) *Dispatcher {
+	d := &Dispatcher{workers: workers, interval: interval, rates: rates}
+	d.released = sync.NewCond(&d.mut)
+	return d
+}
+
+// Runs the given clients until stopCh closes, returning the result of each, in the order given; onCrash is called
+// as soon as a client fails for a reason other than its transactions failing, like a script error
+func (d *Dispatcher) Run(clients []*DispatchedClient, databaseName string, stopCh <-chan struct{},
+	onCrash func(clientId int64, err error)) []WorkerResult {
+	if len(d.workers) == 0 {
+		return nil
+	}
+	// Workers set up in parallel; none of them takes a client until the clients are queued from when they all started
+	queued := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, worker := range d.workers {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			sessions := newWorkerSessions(w.driver, databaseName, &acquisitionTimer{now: w.now})
+			defer sessions.close()
+			if _, started := w.awaitStart(sessions, stopCh); !started {
+				return
+			}
+			<-queued
+			d.runWorker(w, sessions, stopCh, onCrash)
+		}(worker)
+	}
+
+	start, started := d.workers[0].now(), true
+	if barrier := d.workers[0].barrier; barrier != nil {
+		start, started = barrier.Wait(stopCh)
+	}
+	if !started {
+		start = d.workers[0].now()
+	}
+	d.clients = make(dispatchQueue, 0, len(clients))
+	for _, client := range clients {
+		if barrier := d.workers[0].barrier; barrier != nil && started {
+			// Like with a worker per client, phases count from the release, see Worker#RunBenchmark
+			client.Workload.Start = barrier.MeasureStart()
+		}
+		client.nextStart = start
+		client.Recorder.Begin(start)
+		d.clients = append(d.clients, client)
+	}
+	heap.Init(&d.clients)
+	d.active = len(clients)
+	close(queued)
+	wg.Wait()
+
+	results := make([]WorkerResult, 0, len(clients))
+	for _, client := range clients {
+		if client.err != nil {
+			results = append(results, WorkerResult{WorkerId: client.Id, Error: client.err})
+			continue
+		}
+		results = append(results, client.Recorder.Complete(d.workers[0].now()))
+	}
+	return results
+}
+
+func (d *Dispatcher) runWorker(w *Worker, sessions *workerSessions, stopCh <-chan struct{}, onCrash func(clientId int64, err error)) {
+	for {
+		client := d.take()
+		if client == nil {
+			// Every client has stopped or crashed
+			return
+		}
+		if wait := client.nextStart.Sub(w.now()); wait > 0 {
+			w.sleep(wait)
+		}
+
+		select {
+		case <-stopCh:
+			d.release(client)
+			return
+		default:
+		}
+
+		client.stopped, client.err = w.runNext(sessions, client.Workload, client.Id, client.nextStart, true, false, stopCh, client.Recorder)
+		if client.err != nil {
+			onCrash(client.Id, client.err)
+		}
+		interval := d.interval
+		if d.rates != nil {
+			interval = d.rates.ClientInterval()
+		}
+		client.nextStart = client.nextStart.Add(interval)
+		d.release(client)
+	}
+}
+
+// The client due first, which the calling worker then has to itself until it releases it; if all clients are
+// taken, this waits for one to be released. Nil if no clients are left.
+func (d *Dispatcher) take() *DispatchedClient {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	for len(d.clients) == 0 && d.active > 0 {
+		d.released.Wait()
+	}
+	if len(d.clients) == 0 {
+		return nil
+	}
+	return heap.Pop(&d.clients).(*DispatchedClient)
+}
+
+func (d *Dispatcher) release(client *DispatchedClient) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	if client.err != nil || client.stopped {
+		d.active--
+	} else {
+		heap.Push(&d.clients, client)
+	}
+	d.released.Broadcast()
+}
+
+// Clients by when their next transaction is due, see container/heap
+type dispatchQueue []*DispatchedClient
+
+func (q dispatchQueue) Len() int { return len(q) }
+
+func (q dispatchQueue) Less(i, j int) bool { return q[i].nextStart.Before(q[j].nextStart) }
+
+func (q dispatchQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *dispatchQueue) Push(x interface{}) { *q = append(*q, x.(*DispatchedClient)) }
+
+func (q *dispatchQueue) Pop() interface{} {
+	old := *q
+	client := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return client
+}
@@ -0,0 +1,38 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDispatcherRunsClientsOnSharedWorkers(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	stopCh := make(chan struct{})
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &stoppingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}, stop: stopCh, after: 9}
+	// One worker, so the fake clock is only used from one goroutine
+	worker := &Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	clients := make([]*DispatchedClient, 0)
+	for i := int64(0); i < 3; i++ {
+		clients = append(clients, &DispatchedClient{Id: i, Workload: newTestWorkload(r), Recorder: NewResultRecorder(i)})
+	}
+
+	results := NewDispatcher([]*Worker{worker}, time.Second, nil).Run(clients, "", stopCh, func(clientId int64, err error) {
+		t.Errorf("client %d crashed: %s", clientId, err)
+	})
+
+	assert.Len(t, results, 3)
+	combined := NewResult("", "")
+	for i, result := range results {
+		assert.NoError(t, result.Error)
+		assert.Equal(t, int64(i), result.WorkerId)
+		// Each client keeps its own schedule of one transaction a second
+		assert.Equal(t, int64(3), result.Scripts["workertest"].Succeeded)
+		combined.Add(result)
+	}
+	assert.Equal(t, int64(1), combined.ShutdownFailures)
+	// All three clients are due at once, and the one worker runs them one after the other, 1ms each
+	assert.Equal(t, 2*time.Millisecond, combined.MaxScheduleLag)
+}
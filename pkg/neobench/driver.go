@@ -6,6 +6,7 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 	"github.com/pkg/errors"
 	"io"
+	"net"
 	"net/url"
 )
 
@@ -17,9 +18,30 @@ const (
 	EncryptionOn   EncryptionMode = 2
 )
 
-func NewDriver(urlStr, user, password string, encryptionMode EncryptionMode, checkCertificates bool,
+// How neobench reaches the database, beyond what the connection URL says
+type Transport struct {
+	// Name to expect in the server certificate, if not the host in the URL; for servers behind load balancers
+	TLSServerName string
+	// SOCKS5 or HTTP proxy to connect through, ex: socks5://bastion:1080
+	Proxy string
+	// Address to connect to instead of the host of the connection URL, by host or host:port, ex:
+	// core1=127.0.0.1:17687; for reaching a server through a port forward. Other hosts can't be overridden, since
+	// connections through the tunnel go only to the URL's host, see newTunneledDriver.
+	Resolve map[string]string
+}
+
+// The driver has no hooks for these, so connections with them go through a tunnel
+func (t Transport) tunneled() bool {
+	return t.TLSServerName != "" || t.Proxy != "" || len(t.Resolve) > 0
+}
+
+func NewDriver(urlStr, user, password string, encryptionMode EncryptionMode, checkCertificates bool, transport Transport,
 	configurers ...func(*neo4j.Config)) (neo4j.Driver, error) {
 
+	if transport.tunneled() {
+		return newTunneledDriver(urlStr, user, password, encryptionMode, checkCertificates, transport, configurers...)
+	}
+
 	urlStr, err := determineConnectionUrl(urlStr, encryptionMode, checkCertificates)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to determine connection URL to use from %s", urlStr)
@@ -28,6 +50,123 @@ func NewDriver(urlStr, user, password string, encryptionMode EncryptionMode, che
 	return neo4j.NewDriver(urlStr, neo4j.BasicAuth(user, password, ""), configurers...)
 }
 
+// Returns a dial function that connects to the overridden address for addresses in overrides, and to the
+// address itself for others. Overrides are by host:port or by host, and are to an address with or without a port;
+// without one, the port stays the same.
+func resolvingDialer(overrides map[string]string, dial func(address string) (net.Conn, error)) (func(address string) (net.Conn, error), error) {
+	for from, to := range overrides {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid --resolver '%s=%s', expected host=address, ex: core1=127.0.0.1:17687", from, to)
+		}
+	}
+	return func(address string) (net.Conn, error) {
+		if to, found := overrides[address]; found {
+			address = withDefaultPort(to, address)
+		} else if host, port, err := net.SplitHostPort(address); err == nil {
+			if to, found := overrides[host]; found {
+				address = withDefaultPort(to, net.JoinHostPort(host, port))
+			}
+		}
+		return dial(address)
+	}, nil
+}
+
+// Adds the port of the original address to the override, if it has none
+func withDefaultPort(override, original string) string {
+	if _, _, err := net.SplitHostPort(override); err == nil {
+		return override
+	}
+	_, port, err := net.SplitHostPort(original)
+	if err != nil {
+		return override
+	}
+	return net.JoinHostPort(override, port)
+}
+
+// Connects the driver to a local tunnel, which makes the connections to the database the way the transport asks
+// for. The driver connects directly to the tunnel, so there is no cluster routing; all work goes to the server
+// in the URL, or whichever server the load balancer in front of it picks.
+func newTunneledDriver(urlStr, user, password string, encryptionMode EncryptionMode, checkCertificates bool,
+	transport Transport, configurers ...func(*neo4j.Config)) (neo4j.Driver, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse url %s", urlStr)
+	}
+	if u.Scheme == "bolt+unix" {
+		return nil, fmt.Errorf("--tls-server-name, --proxy and --resolver can't be used with bolt+unix connections")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "7687"
+	}
+	address := net.JoinHostPort(u.Hostname(), port)
+	// The tunnel only ever connects to --address; an override of any other host would silently do nothing
+	for from := range transport.Resolve {
+		if from != u.Hostname() && from != address {
+			return nil, fmt.Errorf("invalid --resolver '%s': neobench connects only to --address %s, without cluster routing, "+
+				"so only it can be resolved, ex: --resolver %s=127.0.0.1:17687", from, address, u.Hostname())
+		}
+	}
+	dial := dialTCP
+	if transport.Proxy != "" {
+		dial, err = proxyDialer(transport.Proxy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(transport.Resolve) > 0 {
+		dial, err = resolvingDialer(transport.Resolve, dial)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if encryptionMode == EncryptionAuto {
+		enabled, err := isTlsEnabledVia(u, dial)
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			encryptionMode = EncryptionOn
+		} else {
+			encryptionMode = EncryptionOff
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if encryptionMode == EncryptionOn {
+		tlsConfig = &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: !checkCertificates}
+		if transport.TLSServerName != "" {
+			tlsConfig.ServerName = transport.TLSServerName
+		}
+	} else if transport.TLSServerName != "" {
+		return nil, fmt.Errorf("--tls-server-name is set, but the connection to %s is not encrypted; use -e true", u.Host)
+	}
+
+	t, err := startTunnel(address, dial, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := neo4j.NewDriver(fmt.Sprintf("bolt://%s", t.Address()), neo4j.BasicAuth(user, password, ""), configurers...)
+	if err != nil {
+		_ = t.Close()
+		return nil, err
+	}
+	return &tunneledDriver{Driver: driver, tunnel: t}, nil
+}
+
+type tunneledDriver struct {
+	neo4j.Driver
+	tunnel *tunnel
+}
+
+func (d *tunneledDriver) Close() error {
+	err := d.Driver.Close()
+	if tunnelErr := d.tunnel.Close(); err == nil {
+		err = tunnelErr
+	}
+	return err
+}
+
 // Modifies the input URL to match encryption and certificate check requirements; by default this is done automatically
 func determineConnectionUrl(urlStr string, encryptionMode EncryptionMode, checkCertificates bool) (string, error) {
 	u, err := url.Parse(urlStr)
@@ -68,22 +207,30 @@ func determineConnectionUrl(urlStr string, encryptionMode EncryptionMode, checkC
 }
 
 func isTlsEnabled(u *url.URL) (bool, error) {
+	return isTlsEnabledVia(u, dialTCP)
+}
+
+func isTlsEnabledVia(u *url.URL, dial func(address string) (net.Conn, error)) (bool, error) {
 	host := u.Hostname()
 	port := u.Port()
 	if port == "" {
 		port = "7687"
 	}
 
-	socket, err := tls.Dial("tcp", fmt.Sprintf("%s:%s", host, port), &tls.Config{
+	conn, err := dial(net.JoinHostPort(host, port))
+	if err != nil {
+		return false, fmt.Errorf("failed to auto-detect TLS, consider explicitly setting the -e flag: %s", err)
+	}
+	socket := tls.Client(conn, &tls.Config{
 		InsecureSkipVerify: true,
 		ServerName:         host,
 	})
-	if err != nil {
+	defer socket.Close()
+	if err := socket.Handshake(); err != nil {
 		if err == io.EOF {
 			return false, nil
 		}
 		return false, fmt.Errorf("failed to auto-detect TLS, consider explicitly setting the -e flag: %s", err)
 	}
-	socket.Close()
 	return true, nil
 }
@@ -0,0 +1,207 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Length of the windows the failover tracker measures latency in
+const failoverWindow = time.Second
+
+// Number of windows before an incident that make up the latency baseline it's compared against
+const failoverBaselineWindows = 10
+
+// Watches transaction outcomes for incidents, like a leader switch, where transactions start failing, and measures
+// how the workload recovered from each: how long transactions failed for, how many did, and how long until latency
+// was back to what it was before. These get lost in the percentiles of a whole run.
+//
+// An incident starts with the first failed transaction and lasts until latency has settled after the last failure;
+// failures before then are part of the same incident.
+type FailoverTracker struct {
+	// Start of the run, incidents are reported relative to this
+	Start time.Time
+	// An incident has settled once a whole window after its last failure has a p99 within this factor of the baseline
+	SettleFactor float64
+
+	mut sync.Mutex
+	// Recently completed windows, oldest first, at most failoverBaselineWindows of them
+	recent []*hdrhistogram.Histogram
+	// Window transactions are currently finishing in
+	windowStart    time.Time
+	window         *hdrhistogram.Histogram
+	windowFailures int64
+
+	current   *Incident
+	incidents []Incident
+}
+
+// One period of failures, see FailoverTracker
+type Incident struct {
+	// When the first and the last transaction of the incident failed
+	Start       time.Time
+	LastFailure time.Time
+	Failures    int64
+	// When the first transaction that started after the incident did, succeeded; zero if none did
+	FirstSuccess time.Time
+	// When latency settled back near the baseline, zero if it didn't before the run ended
+	Settled time.Time
+	// p99 of the windows before the incident, and the highest p99 of a window during it
+	BaselineP99 time.Duration
+	PeakP99     time.Duration
+}
+
+// Time transactions were failing for
+func (i Incident) Downtime() time.Duration {
+	return i.LastFailure.Sub(i.Start)
+}
+
+type FailoverResult struct {
+	Start        time.Time
+	SettleFactor float64
+	Incidents    []Incident
+}
+
+func NewFailoverTracker(start time.Time, settleFactor float64) *FailoverTracker {
+	return &FailoverTracker{
+		Start:        start,
+		SettleFactor: settleFactor,
+		windowStart:  start,
+		window:       newFailoverHistogram(),
+	}
+}
+
+// Reports incidents relative to the given start instead; for a tracker made before the run started, once it has
+func (t *FailoverTracker) Begin(start time.Time) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.Start, t.windowStart = start, start
+}
+
+func newFailoverHistogram() *hdrhistogram.Histogram {
+	return hdrhistogram.New(0, 60*60*1000000, 2)
+}
+
+// Records the outcome of a transaction that started and finished at the given times; latency is what's reported
+// for it, which in latency mode is measured from when it should have started
+func (t *FailoverTracker) Record(start, end time.Time, latency time.Duration, succeeded bool) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	// Transactions of the --warmup aren't measured, and would otherwise start incidents from before the run did
+	if end.Before(t.Start) {
+		return
+	}
+	for !end.Before(t.windowStart.Add(failoverWindow)) {
+		t.completeWindow()
+	}
+
+	if !succeeded {
+		t.windowFailures++
+		if t.current == nil {
+			t.current = &Incident{Start: end, BaselineP99: t.baselineP99()}
+		}
+		t.current.Failures++
+		if end.After(t.current.LastFailure) {
+			t.current.LastFailure = end
+		}
+		return
+	}
+
+	_ = t.window.RecordValue(latency.Microseconds())
+	if t.current != nil && t.current.FirstSuccess.IsZero() && start.After(t.current.Start) {
+		t.current.FirstSuccess = end
+	}
+}
+
+// Moves on to the next window, and checks if the incident in progress, if any, has settled
+func (t *FailoverTracker) completeWindow() {
+	windowEnd := t.windowStart.Add(failoverWindow)
+	if t.current != nil && t.window.TotalCount() > 0 {
+		p99 := time.Duration(t.window.ValueAtQuantile(99)) * time.Microsecond
+		if p99 > t.current.PeakP99 {
+			t.current.PeakP99 = p99
+		}
+		settled := float64(p99) <= t.SettleFactor*float64(t.current.BaselineP99) || t.current.BaselineP99 == 0
+		if t.windowFailures == 0 && t.windowStart.After(t.current.LastFailure) && settled {
+			t.current.Settled = windowEnd
+			t.incidents = append(t.incidents, *t.current)
+			t.current = nil
+		}
+	}
+	// Windows during incidents would skew the baseline of the next one
+	if t.current == nil && t.windowFailures == 0 {
+		t.recent = append(t.recent, t.window)
+		if len(t.recent) > failoverBaselineWindows {
+			t.recent = t.recent[1:]
+		}
+		t.window = newFailoverHistogram()
+	} else {
+		t.window.Reset()
+	}
+	t.windowStart = windowEnd
+	t.windowFailures = 0
+}
+
+func (t *FailoverTracker) baselineP99() time.Duration {
+	baseline := newFailoverHistogram()
+	for _, window := range t.recent {
+		baseline.Merge(window)
+	}
+	if baseline.TotalCount() == 0 {
+		return 0
+	}
+	return time.Duration(baseline.ValueAtQuantile(99)) * time.Microsecond
+}
+
+// The incidents so far, including one still in progress at the given time
+func (t *FailoverTracker) Result(now time.Time) *FailoverResult {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	for !now.Before(t.windowStart.Add(failoverWindow)) {
+		t.completeWindow()
+	}
+	incidents := append([]Incident{}, t.incidents...)
+	if t.current != nil {
+		incidents = append(incidents, *t.current)
+	}
+	return &FailoverResult{Start: t.Start, SettleFactor: t.SettleFactor, Incidents: incidents}
+}
+
+// How the workload recovered from each incident
+func writeFailoverReport(result Result, s *strings.Builder) {
+	failover := result.Failover
+	if failover == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Failover --\n\n"))
+	if len(failover.Incidents) == 0 {
+		s.WriteString(fmt.Sprintf("  No transactions failed, nothing to recover from\n\n"))
+		return
+	}
+	for _, incident := range failover.Incidents {
+		s.WriteString(fmt.Sprintf("  Incident at %s:\n", incident.Start.Sub(failover.Start).Round(time.Millisecond)))
+		s.WriteString(fmt.Sprintf("    Downtime: %s, %d failed transactions\n", incident.Downtime().Round(time.Millisecond), incident.Failures))
+		if incident.FirstSuccess.IsZero() {
+			s.WriteString(fmt.Sprintf("    No transaction started after the first failure succeeded\n"))
+		} else {
+			s.WriteString(fmt.Sprintf("    First successful transaction: %s after the first failure\n",
+				incident.FirstSuccess.Sub(incident.Start).Round(time.Millisecond)))
+		}
+		baseline := result.LatencyUnit.FormatDuration(incident.BaselineP99)
+		if incident.BaselineP99 == 0 {
+			baseline = "none, nothing succeeded before the incident"
+		}
+		if incident.Settled.IsZero() {
+			s.WriteString(fmt.Sprintf("    Latency did not settle before the run ended; baseline p99 %s, peak p99 %s\n",
+				baseline, result.LatencyUnit.FormatDuration(incident.PeakP99)))
+		} else {
+			s.WriteString(fmt.Sprintf("    Latency settled %s after the last failure; baseline p99 %s, peak p99 %s\n",
+				incident.Settled.Sub(incident.LastFailure).Round(time.Millisecond), baseline,
+				result.LatencyUnit.FormatDuration(incident.PeakP99)))
+		}
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,101 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailoverTrackerMeasuresRecovery(t *testing.T) {
+	start := time.Unix(0, 0)
+	tracker := NewFailoverTracker(start, 1.5)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	// Steady at 10ms for three seconds
+	for i := time.Duration(0); i < 30; i++ {
+		tracker.Record(at(i*100*time.Millisecond), at(i*100*time.Millisecond+10*time.Millisecond), 10*time.Millisecond, true)
+	}
+	// The leader goes away; a transaction that started before still makes it through, but doesn't count as recovery
+	tracker.Record(at(3000*time.Millisecond), at(3100*time.Millisecond), 10*time.Millisecond, false)
+	tracker.Record(at(2900*time.Millisecond), at(3200*time.Millisecond), 300*time.Millisecond, true)
+	tracker.Record(at(3200*time.Millisecond), at(4500*time.Millisecond), 10*time.Millisecond, false)
+	// The new leader is slow at first, then back to normal
+	tracker.Record(at(4500*time.Millisecond), at(4800*time.Millisecond), 300*time.Millisecond, true)
+	tracker.Record(at(5000*time.Millisecond), at(5200*time.Millisecond), 200*time.Millisecond, true)
+	for i := time.Duration(0); i < 10; i++ {
+		tracker.Record(at(6000*time.Millisecond+i*100*time.Millisecond), at(6010*time.Millisecond+i*100*time.Millisecond), 10*time.Millisecond, true)
+	}
+
+	result := tracker.Result(at(8 * time.Second))
+	assert.Len(t, result.Incidents, 1)
+	incident := result.Incidents[0]
+	// Latencies are kept at two significant figures
+	assert.InDelta(t, 10*time.Millisecond, incident.BaselineP99, float64(100*time.Microsecond))
+	assert.InDelta(t, 300*time.Millisecond, incident.PeakP99, float64(3*time.Millisecond))
+	incident.BaselineP99, incident.PeakP99 = 0, 0
+	assert.Equal(t, Incident{
+		Start:        at(3100 * time.Millisecond),
+		LastFailure:  at(4500 * time.Millisecond),
+		Failures:     2,
+		FirstSuccess: at(4800 * time.Millisecond),
+		Settled:      at(7 * time.Second),
+	}, incident)
+	assert.Equal(t, 1400*time.Millisecond, incident.Downtime())
+}
+
+func TestFailoverTrackerReportsIncidentThatDidNotSettle(t *testing.T) {
+	start := time.Unix(0, 0)
+	tracker := NewFailoverTracker(start, 1.5)
+
+	tracker.Record(start, start.Add(100*time.Millisecond), 100*time.Millisecond, true)
+	tracker.Record(start.Add(time.Second), start.Add(1500*time.Millisecond), 500*time.Millisecond, false)
+
+	result := tracker.Result(start.Add(3 * time.Second))
+	assert.Len(t, result.Incidents, 1)
+	assert.True(t, result.Incidents[0].Settled.IsZero())
+	assert.True(t, result.Incidents[0].FirstSuccess.IsZero())
+}
+
+func TestFailoverTrackerLeavesOutTheWarmup(t *testing.T) {
+	start := time.Unix(0, 0)
+	tracker := NewFailoverTracker(start, 1.5)
+	// Made before the workers were set up, measuring once the warmup is over
+	tracker.Begin(start.Add(10 * time.Second))
+
+	tracker.Record(start.Add(5*time.Second), start.Add(5500*time.Millisecond), 500*time.Millisecond, false)
+	tracker.Record(start.Add(10*time.Second), start.Add(10100*time.Millisecond), 100*time.Millisecond, true)
+
+	result := tracker.Result(start.Add(13 * time.Second))
+	assert.Equal(t, start.Add(10*time.Second), result.Start)
+	assert.Empty(t, result.Incidents)
+}
+
+func TestWriteFailoverReport(t *testing.T) {
+	start := time.Unix(0, 0)
+	result := NewResult("neo4j", "")
+	result.Failover = &FailoverResult{
+		Start:        start,
+		SettleFactor: 1.5,
+		Incidents: []Incident{{
+			Start:        start.Add(83 * time.Second),
+			LastFailure:  start.Add(87 * time.Second),
+			Failures:     312,
+			FirstSuccess: start.Add(86 * time.Second),
+			Settled:      start.Add(93 * time.Second),
+			BaselineP99:  12 * time.Millisecond,
+			PeakP99:      1200 * time.Millisecond,
+		}},
+	}
+
+	s := strings.Builder{}
+	writeFailoverReport(result, &s)
+	assert.Equal(t, `-- Failover --
+
+  Incident at 1m23s:
+    Downtime: 4s, 312 failed transactions
+    First successful transaction: 3s after the first failure
+    Latency settled 6s after the last failure; baseline p99 12.000ms, peak p99 1200.000ms
+
+`, s.String())
+}
@@ -0,0 +1,80 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// When the first record of the statement being measured arrived, with --first-record; see FirstRecordResult
+type firstRecordTimer struct {
+	// When the statement started
+	start time.Time
+	// Whether it returned any records, and how long after it started the first of them arrived
+	seen    bool
+	latency time.Duration
+}
+
+// Time until the first record of each statement arrived against the time until all of them had, by script. Code
+// that streams results starts work on the first record, so for reads that return a lot, the first is closer to the
+// latency users see than the second. Only statements over Bolt that returned records are counted.
+type FirstRecordResult struct {
+	ScriptName string
+	// In microseconds, for the same statements
+	FirstRecord *hdrhistogram.Histogram
+	Complete    *hdrhistogram.Histogram
+}
+
+func newFirstRecordResult(scriptName string) *FirstRecordResult {
+	return &FirstRecordResult{
+		ScriptName:  scriptName,
+		FirstRecord: hdrhistogram.New(0, 60*60*1000000, 3),
+		Complete:    hdrhistogram.New(0, 60*60*1000000, 3),
+	}
+}
+
+func (r *FirstRecordResult) record(stmt statementOutcome) error {
+	if err := r.FirstRecord.RecordValue(stmt.firstRecord.Microseconds()); err != nil {
+		return errors.Wrapf(err, "failed to record latency: %s", stmt.firstRecord)
+	}
+	if err := r.Complete.RecordValue(stmt.latency.Microseconds()); err != nil {
+		return errors.Wrapf(err, "failed to record latency: %s", stmt.latency)
+	}
+	return nil
+}
+
+func (r *FirstRecordResult) merge(other *FirstRecordResult) {
+	r.FirstRecord.Merge(other.FirstRecord)
+	r.Complete.Merge(other.Complete)
+}
+
+// Time to the first record against time to the last, for each script that ran with --first-record
+func writeFirstRecordReport(result Result, s *strings.Builder) {
+	if len(result.FirstRecords) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.FirstRecords))
+	for name := range result.FirstRecords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.WriteString(fmt.Sprintf("-- Time to first record --\n\n"))
+	for _, name := range names {
+		first := result.FirstRecords[name]
+		s.WriteString(fmt.Sprintf("  [%s]: over %d statements that returned records\n", name, first.FirstRecord.TotalCount()))
+		for _, kind := range []struct {
+			name      string
+			latencies *hdrhistogram.Histogram
+		}{{"First record", first.FirstRecord}, {"All records", first.Complete}} {
+			unit := result.LatencyUnit
+			s.WriteString(fmt.Sprintf("    %s: P50: %s, P99: %s, P99.9: %s, Max: %s\n", kind.name,
+				unit.Format(float64(kind.latencies.ValueAtQuantile(50))), unit.Format(float64(kind.latencies.ValueAtQuantile(99))),
+				unit.Format(float64(kind.latencies.ValueAtQuantile(99.9))), unit.Format(float64(kind.latencies.Max()))))
+		}
+	}
+	s.WriteString("\n")
+}
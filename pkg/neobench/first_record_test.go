@@ -0,0 +1,74 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsumeTimesFirstRecord(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	w := &Worker{now: clock.now, sleep: clock.sleep, firstRecords: true}
+	first := firstRecordTimer{start: clock.now()}
+
+	_, err := w.consume(&streamingResult{clock: clock, records: 3, interval: 2 * time.Millisecond}, ConsumeAll, &payloadSize{}, &first)
+
+	assert.NoError(t, err)
+	assert.True(t, first.seen)
+	assert.Equal(t, 2*time.Millisecond, first.latency)
+
+	empty := firstRecordTimer{start: clock.now()}
+	_, err = w.consume(&streamingResult{clock: clock}, ConsumeAll, &payloadSize{}, &empty)
+	assert.NoError(t, err)
+	assert.False(t, empty.seen)
+}
+
+func TestWriteFirstRecordReport(t *testing.T) {
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("stream", "", 10*time.Millisecond, uowOutcome{succeeded: true, statements: []statementOutcome{
+		{databaseName: "neo4j", latency: 10 * time.Millisecond, succeeded: true, firstRecord: time.Millisecond},
+		// Statements without records aren't counted
+		{databaseName: "neo4j", latency: 5 * time.Millisecond, succeeded: true},
+	}}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+
+	s := strings.Builder{}
+	writeFirstRecordReport(result, &s)
+	assert.Equal(t, `-- Time to first record --
+
+  [stream]: over 1 statements that returned records
+    First record: P50: 1.000ms, P99: 1.000ms, P99.9: 1.000ms, Max: 1.000ms
+    All records: P50: 10.007ms, P99: 10.007ms, P99.9: 10.007ms, Max: 10.007ms
+
+`, s.String())
+}
+
+// Returns the given number of records, each the given interval after the one before
+type streamingResult struct {
+	fakeResult
+	clock    *fakeSpaceTimeContinuum
+	records  int
+	interval time.Duration
+	consumed bool
+}
+
+func (r *streamingResult) Record() *neo4j.Record {
+	return &neo4j.Record{Values: []interface{}{int64(1)}}
+}
+
+func (r *streamingResult) Consume() (neo4j.ResultSummary, error) {
+	r.consumed = true
+	return nil, nil
+}
+
+func (r *streamingResult) Next() bool {
+	if r.records == 0 {
+		return false
+	}
+	r.records--
+	r.clock.sleep(r.interval)
+	return true
+}
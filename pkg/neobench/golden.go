@@ -0,0 +1,73 @@
+package neobench
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Output formats RenderResult can render, as for --output
+var OutputFormats = []string{"interactive", "csv", "json"}
+
+// Renders the given result the way neobench reports it at the end of a run, in the given output format: what goes
+// to stdout, followed by what goes to stderr under a "-- stderr --" line, if anything does. The rendering only
+// depends on the result, so tools that parse neobench output can pin it in golden files, see CompareGolden, and
+// notice when a format changes.
+func RenderResult(result Result, format string, latencyMode bool) (string, error) {
+	stdout, stderr := &strings.Builder{}, &strings.Builder{}
+	var output Output
+	switch format {
+	case "interactive":
+		output = &InteractiveOutput{OutStream: stdout, ErrStream: stderr}
+	case "csv":
+		output = &CsvOutput{OutStream: stdout, ErrStream: stderr}
+	case "json":
+		output = &JsonOutput{OutStream: stdout, ErrStream: stderr}
+	default:
+		return "", fmt.Errorf("unknown output format: %s, supported formats are %s", format, strings.Join(OutputFormats, ", "))
+	}
+	if latencyMode {
+		output.ReportLatency(result)
+	} else {
+		output.ReportThroughput(result)
+	}
+	if stderr.Len() == 0 {
+		return stdout.String(), nil
+	}
+	return fmt.Sprintf("%s-- stderr --\n%s", stdout.String(), stderr.String()), nil
+}
+
+// Compares actual to the contents of the golden file at path, returning an error that points at the first line
+// that differs; with update set, this writes actual to the file instead, creating it and its directory if needed.
+func CompareGolden(path, actual string, update bool) error {
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, []byte(actual), 0644)
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file, create it by running with update set: %s", err)
+	}
+	expected := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	if expected == actual {
+		return nil
+	}
+	expectedLines, actualLines := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a || i >= len(expectedLines) || i >= len(actualLines) {
+			return fmt.Errorf("output differs from %s at line %d:\n  expected: %q\n  actual:   %q", path, i+1, e, a)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package neobench
+
+import (
+	"flag"
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata with the current output")
+
+// Pins the output formats; run with -update after changing them on purpose, and review the diff
+func TestOutputGoldenFiles(t *testing.T) {
+	for _, format := range OutputFormats {
+		for _, latencyMode := range []bool{false, true} {
+			mode := "throughput"
+			if latencyMode {
+				mode = "latency"
+			}
+			t.Run(fmt.Sprintf("%s-%s", format, mode), func(t *testing.T) {
+				rendered, err := RenderResult(goldenResult(t), format, latencyMode)
+				assert.NoError(t, err)
+				path := filepath.Join("testdata", fmt.Sprintf("result.%s.%s.golden", format, mode))
+				assert.NoError(t, CompareGolden(path, rendered, *updateGolden))
+			})
+		}
+	}
+}
+
+func TestRenderResultIsDeterministic(t *testing.T) {
+	first, err := RenderResult(goldenResult(t), "interactive", true)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		again, _ := RenderResult(goldenResult(t), "interactive", true)
+		assert.Equal(t, first, again)
+	}
+	_, err = RenderResult(goldenResult(t), "xml", true)
+	assert.Error(t, err)
+}
+
+func TestCompareGoldenPointsAtTheFirstDifference(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.golden")
+	assert.NoError(t, CompareGolden(path, "a\nb\nc\n", true))
+	assert.NoError(t, CompareGolden(path, "a\nb\nc\n", false))
+
+	err := CompareGolden(path, "a\nx\nc\n", false)
+	assert.EqualError(t, err, fmt.Sprintf("output differs from %s at line 2:\n  expected: \"b\"\n  actual:   \"x\"", path))
+	assert.Error(t, CompareGolden(path, "a\nb\nc\nd\n", false))
+}
+
+// A result with a bit of everything the common reports show, built without randomness
+func goldenResult(t *testing.T) Result {
+	result := NewResult("neo4j", " -f reads.script -f writes.script -c 4 -s 1 -d 1m0s -e auto")
+	result.Build = BuildInfo{Version: "1.0.0", Commit: "3f2a9c1", DriverVersion: "v4.3.3", GoVersion: "go1.14"}
+	result.Sla = 150 * time.Millisecond
+	result.Setup = 1500 * time.Millisecond
+	result.Duration = time.Minute
+	for i, name := range []string{"writes.script", "reads.script", "lookups.script"} {
+		latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+		for v := int64(1); v <= 100; v++ {
+			assert.NoError(t, latencies.RecordValue(v*int64(1000*(i+1))))
+		}
+		result.Scripts[name] = &ScriptResult{ScriptName: name, Latencies: latencies, Succeeded: 100, Failed: int64(i), Rate: float64(10 * (i + 1))}
+	}
+	for _, name := range []string{"Neo.TransientError.Transaction.DeadlockDetected", "Neo.ClientError.Statement.SyntaxError"} {
+		latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+		assert.NoError(t, latencies.RecordValue(2500))
+		result.FailedByErrorGroup[name] = FailureGroup{Count: 1, FirstFailure: fmt.Errorf("Server error: [%s] oops", name), Latencies: latencies}
+	}
+	result.Mix = []MixEntry{
+		{ScriptName: "lookups.script", TargetShare: 0.5, AchievedShare: 0.33},
+		{ScriptName: "reads.script", TargetShare: 0.25, AchievedShare: 0.33},
+		{ScriptName: "writes.script", TargetShare: 0.25, AchievedShare: 0.34},
+	}
+	scripts := Scripts{Lanes: []Lane{{Name: "interactive", Scripts: []string{"lookups.script", "reads.script"}}}}
+	scripts.CompleteLanes(&result)
+	return result
+}
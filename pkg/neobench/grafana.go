@@ -0,0 +1,123 @@
+package neobench
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Posts annotations to Grafana when runs start and end and when weight phases change, see --grafana-annotate, so
+// benchmark windows line up with server dashboards without anyone having to note down times. Annotations are
+// tagged "neobench", with the run id and the scenario hash, so dashboards can pick them out.
+type GrafanaAnnotator struct {
+	// Ex: http://localhost:3000
+	baseUrl string
+	apiKey  string
+	tags    []string
+	client  *http.Client
+}
+
+// Parses "url" or "url,apikey"
+func NewGrafanaAnnotator(spec string, run RunTag) (*GrafanaAnnotator, error) {
+	parts := strings.SplitN(spec, ",", 2)
+	u, err := url.Parse(strings.TrimSpace(parts[0]))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid --grafana-annotate '%s', expected http(s)://host:port with an optional API key after a comma", parts[0])
+	}
+	annotator := &GrafanaAnnotator{
+		baseUrl: strings.TrimSuffix(u.String(), "/"),
+		tags:    []string{"neobench", "run:" + run.RunId, "scenario:" + run.ScenarioHash},
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if len(parts) == 2 {
+		annotator.apiKey = strings.TrimSpace(parts[1])
+	}
+	return annotator, nil
+}
+
+type grafanaAnnotation struct {
+	// Milliseconds since the epoch
+	Time int64    `json:"time"`
+	Tags []string `json:"tags"`
+	Text string   `json:"text"`
+}
+
+// Posts an annotation at the given time; tags are added to the ones all annotations of the run have
+func (g *GrafanaAnnotator) Annotate(at time.Time, text string, tags ...string) error {
+	payload, err := json.Marshal(grafanaAnnotation{
+		Time: at.UnixNano() / int64(time.Millisecond),
+		Tags: append(append([]string{}, g.tags...), tags...),
+		Text: text,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, g.baseUrl+"/api/annotations", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post annotation to grafana")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("failed to post annotation to grafana at %s: %s %s", req.URL, res.Status, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	return nil
+}
+
+// Annotates the start and end of each of the given phases, relative to start, until stopCh closes; call in a
+// goroutine of its own
+func (g *GrafanaAnnotator) AnnotatePhases(start time.Time, phases []WeightPhase, stopCh <-chan struct{}, onError func(error)) {
+	for _, phase := range phases {
+		events := []struct {
+			offset time.Duration
+			text   string
+		}{
+			{phase.Start, fmt.Sprintf("Phase %s started: %s", phase.Name, describePhaseWeights(phase))},
+			{phase.End, fmt.Sprintf("Phase %s ended", phase.Name)},
+		}
+		for _, event := range events {
+			at := start.Add(event.offset)
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(time.Until(at)):
+			}
+			if err := g.Annotate(at, event.text, "phase"); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Ex: reads=90, writes=10
+func describePhaseWeights(phase WeightPhase) string {
+	names := make([]string, 0, len(phase.Weights))
+	for name := range phase.Weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	weights := make([]string, 0, len(names))
+	for _, name := range names {
+		weights = append(weights, fmt.Sprintf("%s=%g", name, phase.Weights[name]))
+	}
+	return strings.Join(weights, ", ")
+}
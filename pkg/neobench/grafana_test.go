@@ -0,0 +1,84 @@
+package neobench
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGrafanaAnnotations(t *testing.T) {
+	var received []grafanaAnnotation
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/annotations", r.URL.Path)
+		authorization = r.Header.Get("Authorization")
+		var annotation grafanaAnnotation
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&annotation))
+		received = append(received, annotation)
+		_, _ = w.Write([]byte(`{"id": 1, "message": "Annotation added"}`))
+	}))
+	defer server.Close()
+
+	grafana, err := NewGrafanaAnnotator(server.URL+"/,secret", RunTag{ScenarioHash: "abc", RunId: "r1"})
+	assert.NoError(t, err)
+	assert.NoError(t, grafana.Annotate(time.Unix(10, 0), "neobench started", "run-start"))
+
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, []grafanaAnnotation{{
+		Time: 10000,
+		Tags: []string{"neobench", "run:r1", "scenario:abc", "run-start"},
+		Text: "neobench started",
+	}}, received)
+}
+
+func TestGrafanaAnnotatesPhaseChanges(t *testing.T) {
+	var received []grafanaAnnotation
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var annotation grafanaAnnotation
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&annotation))
+		received = append(received, annotation)
+	}))
+	defer server.Close()
+
+	grafana, err := NewGrafanaAnnotator(server.URL, RunTag{ScenarioHash: "abc", RunId: "r1"})
+	assert.NoError(t, err)
+	// Phases that are already due are annotated right away
+	start := time.Now().Add(-time.Hour)
+	phases := []WeightPhase{
+		{Name: "0-10m", Start: 0, End: 10 * time.Minute, Weights: map[string]float64{"writes": 10, "reads": 90}},
+		{Name: "20m-30m", Start: 20 * time.Minute, End: 30 * time.Minute, Weights: map[string]float64{"reads": 100}},
+	}
+	grafana.AnnotatePhases(start, phases, make(chan struct{}), func(err error) { t.Error(err) })
+
+	texts := make([]string, 0)
+	for _, annotation := range received {
+		texts = append(texts, annotation.Text)
+		assert.Contains(t, annotation.Tags, "phase")
+	}
+	assert.Equal(t, []string{
+		"Phase 0-10m started: reads=90, writes=10",
+		"Phase 0-10m ended",
+		"Phase 20m-30m started: reads=100",
+		"Phase 20m-30m ended",
+	}, texts)
+	assert.Equal(t, start.Add(20*time.Minute).UnixNano()/int64(time.Millisecond), received[2].Time)
+}
+
+func TestGrafanaAnnotationErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	grafana, err := NewGrafanaAnnotator(server.URL, RunTag{})
+	assert.NoError(t, err)
+	err = grafana.Annotate(time.Now(), "neobench started")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+
+	_, err = NewGrafanaAnnotator("localhost:3000", RunTag{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package neobench
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Name of the server metric growth takes store sizes from, see serverMetrics
+const StoreSizeMetric = "Store size (bytes)"
+
+// Counts the nodes and relationships in the database at each progress report, see --track-growth, so that a
+// throughput that drops over a long write-heavy run can be put next to how much the dataset had grown by then.
+type GrowthTracker struct {
+	// Counts nodes and relationships in the database being benchmarked
+	count   func() (nodes, relationships int64, err error)
+	samples []GrowthSample
+}
+
+// The size of the dataset at one point during the run
+type GrowthSample struct {
+	// Since the benchmark started
+	Elapsed       time.Duration
+	Nodes         int64
+	Relationships int64
+	// In bytes, from --server-metrics; negative if not known
+	StoreSize float64
+	// Transactions per second over the progress interval that ended with this sample; 0 for the first sample
+	Rate float64
+}
+
+type GrowthResult struct {
+	Samples []GrowthSample
+}
+
+func NewGrowthTracker(count func() (nodes, relationships int64, err error)) *GrowthTracker {
+	return &GrowthTracker{count: count}
+}
+
+// Counts the dataset as it is when the benchmark starts, for the rest of the samples to grow from; drops any
+// samples from before
+func (g *GrowthTracker) Begin() error {
+	g.samples = nil
+	_, err := g.Sample(0, 0, -1)
+	return err
+}
+
+// Counts the dataset now, at the given time into the run; rate is the throughput of the interval that just
+// ended, and storeSize the size of the store in bytes, negative if not known
+func (g *GrowthTracker) Sample(elapsed time.Duration, rate, storeSize float64) (GrowthSample, error) {
+	nodes, relationships, err := g.count()
+	if err != nil {
+		return GrowthSample{}, fmt.Errorf("failed to count the dataset for --track-growth: %s", err)
+	}
+	sample := GrowthSample{Elapsed: elapsed, Nodes: nodes, Relationships: relationships, StoreSize: storeSize, Rate: rate}
+	g.samples = append(g.samples, sample)
+	return sample, nil
+}
+
+func (g *GrowthTracker) Result() *GrowthResult {
+	return &GrowthResult{Samples: g.samples}
+}
+
+// The growth curve, one line per progress report with the throughput of the interval before it, and how far
+// throughput moved over the run as the dataset grew
+func writeGrowthReport(result Result, s *strings.Builder) {
+	growth := result.Growth
+	if growth == nil || len(growth.Samples) < 2 {
+		return
+	}
+	samples := growth.Samples
+	first, last := samples[0], samples[len(samples)-1]
+	s.WriteString(fmt.Sprintf("-- Dataset growth --\n\n"))
+	s.WriteString(fmt.Sprintf("  Counted at each progress report, next to the throughput of the interval before it\n"))
+	for i, sample := range samples {
+		line := fmt.Sprintf("    %s: %d nodes, %d relationships", sample.Elapsed.Round(time.Second), sample.Nodes, sample.Relationships)
+		if sample.StoreSize >= 0 {
+			line += fmt.Sprintf(", store %s", formatBytes(int64(sample.StoreSize)))
+		}
+		if i > 0 {
+			line += fmt.Sprintf(", %.3f tps", sample.Rate)
+		}
+		s.WriteString(line + "\n")
+	}
+	grew := fmt.Sprintf("  Grew by %d nodes and %d relationships", last.Nodes-first.Nodes, last.Relationships-first.Relationships)
+	if seconds := (last.Elapsed - first.Elapsed).Seconds(); seconds > 0 {
+		grew += fmt.Sprintf(", %.1f nodes per second", float64(last.Nodes-first.Nodes)/seconds)
+	}
+	s.WriteString(grew + "\n")
+	s.WriteString(fmt.Sprintf("  Throughput went from %.3f tps in the first interval to %.3f tps in the last\n\n", samples[1].Rate, last.Rate))
+}
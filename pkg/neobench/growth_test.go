@@ -0,0 +1,50 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteGrowthReport(t *testing.T) {
+	nodes := int64(1000)
+	growth := NewGrowthTracker(func() (int64, int64, error) {
+		count := nodes
+		nodes += 500
+		return count, count * 2, nil
+	})
+	assert.NoError(t, growth.Begin())
+	_, err := growth.Sample(10*time.Second, 450, -1)
+	assert.NoError(t, err)
+	_, err = growth.Sample(20*time.Second, 300, 12<<20)
+	assert.NoError(t, err)
+
+	s := strings.Builder{}
+	writeGrowthReport(Result{Growth: growth.Result()}, &s)
+	assert.Equal(t, `-- Dataset growth --
+
+  Counted at each progress report, next to the throughput of the interval before it
+    0s: 1000 nodes, 2000 relationships
+    10s: 1500 nodes, 3000 relationships, 450.000 tps
+    20s: 2000 nodes, 4000 relationships, store 12MB, 300.000 tps
+  Grew by 1000 nodes and 2000 relationships, 50.0 nodes per second
+  Throughput went from 450.000 tps in the first interval to 300.000 tps in the last
+
+`, s.String())
+}
+
+func TestGrowthBeginDropsEarlierSamples(t *testing.T) {
+	growth := NewGrowthTracker(func() (int64, int64, error) { return 10, 20, nil })
+	assert.NoError(t, growth.Begin())
+	_, err := growth.Sample(time.Second, 100, -1)
+	assert.NoError(t, err)
+
+	// As after --warmup
+	assert.NoError(t, growth.Begin())
+
+	assert.Len(t, growth.Result().Samples, 1)
+	s := strings.Builder{}
+	writeGrowthReport(Result{Growth: growth.Result()}, &s)
+	assert.Equal(t, "", s.String())
+}
@@ -0,0 +1,164 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Exit code when --abort-if stopped the run
+const HealthAbortExitCode = 5
+
+// Kinds of --abort-if probes
+const (
+	// A Cypher query that returns rows when the target is unhealthy
+	HealthProbeCypher = "cypher"
+	// A URL that answers with an error status when the target is unhealthy
+	HealthProbeHttp = "http"
+)
+
+// How long a probe may take before it counts as unhealthy
+const healthProbeTimeout = 5 * time.Second
+
+// A condition that makes the target unhealthy, set with --abort-if, ex: "cypher:SHOW DATABASES WHERE currentStatus <>
+// 'online'" or "http:http://localhost:7474/db/neo4j/cluster/available". Probes that fail to run, like a query that
+// can't connect, count as unhealthy too, since that's the state a run shouldn't go on recording errors in.
+type HealthProbe struct {
+	// As given, ex: cypher:SHOW DATABASES WHERE currentStatus <> 'online'
+	Spec string
+	Kind string
+	// The query or URL
+	Target string
+}
+
+func ParseHealthProbe(spec string) (HealthProbe, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		return HealthProbe{}, fmt.Errorf("invalid --abort-if '%s', expected cypher:<query> or http:<url>", spec)
+	}
+	kind := strings.TrimSpace(parts[0])
+	if kind != HealthProbeCypher && kind != HealthProbeHttp {
+		return HealthProbe{}, fmt.Errorf("invalid --abort-if '%s', unknown probe '%s', expected cypher:<query> or http:<url>", spec, kind)
+	}
+	return HealthProbe{Spec: spec, Kind: kind, Target: strings.TrimSpace(parts[1])}, nil
+}
+
+// Runs the --abort-if probes at each progress report, and decides to stop the run at the first that finds the
+// target unhealthy, so an unattended run doesn't go on recording an hour of connection errors against a server that
+// went down. Probes are checked once before the run too, so a probe that can't pass doesn't stop it right away.
+type HealthGuard struct {
+	probes []HealthProbe
+	// Returns why the target is unhealthy by the given probe, empty if it's healthy
+	check  func(probe HealthProbe) string
+	start  time.Time
+	result HealthResult
+}
+
+type HealthResult struct {
+	Probes []string
+	// Times the probes were run during the run
+	Checks int
+	// Whether a probe stopped the run, how far into it, and why
+	Aborted      bool
+	AbortedAfter time.Duration
+	Reason       string
+}
+
+// Cypher probes run against databaseName, except administration commands like SHOW DATABASES, which Neo4j wants
+// run against the system database
+func NewHealthGuard(probes []HealthProbe, driver neo4j.Driver, databaseName string) *HealthGuard {
+	client := &http.Client{Timeout: healthProbeTimeout}
+	return &HealthGuard{probes: probes, check: func(probe HealthProbe) string {
+		if probe.Kind == HealthProbeHttp {
+			return checkHttpHealth(client, probe.Target)
+		}
+		return checkCypherHealth(driver, databaseName, probe.Target)
+	}}
+}
+
+// Checks each probe before the run starts; an error if any finds the target unhealthy already
+func (g *HealthGuard) Begin(start time.Time) error {
+	g.start = start
+	for _, probe := range g.probes {
+		if reason := g.check(probe); reason != "" {
+			return fmt.Errorf("--abort-if %s fails before the run has started: %s", probe.Spec, reason)
+		}
+	}
+	return nil
+}
+
+// Runs every probe; true if one found the target unhealthy and the run should stop
+func (g *HealthGuard) Check(now time.Time) bool {
+	g.result.Checks++
+	for _, probe := range g.probes {
+		if reason := g.check(probe); reason != "" {
+			g.result.Aborted = true
+			g.result.AbortedAfter = now.Sub(g.start)
+			g.result.Reason = fmt.Sprintf("--abort-if %s: %s", probe.Spec, reason)
+			return true
+		}
+	}
+	return false
+}
+
+func (g *HealthGuard) Result() *HealthResult {
+	result := g.result
+	for _, probe := range g.probes {
+		result.Probes = append(result.Probes, probe.Spec)
+	}
+	return &result
+}
+
+func checkCypherHealth(driver neo4j.Driver, databaseName, query string) string {
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SHOW ") {
+		databaseName = "system"
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead, DatabaseName: databaseName})
+	defer session.Close()
+	res, err := session.Run(query, nil, neo4j.WithTxTimeout(healthProbeTimeout))
+	if err != nil {
+		return err.Error()
+	}
+	records, err := res.Collect()
+	if err != nil {
+		return err.Error()
+	}
+	if len(records) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("returned %d rows, like %s", len(records), describeRecord(records[0]))
+}
+
+// Ex: {name: neo4j, currentStatus: offline}
+func describeRecord(record *neo4j.Record) string {
+	fields := make([]string, 0, len(record.Keys))
+	for i, key := range record.Keys {
+		fields = append(fields, fmt.Sprintf("%s: %v", key, record.Values[i]))
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func checkHttpHealth(client *http.Client, url string) string {
+	res, err := client.Get(url)
+	if err != nil {
+		return err.Error()
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode >= 300 {
+		return fmt.Sprintf("answered %s", res.Status)
+	}
+	return ""
+}
+
+// Says why the run was stopped early, for the top of the reports; empty unless --abort-if stopped it
+func (r *Result) describeHealthAbort() string {
+	if r.Health == nil || !r.Health.Aborted {
+		return ""
+	}
+	return fmt.Sprintf("ABORTED %s into the run, the target became unhealthy by %s\n", r.Health.AbortedAfter.Round(time.Second), r.Health.Reason)
+}
@@ -0,0 +1,66 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseHealthProbe(t *testing.T) {
+	probe, err := ParseHealthProbe("cypher:SHOW DATABASES WHERE currentStatus <> 'online'")
+	assert.NoError(t, err)
+	assert.Equal(t, HealthProbeCypher, probe.Kind)
+	assert.Equal(t, "SHOW DATABASES WHERE currentStatus <> 'online'", probe.Target)
+
+	probe, err = ParseHealthProbe("http:http://localhost:7474/db/neo4j/cluster/available")
+	assert.NoError(t, err)
+	assert.Equal(t, HealthProbeHttp, probe.Kind)
+	assert.Equal(t, "http://localhost:7474/db/neo4j/cluster/available", probe.Target)
+
+	_, err = ParseHealthProbe("SHOW DATABASES")
+	assert.Error(t, err)
+	_, err = ParseHealthProbe("bash:curl localhost")
+	assert.Error(t, err)
+	_, err = ParseHealthProbe("cypher: ")
+	assert.Error(t, err)
+}
+
+func TestHealthGuardStopsTheRunAtTheFirstUnhealthyProbe(t *testing.T) {
+	online, err := ParseHealthProbe("cypher:SHOW DATABASES WHERE currentStatus <> 'online'")
+	assert.NoError(t, err)
+	available, err := ParseHealthProbe("http:http://localhost:7474/db/neo4j/cluster/available")
+	assert.NoError(t, err)
+	unhealthy := map[string]string{}
+	guard := &HealthGuard{probes: []HealthProbe{online, available}, check: func(probe HealthProbe) string {
+		return unhealthy[probe.Kind]
+	}}
+
+	start := time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)
+	assert.NoError(t, guard.Begin(start))
+	assert.False(t, guard.Check(start.Add(time.Minute)))
+
+	unhealthy[HealthProbeCypher] = "returned 1 rows, like {name: neo4j, currentStatus: offline}"
+	assert.True(t, guard.Check(start.Add(2*time.Minute)))
+
+	result := guard.Result()
+	assert.Equal(t, 2, result.Checks)
+	assert.True(t, result.Aborted)
+	assert.Equal(t, 2*time.Minute, result.AbortedAfter)
+	assert.Equal(t, []string{online.Spec, available.Spec}, result.Probes)
+
+	r := Result{Health: result}
+	assert.Equal(t, "ABORTED 2m0s into the run, the target became unhealthy by --abort-if cypher:SHOW DATABASES WHERE "+
+		"currentStatus <> 'online': returned 1 rows, like {name: neo4j, currentStatus: offline}\n", r.describeHealthAbort())
+}
+
+func TestHealthGuardRefusesToStartAnUnhealthyRun(t *testing.T) {
+	probe, err := ParseHealthProbe("http:http://localhost:7474/db/neo4j/cluster/available")
+	assert.NoError(t, err)
+	guard := &HealthGuard{probes: []HealthProbe{probe}, check: func(probe HealthProbe) string {
+		return "answered 503 Service Unavailable"
+	}}
+
+	assert.EqualError(t, guard.Begin(time.Now()), "--abort-if http:http://localhost:7474/db/neo4j/cluster/available "+
+		"fails before the run has started: answered 503 Service Unavailable")
+	assert.Equal(t, "", (&Result{}).describeHealthAbort())
+}
@@ -0,0 +1,30 @@
+package neobench
+
+import (
+	"math/rand"
+	"os"
+	"time"
+)
+
+const HeartbeatScriptName = "builtin:heartbeat"
+
+// The heartbeat is a trivial read query run at a low, fixed rate alongside the workload, over its own
+// connection. Because the query itself does next to no work, latency spikes in the heartbeat point to
+// the whole server stalling - eg. GC or checkpoint pauses - rather than to the workload queries being slow.
+const heartbeatScript = `RETURN 1;`
+
+// Creates the client workload used by the heartbeat worker; run it with a Worker in latency mode,
+// so heartbeats that are delayed by a stalled server are measured from when they should have been sent.
+func NewHeartbeatWorkload() (ClientWorkload, error) {
+	script, err := Parse(HeartbeatScriptName, heartbeatScript, 1)
+	if err != nil {
+		return ClientWorkload{}, err
+	}
+	script.Readonly = true
+	return ClientWorkload{
+		Variables: map[string]interface{}{},
+		Scripts:   NewScripts(script),
+		Rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		Stderr:    os.Stderr,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatRunsAtItsIntervalInReadTransactions(t *testing.T) {
+	driver, w := newRoutingWorker()
+	start := driver.clock.now()
+	heartbeat, err := NewHeartbeatWorkload()
+	assert.NoError(t, err)
+
+	result := w.RunBenchmark(heartbeat, "", time.Second, Burst{}, 5, make(chan struct{}), NewResultRecorder(-1))
+
+	assert.NoError(t, result.Error)
+	// Sent at 0s, 1s, 2s, 3s and 4s, each taking a millisecond
+	assert.Equal(t, start.Add(4*time.Second+time.Millisecond), driver.clock.now())
+	assert.Equal(t, []string{"read", "read", "read", "read", "read"}, driver.transactions)
+	assert.Equal(t, neo4j.AccessModeRead, driver.sessions[0].AccessMode)
+	assert.Equal(t, int64(5), result.Scripts[HeartbeatScriptName].Succeeded)
+	assert.Equal(t, int64(1000), result.Scripts[HeartbeatScriptName].Latencies.Max())
+}
+
+func TestHeartbeatIsReportedApartFromTheWorkload(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	driver := &fakeDriver{clock: clock, r: r, minLatency: 5 * time.Millisecond, maxLatency: 5 * time.Millisecond}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	workload := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 10, make(chan struct{}), NewResultRecorder(0))
+	assert.NoError(t, workload.Error)
+
+	heartbeatDriver, heartbeatWorker := newRoutingWorker()
+	heartbeatWork, err := NewHeartbeatWorkload()
+	assert.NoError(t, err)
+	heartbeat := heartbeatWorker.RunBenchmark(heartbeatWork, "", time.Second, Burst{}, 3, make(chan struct{}), NewResultRecorder(-1))
+	assert.NoError(t, heartbeat.Error)
+	assert.Len(t, heartbeatDriver.transactions, 3)
+
+	result := NewResult("neo4j", "")
+	result.Add(workload)
+	result.SetHeartbeat(heartbeat)
+
+	assert.Equal(t, int64(3), result.Heartbeat.Succeeded)
+	assert.NotContains(t, result.Scripts, HeartbeatScriptName)
+	assert.Equal(t, int64(10), result.TotalSucceeded())
+	// Heartbeats take a millisecond, and the workload's 5ms latencies are left alone by them
+	assert.Equal(t, int64(5000), result.Scripts["workertest"].Latencies.Min())
+}
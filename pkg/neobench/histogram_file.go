@@ -0,0 +1,72 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Characters left out of script names when they go into file names
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Writes the latencies of the run in HdrHistogram's plaintext percentile format, the .hgrm files plotting tools
+// for it read, see --latency-histogram-file. The given path gets the latencies of all scripts together, and each
+// script gets a file of its own next to it, with the script name before the extension: out.hgrm, out.reads.hgrm.
+// Latencies are in milliseconds. Returns the paths written.
+func WriteLatencyHistograms(path string, result Result) ([]string, error) {
+	scripts := sortedScripts(result.Scripts)
+	all := hdrhistogram.New(0, 60*60*1000000, 3)
+	for _, script := range scripts {
+		all.Merge(script.Latencies)
+	}
+	paths := []string{path}
+	if err := writeHistogramFile(path, all); err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(path)
+	for _, script := range scripts {
+		scriptPath := fmt.Sprintf("%s.%s%s", strings.TrimSuffix(path, ext), unsafeFileNameChars.ReplaceAllString(script.ScriptName, "_"), ext)
+		if err := writeHistogramFile(scriptPath, script.Latencies); err != nil {
+			return nil, err
+		}
+		paths = append(paths, scriptPath)
+	}
+	return paths, nil
+}
+
+func writeHistogramFile(path string, histo *hdrhistogram.Histogram) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write latency histogram")
+	}
+	defer file.Close()
+	if err := writePercentileDistribution(file, histo); err != nil {
+		return errors.Wrapf(err, "failed to write latency histogram to %s", path)
+	}
+	return nil
+}
+
+// The layout of HdrHistogram's outputPercentileDistribution, with values in milliseconds; the histograms here don't
+// tell their bucket counts, so the footer leaves those out
+func writePercentileDistribution(w io.Writer, histo *hdrhistogram.Histogram) error {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("%12s %14s %10s %14s\n\n", "Value", "Percentile", "TotalCount", "1/(1-Percentile)"))
+	for _, bracket := range histo.CumulativeDistribution() {
+		quantile := bracket.Quantile / 100
+		value := float64(bracket.ValueAt) / 1000.0
+		if quantile < 1 {
+			s.WriteString(fmt.Sprintf("%12.3f %2.12f %10d %14.2f\n", value, quantile, bracket.Count, 1/(1-quantile)))
+		} else {
+			s.WriteString(fmt.Sprintf("%12.3f %2.12f %10d\n", value, quantile, bracket.Count))
+		}
+	}
+	s.WriteString(fmt.Sprintf("#[Mean    = %12.3f, StdDeviation   = %12.3f]\n", histo.Mean()/1000.0, histo.StdDev()/1000.0))
+	s.WriteString(fmt.Sprintf("#[Max     = %12.3f, Total count    = %12d]\n", float64(histo.Max())/1000.0, histo.TotalCount()))
+	_, err := io.WriteString(w, s.String())
+	return err
+}
@@ -0,0 +1,49 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePercentileDistribution(t *testing.T) {
+	worker := NewWorkerResult(0)
+	for _, latency := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond} {
+		assert.NoError(t, worker.record("reads", "", latency, uowOutcome{succeeded: true}))
+	}
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+
+	s := strings.Builder{}
+	assert.NoError(t, writePercentileDistribution(&s, result.Scripts["reads"].Latencies))
+	assert.Equal(t, `       Value     Percentile TotalCount 1/(1-Percentile)
+
+       1.000 0.000000000000          1           1.00
+       2.000 0.500000000000          2           2.00
+       3.001 0.750000000000          3           4.00
+       4.001 0.875000000000          4           8.00
+       4.001 1.000000000000          4
+#[Mean    =        2.501, StdDeviation   =        1.118]
+#[Max     =        4.001, Total count    =            4]
+`, s.String())
+}
+
+func TestWriteLatencyHistogramsWritesAFilePerScript(t *testing.T) {
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("reads", "", time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, worker.record("path/writes", "", time.Millisecond, uowOutcome{succeeded: true}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+	dir := t.TempDir()
+
+	paths, err := WriteLatencyHistograms(filepath.Join(dir, "out.hgrm"), result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "out.hgrm"), filepath.Join(dir, "out.path_writes.hgrm"), filepath.Join(dir, "out.reads.hgrm")}, paths)
+	all, err := ioutil.ReadFile(paths[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(all), "Total count    =            2]")
+}
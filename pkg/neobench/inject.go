@@ -0,0 +1,55 @@
+package neobench
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Delay added to every statement, to emulate the network distance between an application server and the database,
+// see --inject-latency. Each statement is at least one round trip to the server, so this is added to each,
+// uniformly distributed between Base-Jitter and Base+Jitter.
+type InjectedLatency struct {
+	Base   time.Duration
+	Jitter time.Duration
+}
+
+// Parses latencies like "5ms", "5ms±2ms" or "5ms+-2ms"
+func ParseInjectedLatency(s string) (InjectedLatency, error) {
+	trimmed := strings.TrimSpace(s)
+	base, jitter := trimmed, "0"
+	for _, separator := range []string{"±", "+-"} {
+		if parts := strings.SplitN(trimmed, separator, 2); len(parts) == 2 {
+			base, jitter = parts[0], parts[1]
+			break
+		}
+	}
+	invalid := fmt.Errorf("invalid --inject-latency '%s', expected a duration with optional jitter, like 5ms or 5ms±2ms", s)
+	var latency InjectedLatency
+	var err error
+	if latency.Base, err = time.ParseDuration(strings.TrimSpace(base)); err != nil || latency.Base < 0 {
+		return InjectedLatency{}, invalid
+	}
+	if latency.Jitter, err = time.ParseDuration(strings.TrimSpace(jitter)); err != nil || latency.Jitter < 0 {
+		return InjectedLatency{}, invalid
+	}
+	return latency, nil
+}
+
+// Delay to add to the next statement; random is a number in [0, 1), as from rand.Float64
+func (l InjectedLatency) delay(random float64) time.Duration {
+	d := l.Base + time.Duration((2*random-1)*float64(l.Jitter))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Sleeps for the injected latency, if any
+func (w *Worker) injectLatency() {
+	if w.injectedLatency.Base == 0 && w.injectedLatency.Jitter == 0 {
+		return
+	}
+	w.sleep(w.injectedLatency.delay(rand.Float64()))
+}
@@ -0,0 +1,48 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseInjectedLatency(t *testing.T) {
+	valid := map[string]InjectedLatency{
+		"5ms":          {Base: 5 * time.Millisecond},
+		"5ms±2ms":      {Base: 5 * time.Millisecond, Jitter: 2 * time.Millisecond},
+		" 20ms +- 1ms": {Base: 20 * time.Millisecond, Jitter: time.Millisecond},
+	}
+	for given, expected := range valid {
+		actual, err := ParseInjectedLatency(given)
+		assert.NoError(t, err, given)
+		assert.Equal(t, expected, actual, given)
+	}
+
+	for _, invalid := range []string{"5", "-5ms", "5ms±", "5ms±some", "fast"} {
+		_, err := ParseInjectedLatency(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestInjectedLatencyJitter(t *testing.T) {
+	latency := InjectedLatency{Base: 5 * time.Millisecond, Jitter: 2 * time.Millisecond}
+	assert.Equal(t, 3*time.Millisecond, latency.delay(0))
+	assert.Equal(t, 5*time.Millisecond, latency.delay(0.5))
+	// Jitter larger than the base doesn't make for negative delays
+	assert.Equal(t, time.Duration(0), InjectedLatency{Base: time.Millisecond, Jitter: 5 * time.Millisecond}.delay(0))
+}
+
+func TestInjectedLatencyCountsTowardsStatementsAndUnits(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &statementDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetInjectedLatency(InjectedLatency{Base: 5 * time.Millisecond})
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 10, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.InDelta(t, 6000, result.Scripts["workertest"].Latencies.ValueAtQuantile(50), 10)
+	assert.InDelta(t, 6000, result.Databases[""].Latencies.ValueAtQuantile(50), 10)
+}
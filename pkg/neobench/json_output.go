@@ -0,0 +1,364 @@
+package neobench
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Writes the result of the run as a single JSON document to OutStream, for pipelines that would rather not parse
+// text or CSV; progress goes to ErrStream as text. The document covers what the main interactive report does, see
+// jsonResult; the sections of the other reports are only in the interactive and csv outputs.
+type JsonOutput struct {
+	ErrStream io.Writer
+	OutStream io.Writer
+	// Used to rate-limit progress reporting
+	LastProgressReport ProgressReport
+	LastProgressTime   time.Time
+}
+
+// The document JsonOutput writes. Latencies are in the unit of --latency-unit, which their names end with, ex: p99_us,
+// milliseconds for auto; durations are in seconds, and names follow the CSV columns.
+type jsonResult struct {
+	Mode                  string         `json:"mode"`
+	Database              string         `json:"database"`
+	Scenario              string         `json:"scenario"`
+	Build                 jsonBuild      `json:"build"`
+	SlaMs                 float64        `json:"sla_ms,omitempty"`
+	SetupSeconds          float64        `json:"setup_seconds"`
+	WarmupSeconds         float64        `json:"warmup_seconds,omitempty"`
+	DurationSeconds       float64        `json:"duration_seconds"`
+	Succeeded             int64          `json:"succeeded"`
+	Failed                int64          `json:"failed"`
+	TransactionsPerSecond float64        `json:"transactions_per_second"`
+	SucceededPerSecond    float64        `json:"succeeded_per_second"`
+	GoodputPerSecond      float64        `json:"goodput_per_second"`
+	Scripts               []jsonScript   `json:"scripts"`
+	Lanes                 []jsonScript   `json:"lanes,omitempty"`
+	Phases                []jsonPhase    `json:"phases,omitempty"`
+	Databases             []jsonDatabase `json:"databases,omitempty"`
+	Mix                   []jsonMix      `json:"mix,omitempty"`
+	Failures              []jsonFailure  `json:"failures"`
+	Schedule              *jsonSchedule  `json:"schedule,omitempty"`
+	// Why --abort-if stopped the run, if it did
+	Aborted string `json:"aborted,omitempty"`
+	// Failed while the run was stopping, and not counted in Failed
+	ShutdownFailures int64 `json:"shutdown_failures,omitempty"`
+}
+
+// How well latency mode kept to the rate, see BacklogResult
+type jsonSchedule struct {
+	RequestedPerSecond  float64 `json:"requested_per_second,omitempty"`
+	AchievedPerSecond   float64 `json:"achieved_per_second"`
+	IntervalsOnSchedule int     `json:"intervals_on_schedule"`
+	Intervals           int     `json:"intervals"`
+	PeakBacklogSeconds  float64 `json:"peak_backlog_seconds"`
+	Missed              int64   `json:"missed"`
+}
+
+type jsonBuild struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	DriverVersion string `json:"driver_version"`
+	GoVersion     string `json:"go_version"`
+}
+
+type jsonScript struct {
+	Name                  string       `json:"name"`
+	Succeeded             int64        `json:"succeeded"`
+	Failed                int64        `json:"failed"`
+	TransactionsPerSecond float64      `json:"transactions_per_second"`
+	SucceededPerSecond    float64      `json:"succeeded_per_second"`
+	GoodputPerSecond      float64      `json:"goodput_per_second"`
+	Latency               *jsonLatency `json:"latency,omitempty"`
+}
+
+// Latencies in the unit of --latency-unit, which the field names end with, ex: p99_ms
+type jsonLatency struct {
+	unit   LatencyUnit
+	Min    float64
+	Mean   float64
+	Stddev float64
+	Max    float64
+	P25    float64
+	P50    float64
+	P75    float64
+	P95    float64
+	P99    float64
+	P999   float64
+	P99999 float64
+}
+
+type jsonLatencyField struct {
+	name  string
+	value *float64
+}
+
+func (l *jsonLatency) fields() []jsonLatencyField {
+	return []jsonLatencyField{
+		{"min", &l.Min}, {"mean", &l.Mean}, {"stddev", &l.Stddev}, {"max", &l.Max}, {"p25", &l.P25}, {"p50", &l.P50},
+		{"p75", &l.P75}, {"p95", &l.P95}, {"p99", &l.P99}, {"p99_9", &l.P999}, {"p99_999", &l.P99999},
+	}
+}
+
+func (l *jsonLatency) MarshalJSON() ([]byte, error) {
+	s := strings.Builder{}
+	s.WriteString("{")
+	for i, field := range l.fields() {
+		if i > 0 {
+			s.WriteString(",")
+		}
+		value, err := json.Marshal(*field.value)
+		if err != nil {
+			return nil, err
+		}
+		s.WriteString(fmt.Sprintf("\"%s_%s\":%s", field.name, l.unit.Suffix(), value))
+	}
+	s.WriteString("}")
+	return []byte(s.String()), nil
+}
+
+// Reads back what MarshalJSON wrote, taking the unit from the suffix of the names, for `neobench compare`
+func (l *jsonLatency) UnmarshalJSON(data []byte) error {
+	values := map[string]float64{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	for _, unit := range []LatencyUnit{LatencyMicroseconds, LatencyMilliseconds, LatencySeconds} {
+		if _, ok := values["p50_"+unit.Suffix()]; ok {
+			l.unit = unit
+		}
+	}
+	if l.unit == "" {
+		return fmt.Errorf("latency has no p50 in any of the units 'us', 'ms' and 's'")
+	}
+	for _, field := range l.fields() {
+		*field.value = values[field.name+"_"+l.unit.Suffix()]
+	}
+	return nil
+}
+
+// Microseconds, whatever unit the latency is in
+func (l *jsonLatency) micros(value float64) float64 {
+	return value / l.unit.Value(1)
+}
+
+type jsonPhase struct {
+	Name         string       `json:"name"`
+	StartSeconds float64      `json:"start_seconds"`
+	EndSeconds   float64      `json:"end_seconds"`
+	Scripts      []jsonScript `json:"scripts"`
+}
+
+type jsonDatabase struct {
+	Name      string       `json:"name"`
+	Succeeded int64        `json:"succeeded"`
+	Failed    int64        `json:"failed"`
+	Latency   *jsonLatency `json:"latency,omitempty"`
+}
+
+type jsonMix struct {
+	Script        string  `json:"script"`
+	TargetShare   float64 `json:"target_share"`
+	AchievedShare float64 `json:"achieved_share"`
+	Drifted       bool    `json:"drifted"`
+}
+
+type jsonFailure struct {
+	Group   string       `json:"group"`
+	Count   int64        `json:"count"`
+	Example string       `json:"example"`
+	Latency *jsonLatency `json:"latency,omitempty"`
+}
+
+func (o *JsonOutput) BenchmarkStart(databaseName, url, scenario string, run RunTag) {
+	if databaseName == "" {
+		databaseName = "<default>"
+	}
+	_, err := fmt.Fprintf(o.ErrStream,
+		"Starting workload on database %s against %s\n"+
+			"Scenario: %s\n"+
+			"Run: %s (scenario hash %s)\n"+
+			"Build: %s\n", databaseName, url, scenario, run.RunId, run.ScenarioHash, CurrentBuild())
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *JsonOutput) ReportInitProgress(report ProgressReport) {
+	now := time.Now()
+	if report.Section == o.LastProgressReport.Section && report.Step == o.LastProgressReport.Step && now.Sub(o.LastProgressTime).Seconds() < 10 {
+		return
+	}
+	o.LastProgressReport = report
+	o.LastProgressTime = now
+	_, err := fmt.Fprintf(o.ErrStream, "[%s][%s] %.02f%%\n", report.Section, report.Step, report.Completeness*100)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Progress is text on stderr, so stdout holds just the one document
+func (o *JsonOutput) ReportWorkloadProgress(completeness float64, checkpoint Result) {
+	_, err := fmt.Fprintf(o.ErrStream, "[workload] %.02f%% done, %.02f tps, %.02f succeeded, %.02f goodput / %d failures\n", completeness*100,
+		checkpoint.TotalRate(), checkpoint.TotalSucceededRate(), checkpoint.TotalGoodput(), checkpoint.TotalFailed())
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *JsonOutput) ReportThroughput(result Result) {
+	o.write(result, "throughput")
+}
+
+func (o *JsonOutput) ReportLatency(result Result) {
+	o.write(result, "latency")
+}
+
+func (o *JsonOutput) write(result Result, mode string) {
+	encoder := json.NewEncoder(o.OutStream)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newJsonResult(result, mode)); err != nil {
+		panic(err)
+	}
+}
+
+func (o *JsonOutput) Errorf(format string, a ...interface{}) {
+	_, err := fmt.Fprintf(o.ErrStream, "ERROR: %s\n", fmt.Sprintf(format, a...))
+	if err != nil {
+		panic(err)
+	}
+}
+
+var _ Output = &JsonOutput{}
+
+func newJsonResult(result Result, mode string) jsonResult {
+	out := jsonResult{
+		Mode:     mode,
+		Database: result.DatabaseName,
+		Scenario: result.Scenario,
+		Build: jsonBuild{
+			Version:       result.Build.Version,
+			Commit:        result.Build.Commit,
+			DriverVersion: result.Build.DriverVersion,
+			GoVersion:     result.Build.GoVersion,
+		},
+		SlaMs:                 float64(result.Sla.Microseconds()) / 1000.0,
+		SetupSeconds:          result.Setup.Seconds(),
+		DurationSeconds:       result.Duration.Seconds(),
+		Succeeded:             result.TotalSucceeded(),
+		Failed:                result.TotalFailed(),
+		TransactionsPerSecond: result.TotalRate(),
+		SucceededPerSecond:    result.TotalSucceededRate(),
+		GoodputPerSecond:      result.TotalGoodput(),
+		Scripts:               jsonScripts(sortedScripts(result.Scripts), result.Sla, result.LatencyUnit),
+		Failures:              []jsonFailure{},
+		ShutdownFailures:      result.ShutdownFailures,
+	}
+	if result.Health != nil && result.Health.Aborted {
+		out.Aborted = result.Health.Reason
+	}
+	if backlog := result.Backlog; backlog != nil {
+		out.Schedule = &jsonSchedule{
+			RequestedPerSecond:  backlog.RequestedRate,
+			AchievedPerSecond:   backlog.AchievedRate,
+			IntervalsOnSchedule: backlog.OnSchedule,
+			Intervals:           len(backlog.Samples),
+			PeakBacklogSeconds:  backlog.Peak.Seconds(),
+			Missed:              backlog.Missed,
+		}
+	}
+	if len(result.Lanes) > 0 {
+		out.Lanes = jsonScripts(result.Lanes, result.Sla, result.LatencyUnit)
+	}
+	for _, phase := range result.Phases {
+		out.Phases = append(out.Phases, jsonPhase{
+			Name:         phase.Name,
+			StartSeconds: phase.Start.Seconds(),
+			EndSeconds:   phase.End.Seconds(),
+			Scripts:      jsonScripts(sortedScripts(phase.Scripts), result.Sla, result.LatencyUnit),
+		})
+	}
+	if len(result.Databases) > 1 {
+		names := make([]string, 0, len(result.Databases))
+		for name := range result.Databases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			db := result.Databases[name]
+			out.Databases = append(out.Databases, jsonDatabase{
+				Name:      name,
+				Succeeded: db.Succeeded,
+				Failed:    db.Failed,
+				Latency:   newJsonLatency(db.Latencies, result.LatencyUnit),
+			})
+		}
+	}
+	for _, entry := range result.Mix {
+		out.Mix = append(out.Mix, jsonMix{
+			Script:        entry.ScriptName,
+			TargetShare:   entry.TargetShare,
+			AchievedShare: entry.AchievedShare,
+			Drifted:       entry.Drifted,
+		})
+	}
+	groups := make([]string, 0, len(result.FailedByErrorGroup))
+	for group := range result.FailedByErrorGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		info := result.FailedByErrorGroup[group]
+		failure := jsonFailure{Group: group, Count: info.Count, Latency: newJsonLatency(info.Latencies, result.LatencyUnit)}
+		if info.FirstFailure != nil {
+			failure.Example = info.FirstFailure.Error()
+		}
+		out.Failures = append(out.Failures, failure)
+	}
+	return out
+}
+
+func jsonScripts(scripts []*ScriptResult, sla time.Duration, unit LatencyUnit) []jsonScript {
+	out := make([]jsonScript, 0, len(scripts))
+	for _, script := range scripts {
+		out = append(out, jsonScript{
+			Name:                  script.ScriptName,
+			Succeeded:             script.Succeeded,
+			Failed:                script.Failed,
+			TransactionsPerSecond: script.Rate,
+			SucceededPerSecond:    script.SucceededRate(),
+			GoodputPerSecond:      script.Goodput(sla),
+			Latency:               newJsonLatency(script.Latencies, unit),
+		})
+	}
+	return out
+}
+
+// Nil if nothing was recorded, so the document leaves the latency out rather than claim it was 0
+func newJsonLatency(histo *hdrhistogram.Histogram, unit LatencyUnit) *jsonLatency {
+	if histo == nil || histo.TotalCount() == 0 {
+		return nil
+	}
+	at := func(quantile float64) float64 {
+		return unit.Value(float64(histo.ValueAtQuantile(quantile)))
+	}
+	return &jsonLatency{
+		unit:   unit,
+		Min:    unit.Value(float64(histo.Min())),
+		Mean:   unit.Value(histo.Mean()),
+		Stddev: unit.Value(histo.StdDev()),
+		Max:    unit.Value(float64(histo.Max())),
+		P25:    at(25),
+		P50:    at(50),
+		P75:    at(75),
+		P95:    at(95),
+		P99:    at(99),
+		P999:   at(99.9),
+		P99999: at(99.999),
+	}
+}
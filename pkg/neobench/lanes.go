@@ -0,0 +1,104 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"sort"
+	"strings"
+)
+
+// A named group of scripts, like "interactive" or "batch", see --lane. SLOs are usually set for a kind of work
+// rather than for each query, so the report includes percentiles over all the scripts of each lane, on top of the
+// percentiles of each script.
+type Lane struct {
+	Name string
+	// Names of the scripts in this lane
+	Scripts []string
+}
+
+// Parses lanes like "interactive=reads,lookups". Scripts are named as for --weight-phase, see parseWeightPhase; each
+// script can be in at most one lane.
+func ParseLanes(specs []string, scripts []Script) ([]Lane, error) {
+	lanes := make([]Lane, 0, len(specs))
+	laneOf := make(map[string]string)
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid --lane '%s', expected a name and the scripts in it, like interactive=reads,lookups", spec)
+		}
+		lane := Lane{Name: strings.TrimSpace(parts[0])}
+		for _, key := range strings.Split(parts[1], ",") {
+			key = strings.TrimSpace(key)
+			matched := false
+			for _, script := range scripts {
+				if !phaseKeyMatches(key, script.Name) {
+					continue
+				}
+				matched = true
+				if other, found := laneOf[script.Name]; found {
+					if other == lane.Name {
+						continue
+					}
+					return nil, fmt.Errorf("invalid --lane '%s', script %s is already in lane %s", spec, script.Name, other)
+				}
+				laneOf[script.Name] = lane.Name
+				lane.Scripts = append(lane.Scripts, script.Name)
+			}
+			if !matched {
+				return nil, fmt.Errorf("invalid --lane '%s', '%s' doesn't match any script in the workload", spec, key)
+			}
+		}
+		for _, existing := range lanes {
+			if existing.Name == lane.Name {
+				return nil, fmt.Errorf("there is more than one --lane named '%s'", lane.Name)
+			}
+		}
+		lanes = append(lanes, lane)
+	}
+	return lanes, nil
+}
+
+// The results of the scripts in each lane, combined; these have the lane name as script name
+func (s *Scripts) CompleteLanes(result *Result) {
+	result.Lanes = make([]*ScriptResult, 0, len(s.Lanes))
+	for _, lane := range s.Lanes {
+		combined := &ScriptResult{ScriptName: lane.Name, Latencies: hdrhistogram.New(0, 60*60*1000000, 3)}
+		for _, name := range lane.Scripts {
+			script, found := result.Scripts[name]
+			if !found {
+				continue
+			}
+			combined.Rate += script.Rate
+			combined.Succeeded += script.Succeeded
+			combined.Failed += script.Failed
+			combined.Latencies.Merge(script.Latencies)
+		}
+		result.Lanes = append(result.Lanes, combined)
+	}
+}
+
+// Latency or rate of each lane, over all the scripts in it
+func writeLaneReport(result Result, latencyMode bool, s *strings.Builder) {
+	if len(result.Lanes) == 0 {
+		return
+	}
+	lanes := append([]*ScriptResult{}, result.Lanes...)
+	sort.Slice(lanes, func(i, j int) bool { return lanes[i].ScriptName < lanes[j].ScriptName })
+	if !latencyMode {
+		s.WriteString("-- Lanes --\n\n")
+		for _, lane := range lanes {
+			s.WriteString(fmt.Sprintf("  [%s]: %s transactions per second\n", lane.ScriptName, describeRates(lane, result.Sla)))
+		}
+		s.WriteString("\n")
+		return
+	}
+	for _, lane := range lanes {
+		s.WriteString(fmt.Sprintf("-- Lane: %s --\n\n", lane.ScriptName))
+		if lane.Succeeded == 0 {
+			s.WriteString(fmt.Sprintf("  %d successful transactions, %d failed.\n\n", lane.Succeeded, lane.Failed))
+			continue
+		}
+		summarizeLatency(lane, result.Sla, result.LatencyUnit, s, "  ")
+		s.WriteString("\n")
+	}
+}
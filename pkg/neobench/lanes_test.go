@@ -0,0 +1,56 @@
+package neobench
+
+import (
+	"github.com/codahale/hdrhistogram"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseLanes(t *testing.T) {
+	scripts := []Script{{Name: "/tmp/lookup.script"}, {Name: "/tmp/search.script"}, {Name: "builtin:tpcb-like"}}
+
+	lanes, err := ParseLanes([]string{"interactive=lookup, search.script", "batch=tpcb-like"}, scripts)
+	assert.NoError(t, err)
+	assert.Equal(t, []Lane{
+		{Name: "interactive", Scripts: []string{"/tmp/lookup.script", "/tmp/search.script"}},
+		{Name: "batch", Scripts: []string{"builtin:tpcb-like"}},
+	}, lanes)
+
+	for _, invalid := range [][]string{
+		{"interactive"},
+		{"=lookup"},
+		{"interactive=nope"},
+		{"interactive=lookup", "batch=lookup"},
+		{"interactive=lookup", "interactive=search"},
+	} {
+		_, err := ParseLanes(invalid, scripts)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLanesCombineTheirScripts(t *testing.T) {
+	s := Scripts{Lanes: []Lane{{Name: "interactive", Scripts: []string{"lookup", "search"}}}}
+	result := NewResult("", "")
+	for name, latency := range map[string]int64{"lookup": 1000, "search": 3000, "report": 100000} {
+		script := &ScriptResult{ScriptName: name, Succeeded: 10, Rate: 2, Latencies: hdrhistogram.New(0, 60*60*1000000, 3)}
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, script.Latencies.RecordValue(latency))
+		}
+		result.Scripts[name] = script
+	}
+
+	s.CompleteLanes(&result)
+
+	assert.Len(t, result.Lanes, 1)
+	lane := result.Lanes[0]
+	assert.Equal(t, "interactive", lane.ScriptName)
+	assert.Equal(t, int64(20), lane.Succeeded)
+	assert.Equal(t, 4.0, lane.Rate)
+	assert.InDelta(t, 3000, lane.Latencies.Max(), 10)
+
+	out := strings.Builder{}
+	writeLaneReport(result, true, &out)
+	assert.Contains(t, out.String(), "-- Lane: interactive --")
+	assert.Contains(t, out.String(), "20 successful transactions, 0 failed.")
+}
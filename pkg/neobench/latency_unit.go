@@ -0,0 +1,88 @@
+package neobench
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Unit latencies are reported in, set with --latency-unit; histograms record microseconds, and every report goes
+// through this to show them, so all of them agree. The zero value reports milliseconds.
+type LatencyUnit string
+
+const (
+	// Picks a unit for each value in the interactive output, like 850µs, 12.500ms or 3.200s; milliseconds in CSV
+	// and JSON, which need one unit per column
+	LatencyAuto         LatencyUnit = "auto"
+	LatencyMicroseconds LatencyUnit = "us"
+	LatencyMilliseconds LatencyUnit = "ms"
+	LatencySeconds      LatencyUnit = "s"
+)
+
+func ParseLatencyUnit(s string) (LatencyUnit, error) {
+	switch unit := LatencyUnit(s); unit {
+	case LatencyAuto, LatencyMicroseconds, LatencyMilliseconds, LatencySeconds:
+		return unit, nil
+	}
+	return "", fmt.Errorf("unknown latency unit: %s, supported units are 'auto', 'us', 'ms' and 's'", s)
+}
+
+// The unit for outputs that need the same one throughout, like CSV columns
+func (u LatencyUnit) Fixed() LatencyUnit {
+	if u == LatencyAuto || u == "" {
+		return LatencyMilliseconds
+	}
+	return u
+}
+
+// Suffix of CSV columns and JSON fields in this unit, ex: ms for p99_ms
+func (u LatencyUnit) Suffix() string {
+	return string(u.Fixed())
+}
+
+// The given number of microseconds in this unit, or in milliseconds for LatencyAuto
+func (u LatencyUnit) Value(micros float64) float64 {
+	switch u.Fixed() {
+	case LatencyMicroseconds:
+		return micros
+	case LatencySeconds:
+		return micros / 1000000.0
+	}
+	return micros / 1000.0
+}
+
+// Ex: 12.500ms, or 850µs for LatencyAuto
+func (u LatencyUnit) Format(micros float64) string {
+	unit := u
+	if u == LatencyAuto {
+		unit = humanUnit(math.Abs(micros))
+	}
+	if unit == LatencyMicroseconds {
+		// Histograms don't record fractions of microseconds, so there are none to show
+		return fmt.Sprintf("%.0fµs", micros)
+	}
+	return fmt.Sprintf("%.3f%s", unit.Value(micros), unit.Fixed())
+}
+
+// Like Format, for differences, which always carry a sign, ex: +2.500ms
+func (u LatencyUnit) FormatDelta(micros float64) string {
+	if micros < 0 {
+		return u.Format(micros)
+	}
+	return "+" + u.Format(micros)
+}
+
+func (u LatencyUnit) FormatDuration(d time.Duration) string {
+	return u.Format(float64(d.Microseconds()))
+}
+
+// The largest unit that keeps the value at 1 or more
+func humanUnit(micros float64) LatencyUnit {
+	if micros < 1000 {
+		return LatencyMicroseconds
+	}
+	if micros < 1000000 {
+		return LatencyMilliseconds
+	}
+	return LatencySeconds
+}
@@ -0,0 +1,53 @@
+package neobench
+
+import (
+	"encoding/json"
+	"github.com/codahale/hdrhistogram"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseLatencyUnit(t *testing.T) {
+	for _, given := range []string{"auto", "us", "ms", "s"} {
+		unit, err := ParseLatencyUnit(given)
+		assert.NoError(t, err, given)
+		assert.Equal(t, LatencyUnit(given), unit)
+	}
+	_, err := ParseLatencyUnit("ns")
+	assert.EqualError(t, err, "unknown latency unit: ns, supported units are 'auto', 'us', 'ms' and 's'")
+}
+
+func TestAutoLatencyUnitPicksAUnitPerValue(t *testing.T) {
+	assert.Equal(t, "850µs", LatencyAuto.Format(850))
+	assert.Equal(t, "12.500ms", LatencyAuto.Format(12500))
+	assert.Equal(t, "3.200s", LatencyAuto.FormatDuration(3200*time.Millisecond))
+	assert.Equal(t, "+2.500ms", LatencyAuto.FormatDelta(2500))
+	assert.Equal(t, "-850µs", LatencyAuto.FormatDelta(-850))
+}
+
+func TestFixedLatencyUnitsFormatEveryValueTheSame(t *testing.T) {
+	assert.Equal(t, "3200000µs", LatencyMicroseconds.Format(3200000))
+	assert.Equal(t, "0.850ms", LatencyMilliseconds.Format(850))
+	assert.Equal(t, "0.013s", LatencySeconds.Format(12500))
+	// The zero value, for results built without a unit, reports milliseconds
+	assert.Equal(t, "12.500ms", LatencyUnit("").Format(12500))
+
+	assert.Equal(t, 12.5, LatencyAuto.Value(12500))
+	assert.Equal(t, 12500.0, LatencyMicroseconds.Value(12500))
+	assert.Equal(t, 0.0125, LatencySeconds.Value(12500))
+}
+
+func TestJsonLatencyFieldsEndWithTheUnit(t *testing.T) {
+	histo := hdrhistogram.New(0, 60*60*1000000, 3)
+	assert.NoError(t, histo.RecordValues(1500, 4))
+
+	out, err := json.Marshal(newJsonLatency(histo, LatencyMicroseconds))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"min_us":1500,"mean_us":1500,"stddev_us":0,"max_us":1500,"p25_us":1500,"p50_us":1500,"p75_us":1500,`+
+		`"p95_us":1500,"p99_us":1500,"p99_9_us":1500,"p99_999_us":1500}`, string(out))
+
+	out, err = json.Marshal(newJsonLatency(histo, LatencyAuto))
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"p99_ms":1.5`)
+}
@@ -0,0 +1,77 @@
+package neobench
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Number of people the ldbc-like dataset has at scale 1; people have ids 1 through the scaled count
+const LDBCPeoplePerScale = 9892
+
+// Message ids in the ldbc-like dataset encode the forum the message is in and its position in that forum, so
+// random messages can be picked without coordinating with the database
+func LDBCMessageId(forumId, messageIndex int64) int64 {
+	return forumId<<32 | messageIndex
+}
+
+// Number of people in the ldbc-like dataset at the given scale
+func LDBCPeople(scale float64) int64 {
+	n := int64(LDBCPeoplePerScale * scale)
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// Which messages exist in a populated ldbc-like dataset. Unlike people, this depends on how the simulated activity
+// played out, so the generator records it in the database.
+type LDBCIds struct {
+	// Scale the dataset was populated with
+	Scale float64
+	// Number of messages in each forum, indexed by forum id; forum ids start at 1, so the first entry is unused
+	MessageCounts []int64
+	// Cumulative message counts, for picking a forum weighted by how many messages it has
+	cumulative []int64
+}
+
+func NewLDBCIds(scale float64, messageCounts []int64) *LDBCIds {
+	cumulative := make([]int64, len(messageCounts))
+	total := int64(0)
+	for i, count := range messageCounts {
+		total += count
+		cumulative[i] = total
+	}
+	return &LDBCIds{Scale: scale, MessageCounts: messageCounts, cumulative: cumulative}
+}
+
+// Picks a message uniformly at random among all messages in the dataset
+func (l *LDBCIds) RandomMessage(random *rand.Rand) (int64, error) {
+	if len(l.cumulative) == 0 || l.cumulative[len(l.cumulative)-1] == 0 {
+		return 0, fmt.Errorf("the ldbc-like dataset has no messages")
+	}
+	n := random.Int63n(l.cumulative[len(l.cumulative)-1])
+	forumId := sort.Search(len(l.cumulative), func(i int) bool { return l.cumulative[i] > n })
+	messageIndex := n - (l.cumulative[forumId] - l.MessageCounts[forumId])
+	return LDBCMessageId(int64(forumId), messageIndex), nil
+}
+
+// Loads the ldbc-like ids on first use and caches them, safe for concurrent use
+type LDBCIdLoader struct {
+	once sync.Once
+	load func() (*LDBCIds, error)
+	ids  *LDBCIds
+	err  error
+}
+
+func NewLDBCIdLoader(load func() (*LDBCIds, error)) *LDBCIdLoader {
+	return &LDBCIdLoader{load: load}
+}
+
+func (l *LDBCIdLoader) Load() (*LDBCIds, error) {
+	l.once.Do(func() {
+		l.ids, l.err = l.load()
+	})
+	return l.ids, l.err
+}
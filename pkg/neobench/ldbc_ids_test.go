@@ -0,0 +1,30 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestLDBCRandomMessagePicksEveryMessageAndOnlyThose(t *testing.T) {
+	ids := NewLDBCIds(1, []int64{0, 2, 0, 3})
+	random := rand.New(rand.NewSource(1337))
+
+	seen := make(map[int64]int)
+	for i := 0; i < 5000; i++ {
+		id, err := ids.RandomMessage(random)
+		assert.NoError(t, err)
+		seen[id] += 1
+	}
+
+	assert.Len(t, seen, 5)
+	for _, id := range []int64{LDBCMessageId(1, 0), LDBCMessageId(1, 1), LDBCMessageId(3, 0), LDBCMessageId(3, 1), LDBCMessageId(3, 2)} {
+		// Uniform across messages, so each should get about a fifth
+		assert.InDelta(t, 1000, seen[id], 150, "message %d", id)
+	}
+}
+
+func TestLDBCRandomMessageWithoutMessages(t *testing.T) {
+	_, err := NewLDBCIds(1, []int64{0, 0}).RandomMessage(rand.New(rand.NewSource(1337)))
+	assert.EqualError(t, err, "the ldbc-like dataset has no messages")
+}
@@ -0,0 +1,137 @@
+package neobench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Most lines a script may expand to with :for, so a typo like 1..1000000000 fails rather than eats all memory
+const maxExpandedLines = 1000000
+
+// Expands `:for <var> in <values>` ... `:endfor` blocks, before the script is parsed, into the lines between them
+// repeated once for each value, with {{var}} replaced by the value. Values are an inclusive range of integers, ex:
+// 1..20, or a comma separated list, ex: name, age, email. Loops can be nested, and the values of inner loops can
+// refer to outer loop variables, ex: :for j in 1..{{i}}.
+func expandLoops(filename, script string) (string, error) {
+	if !strings.Contains(script, ":for") && !strings.Contains(script, ":endfor") {
+		return script, nil
+	}
+	lines := strings.Split(script, "\n")
+	expanded, err := expandLoopLines(filename, lines, 0)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(expanded, "\n"), nil
+}
+
+// Expands lines, which start at the given line of the script, counting from zero
+func expandLoopLines(filename string, lines []string, firstLine int) ([]string, error) {
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		keyword, _ := loopKeyword(lines[i])
+		if keyword == ":endfor" {
+			return nil, fmt.Errorf(":endfor without a :for before it (at %s:%d)", filename, firstLine+i+1)
+		}
+		if keyword != ":for" {
+			out = append(out, lines[i])
+			continue
+		}
+
+		end, err := matchingEndFor(lines, i)
+		if err != nil {
+			return nil, fmt.Errorf("%s (at %s:%d)", err, filename, firstLine+i+1)
+		}
+		varName, values, err := parseForHeader(lines[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s (at %s:%d)", err, filename, firstLine+i+1)
+		}
+		body := lines[i+1 : end]
+		for _, value := range values {
+			iteration := make([]string, len(body))
+			for j, line := range body {
+				iteration[j] = strings.ReplaceAll(line, "{{"+varName+"}}", value)
+			}
+			expanded, err := expandLoopLines(filename, iteration, firstLine+i+1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded...)
+			if len(out) > maxExpandedLines {
+				return nil, fmt.Errorf(":for loops expand to over %d lines (at %s:%d)", maxExpandedLines, filename, firstLine+i+1)
+			}
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// :for or :endfor if the line is one of those, and the rest of the line
+func loopKeyword(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+	for _, keyword := range []string{":endfor", ":for"} {
+		if trimmed == keyword {
+			return keyword, ""
+		}
+		if strings.HasPrefix(trimmed, keyword+" ") || strings.HasPrefix(trimmed, keyword+"\t") {
+			return keyword, strings.TrimSpace(trimmed[len(keyword):])
+		}
+	}
+	return "", ""
+}
+
+// Index of the :endfor that closes the :for at index start
+func matchingEndFor(lines []string, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		switch keyword, _ := loopKeyword(lines[i]); keyword {
+		case ":for":
+			depth++
+		case ":endfor":
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf(":for without an :endfor after it")
+}
+
+// Parses `:for i in 1..10` or `:for prop in name, age`
+func parseForHeader(line string) (string, []string, error) {
+	_, rest := loopKeyword(line)
+	parts := strings.SplitN(rest, " in ", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("expected :for <variable> in <values>, ex: :for i in 1..10, got '%s'", strings.TrimSpace(line))
+	}
+	varName, rawValues := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if varName == "" || strings.ContainsAny(varName, " \t{}") {
+		return "", nil, fmt.Errorf("invalid :for variable '%s'", varName)
+	}
+
+	if bounds := strings.SplitN(rawValues, "..", 2); len(bounds) == 2 {
+		from, fromErr := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 64)
+		to, toErr := strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 64)
+		if fromErr != nil || toErr != nil {
+			return "", nil, fmt.Errorf("invalid :for range '%s', expected whole numbers like 1..10", rawValues)
+		}
+		if to-from >= maxExpandedLines {
+			return "", nil, fmt.Errorf(":for range '%s' has over %d values", rawValues, maxExpandedLines)
+		}
+		values := make([]string, 0)
+		for v := from; v <= to; v++ {
+			values = append(values, strconv.FormatInt(v, 10))
+		}
+		return varName, values, nil
+	}
+
+	values := make([]string, 0)
+	for _, value := range strings.Split(rawValues, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return "", nil, fmt.Errorf("invalid :for values '%s', expected a range like 1..10 or a list like name, age", rawValues)
+		}
+		values = append(values, value)
+	}
+	return varName, values, nil
+}
@@ -0,0 +1,64 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestForLoopExpandsIntoStatements(t *testing.T) {
+	script, err := Parse("loops", `:set id random(1, 10)
+:for i in 1..3
+:set v{{i}} {{i}} * 10
+MATCH (n:Node {id: $id}) SET n.p{{i}} = $v{{i}};
+:endfor
+:for prop in name, email
+MATCH (n:Node {id: $id}) RETURN n.{{prop}};
+:endfor
+`, 1)
+	assert.NoError(t, err)
+
+	uow, err := script.Eval(ScriptContext{Vars: map[string]interface{}{"scale": int64(1)}, Rand: rand.New(rand.NewSource(1))})
+	assert.NoError(t, err)
+	queries := make([]string, 0)
+	for _, statement := range uow.Statements {
+		queries = append(queries, statement.Query)
+	}
+	assert.Equal(t, []string{
+		"MATCH (n:Node {id: $id}) SET n.p1 = $v1",
+		"MATCH (n:Node {id: $id}) SET n.p2 = $v2",
+		"MATCH (n:Node {id: $id}) SET n.p3 = $v3",
+		"MATCH (n:Node {id: $id}) RETURN n.name",
+		"MATCH (n:Node {id: $id}) RETURN n.email",
+	}, queries)
+	assert.Equal(t, int64(30), uow.Statements[2].Params["v3"])
+}
+
+func TestNestedForLoops(t *testing.T) {
+	expanded, err := expandLoops("nested", `:for i in 1..3
+  :for j in {{i}}..3
+RETURN {{i}}, {{j}};
+  :endfor
+:endfor`)
+	assert.NoError(t, err)
+	assert.Equal(t, "RETURN 1, 1;\nRETURN 1, 2;\nRETURN 1, 3;\nRETURN 2, 2;\nRETURN 2, 3;\nRETURN 3, 3;", expanded)
+
+	expanded, err = expandLoops("empty", ":for i in 1..0\nRETURN {{i}};\n:endfor\nRETURN 0;")
+	assert.NoError(t, err)
+	assert.Equal(t, "RETURN 0;", expanded)
+}
+
+func TestInvalidForLoops(t *testing.T) {
+	for script, expected := range map[string]string{
+		":for i in 1..3\nRETURN {{i}};":                    ":for without an :endfor after it (at bad:1)",
+		"RETURN 1;\n:endfor":                               ":endfor without a :for before it (at bad:2)",
+		"RETURN 1;\n:for i 1..3\nRETURN 1;\n:endfor":       "expected :for <variable> in <values>, ex: :for i in 1..10, got ':for i 1..3' (at bad:2)",
+		":for i in 1..x\nRETURN 1;\n:endfor":               "invalid :for range '1..x', expected whole numbers like 1..10 (at bad:1)",
+		":for i in a,,b\nRETURN 1;\n:endfor":               "invalid :for values 'a,,b', expected a range like 1..10 or a list like name, age (at bad:1)",
+		":for i in 1..2000000\nRETURN 1;\n:endfor":         ":for range '1..2000000' has over 1000000 values (at bad:1)",
+		":for i in 1..2\n:for j in 1..x\n:endfor\n:endfor": "invalid :for range '1..x', expected whole numbers like 1..10 (at bad:2)",
+	} {
+		_, err := Parse("bad", script, 1)
+		assert.EqualError(t, err, expected, script)
+	}
+}
@@ -0,0 +1,206 @@
+package neobench
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"time"
+)
+
+// What to do once a unit of work has failed, and any retries have failed too
+const (
+	// Count the unit of work as failed, the default
+	OnErrorAbort = "abort"
+	// Count the unit of work as succeeded, as an application that swallows the error would
+	OnErrorIgnore = "ignore"
+	// Run the fallback statement instead; the unit of work succeeds if that does
+	OnErrorFallback = "fallback"
+)
+
+// How a script handles its units of work failing, set with `:on-error`, to emulate the error handling of an
+// application rather than count every failure the same. The policy applies to the unit of work as a whole, since a
+// failed statement fails the transaction it is part of.
+type ErrorPolicy struct {
+	// Times to run a failed unit of work again, from the top of the script, before giving up on it
+	Retries int
+	// What to do when giving up, one of the OnError constants; empty is the same as OnErrorAbort
+	Action string
+	// Statement to run as an auto-commit transaction of its own with OnErrorFallback
+	Fallback *QueryCommand
+}
+
+// Parses the rest of an `:on-error` line, one of `retry <n>`, `ignore`, `abort` or `fallback` followed by a query
+func parseOnError(s *Script, c *parseContext) {
+	switch action := ident(c); action {
+	case "retry":
+		tok, text := c.Next()
+		retries, err := strconv.Atoi(text)
+		if tok != scanner.Int || err != nil || retries < 1 {
+			c.fail(fmt.Errorf(":on-error retry must be followed by the number of times to retry, ex: :on-error retry 3"))
+			return
+		}
+		s.OnError.Retries = retries
+	case OnErrorIgnore, OnErrorAbort:
+		s.OnError.Action = action
+	case OnErrorFallback:
+		for c.PeekToken() == '\n' {
+			c.Next()
+		}
+		if tok := c.PeekToken(); tok == ':' || tok == scanner.EOF {
+			c.fail(fmt.Errorf(":on-error fallback must be followed by a query"))
+			return
+		}
+		query := command(c).(QueryCommand)
+		query.Autocommit = true
+		s.OnError.Action = action
+		s.OnError.Fallback = &query
+	default:
+		c.fail(fmt.Errorf("unexpected :on-error '%s', expected retry <n>, ignore, abort or fallback", action))
+	}
+}
+
+// Evaluates the fallback statement of the script, with the variables as the most recent call to Run left them, so
+// it can refer to anything the script set before it failed
+func (u *UnitOfWork) FallbackStatement() (Statement, error) {
+	ctx := u.ctx
+	if u.lastVars != nil {
+		ctx.Vars = u.lastVars
+	}
+	fallback := UnitOfWork{}
+	if err := u.OnError.Fallback.Execute(&ctx, &fallback); err != nil {
+		return Statement{}, err
+	}
+	return fallback.Statements[0], nil
+}
+
+// Runs the unit of work, over Bolt or the Query API, and again as many times as its error policy says if it fails
+func (w *Worker) runWithPolicy(sessions *workerSessions, uow *UnitOfWork, stopCh <-chan struct{}) (uowOutcome, error) {
+	var failed uowOutcome
+	for attempt := 0; ; attempt++ {
+		outcome, err := w.runAttempt(sessions, uow)
+		if err != nil {
+			return outcome, err
+		}
+		// What the earlier attempts did counts too
+		outcome.retries = attempt
+		outcome.statements = append(failed.statements, outcome.statements...)
+		outcome.acquisitionWaits = append(failed.acquisitionWaits, outcome.acquisitionWaits...)
+		if outcome.succeeded || attempt >= uow.OnError.Retries || stopping(stopCh) {
+			if !outcome.succeeded && uow.OnError.Action != "" && !stopping(stopCh) {
+				return w.handleFailure(sessions, uow, outcome)
+			}
+			return outcome, nil
+		}
+		failed = outcome
+		jitter := rand.Intn(100)
+		w.sleep(time.Duration(attempt*10+jitter) * time.Millisecond)
+	}
+}
+
+// Does what the error policy of the unit of work says to once it has failed for good
+func (w *Worker) handleFailure(sessions *workerSessions, uow *UnitOfWork, outcome uowOutcome) (uowOutcome, error) {
+	switch uow.OnError.Action {
+	case OnErrorIgnore:
+		outcome.succeeded = true
+		outcome.handled = OnErrorIgnore
+	case OnErrorFallback:
+		stmt, err := uow.FallbackStatement()
+		if err != nil {
+			return uowOutcome{}, fmt.Errorf("failed to evaluate the :on-error fallback of script '%s': %s", uow.ScriptName, err)
+		}
+		start := w.now()
+		w.injectLatency()
+		sessions.acquisition.begin()
+		res, err := sessions.get(stmt.Database).Run(stmt.Query, stmt.Params)
+		if err == nil {
+			_, err = res.Consume()
+		}
+		outcome.statements = append(outcome.statements, statementOutcome{
+			databaseName: sessions.resolve(stmt.Database),
+			latency:      w.now().Sub(start),
+			succeeded:    err == nil,
+		})
+		outcome.acquisitionWaits = append(outcome.acquisitionWaits, sessions.acquisition.take()...)
+		outcome.handled = OnErrorFallback
+		if err == nil {
+			outcome.succeeded = true
+		} else {
+			outcome.fallbackFailed = true
+		}
+	}
+	return outcome, nil
+}
+
+func stopping(stopCh <-chan struct{}) bool {
+	select {
+	case <-stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// How the error policy of one script played out, see ErrorPolicy
+type ErrorHandlingResult struct {
+	ScriptName string
+	// Units of work that succeeded after being retried, and the retries it took all units of work
+	Recovered int64
+	Retries   int64
+	// Units of work that failed for good and were counted as succeeded, with `:on-error ignore`
+	Ignored int64
+	// Units of work that ran their `:on-error fallback` statement, and how many of those failed too
+	Fallbacks      int64
+	FallbackFailed int64
+}
+
+func (r *ErrorHandlingResult) record(outcome uowOutcome) {
+	r.Retries += int64(outcome.retries)
+	switch {
+	case outcome.handled == OnErrorIgnore:
+		r.Ignored++
+	case outcome.handled == OnErrorFallback:
+		r.Fallbacks++
+		if outcome.fallbackFailed {
+			r.FallbackFailed++
+		}
+	case outcome.succeeded && outcome.retries > 0:
+		r.Recovered++
+	}
+}
+
+func (r *ErrorHandlingResult) merge(other *ErrorHandlingResult) {
+	r.Recovered += other.Recovered
+	r.Retries += other.Retries
+	r.Ignored += other.Ignored
+	r.Fallbacks += other.Fallbacks
+	r.FallbackFailed += other.FallbackFailed
+}
+
+// Retries, ignored failures and fallbacks by script; only written if scripts with `:on-error` failed
+func writeErrorHandlingReport(result Result, s *strings.Builder) {
+	if len(result.ErrorHandling) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.ErrorHandling))
+	for name := range result.ErrorHandling {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.WriteString(fmt.Sprintf("-- Error handling --\n\n"))
+	for _, name := range names {
+		handling := result.ErrorHandling[name]
+		s.WriteString(fmt.Sprintf("  [%s]: %d retries, %d units of work succeeded after retrying", name, handling.Retries, handling.Recovered))
+		if handling.Ignored > 0 {
+			s.WriteString(fmt.Sprintf(", %d failures ignored", handling.Ignored))
+		}
+		if handling.Fallbacks > 0 {
+			s.WriteString(fmt.Sprintf(", %d fallbacks of which %d failed", handling.Fallbacks, handling.FallbackFailed))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,119 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOnError(t *testing.T) {
+	script, err := Parse("policy", `:on-error retry 3
+:on-error fallback
+MATCH (n:Cached {id: $id}) RETURN n;
+:set id 1
+MATCH (n {id: $id}) RETURN n;`, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, script.OnError.Retries)
+	assert.Equal(t, OnErrorFallback, script.OnError.Action)
+	assert.Equal(t, "MATCH (n:Cached {id: $id}) RETURN n", script.OnError.Fallback.Query)
+	assert.Len(t, script.Commands, 2)
+
+	for _, invalid := range []string{":on-error retry", ":on-error retry 0", ":on-error panic", ":on-error fallback"} {
+		_, err := Parse("invalid", invalid, 1)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestOnErrorRetriesUntilSuccess(t *testing.T) {
+	driver, w := newOnErrorWorker(2)
+	result := w.RunBenchmark(newOnErrorWorkload(t, ":on-error retry 3"), "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.Scripts["policy"].Succeeded)
+	assert.Equal(t, 3, driver.attempts)
+	handling := result.ErrorHandling["policy"]
+	assert.Equal(t, int64(2), handling.Retries)
+	assert.Equal(t, int64(1), handling.Recovered)
+}
+
+func TestOnErrorGivesUpAfterRetries(t *testing.T) {
+	driver, w := newOnErrorWorker(10)
+	result := w.RunBenchmark(newOnErrorWorkload(t, ":on-error retry 2"), "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.Scripts["policy"].Failed)
+	assert.Equal(t, 3, driver.attempts)
+	assert.Equal(t, int64(0), result.ErrorHandling["policy"].Recovered)
+}
+
+func TestOnErrorIgnore(t *testing.T) {
+	_, w := newOnErrorWorker(10)
+	result := w.RunBenchmark(newOnErrorWorkload(t, ":on-error ignore"), "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.Scripts["policy"].Succeeded)
+	assert.Equal(t, int64(1), result.ErrorHandling["policy"].Ignored)
+}
+
+func TestOnErrorFallbackSeesVariablesSetBeforeTheFailure(t *testing.T) {
+	driver, w := newOnErrorWorker(10)
+	result := w.RunBenchmark(newOnErrorWorkload(t, ":on-error fallback\nRETURN $id AS fallback;"), "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(1), result.Scripts["policy"].Succeeded)
+	assert.Equal(t, []string{"RETURN $id AS fallback"}, driver.autocommits)
+	assert.Equal(t, int64(42), driver.autocommitParams[0]["id"])
+
+	total := NewResult("", "")
+	total.Add(result)
+	s := strings.Builder{}
+	writeErrorHandlingReport(total, &s)
+	assert.Contains(t, s.String(), "[policy]: 0 retries, 0 units of work succeeded after retrying, 1 fallbacks of which 0 failed")
+}
+
+func newOnErrorWorkload(t *testing.T, policy string) ClientWorkload {
+	script, err := Parse("policy", policy+"\n:set id 42\nCREATE (n {id: $id});", 1)
+	assert.NoError(t, err)
+	return ClientWorkload{Scripts: NewScripts(script), Rand: rand.New(rand.NewSource(1337))}
+}
+
+func newOnErrorWorker(failures int) (*failingDriver, *Worker) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &failingDriver{fakeDriver: &fakeDriver{clock: clock, r: rand.New(rand.NewSource(1337)), minLatency: time.Millisecond, maxLatency: time.Millisecond}, failures: failures}
+	return driver, &Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+}
+
+// Fails the first few write transactions, and answers auto-commit statements
+type failingDriver struct {
+	*fakeDriver
+	failures         int
+	attempts         int
+	autocommits      []string
+	autocommitParams []map[string]interface{}
+}
+
+func (d *failingDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d
+}
+
+func (d *failingDriver) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	d.attempts++
+	tx := &fakeTransaction{clock: d.clock, latency: d.minLatency}
+	if _, err := work(tx); err != nil {
+		return nil, err
+	}
+	if d.attempts <= d.failures {
+		return nil, fmt.Errorf("induced error from test harness")
+	}
+	return nil, nil
+}
+
+func (d *failingDriver) Run(cypher string, params map[string]interface{}, configurers ...func(*neo4j.TransactionConfig)) (neo4j.Result, error) {
+	d.autocommits = append(d.autocommits, cypher)
+	d.autocommitParams = append(d.autocommitParams, params)
+	return &fakeResult{}, nil
+}
@@ -10,6 +10,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -24,19 +26,140 @@ type Result struct {
 	// Targeted database
 	DatabaseName string
 	Scenario     string
+	// The neobench build that produced this result
+	Build BuildInfo
+	// Successful transactions that took longer than this don't count towards goodput; 0 counts them all
+	Sla time.Duration
+	// Unit the reports show latencies in; see LatencyUnit
+	LatencyUnit LatencyUnit
+	// How long the workers took to connect before the run started, which isn't counted in it; see ReadyBarrier
+	Setup time.Duration
+	// How long the workload ran before measuring started, which isn't counted in the run either; see --warmup
+	Warmup time.Duration
+	// How long the run went on for once the workers had connected, and warmed up
+	Duration time.Duration
+	// How long each worker took to set up the connection it started out with, in microseconds; see ConnectPacer
+	ConnectLatencies *hdrhistogram.Histogram
 
 	FailedByErrorGroup map[string]FailureGroup
 
 	// Results by script
 	Scripts map[string]*ScriptResult
+
+	// Checkpoints that stood out during the run, see AnomalyDetector
+	Anomalies []Anomaly
+
+	// Latency of the heartbeat query, if --heartbeat is enabled; nil otherwise
+	Heartbeat *ScriptResult
+
+	// Achieved vs configured script mix, only set for workloads with more than one script
+	Mix []MixEntry
+
+	// Results of the scripts in each --lane, combined; these have the lane name as script name
+	Lanes []*ScriptResult
+
+	// Statement statistics by the database statements ran against; scripts can switch database with `:use`
+	Databases map[string]*DatabaseResult
+
+	// Results for each --weight-phase, in order; see Scripts#CompletePhases
+	Phases []PhaseResult
+
+	// Results for the transactions run as part of a --burst, by script; these are also counted in Scripts
+	Bursts map[string]*ScriptResult
+
+	// How the rate was adjusted to hold --target-latency; nil if the rate was fixed
+	Control *ControlResult
+
+	// Throughput at each step of --ramp; nil without a ramp
+	Ramp *RampResult
+
+	// Requested against achieved rate and latency at each step of a --rate schedule; nil without one
+	RateSchedule *RateScheduleResult
+
+	// Time workers spent waiting for the driver to provide a connection, in microseconds
+	AcquisitionWaits *hdrhistogram.Histogram
+
+	// How busy the driver's connection pool was during the run; nil if it wasn't tracked
+	Pool *PoolResult
+
+	// Results by the protocol transactions ran over, with --dual-protocol; see ProtocolSchedule#Complete
+	Protocols []ProtocolResult
+
+	// Number of `:readback` statements, how many of them read stale data, and how many did per second
+	Readbacks     int64
+	StaleReads    int64
+	StaleReadRate float64
+
+	// Incidents where transactions failed and how the workload recovered from them, with --failover; nil otherwise
+	Failover *FailoverResult
+
+	// Results that were written out during the run with --soak, if any; the rest of the result only covers the
+	// time since the last of them
+	Soak *SoakResult
+
+	// Latency by payload size, by script, with --payload-sizes
+	Payloads map[string]*PayloadResult
+
+	// Time to first record against time to all records, by script, with --first-record
+	FirstRecords map[string]*FirstRecordResult
+
+	// Server-side cost of profiled units of work, by script, with --profile-sample
+	Profiles map[string]*ProfileResult
+
+	// Time spent evaluating script commands, by script, with --profile-scripts
+	ScriptEvals map[string]*ScriptEvalResult
+
+	// Units of work that sent more parameter data than --param-size-warn, by script
+	OversizedParams map[string]*OversizedParamsResult
+
+	// Units of work that failed after the run was asked to stop, which aren't counted as failures
+	ShutdownFailures int64
+
+	// In latency mode, how far behind schedule the furthest behind worker was with its last unit of work, and
+	// at most; see BacklogTracker
+	ScheduleLag    time.Duration
+	MaxScheduleLag time.Duration
+	Backlog        *BacklogResult
+	// Units of work skipped for being too far behind schedule, with --max-schedule-lag
+	Missed int64
+
+	// Metrics scraped from the server at each progress report, with --server-metrics
+	ServerMetrics *ServerMetricsResult
+	// Size of the dataset at each progress report, with --track-growth
+	Growth *GrowthResult
+
+	// CPU use of neobench itself, see CpuGuard; nil with --cpu-guard off
+	Cpu *CpuResult
+
+	// Probes of the health of the target, see HealthGuard; nil without --abort-if
+	Health *HealthResult
+
+	// Transactions rolled back on purpose and retried, with --abort-fraction; nil otherwise
+	Aborts *AbortResult
+
+	// Units of work that reconnected against those that didn't, with --reconnect-every; nil otherwise
+	Churn *ChurnResult
+
+	// What the `:on-error` policies of the scripts did, by script
+	ErrorHandling map[string]*ErrorHandlingResult
 }
 
 func NewResult(databaseName, scenario string) Result {
 	return Result{
 		DatabaseName:       databaseName,
 		Scenario:           scenario,
+		Build:              CurrentBuild(),
 		FailedByErrorGroup: make(map[string]FailureGroup),
 		Scripts:            make(map[string]*ScriptResult),
+		Databases:          make(map[string]*DatabaseResult),
+		Bursts:             make(map[string]*ScriptResult),
+		AcquisitionWaits:   hdrhistogram.New(0, 60*60*1000000, 3),
+		Payloads:           make(map[string]*PayloadResult),
+		FirstRecords:       make(map[string]*FirstRecordResult),
+		Profiles:           make(map[string]*ProfileResult),
+		ScriptEvals:        make(map[string]*ScriptEvalResult),
+		OversizedParams:    make(map[string]*OversizedParamsResult),
+		ErrorHandling:      make(map[string]*ErrorHandlingResult),
 	}
 }
 
@@ -54,6 +177,7 @@ func (r *Result) TotalFailed() (n int64) {
 	return
 }
 
+// Transactions attempted per second, succeeded and failed
 func (r *Result) TotalRate() (n float64) {
 	for _, s := range r.Scripts {
 		n += s.Rate
@@ -61,53 +185,286 @@ func (r *Result) TotalRate() (n float64) {
 	return
 }
 
+// Successful transactions per second
+func (r *Result) TotalSucceededRate() (n float64) {
+	for _, s := range r.Scripts {
+		n += s.SucceededRate()
+	}
+	return
+}
+
+// Successful transactions per second that took at most --sla
+func (r *Result) TotalGoodput() (n float64) {
+	for _, s := range r.Scripts {
+		n += s.Goodput(r.Sla)
+	}
+	return
+}
+
+// Ex: 10.000 attempted, 9.500 succeeded, 9.000 goodput
+func (r *Result) describeRates() string {
+	return fmt.Sprintf("%.3f attempted, %.3f succeeded, %.3f goodput", r.TotalRate(), r.TotalSucceededRate(), r.TotalGoodput())
+}
+
+// Says how long setting up and warming up took, for the reports; empty if neither was measured
+func (r *Result) describeSetup() string {
+	if r.Setup <= 0 && r.Warmup <= 0 {
+		return ""
+	}
+	setup := ""
+	if r.Setup > 0 {
+		setup = fmt.Sprintf("Setup: %.3fs for the clients to connect, not counted in the run\n", r.Setup.Seconds())
+	}
+	if r.Warmup > 0 {
+		setup += fmt.Sprintf("Warmup: %.3fs of load before measuring, not counted in the run\n", r.Warmup.Seconds())
+	}
+	if c := r.ConnectLatencies; c != nil && c.TotalCount() > 0 {
+		setup += fmt.Sprintf("Connecting: P50: %s, P99: %s, Max: %s, over %d connections\n", r.LatencyUnit.Format(float64(c.ValueAtQuantile(50))),
+			r.LatencyUnit.Format(float64(c.ValueAtQuantile(99))), r.LatencyUnit.Format(float64(c.Max())), c.TotalCount())
+	}
+	return setup
+}
+
+// Says what goodput counts, for the reports
+func (r *Result) describeGoodput() string {
+	if r.Sla <= 0 {
+		return "Goodput counts all successful transactions, set --sla to only count those fast enough\n"
+	}
+	return fmt.Sprintf("Goodput counts successful transactions that took at most --sla %s\n", r.Sla)
+}
+
+// Sets the heartbeat latency from the result of the heartbeat worker
+func (r *Result) SetHeartbeat(res WorkerResult) {
+	r.Heartbeat = res.Scripts[HeartbeatScriptName]
+}
+
 func (r *Result) Add(res WorkerResult) {
-	for _, workerScriptResult := range res.Scripts {
-		combinedScriptResult := r.Scripts[workerScriptResult.ScriptName]
-		if combinedScriptResult == nil {
-			r.Scripts[workerScriptResult.ScriptName] = &ScriptResult{
-				ScriptName: workerScriptResult.ScriptName,
-				Latencies:  hdrhistogram.Import(workerScriptResult.Latencies.Export()),
-				Rate:       workerScriptResult.Rate,
-				Succeeded:  workerScriptResult.Succeeded,
-				Failed:     workerScriptResult.Failed,
+	addScriptResults(r.Scripts, res.Scripts)
+	addScriptResults(r.Bursts, res.Bursts)
+	r.Readbacks += res.Readbacks
+	r.ShutdownFailures += res.ShutdownFailures
+	r.Missed += res.Missed
+	if res.ScheduleLag > r.ScheduleLag {
+		r.ScheduleLag = res.ScheduleLag
+	}
+	if res.MaxScheduleLag > r.MaxScheduleLag {
+		r.MaxScheduleLag = res.MaxScheduleLag
+	}
+	r.StaleReads += res.StaleReads
+	r.StaleReadRate += res.StaleReadRate
+	for name, profile := range res.Profiles {
+		existing, found := r.Profiles[name]
+		if !found {
+			existing = newProfileResult(name)
+			r.Profiles[name] = existing
+		}
+		existing.merge(profile)
+	}
+	for name, eval := range res.ScriptEvals {
+		existing, found := r.ScriptEvals[name]
+		if !found {
+			existing = newScriptEvalResult(name)
+			r.ScriptEvals[name] = existing
+		}
+		existing.merge(eval)
+	}
+	for name, handling := range res.ErrorHandling {
+		existing, found := r.ErrorHandling[name]
+		if !found {
+			existing = &ErrorHandlingResult{ScriptName: name}
+			r.ErrorHandling[name] = existing
+		}
+		existing.merge(handling)
+	}
+	for name, oversized := range res.OversizedParams {
+		existing, found := r.OversizedParams[name]
+		if !found {
+			existing = &OversizedParamsResult{ScriptName: name}
+			r.OversizedParams[name] = existing
+		}
+		existing.merge(oversized)
+	}
+	for name, payload := range res.Payloads {
+		existing, found := r.Payloads[name]
+		if !found {
+			existing = newPayloadResult(name)
+			r.Payloads[name] = existing
+		}
+		existing.merge(payload)
+	}
+	for name, first := range res.FirstRecords {
+		existing, found := r.FirstRecords[name]
+		if !found {
+			existing = newFirstRecordResult(name)
+			r.FirstRecords[name] = existing
+		}
+		existing.merge(first)
+	}
+	if res.AcquisitionWaits != nil {
+		r.AcquisitionWaits.Merge(res.AcquisitionWaits)
+	}
+	if res.Aborts != nil {
+		if r.Aborts == nil {
+			r.Aborts = newAbortResult()
+		}
+		r.Aborts.merge(res.Aborts)
+	}
+	if res.Churn != nil {
+		if r.Churn == nil {
+			r.Churn = newChurnResult()
+		}
+		r.Churn.merge(res.Churn)
+	}
+	for name, workerProtocolScripts := range res.Protocols {
+		found := false
+		for _, protocol := range r.Protocols {
+			if protocol.Protocol == name {
+				addScriptResults(protocol.Scripts, workerProtocolScripts)
+				found = true
+			}
+		}
+		if !found {
+			protocol := ProtocolResult{Protocol: name, Scripts: make(map[string]*ScriptResult)}
+			addScriptResults(protocol.Scripts, workerProtocolScripts)
+			r.Protocols = append(r.Protocols, protocol)
+		}
+	}
+	for name, workerPhaseScripts := range res.Phases {
+		found := false
+		for _, phase := range r.Phases {
+			if phase.Name == name {
+				addScriptResults(phase.Scripts, workerPhaseScripts)
+				found = true
+			}
+		}
+		if !found {
+			phase := PhaseResult{Name: name, Scripts: make(map[string]*ScriptResult)}
+			addScriptResults(phase.Scripts, workerPhaseScripts)
+			r.Phases = append(r.Phases, phase)
+		}
+	}
+	for _, workerDbResult := range res.Databases {
+		combinedDbResult := r.Databases[workerDbResult.DatabaseName]
+		if combinedDbResult == nil {
+			r.Databases[workerDbResult.DatabaseName] = &DatabaseResult{
+				DatabaseName: workerDbResult.DatabaseName,
+				Latencies:    hdrhistogram.Import(workerDbResult.Latencies.Export()),
+				Succeeded:    workerDbResult.Succeeded,
+				Failed:       workerDbResult.Failed,
 			}
 		} else {
-			combinedScriptResult.Rate += workerScriptResult.Rate
-			combinedScriptResult.Succeeded += workerScriptResult.Succeeded
-			combinedScriptResult.Failed += workerScriptResult.Failed
-			combinedScriptResult.Latencies.Merge(workerScriptResult.Latencies)
+			combinedDbResult.Succeeded += workerDbResult.Succeeded
+			combinedDbResult.Failed += workerDbResult.Failed
+			combinedDbResult.Latencies.Merge(workerDbResult.Latencies)
 		}
 	}
 	for name, group := range res.FailedByErrorGroup {
 		existing, found := r.FailedByErrorGroup[name]
 		if found {
+			if existing.Latencies != nil && group.Latencies != nil {
+				existing.Latencies.Merge(group.Latencies)
+			}
 			r.FailedByErrorGroup[name] = FailureGroup{
 				Count:        existing.Count + group.Count,
 				FirstFailure: existing.FirstFailure,
+				Latencies:    existing.Latencies,
 			}
 		} else {
+			if group.Latencies != nil {
+				group.Latencies = hdrhistogram.Import(group.Latencies.Export())
+			}
 			r.FailedByErrorGroup[name] = group
 		}
 	}
 }
 
+func addScriptResults(combined map[string]*ScriptResult, worker map[string]*ScriptResult) {
+	for _, workerScriptResult := range worker {
+		combinedScriptResult := combined[workerScriptResult.ScriptName]
+		if combinedScriptResult == nil {
+			combined[workerScriptResult.ScriptName] = &ScriptResult{
+				ScriptName: workerScriptResult.ScriptName,
+				Latencies:  hdrhistogram.Import(workerScriptResult.Latencies.Export()),
+				Rate:       workerScriptResult.Rate,
+				Succeeded:  workerScriptResult.Succeeded,
+				Failed:     workerScriptResult.Failed,
+			}
+		} else {
+			combinedScriptResult.Rate += workerScriptResult.Rate
+			combinedScriptResult.Succeeded += workerScriptResult.Succeeded
+			combinedScriptResult.Failed += workerScriptResult.Failed
+			combinedScriptResult.Latencies.Merge(workerScriptResult.Latencies)
+		}
+	}
+}
+
 // Result for one script; normally a workload is just one script, but we allow workloads to be made up of
 // lots of scripts as well, with a weighted random mix of them. We report results per-script, since latencies
 // between different scripts will mean totally different things.
 type ScriptResult struct {
 	ScriptName string
-	// Rate is scripts executed per second, both succeeded and failed
-	// TODO should this just count succeeded? That creates confusing effects with how the workload paces itself tho..
+	// Rate is scripts attempted per second, both succeeded and failed; the workload paces itself by attempts, so
+	// this is what it offered the database. See SucceededRate and Goodput for what the database delivered.
 	Rate      float64
 	Failed    int64
 	Succeeded int64
 	Latencies *hdrhistogram.Histogram
 }
 
+// Successful transactions per second
+func (s *ScriptResult) SucceededRate() float64 {
+	if s.Succeeded+s.Failed == 0 {
+		return 0
+	}
+	return s.Rate * float64(s.Succeeded) / float64(s.Succeeded+s.Failed)
+}
+
+// Successful transactions per second that took at most sla, or all successful ones if sla is 0
+func (s *ScriptResult) Goodput(sla time.Duration) float64 {
+	if s.Succeeded == 0 {
+		return 0
+	}
+	return s.SucceededRate() * float64(s.SucceededWithin(sla)) / float64(s.Succeeded)
+}
+
+// Number of successful transactions that took at most sla, or all of them if sla is 0
+func (s *ScriptResult) SucceededWithin(sla time.Duration) int64 {
+	if sla <= 0 || s.Latencies == nil {
+		return s.Succeeded
+	}
+	return countAtMost(s.Latencies, sla.Microseconds())
+}
+
+// Number of values recorded in the given histogram that are at most limit, to the precision of the histogram
+func countAtMost(histo *hdrhistogram.Histogram, limit int64) int64 {
+	n := int64(0)
+	for _, bar := range histo.Distribution() {
+		if bar.From > limit {
+			break
+		}
+		n += bar.Count
+	}
+	return n
+}
+
+// Ex: 10.000 attempted, 9.500 succeeded, 9.000 goodput
+func describeRates(script *ScriptResult, sla time.Duration) string {
+	return fmt.Sprintf("%.3f attempted, %.3f succeeded, %.3f goodput", script.Rate, script.SucceededRate(), script.Goodput(sla))
+}
+
+// Per-statement results for one database. Unlike ScriptResult, these count individual statements, and latencies
+// are for running a single statement, excluding the time spent on the rest of the script.
+type DatabaseResult struct {
+	// Empty for the default database
+	DatabaseName string
+	Failed       int64
+	Succeeded    int64
+	Latencies    *hdrhistogram.Histogram
+}
+
 type Output interface {
 	// scenario is a string describing the flags you'd need to pass to neobench to run an equivalent load
-	BenchmarkStart(databaseName, url, scenario string)
+	BenchmarkStart(databaseName, url, scenario string, run RunTag)
 	// Called if running in --init mode, eg. we are doing dataset population for one of the built-in workloads
 	ReportInitProgress(report ProgressReport)
 	// Called at interval set by --progress <interval>
@@ -123,15 +480,20 @@ type Output interface {
 // Creates the output specified by name; if prometheusAddress is set, also starts
 // that as an output, returning an output that publishes to both
 // TODO(jake): Maybe this would be nicer with `name` a comma-separated list, eg. csv,prometheus
-func InitOutput(name, prometheusAddress string) (Output, error) {
-	if name == "auto" {
-		fi, _ := os.Stdout.Stat()
-		if fi.Mode()&os.ModeCharDevice == 0 {
-			name = "csv"
-		} else {
-			name = "interactive"
-		}
+// The output format "auto" stands for: csv if stdout is redirected, interactive if it's a terminal
+func ResolveOutputFormat(name string) string {
+	if name != "auto" {
+		return name
 	}
+	fi, _ := os.Stdout.Stat()
+	if fi.Mode()&os.ModeCharDevice == 0 {
+		return "csv"
+	}
+	return "interactive"
+}
+
+func InitOutput(name, prometheusAddress string) (Output, error) {
+	name = ResolveOutputFormat(name)
 
 	var output Output
 	if name == "interactive" {
@@ -144,8 +506,13 @@ func InitOutput(name, prometheusAddress string) (Output, error) {
 			ErrStream: os.Stderr,
 			OutStream: os.Stdout,
 		}
+	} else if name == "json" {
+		output = &JsonOutput{
+			ErrStream: os.Stderr,
+			OutStream: os.Stdout,
+		}
 	} else {
-		return nil, fmt.Errorf("unknown output format: %s, supported formats are 'auto', 'interactive' and 'csv'", name)
+		return nil, fmt.Errorf("unknown output format: %s, supported formats are 'auto', 'interactive', 'csv' and 'json'", name)
 	}
 
 	if prometheusAddress != "" {
@@ -166,20 +533,56 @@ type InteractiveOutput struct {
 	LastProgressTime   time.Time
 }
 
-func (o *InteractiveOutput) BenchmarkStart(databaseName, url, scenario string) {
+func (o *InteractiveOutput) BenchmarkStart(databaseName, url, scenario string, run RunTag) {
 	if databaseName == "" {
 		databaseName = "<default>"
 	}
 	_, err := fmt.Fprintf(o.ErrStream,
 		"Starting workload on database %s against %s\n"+
-			"Scenario: %s\n", databaseName, url, scenario)
+			"Scenario: %s\n"+
+			"Run: %s (scenario hash %s)\n"+
+			"Build: %s\n", databaseName, url, scenario, run.RunId, run.ScenarioHash, CurrentBuild())
 	if err != nil {
 		panic(err)
 	}
 }
 
 func (o *InteractiveOutput) ReportWorkloadProgress(completeness float64, checkpoint Result) {
-	_, err := fmt.Fprintf(o.ErrStream, "[%.02f%%] %.02f tps / %d failures\n", completeness*100, checkpoint.TotalRate(), checkpoint.TotalFailed())
+	heartbeat := ""
+	if checkpoint.Heartbeat != nil && checkpoint.Heartbeat.Succeeded > 0 {
+		heartbeat = fmt.Sprintf(" / heartbeat p99 %s", checkpoint.LatencyUnit.Format(float64(checkpoint.Heartbeat.Latencies.ValueAtQuantile(99))))
+	}
+	staleReads := ""
+	if checkpoint.Readbacks > 0 {
+		staleReads = fmt.Sprintf(" / %.02f stale reads per second", checkpoint.StaleReadRate)
+	}
+	// Shows how server-side cost changes over the run, with --profile-sample
+	dbHits := ""
+	if len(checkpoint.Profiles) > 0 {
+		combined := newProfileResult("")
+		for _, profile := range checkpoint.Profiles {
+			combined.merge(profile)
+		}
+		dbHits = fmt.Sprintf(" / %.01f db hits per profiled unit", combined.DbHitsPerUnit())
+	}
+	backlog := ""
+	if checkpoint.MaxScheduleLag >= backlogThreshold {
+		backlog = fmt.Sprintf(" / %s behind schedule", formatLag(checkpoint.MaxScheduleLag))
+	}
+	if checkpoint.Missed > 0 {
+		backlog += fmt.Sprintf(" / %d missed", checkpoint.Missed)
+	}
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("[%.02f%%] %.02f tps, %.02f succeeded, %.02f goodput / %d failures%s%s%s%s\n", completeness*100,
+		checkpoint.TotalRate(), checkpoint.TotalSucceededRate(), checkpoint.TotalGoodput(), checkpoint.TotalFailed(), heartbeat, staleReads, dbHits, backlog))
+	// Regressions often hit one script of a mix, which the totals above average away
+	if len(checkpoint.Scripts) > 1 {
+		for _, script := range sortedScripts(checkpoint.Scripts) {
+			s.WriteString(fmt.Sprintf("  [%s] %.02f tps, p99 %s / %d failures\n", script.ScriptName, script.Rate,
+				checkpoint.LatencyUnit.Format(float64(script.Latencies.ValueAtQuantile(99))), script.Failed))
+		}
+	}
+	_, err := io.WriteString(o.ErrStream, s.String())
 	if err != nil {
 		panic(err)
 	}
@@ -203,13 +606,41 @@ func (o *InteractiveOutput) ReportThroughput(result Result) {
 
 	s.WriteString("== Results ==\n")
 	s.WriteString(fmt.Sprintf("Scenario: %s\n", result.Scenario))
-	s.WriteString(fmt.Sprintf("%d successful transactions, %d failed. (Total of %.3f per second)\n", result.TotalSucceeded(), result.TotalFailed(), result.TotalRate()))
+	s.WriteString(fmt.Sprintf("Build: %s\n", result.Build))
+	s.WriteString(result.describeSetup())
+	s.WriteString(result.describeHealthAbort())
+	s.WriteString(fmt.Sprintf("%d successful transactions, %d failed. (%s per second)\n", result.TotalSucceeded(), result.TotalFailed(), result.describeRates()))
+	s.WriteString(result.describeGoodput())
 	s.WriteString("\n")
-	for _, script := range result.Scripts {
-		s.WriteString(fmt.Sprintf("  [%s]: %.03f total transactions per second\n", script.ScriptName, script.Rate))
+	for _, script := range sortedScripts(result.Scripts) {
+		s.WriteString(fmt.Sprintf("  [%s]: %s transactions per second\n", script.ScriptName, describeRates(script, result.Sla)))
 	}
 	s.WriteString("\n")
+	writeLaneReport(result, false, &s)
+	writeSoakReport(result, &s)
+	writeHeartbeatReport(result, &s)
+	writeReadbackReport(result, &s)
+	writeFailoverReport(result, &s)
+	writeCpuReport(result, &s)
+	writePoolReport(result, &s)
+	writeAbortReport(result, &s)
+	writeChurnReport(result, &s)
+	writeErrorHandlingReport(result, &s)
+	writeServerMetricsReport(result, &s)
+	writeGrowthReport(result, &s)
+	writePayloadReport(result, &s)
+	writeFirstRecordReport(result, &s)
+	writeProfileReport(result, &s)
+	writeCostReport(result, &s)
+	writeScriptEvalReport(result, &s)
+	writeOversizedParamsReport(result, &s)
+	writeDatabaseReport(result, &s)
+	writeMixReport(result.Mix, &s)
+	writeRampReport(result, &s)
+	writePhaseReport(result, false, &s)
+	writeProtocolReport(result, false, &s)
 	writeErrorReport(result, &s)
+	writeAnomalyReport(result, &s)
 
 	_, err := fmt.Fprintf(o.OutStream, s.String())
 	if err != nil {
@@ -223,17 +654,48 @@ func (o *InteractiveOutput) ReportLatency(result Result) {
 	s.WriteString("== Results ==\n")
 
 	s.WriteString(fmt.Sprintf("Scenario: %s\n", result.Scenario))
-	s.WriteString(fmt.Sprintf("%d successful transactions, %d failed. (Total of %.3f per second)\n", result.TotalSucceeded(), result.TotalFailed(), result.TotalRate()))
+	s.WriteString(fmt.Sprintf("Build: %s\n", result.Build))
+	s.WriteString(result.describeSetup())
+	s.WriteString(result.describeHealthAbort())
+	s.WriteString(fmt.Sprintf("%d successful transactions, %d failed. (%s per second)\n", result.TotalSucceeded(), result.TotalFailed(), result.describeRates()))
+	s.WriteString(result.describeGoodput())
 
 	if result.TotalSucceeded() > 0 {
-		for _, workload := range result.Scripts {
+		for _, workload := range sortedScripts(result.Scripts) {
 			s.WriteString("\n")
 			s.WriteString(fmt.Sprintf("-- Script: %s --\n\n", workload.ScriptName))
-			summarizeLatency(workload, &s, "  ")
+			summarizeLatency(workload, result.Sla, result.LatencyUnit, &s, "  ")
 		}
 	}
 	s.WriteString("\n")
+	writeLaneReport(result, true, &s)
+	writeSoakReport(result, &s)
+	writeBacklogReport(result, &s)
+	writeHeartbeatReport(result, &s)
+	writeReadbackReport(result, &s)
+	writeFailoverReport(result, &s)
+	writeBurstReport(result, &s)
+	writeControlReport(result, &s)
+	writeCpuReport(result, &s)
+	writePoolReport(result, &s)
+	writeAbortReport(result, &s)
+	writeChurnReport(result, &s)
+	writeErrorHandlingReport(result, &s)
+	writeServerMetricsReport(result, &s)
+	writeGrowthReport(result, &s)
+	writePayloadReport(result, &s)
+	writeFirstRecordReport(result, &s)
+	writeProfileReport(result, &s)
+	writeCostReport(result, &s)
+	writeScriptEvalReport(result, &s)
+	writeOversizedParamsReport(result, &s)
+	writeDatabaseReport(result, &s)
+	writeMixReport(result.Mix, &s)
+	writeRateScheduleReport(result, &s)
+	writePhaseReport(result, true, &s)
+	writeProtocolReport(result, true, &s)
 	writeErrorReport(result, &s)
+	writeAnomalyReport(result, &s)
 
 	_, err := fmt.Fprint(o.OutStream, s.String())
 	if err != nil {
@@ -241,20 +703,30 @@ func (o *InteractiveOutput) ReportLatency(result Result) {
 	}
 }
 
-func summarizeLatency(script *ScriptResult, s *strings.Builder, indent string) {
+// The given script results by name, so reports list them in the same order every time
+func sortedScripts(scripts map[string]*ScriptResult) []*ScriptResult {
+	out := make([]*ScriptResult, 0, len(scripts))
+	for _, script := range scripts {
+		out = append(out, script)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ScriptName < out[j].ScriptName })
+	return out
+}
+
+func summarizeLatency(script *ScriptResult, sla time.Duration, unit LatencyUnit, s *strings.Builder, indent string) {
 	histo := script.Latencies
 	lines := []string{
-		fmt.Sprintf("%d successful transactions, %d failed. (Total of %.3f per second)\n", script.Succeeded, script.Failed, script.Rate),
-		fmt.Sprintf("Max: %.3fms, Min: %.3fms, Mean: %.3fms, Stddev: %.3f\n\n",
-			float64(histo.Max())/1000.0, float64(histo.Min())/1000.0, histo.Mean()/1000.0, histo.StdDev()/1000.0),
+		fmt.Sprintf("%d successful transactions, %d failed. (%s per second)\n", script.Succeeded, script.Failed, describeRates(script, sla)),
+		fmt.Sprintf("Max: %s, Min: %s, Mean: %s, Stddev: %s\n\n",
+			unit.Format(float64(histo.Max())), unit.Format(float64(histo.Min())), unit.Format(histo.Mean()), unit.Format(histo.StdDev())),
 		fmt.Sprintf("Latency distribution:\n"),
-		fmt.Sprintf("  P00.000: %.03fms\n", float64(histo.Min())/1000.0),
-		fmt.Sprintf("  P25.000: %.03fms\n", float64(histo.ValueAtQuantile(25))/1000.0),
-		fmt.Sprintf("  P50.000: %.03fms\n", float64(histo.ValueAtQuantile(50))/1000.0),
-		fmt.Sprintf("  P75.000: %.03fms\n", float64(histo.ValueAtQuantile(75))/1000.0),
-		fmt.Sprintf("  P95.000: %.03fms\n", float64(histo.ValueAtQuantile(95))/1000.0),
-		fmt.Sprintf("  P99.000: %.03fms\n", float64(histo.ValueAtQuantile(99))/1000.0),
-		fmt.Sprintf("  P99.999: %.03fms\n", float64(histo.ValueAtQuantile(99.999))/1000.0),
+		fmt.Sprintf("  P00.000: %s\n", unit.Format(float64(histo.Min()))),
+		fmt.Sprintf("  P25.000: %s\n", unit.Format(float64(histo.ValueAtQuantile(25)))),
+		fmt.Sprintf("  P50.000: %s\n", unit.Format(float64(histo.ValueAtQuantile(50)))),
+		fmt.Sprintf("  P75.000: %s\n", unit.Format(float64(histo.ValueAtQuantile(75)))),
+		fmt.Sprintf("  P95.000: %s\n", unit.Format(float64(histo.ValueAtQuantile(95)))),
+		fmt.Sprintf("  P99.000: %s\n", unit.Format(float64(histo.ValueAtQuantile(99)))),
+		fmt.Sprintf("  P99.999: %s\n", unit.Format(float64(histo.ValueAtQuantile(99.999)))),
 	}
 	for _, line := range lines {
 		s.WriteString(indent)
@@ -262,6 +734,131 @@ func summarizeLatency(script *ScriptResult, s *strings.Builder, indent string) {
 	}
 }
 
+func writeHeartbeatReport(result Result, s *strings.Builder) {
+	if result.Heartbeat == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Heartbeat --\n\n"))
+	summarizeLatency(result.Heartbeat, result.Sla, result.LatencyUnit, s, "  ")
+	s.WriteString("\n")
+}
+
+// How often `:readback` statements didn't see the writes before them
+func writeReadbackReport(result Result, s *strings.Builder) {
+	if result.Readbacks == 0 {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Read your writes --\n\n"))
+	s.WriteString(fmt.Sprintf("  %d of %d reads were stale (%.3f%%), %.3f per second\n\n", result.StaleReads, result.Readbacks,
+		float64(result.StaleReads)/float64(result.Readbacks)*100, result.StaleReadRate))
+}
+
+func writeControlReport(result Result, s *strings.Builder) {
+	control := result.Control
+	if control == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Latency target --\n\n"))
+	s.WriteString(fmt.Sprintf("  Target: p%g at most %s\n", control.Target.Percentile, control.Target.Latency))
+	if control.SustainedRate > 0 {
+		s.WriteString(fmt.Sprintf("  Sustained rate: %.3f transactions per second, the highest rate that met the target for a whole progress interval\n", control.SustainedRate))
+	} else {
+		s.WriteString(fmt.Sprintf("  Sustained rate: none, no progress interval met the target\n"))
+	}
+	s.WriteString(fmt.Sprintf("  Final offered rate: %.3f transactions per second\n", control.FinalRate))
+	s.WriteString(fmt.Sprintf("\n  Offered tps, achieved tps, p%g:\n", control.Target.Percentile))
+	for _, window := range control.Windows {
+		met := ""
+		if !window.MetTarget {
+			met = " (missed)"
+		}
+		s.WriteString(fmt.Sprintf("    %.3f, %.3f, %s%s\n", window.OfferedRate, window.AchievedRate,
+			result.LatencyUnit.FormatDuration(window.Latency), met))
+	}
+	s.WriteString("\n")
+}
+
+// Latency of the transactions that ran as part of a --burst, measured from when the burst was due
+func writeBurstReport(result Result, s *strings.Builder) {
+	if len(result.Bursts) == 0 {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Bursts --\n\n"))
+	names := make([]string, 0, len(result.Bursts))
+	for name := range result.Bursts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.WriteString(fmt.Sprintf("  [%s]:\n", name))
+		summarizeLatency(result.Bursts[name], result.Sla, result.LatencyUnit, s, "    ")
+	}
+	s.WriteString("\n")
+}
+
+// Only written if statements ran against more than one database
+func writeDatabaseReport(result Result, s *strings.Builder) {
+	if len(result.Databases) < 2 {
+		return
+	}
+	names := make([]string, 0, len(result.Databases))
+	for name := range result.Databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.WriteString(fmt.Sprintf("Statements by database:\n"))
+	for _, name := range names {
+		db := result.Databases[name]
+		if name == "" {
+			name = "<default>"
+		}
+		s.WriteString(fmt.Sprintf("  [%s]: %d succeeded, %d failed, p50 %s, p99 %s\n", name, db.Succeeded, db.Failed,
+			result.LatencyUnit.Format(float64(db.Latencies.ValueAtQuantile(50))), result.LatencyUnit.Format(float64(db.Latencies.ValueAtQuantile(99)))))
+	}
+	s.WriteString("\n")
+}
+
+func writeMixReport(mix []MixEntry, s *strings.Builder) {
+	if len(mix) == 0 {
+		return
+	}
+	drifted := false
+	s.WriteString(fmt.Sprintf("Workload mix (achieved / target):\n"))
+	for _, entry := range mix {
+		s.WriteString(fmt.Sprintf("  [%s]: %.2f%% / %.2f%%\n", entry.ScriptName, entry.AchievedShare*100, entry.TargetShare*100))
+		drifted = drifted || entry.Drifted
+	}
+	if drifted {
+		s.WriteString(fmt.Sprintf("  WARNING: achieved mix drifted from the configured script weights; " +
+			"slow scripts may be starving others, consider latency mode (--latency)\n"))
+	}
+	s.WriteString("\n")
+}
+
+// Results for each --weight-phase; with latencies in latency mode, otherwise just rates
+func writePhaseReport(result Result, latencies bool, s *strings.Builder) {
+	for _, phase := range result.Phases {
+		s.WriteString(fmt.Sprintf("-- Phase %s --\n\n", phase.Name))
+		names := make([]string, 0, len(phase.Scripts))
+		for name := range phase.Scripts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			script := phase.Scripts[name]
+			if latencies {
+				s.WriteString(fmt.Sprintf("  [%s]:\n", name))
+				summarizeLatency(script, result.Sla, result.LatencyUnit, s, "    ")
+			} else {
+				s.WriteString(fmt.Sprintf("  [%s]: %d succeeded, %d failed, %.03f transactions per second\n", name,
+					script.Succeeded, script.Failed, script.Rate))
+			}
+		}
+		s.WriteString("\n")
+		writeMixReport(phase.Mix, s)
+	}
+}
+
 func writeErrorReport(result Result, s *strings.Builder) {
 	s.WriteString(fmt.Sprintf("Error stats:\n"))
 	if result.TotalFailed() == 0 {
@@ -270,11 +867,37 @@ func writeErrorReport(result Result, s *strings.Builder) {
 		s.WriteString(fmt.Sprintf("  Failed transactions: %d (%.3f %%)\n", result.TotalFailed(), 100*float64(result.TotalFailed())/float64(result.TotalFailed()+result.TotalSucceeded())))
 		s.WriteString(fmt.Sprintf("\n"))
 		s.WriteString(fmt.Sprintf("  Causes:\n"))
-		for name, info := range result.FailedByErrorGroup {
-			s.WriteString(fmt.Sprintf("    %s: %d failures\n", name, info.Count))
+		names := make([]string, 0, len(result.FailedByErrorGroup))
+		for name := range result.FailedByErrorGroup {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			info := result.FailedByErrorGroup[name]
+			if info.Latencies != nil && info.Latencies.TotalCount() > 0 {
+				s.WriteString(fmt.Sprintf("    %s: %d failures, taking P50: %s, P99: %s, Max: %s\n", name, info.Count,
+					result.LatencyUnit.Format(float64(info.Latencies.ValueAtQuantile(50))), result.LatencyUnit.Format(float64(info.Latencies.ValueAtQuantile(99))),
+					result.LatencyUnit.Format(float64(info.Latencies.Max()))))
+			} else {
+				s.WriteString(fmt.Sprintf("    %s: %d failures\n", name, info.Count))
+			}
 			s.WriteString(fmt.Sprintf("      (ex: %s)\n", info.FirstFailure))
 		}
 	}
+	if result.ShutdownFailures > 0 {
+		s.WriteString(fmt.Sprintf("  %d more failed while the run was stopping; these are left out, as stopping may be what failed them\n",
+			result.ShutdownFailures))
+	}
+}
+
+func writeAnomalyReport(result Result, s *strings.Builder) {
+	if len(result.Anomalies) == 0 {
+		return
+	}
+	s.WriteString(fmt.Sprintf("\nAnomalies:\n"))
+	for _, a := range result.Anomalies {
+		s.WriteString(fmt.Sprintf("  %s [%s]: %s\n", a.At.Format(time.RFC3339), a.ScriptName, a.Description))
+	}
 }
 
 func (o *InteractiveOutput) Errorf(format string, a ...interface{}) {
@@ -294,13 +917,15 @@ type CsvOutput struct {
 	LastProgressTime   time.Time
 }
 
-func (o *CsvOutput) BenchmarkStart(databaseName, url, scenario string) {
+func (o *CsvOutput) BenchmarkStart(databaseName, url, scenario string, run RunTag) {
 	if databaseName == "" {
 		databaseName = "<default>"
 	}
 	_, err := fmt.Fprintf(o.ErrStream,
 		"Starting workload on database %s against %s\n"+
-			"Scenario: %s\n", databaseName, url, scenario)
+			"Scenario: %s\n"+
+			"Run: %s (scenario hash %s)\n"+
+			"Build: %s\n", databaseName, url, scenario, run.RunId, run.ScenarioHash, CurrentBuild())
 	if err != nil {
 		panic(err)
 	}
@@ -337,18 +962,21 @@ func (o *CsvOutput) ReportWorkloadProgress(completeness float64, checkpoint Resu
 }
 
 func (o *CsvOutput) ReportThroughput(result Result) {
-	columns := []string{"script", "succeeded", "failed", "transactions_per_second"}
+	columns := []string{"script", "succeeded", "failed", "transactions_per_second", "succeeded_per_second", "goodput_per_second"}
 
 	s := strings.Builder{}
 	separator := ","
 	s.WriteString(strings.Join(columns, separator))
 	s.WriteString("\n")
 
-	for _, script := range result.Scripts {
+	scripts := append(sortedScripts(result.Scripts), csvLanes(result)...)
+	for _, script := range scripts {
 		row := []float64{
 			float64(script.Succeeded),
 			float64(script.Failed),
 			script.Rate,
+			script.SucceededRate(),
+			script.Goodput(result.Sla),
 		}
 		s.WriteString(fmt.Sprintf("\"%s\",", script.ScriptName))
 		for i, cell := range row {
@@ -363,24 +991,148 @@ func (o *CsvOutput) ReportThroughput(result Result) {
 	if _, err := fmt.Fprint(o.OutStream, s.String()); err != nil {
 		panic(err)
 	}
+	o.writeSetup(result)
 
-	if result.TotalFailed() > 0 {
+	if result.TotalFailed() > 0 || result.ShutdownFailures > 0 {
 		s.Reset()
 		writeErrorReport(result, &s)
 		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
 			panic(err)
 		}
 	}
+
+	if len(result.Databases) > 1 {
+		s.Reset()
+		writeDatabaseReport(result, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(result.Mix) > 0 {
+		s.Reset()
+		writeMixReport(result.Mix, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(result.Anomalies) > 0 {
+		s.Reset()
+		writeAnomalyReport(result, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+
+	if result.Pool != nil || len(result.Protocols) > 0 || result.Readbacks > 0 || result.Failover != nil ||
+		result.Soak != nil || len(result.Payloads) > 0 || len(result.FirstRecords) > 0 || len(result.Profiles) > 0 || len(result.ScriptEvals) > 0 ||
+		len(result.OversizedParams) > 0 || result.ServerMetrics != nil || result.Growth != nil || (result.Cpu != nil && result.Cpu.Saturated()) ||
+		result.Aborts != nil || result.Churn != nil || len(result.ErrorHandling) > 0 {
+		s.Reset()
+		writeSoakReport(result, &s)
+		writeReadbackReport(result, &s)
+		writeFailoverReport(result, &s)
+		writeCpuReport(result, &s)
+		writePoolReport(result, &s)
+		writeAbortReport(result, &s)
+		writeChurnReport(result, &s)
+		writeErrorHandlingReport(result, &s)
+		writeServerMetricsReport(result, &s)
+		writeGrowthReport(result, &s)
+		writePayloadReport(result, &s)
+		writeFirstRecordReport(result, &s)
+		writeProfileReport(result, &s)
+		writeCostReport(result, &s)
+		writeScriptEvalReport(result, &s)
+		writeOversizedParamsReport(result, &s)
+		writeRampReport(result, &s)
+		writeProtocolReport(result, false, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+
+	o.writePhases(result, false)
 }
 
 func (o *CsvOutput) ReportLatency(result Result) {
 	o.writeLatencyRow(result)
+	o.writeSetup(result)
+	o.writePhases(result, true)
+	if len(result.Bursts) > 0 || result.Control != nil || result.Pool != nil || len(result.Protocols) > 0 || result.Readbacks > 0 ||
+		result.Failover != nil || result.Soak != nil || len(result.Payloads) > 0 || len(result.FirstRecords) > 0 || len(result.Profiles) > 0 ||
+		len(result.ScriptEvals) > 0 || len(result.OversizedParams) > 0 || result.Backlog != nil ||
+		result.ServerMetrics != nil || result.Growth != nil || (result.Cpu != nil && result.Cpu.Saturated()) || result.Aborts != nil ||
+		result.Churn != nil || len(result.ErrorHandling) > 0 {
+		s := strings.Builder{}
+		writeSoakReport(result, &s)
+		writeBacklogReport(result, &s)
+		writeReadbackReport(result, &s)
+		writeFailoverReport(result, &s)
+		writeBurstReport(result, &s)
+		writeControlReport(result, &s)
+		writeCpuReport(result, &s)
+		writePoolReport(result, &s)
+		writeAbortReport(result, &s)
+		writeChurnReport(result, &s)
+		writeErrorHandlingReport(result, &s)
+		writeServerMetricsReport(result, &s)
+		writeGrowthReport(result, &s)
+		writePayloadReport(result, &s)
+		writeFirstRecordReport(result, &s)
+		writeProfileReport(result, &s)
+		writeCostReport(result, &s)
+		writeScriptEvalReport(result, &s)
+		writeOversizedParamsReport(result, &s)
+		writeRateScheduleReport(result, &s)
+		writeProtocolReport(result, true, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Setup time, and whether --abort-if stopped the run, go to stderr, so the CSV stays the same shape
+func (o *CsvOutput) writeSetup(result Result) {
+	if _, err := fmt.Fprint(o.ErrStream, result.describeSetup()+result.describeHealthAbort()); err != nil {
+		panic(err)
+	}
+}
+
+// Phase results go to stderr, like the other reports that don't fit the CSV columns
+func (o *CsvOutput) writePhases(result Result, latencies bool) {
+	if len(result.Phases) == 0 {
+		return
+	}
+	s := strings.Builder{}
+	writePhaseReport(result, latencies, &s)
+	if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+		panic(err)
+	}
+}
+
+// Lanes as rows of the csv output, named lane:<name> to tell them from scripts
+func csvLanes(result Result) []*ScriptResult {
+	lanes := make([]*ScriptResult, 0, len(result.Lanes))
+	for _, lane := range result.Lanes {
+		row := *lane
+		row.ScriptName = "lane:" + lane.ScriptName
+		lanes = append(lanes, &row)
+	}
+	return lanes
 }
 
 func (o *CsvOutput) writeLatencyRow(result Result) {
 	s := strings.Builder{}
 
-	for _, script := range result.Scripts {
+	scripts := sortedScripts(result.Scripts)
+	if result.Heartbeat != nil {
+		scripts = append(scripts, result.Heartbeat)
+	}
+	scripts = append(scripts, csvLanes(result)...)
+
+	for _, script := range scripts {
 		for i, col := range csvColumns {
 			if i != 0 {
 				s.WriteString(",")
@@ -402,6 +1154,30 @@ func (o *CsvOutput) writeLatencyRow(result Result) {
 			panic(err)
 		}
 	}
+
+	if len(result.Databases) > 1 {
+		s.Reset()
+		writeDatabaseReport(result, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(result.Mix) > 0 {
+		s.Reset()
+		writeMixReport(result.Mix, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(result.Anomalies) > 0 {
+		s.Reset()
+		writeAnomalyReport(result, &s)
+		if _, err := fmt.Fprint(o.ErrStream, s.String()); err != nil {
+			panic(err)
+		}
+	}
 }
 
 func fmtFloat(v interface{}) string {
@@ -423,25 +1199,31 @@ var csvColumns = []struct {
 	{"rate", func(r Result, s *ScriptResult) string { return fmtFloat(s.Rate) }},
 	{"succeeded", func(r Result, s *ScriptResult) string { return fmtFloat(s.Latencies.TotalCount()) }},
 	{"failed", func(r Result, s *ScriptResult) string { return fmtFloat(s.Failed) }},
-	{"mean", func(r Result, s *ScriptResult) string { return fmtFloat(s.Latencies.Mean() / 1000.0) }},
-	{"stdev", func(r Result, s *ScriptResult) string { return fmtFloat(s.Latencies.StdDev()) }},
-	{"p0", func(r Result, s *ScriptResult) string { return fmtFloat(float64(s.Latencies.Min()) / 1000.0) }},
+	{"mean", func(r Result, s *ScriptResult) string { return fmtFloat(r.LatencyUnit.Value(s.Latencies.Mean())) }},
+	{"stdev", func(r Result, s *ScriptResult) string { return fmtFloat(r.LatencyUnit.Value(s.Latencies.StdDev())) }},
+	{"p0", func(r Result, s *ScriptResult) string {
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.Min())))
+	}},
 	{"p25", func(r Result, s *ScriptResult) string {
-		return fmtFloat(float64(s.Latencies.ValueAtQuantile(25)) / 1000.0)
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.ValueAtQuantile(25))))
 	}},
 	{"p50", func(r Result, s *ScriptResult) string {
-		return fmtFloat(float64(s.Latencies.ValueAtQuantile(50)) / 1000.0)
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.ValueAtQuantile(50))))
 	}},
 	{"p75", func(r Result, s *ScriptResult) string {
-		return fmtFloat(float64(s.Latencies.ValueAtQuantile(75)) / 1000.0)
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.ValueAtQuantile(75))))
 	}},
 	{"p99", func(r Result, s *ScriptResult) string {
-		return fmtFloat(float64(s.Latencies.ValueAtQuantile(99)) / 1000.0)
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.ValueAtQuantile(99))))
 	}},
 	{"p99999", func(r Result, s *ScriptResult) string {
-		return fmtFloat(float64(s.Latencies.ValueAtQuantile(99.999)) / 1000.0)
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.ValueAtQuantile(99.999))))
+	}},
+	{"p100", func(r Result, s *ScriptResult) string {
+		return fmtFloat(r.LatencyUnit.Value(float64(s.Latencies.Max())))
 	}},
-	{"p100", func(r Result, s *ScriptResult) string { return fmtFloat(float64(s.Latencies.Max()) / 1000.0) }},
+	{"succeeded_rate", func(r Result, s *ScriptResult) string { return fmtFloat(s.SucceededRate()) }},
+	{"goodput_rate", func(r Result, s *ScriptResult) string { return fmtFloat(s.Goodput(r.Sla)) }},
 }
 
 func (o *CsvOutput) Errorf(format string, a ...interface{}) {
@@ -462,12 +1244,35 @@ func InitPrometheus(addr string) {
 	}()
 }
 
+// Exported once, with the build as labels, so dashboards can tell which build produced the numbers
+func registerPrometheusBuildInfo() {
+	build := CurrentBuild()
+	promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "neobench_build_info",
+		Help: "Always 1, labelled with the neobench build that produced these metrics",
+		ConstLabels: prometheus.Labels{
+			"version": build.Version, "commit": build.Commit, "driver_version": build.DriverVersion, "go_version": build.GoVersion,
+		},
+	}).Set(1)
+}
+
 type PrometheusOutput struct {
 	totalSucceededCounter prometheus.Counter
 	totalFailedCounter    prometheus.Counter
+	goodputCounter        prometheus.Counter
+	staleReadsCounter     prometheus.Counter
+	// By script, and for failures by error group, see groupError
+	scriptTransactions *prometheus.CounterVec
+	scriptRate         *prometheus.GaugeVec
+	scriptLatency      *prometheus.GaugeVec
+	errorCounter       *prometheus.CounterVec
 }
 
+// Latency quantiles published for each script, as percentiles
+var prometheusQuantiles = []float64{50, 90, 99, 99.9}
+
 func NewPrometheusOutput() *PrometheusOutput {
+	registerPrometheusBuildInfo()
 	return &PrometheusOutput{
 		totalSucceededCounter: promauto.NewCounter(prometheus.CounterOpts{
 			Name: "neobench_successful_transactions_total",
@@ -477,10 +1282,34 @@ func NewPrometheusOutput() *PrometheusOutput {
 			Name: "neobench_failed_transactions_total",
 			Help: "The total number of failed transactions",
 		}),
+		goodputCounter: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "neobench_goodput_transactions_total",
+			Help: "The total number of successful transactions that took at most --sla, or of all successful ones without it",
+		}),
+		staleReadsCounter: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "neobench_stale_reads_total",
+			Help: "The total number of :readback statements that did not see the writes before them",
+		}),
+		scriptTransactions: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "neobench_script_transactions_total",
+			Help: "The total number of transactions of each script, by whether they succeeded or failed",
+		}, []string{"script", "outcome"}),
+		scriptRate: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "neobench_script_transactions_per_second",
+			Help: "Transactions of each script attempted per second, over the last progress interval",
+		}, []string{"script"}),
+		scriptLatency: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "neobench_script_latency_seconds",
+			Help: "Latency of the successful transactions of each script at the given quantile, over the last progress interval",
+		}, []string{"script", "quantile"}),
+		errorCounter: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "neobench_errors_total",
+			Help: "The total number of failed transactions by error, the Neo4j status code or unknown",
+		}, []string{"error"}),
 	}
 }
 
-func (p *PrometheusOutput) BenchmarkStart(databaseName, url, scenario string) {
+func (p *PrometheusOutput) BenchmarkStart(databaseName, url, scenario string, run RunTag) {
 }
 
 func (p *PrometheusOutput) ReportInitProgress(report ProgressReport) {
@@ -489,6 +1318,27 @@ func (p *PrometheusOutput) ReportInitProgress(report ProgressReport) {
 func (p *PrometheusOutput) ReportWorkloadProgress(completeness float64, checkpoint Result) {
 	p.totalSucceededCounter.Add(float64(checkpoint.TotalSucceeded()))
 	p.totalFailedCounter.Add(float64(checkpoint.TotalFailed()))
+	for _, script := range checkpoint.Scripts {
+		p.goodputCounter.Add(float64(script.SucceededWithin(checkpoint.Sla)))
+	}
+	p.staleReadsCounter.Add(float64(checkpoint.StaleReads))
+
+	for name, script := range checkpoint.Scripts {
+		p.scriptTransactions.WithLabelValues(name, "succeeded").Add(float64(script.Succeeded))
+		p.scriptTransactions.WithLabelValues(name, "failed").Add(float64(script.Failed))
+		p.scriptRate.WithLabelValues(name).Set(script.Rate)
+		// Intervals without successes keep the latencies of the last one that had some
+		if script.Succeeded == 0 || script.Latencies == nil {
+			continue
+		}
+		for _, q := range prometheusQuantiles {
+			p.scriptLatency.WithLabelValues(name, strconv.FormatFloat(q/100, 'f', -1, 64)).
+				Set(float64(script.Latencies.ValueAtQuantile(q)) / 1000000.0)
+		}
+	}
+	for group, failures := range checkpoint.FailedByErrorGroup {
+		p.errorCounter.WithLabelValues(group).Add(float64(failures.Count))
+	}
 }
 
 func (p *PrometheusOutput) ReportThroughput(result Result) {
@@ -507,9 +1357,14 @@ type CombinedOutput struct {
 	delegates []Output
 }
 
-func (c *CombinedOutput) BenchmarkStart(databaseName, url, scenario string) {
+// Sends everything to each of the given outputs, in order
+func CombineOutputs(outputs ...Output) Output {
+	return &CombinedOutput{delegates: outputs}
+}
+
+func (c *CombinedOutput) BenchmarkStart(databaseName, url, scenario string, run RunTag) {
 	for _, d := range c.delegates {
-		d.BenchmarkStart(databaseName, url, scenario)
+		d.BenchmarkStart(databaseName, url, scenario, run)
 	}
 }
 
@@ -0,0 +1,51 @@
+package neobench
+
+import (
+	"github.com/codahale/hdrhistogram"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusOutputPublishesScripts(t *testing.T) {
+	out := NewPrometheusOutput()
+	latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+	for i := int64(1); i <= 100; i++ {
+		assert.NoError(t, latencies.RecordValue(i*1000))
+	}
+	checkpoint := NewResult("neo4j", "")
+	checkpoint.Scripts["read"] = &ScriptResult{ScriptName: "read", Rate: 110, Succeeded: 100, Failed: 10, Latencies: latencies}
+	checkpoint.FailedByErrorGroup["Neo.TransientError.Transaction.DeadlockDetected"] = FailureGroup{Count: 10}
+
+	out.ReportWorkloadProgress(0.5, checkpoint)
+	out.ReportWorkloadProgress(1, checkpoint)
+
+	assert.Equal(t, 200.0, testutil.ToFloat64(out.scriptTransactions.WithLabelValues("read", "succeeded")))
+	assert.Equal(t, 20.0, testutil.ToFloat64(out.scriptTransactions.WithLabelValues("read", "failed")))
+	assert.Equal(t, 110.0, testutil.ToFloat64(out.scriptRate.WithLabelValues("read")))
+	assert.InDelta(t, 0.05, testutil.ToFloat64(out.scriptLatency.WithLabelValues("read", "0.5")), 0.0001)
+	assert.InDelta(t, 0.099, testutil.ToFloat64(out.scriptLatency.WithLabelValues("read", "0.99")), 0.0001)
+	assert.Equal(t, 20.0, testutil.ToFloat64(out.errorCounter.WithLabelValues("Neo.TransientError.Transaction.DeadlockDetected")))
+}
+
+func TestInteractiveProgressBreaksMixesDownByScript(t *testing.T) {
+	errStream := &strings.Builder{}
+	out := &InteractiveOutput{ErrStream: errStream, OutStream: &strings.Builder{}}
+	checkpoint := NewResult("neo4j", "")
+	reads := hdrhistogram.New(0, 60*60*1000000, 3)
+	writes := hdrhistogram.New(0, 60*60*1000000, 3)
+	for i := int64(1); i <= 100; i++ {
+		assert.NoError(t, reads.RecordValue(i*100))
+		assert.NoError(t, writes.RecordValue(i*1000))
+	}
+	checkpoint.Scripts["writes"] = &ScriptResult{ScriptName: "writes", Rate: 10, Succeeded: 98, Failed: 2, Latencies: writes}
+	checkpoint.Scripts["reads"] = &ScriptResult{ScriptName: "reads", Rate: 90, Succeeded: 100, Latencies: reads}
+
+	out.ReportWorkloadProgress(0.5, checkpoint)
+
+	assert.Equal(t, `[50.00%] 100.00 tps, 99.80 succeeded, 99.80 goodput / 2 failures
+  [reads] 90.00 tps, p99 9.903ms / 0 failures
+  [writes] 10.00 tps, p99 99.007ms / 2 failures
+`, errStream.String())
+}
@@ -0,0 +1,118 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"sync"
+	"time"
+)
+
+// Wraps a driver so that at most a given number of transactions a second go through it, and so that they fail once
+// stopCh closes. This lets the dataset generators of the builtin workloads run in the background of a run, see
+// --init-background, writing at a steady rate rather than as fast as they can, and stopping with the run.
+type PacedDriver struct {
+	neo4j.Driver
+	mut sync.Mutex
+	// Time between one transaction starting and the next; 0 doesn't pace them
+	interval time.Duration
+	// When the next transaction may start
+	next   time.Time
+	stopCh <-chan struct{}
+}
+
+// Transactions fail with this once the PacedDriver has been stopped
+var ErrPacedDriverStopped = fmt.Errorf("stopped, the run is over")
+
+// Paces transactions through driver to the given number a second; 0 doesn't pace them
+func NewPacedDriver(driver neo4j.Driver, perSecond float64, stopCh <-chan struct{}) *PacedDriver {
+	d := &PacedDriver{Driver: driver, stopCh: stopCh}
+	if perSecond > 0 {
+		d.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return d
+}
+
+func (d *PacedDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return &pacedSession{Session: d.Driver.NewSession(config), driver: d}
+}
+
+func (d *PacedDriver) Session(accessMode neo4j.AccessMode, bookmarks ...string) (neo4j.Session, error) {
+	session, err := d.Driver.Session(accessMode, bookmarks...)
+	if err != nil {
+		return nil, err
+	}
+	return &pacedSession{Session: session, driver: d}, nil
+}
+
+// The driver is shared with the run, closing it is up to the run
+func (d *PacedDriver) Close() error {
+	return nil
+}
+
+// Waits for the turn of the next transaction; ErrPacedDriverStopped if stopped first
+func (d *PacedDriver) await() error {
+	select {
+	case <-d.stopCh:
+		return ErrPacedDriverStopped
+	default:
+	}
+	if d.interval <= 0 {
+		return nil
+	}
+	now := time.Now()
+	wait := d.reserve(now).Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-d.stopCh:
+		return ErrPacedDriverStopped
+	}
+}
+
+// Reserves the next time a transaction may start, at the earliest now
+func (d *PacedDriver) reserve(now time.Time) time.Time {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	turn := d.next
+	if turn.Before(now) {
+		turn = now
+	}
+	d.next = turn.Add(d.interval)
+	return turn
+}
+
+type pacedSession struct {
+	neo4j.Session
+	driver *PacedDriver
+}
+
+func (s *pacedSession) BeginTransaction(configurers ...func(*neo4j.TransactionConfig)) (neo4j.Transaction, error) {
+	if err := s.driver.await(); err != nil {
+		return nil, err
+	}
+	return s.Session.BeginTransaction(configurers...)
+}
+
+func (s *pacedSession) ReadTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	if err := s.driver.await(); err != nil {
+		return nil, err
+	}
+	return s.Session.ReadTransaction(work, configurers...)
+}
+
+func (s *pacedSession) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	if err := s.driver.await(); err != nil {
+		return nil, err
+	}
+	return s.Session.WriteTransaction(work, configurers...)
+}
+
+func (s *pacedSession) Run(cypher string, params map[string]interface{}, configurers ...func(*neo4j.TransactionConfig)) (neo4j.Result, error) {
+	if err := s.driver.await(); err != nil {
+		return nil, err
+	}
+	return s.Session.Run(cypher, params, configurers...)
+}
@@ -0,0 +1,47 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPacedDriverSpacesOutTransactions(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	inner := &fakeDriver{clock: clock, r: rand.New(rand.NewSource(1337)), minLatency: time.Millisecond, maxLatency: time.Millisecond}
+	driver := NewPacedDriver(inner, 100, make(chan struct{}))
+	session := driver.NewSession(neo4j.SessionConfig{})
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) { return nil, nil })
+		assert.NoError(t, err)
+	}
+
+	// The first goes right away, the other four 10ms apart
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond))
+}
+
+func TestPacedDriverFailsTransactionsOnceStopped(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	inner := &fakeDriver{clock: clock, r: rand.New(rand.NewSource(1337)), minLatency: time.Millisecond, maxLatency: time.Millisecond}
+	stopCh := make(chan struct{})
+	// Slow enough that the second transaction waits until stopped
+	driver := NewPacedDriver(inner, 0.001, stopCh)
+	session := driver.NewSession(neo4j.SessionConfig{})
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) { return nil, nil })
+	assert.NoError(t, err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(stopCh)
+	}()
+	_, err = session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) { return nil, nil })
+	assert.Equal(t, ErrPacedDriverStopped, err)
+
+	// Closing it leaves the driver it wraps open for the run
+	assert.NoError(t, driver.Close())
+}
@@ -0,0 +1,123 @@
+package neobench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Watches how much parameter data units of work send. Large parameters are almost always a mistake in the script,
+// like a list built over the wrong range, and otherwise only show up as mysterious slowness; units of work over Warn
+// are counted and logged, at most one per Interval, and units of work over Limit stop the run before they're sent.
+type ParamSizeGuard struct {
+	// Bytes of parameters per unit of work, over all its statements, above which it is counted and logged; 0 to disable
+	Warn int64
+	// Bytes of parameters per unit of work that stop the run; 0 for no limit
+	Limit    int64
+	Interval time.Duration
+
+	mut        sync.Mutex
+	out        io.Writer
+	lastLogged time.Time
+	suppressed int64
+}
+
+func NewParamSizeGuard(warn, limit int64, out io.Writer) *ParamSizeGuard {
+	return &ParamSizeGuard{
+		Warn:     warn,
+		Limit:    limit,
+		Interval: time.Second,
+		out:      out,
+	}
+}
+
+// Whether a unit of work that sent the given bytes of parameters is over Warn
+func (g *ParamSizeGuard) oversized(paramBytes int64) bool {
+	return g.Warn > 0 && paramBytes > g.Warn
+}
+
+// Logs the given unit of work if its parameters are oversized and nothing else was logged too recently, naming the
+// largest parameter of the given statements
+func (g *ParamSizeGuard) Observe(now time.Time, workerId int64, scriptName string, paramBytes int64, statements []Statement) {
+	if !g.oversized(paramBytes) {
+		return
+	}
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	if !g.lastLogged.IsZero() && now.Sub(g.lastLogged) < g.Interval {
+		g.suppressed++
+		return
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Oversized parameters: [%s] sent %s of parameters in one unit of work on worker %d, over the %s warning threshold",
+		scriptName, formatBytes(paramBytes), workerId, formatBytes(g.Warn)))
+	if name, size := largestParam(statements); name != "" {
+		s.WriteString(fmt.Sprintf("; the largest is $%s, %s", name, formatBytes(size)))
+	}
+	if g.suppressed > 0 {
+		s.WriteString(fmt.Sprintf(" (%d more since the last one logged)", g.suppressed))
+	}
+	s.WriteString("\n")
+	_, _ = fmt.Fprint(g.out, s.String())
+
+	g.lastLogged = now
+	g.suppressed = 0
+}
+
+// Name and size of the largest parameter of the given statements
+func largestParam(statements []Statement) (string, int64) {
+	name, largest := "", int64(0)
+	for _, statement := range statements {
+		for param, value := range statement.Params {
+			if size := valueSize(value); size > largest || (size == largest && param < name) {
+				name, largest = param, size
+			}
+		}
+	}
+	return name, largest
+}
+
+// Units of work of one script that sent more parameter data than --param-size-warn
+type OversizedParamsResult struct {
+	ScriptName string
+	Units      int64
+	// Bytes of parameters of the largest of them
+	Largest int64
+}
+
+func (r *OversizedParamsResult) record(paramBytes int64) {
+	r.Units++
+	if paramBytes > r.Largest {
+		r.Largest = paramBytes
+	}
+}
+
+func (r *OversizedParamsResult) merge(other *OversizedParamsResult) {
+	r.Units += other.Units
+	if other.Largest > r.Largest {
+		r.Largest = other.Largest
+	}
+}
+
+func writeOversizedParamsReport(result Result, s *strings.Builder) {
+	if len(result.OversizedParams) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.OversizedParams))
+	for name := range result.OversizedParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.WriteString(fmt.Sprintf("-- Oversized parameters --\n\n"))
+	for _, name := range names {
+		oversized := result.OversizedParams[name]
+		s.WriteString(fmt.Sprintf("  [%s]: %d units of work sent more parameter data than --param-size-warn, the largest %s\n",
+			name, oversized.Units, formatBytes(oversized.Largest)))
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,72 @@
+package neobench
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnitOfWorkMeasuresAndLimitsParams(t *testing.T) {
+	script, err := Parse("params", `
+:set ids range(1, 100)
+RETURN $ids;
+:set name "hello"
+RETURN $name, $ids;`, 1)
+	assert.NoError(t, err)
+	ctx := ScriptContext{Vars: map[string]interface{}{}, Rand: rand.New(rand.NewSource(1337))}
+
+	uow := script.NewUnitOfWork(ctx)
+	uow.MeasureParams = true
+	assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+	assert.Equal(t, int64(len("ids")+100*8+len("name")+len("hello")+len("ids")+100*8), uow.ParamBytes)
+	// Retries start counting over
+	assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+	assert.Equal(t, int64(len("ids")+100*8+len("name")+len("hello")+len("ids")+100*8), uow.ParamBytes)
+
+	uow = script.NewUnitOfWork(ctx)
+	uow.MeasureParams, uow.ParamLimit = true, 1000
+	sent := 0
+	err = uow.Run(func(Statement) error {
+		sent++
+		return nil
+	})
+	assert.IsType(t, &ScriptError{}, err)
+	assert.EqualError(t, err, "parameters add up to 1KB by statement 2, over the --param-size-limit of 1000B")
+	assert.Equal(t, 1, sent)
+}
+
+func TestParamSizeGuardLogsOversizedUnits(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	guard := NewParamSizeGuard(1000, 0, out)
+	start := time.Unix(0, 0)
+	statements := []Statement{{Params: map[string]interface{}{"ids": make([]interface{}, 200), "name": "x"}}}
+
+	guard.Observe(start, 1, "test", 1000, statements)
+	assert.Equal(t, "", out.String())
+	guard.Observe(start, 1, "test", 2<<20, statements)
+	guard.Observe(start.Add(100*time.Millisecond), 2, "test", 2<<20, statements)
+	guard.Observe(start.Add(2*time.Second), 3, "test", 4<<20, statements)
+	assert.Equal(t, `Oversized parameters: [test] sent 2MB of parameters in one unit of work on worker 1, over the 1000B warning threshold; the largest is $ids, 200B
+Oversized parameters: [test] sent 4MB of parameters in one unit of work on worker 3, over the 1000B warning threshold; the largest is $ids, 200B (1 more since the last one logged)
+`, out.String())
+}
+
+func TestWriteOversizedParamsReport(t *testing.T) {
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("test", "", time.Millisecond, uowOutcome{succeeded: true, paramBytes: 2 << 20}))
+	assert.NoError(t, worker.record("test", "", time.Millisecond, uowOutcome{succeeded: false, paramBytes: 5 << 20}))
+	assert.NoError(t, worker.record("small", "", time.Millisecond, uowOutcome{succeeded: true}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+
+	s := strings.Builder{}
+	writeOversizedParamsReport(result, &s)
+	assert.Equal(t, `-- Oversized parameters --
+
+  [test]: 2 units of work sent more parameter data than --param-size-warn, the largest 5MB
+
+`, s.String())
+}
@@ -0,0 +1,222 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Payload buckets grow by this factor; the first holds payloads up to payloadBucketBase bytes
+const payloadBucketFactor = 4
+const payloadBucketBase = 256
+
+// How much data units of work moved, with --payload-sizes; see PayloadResult
+type payloadSize struct {
+	// Approximate bytes of parameters sent, and of records received
+	sent     int64
+	received int64
+	rows     int64
+}
+
+func (p payloadSize) total() int64 {
+	return p.sent + p.received
+}
+
+// Latency of one script by the size of its payload, to show whether large transactions are the slow ones. Sizes are
+// approximate: they count the bytes values take up, not what the protocol adds around them.
+type PayloadResult struct {
+	ScriptName string
+	// Keyed by bucket, see payloadBucket
+	Buckets map[int]*PayloadBucket
+
+	// Running sums to work out the correlation of payload size and latency in microseconds
+	n, sumSize, sumLatency, sumSizeSquared, sumLatencySquared, sumProduct float64
+}
+
+type PayloadBucket struct {
+	Transactions int64
+	Sent         int64
+	Received     int64
+	Rows         int64
+	Latencies    *hdrhistogram.Histogram
+}
+
+func newPayloadResult(scriptName string) *PayloadResult {
+	return &PayloadResult{ScriptName: scriptName, Buckets: make(map[int]*PayloadBucket)}
+}
+
+func (p *PayloadResult) record(size payloadSize, latencyMicros int64) error {
+	bucket, found := p.Buckets[payloadBucket(size.total())]
+	if !found {
+		bucket = &PayloadBucket{Latencies: hdrhistogram.New(0, 60*60*1000000, 3)}
+		p.Buckets[payloadBucket(size.total())] = bucket
+	}
+	bucket.Transactions++
+	bucket.Sent += size.sent
+	bucket.Received += size.received
+	bucket.Rows += size.rows
+	if err := bucket.Latencies.RecordValue(latencyMicros); err != nil {
+		return err
+	}
+
+	x, y := float64(size.total()), float64(latencyMicros)
+	p.n++
+	p.sumSize += x
+	p.sumLatency += y
+	p.sumSizeSquared += x * x
+	p.sumLatencySquared += y * y
+	p.sumProduct += x * y
+	return nil
+}
+
+func (p *PayloadResult) merge(other *PayloadResult) {
+	for index, bucket := range other.Buckets {
+		existing, found := p.Buckets[index]
+		if !found {
+			p.Buckets[index] = &PayloadBucket{
+				Transactions: bucket.Transactions,
+				Sent:         bucket.Sent,
+				Received:     bucket.Received,
+				Rows:         bucket.Rows,
+				Latencies:    hdrhistogram.Import(bucket.Latencies.Export()),
+			}
+			continue
+		}
+		existing.Transactions += bucket.Transactions
+		existing.Sent += bucket.Sent
+		existing.Received += bucket.Received
+		existing.Rows += bucket.Rows
+		existing.Latencies.Merge(bucket.Latencies)
+	}
+	p.n += other.n
+	p.sumSize += other.sumSize
+	p.sumLatency += other.sumLatency
+	p.sumSizeSquared += other.sumSizeSquared
+	p.sumLatencySquared += other.sumLatencySquared
+	p.sumProduct += other.sumProduct
+}
+
+// Pearson correlation of payload size and latency, from -1 to 1; 0 if either didn't vary
+func (p *PayloadResult) Correlation() float64 {
+	sizeVariance := p.n*p.sumSizeSquared - p.sumSize*p.sumSize
+	latencyVariance := p.n*p.sumLatencySquared - p.sumLatency*p.sumLatency
+	if sizeVariance <= 0 || latencyVariance <= 0 {
+		return 0
+	}
+	return (p.n*p.sumProduct - p.sumSize*p.sumLatency) / math.Sqrt(sizeVariance*latencyVariance)
+}
+
+// Bucket 0 holds payloads of up to payloadBucketBase bytes, and each next one payloads payloadBucketFactor times larger
+func payloadBucket(bytes int64) int {
+	bucket := 0
+	for limit := int64(payloadBucketBase); bytes > limit; limit *= payloadBucketFactor {
+		bucket++
+	}
+	return bucket
+}
+
+// Upper bound of the given bucket, in bytes
+func payloadBucketLimit(bucket int) int64 {
+	limit := int64(payloadBucketBase)
+	for i := 0; i < bucket; i++ {
+		limit *= payloadBucketFactor
+	}
+	return limit
+}
+
+// Approximate size of a parameter or record value, in bytes
+func valueSize(v interface{}) int64 {
+	switch value := v.(type) {
+	case nil, bool:
+		return 1
+	case string:
+		return int64(len(value))
+	case []byte:
+		return int64(len(value))
+	case []interface{}:
+		size := int64(0)
+		for _, item := range value {
+			size += valueSize(item)
+		}
+		return size
+	case map[string]interface{}:
+		size := int64(0)
+		for key, item := range value {
+			size += int64(len(key)) + valueSize(item)
+		}
+		return size
+	case neo4j.Node:
+		size := int64(8)
+		for _, label := range value.Labels {
+			size += int64(len(label))
+		}
+		return size + valueSize(value.Props)
+	case neo4j.Relationship:
+		return 24 + int64(len(value.Type)) + valueSize(value.Props)
+	case neo4j.Path:
+		size := int64(0)
+		for _, node := range value.Nodes {
+			size += valueSize(node)
+		}
+		for _, rel := range value.Relationships {
+			size += valueSize(rel)
+		}
+		return size
+	default:
+		// Numbers, temporal and spatial values, and anything else fixed-size
+		return 8
+	}
+}
+
+func formatBytes(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%dGB", bytes>>30)
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%dMB", bytes>>20)
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%dKB", bytes>>10)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
+
+// Latency by payload size, for each script that ran with --payload-sizes
+func writePayloadReport(result Result, s *strings.Builder) {
+	if len(result.Payloads) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.Payloads))
+	for name := range result.Payloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.WriteString(fmt.Sprintf("-- Payload sizes --\n\n"))
+	for _, name := range names {
+		payload := result.Payloads[name]
+		s.WriteString(fmt.Sprintf("  [%s]: correlation of size and latency %.2f\n", name, payload.Correlation()))
+		buckets := make([]int, 0, len(payload.Buckets))
+		for index := range payload.Buckets {
+			buckets = append(buckets, index)
+		}
+		sort.Ints(buckets)
+		for _, index := range buckets {
+			bucket := payload.Buckets[index]
+			lower := "0B"
+			if index > 0 {
+				lower = formatBytes(payloadBucketLimit(index - 1))
+			}
+			n := float64(bucket.Transactions)
+			s.WriteString(fmt.Sprintf("    %s-%s: %d transactions, mean %s sent, %s received in %.1f rows, P50: %s, P99: %s\n",
+				lower, formatBytes(payloadBucketLimit(index)), bucket.Transactions,
+				formatBytes(int64(float64(bucket.Sent)/n)), formatBytes(int64(float64(bucket.Received)/n)), float64(bucket.Rows)/n,
+				result.LatencyUnit.Format(float64(bucket.Latencies.ValueAtQuantile(50))),
+				result.LatencyUnit.Format(float64(bucket.Latencies.ValueAtQuantile(99)))))
+		}
+	}
+	s.WriteString("\n")
+}
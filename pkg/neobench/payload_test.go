@@ -0,0 +1,69 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueSize(t *testing.T) {
+	assert.Equal(t, int64(0), valueSize(map[string]interface{}(nil)))
+	// Keys count too
+	assert.Equal(t, int64(len("id")+8+len("name")+len("hello")+len("flags")+1), valueSize(map[string]interface{}{
+		"id":    int64(1),
+		"name":  "hello",
+		"flags": []interface{}{true},
+	}))
+	assert.Equal(t, int64(8+len("Person")+len("name")+len("Ada")), valueSize(neo4j.Node{
+		Id:     1,
+		Labels: []string{"Person"},
+		Props:  map[string]interface{}{"name": "Ada"},
+	}))
+}
+
+func TestPayloadBuckets(t *testing.T) {
+	assert.Equal(t, 0, payloadBucket(0))
+	assert.Equal(t, 0, payloadBucket(256))
+	assert.Equal(t, 1, payloadBucket(257))
+	assert.Equal(t, 1, payloadBucket(1024))
+	assert.Equal(t, 2, payloadBucket(1025))
+	assert.Equal(t, int64(4096), payloadBucketLimit(2))
+}
+
+func TestPayloadCorrelation(t *testing.T) {
+	payload := newPayloadResult("test")
+	for i := int64(1); i <= 10; i++ {
+		assert.NoError(t, payload.record(payloadSize{sent: i * 100}, i*1000))
+	}
+	assert.InDelta(t, 1.0, payload.Correlation(), 0.0001)
+
+	flat := newPayloadResult("test")
+	for i := int64(1); i <= 10; i++ {
+		assert.NoError(t, flat.record(payloadSize{sent: i * 100}, 1000))
+	}
+	assert.Equal(t, 0.0, flat.Correlation())
+}
+
+func TestWritePayloadReport(t *testing.T) {
+	worker := NewWorkerResult(0)
+	small := &payloadSize{sent: 100, received: 50, rows: 1}
+	large := &payloadSize{sent: 100, received: 8000, rows: 20}
+	assert.NoError(t, worker.record("test", "", time.Millisecond, uowOutcome{succeeded: true, payload: small}))
+	assert.NoError(t, worker.record("test", "", 10*time.Millisecond, uowOutcome{succeeded: true, payload: large}))
+	// Failures aren't counted, their latency says nothing about their size
+	assert.NoError(t, worker.record("test", "", time.Second, uowOutcome{succeeded: false, payload: large}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+
+	s := strings.Builder{}
+	writePayloadReport(result, &s)
+	assert.Equal(t, `-- Payload sizes --
+
+  [test]: correlation of size and latency 1.00
+    0B-256B: 1 transactions, mean 100B sent, 50B received in 1.0 rows, P50: 1.000ms, P99: 1.000ms
+    4KB-16KB: 1 transactions, mean 100B sent, 7KB received in 20.0 rows, P50: 10.007ms, P99: 10.007ms
+
+`, s.String())
+}
@@ -0,0 +1,187 @@
+package neobench
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A period of the run with its own script weights, for modelling workloads whose mix changes over time, like a
+// burst of writes in an otherwise read-mostly run. Set with --weight-phase, ex: 0-10m:reads=90,writes=10
+type WeightPhase struct {
+	// The time range as given, ex: 0-10m
+	Name  string
+	Start time.Duration
+	End   time.Duration
+	// Weight of each script in this phase, by script name; scripts the phase doesn't mention have weight 0
+	Weights map[string]float64
+	lookup  *WeightedRandom
+}
+
+// Parses phases like "0-10m:reads=90,writes=10". Scripts are named by their path, with or without directory and
+// extension, or by builtin workload name; a name that matches several scripts, like ldbc-like, splits its weight
+// between them in proportion to their own weights.
+func ParseWeightPhases(specs []string, scripts []Script) ([]WeightPhase, error) {
+	phases := make([]WeightPhase, 0, len(specs))
+	for _, spec := range specs {
+		phase, err := parseWeightPhase(spec, scripts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --weight-phase '%s': %s", spec, err)
+		}
+		phases = append(phases, phase)
+	}
+	sort.Slice(phases, func(i, j int) bool { return phases[i].Start < phases[j].Start })
+	for i := 1; i < len(phases); i++ {
+		if phases[i].Start < phases[i-1].End {
+			return nil, fmt.Errorf("--weight-phase %s overlaps with %s, phases must not overlap", phases[i].Name, phases[i-1].Name)
+		}
+	}
+	return phases, nil
+}
+
+func parseWeightPhase(spec string, scripts []Script) (WeightPhase, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 {
+		return WeightPhase{}, fmt.Errorf("expected a time range and script weights, like 0-10m:reads=90,writes=10")
+	}
+	name := strings.TrimSpace(parts[0])
+	bounds := strings.SplitN(name, "-", 2)
+	if len(bounds) != 2 {
+		return WeightPhase{}, fmt.Errorf("expected a time range like 0-10m or 10m-20m, got '%s'", name)
+	}
+	start, err := parsePhaseOffset(bounds[0])
+	if err != nil {
+		return WeightPhase{}, err
+	}
+	end, err := parsePhaseOffset(bounds[1])
+	if err != nil {
+		return WeightPhase{}, err
+	}
+	if end <= start {
+		return WeightPhase{}, fmt.Errorf("phase must end after it starts, got '%s'", name)
+	}
+
+	weights := make(map[string]float64)
+	for _, entry := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return WeightPhase{}, fmt.Errorf("expected script weights like reads=90, got '%s'", entry)
+		}
+		key := strings.TrimSpace(kv[0])
+		weight, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || weight < 0 {
+			return WeightPhase{}, fmt.Errorf("weight for '%s' must be a non-negative number, got '%s'", key, kv[1])
+		}
+		matched := make([]Script, 0)
+		matchedWeight := 0.0
+		for _, script := range scripts {
+			if phaseKeyMatches(key, script.Name) {
+				matched = append(matched, script)
+				matchedWeight += script.Weight
+			}
+		}
+		if len(matched) == 0 {
+			return WeightPhase{}, fmt.Errorf("'%s' doesn't match any script in the workload", key)
+		}
+		for _, script := range matched {
+			share := 1.0 / float64(len(matched))
+			if matchedWeight > 0 {
+				share = script.Weight / matchedWeight
+			}
+			weights[script.Name] += weight * share
+		}
+	}
+
+	lookup := &WeightedRandom{}
+	for _, script := range scripts {
+		if weight := int(weights[script.Name] * 10000); weight > 0 {
+			lookup.Add(script, weight)
+		}
+	}
+	if lookup.totalWeight == 0 {
+		return WeightPhase{}, fmt.Errorf("at least one script needs a weight above zero")
+	}
+	return WeightPhase{Name: name, Start: start, End: end, Weights: weights, lookup: lookup}, nil
+}
+
+// Offsets into the run are durations, except that the start of the run can be given as a plain 0
+func parsePhaseOffset(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s' in phase, use durations like 90s or 10m", raw)
+	}
+	return d, nil
+}
+
+// Script names are paths for -f, "-S #n" for -S and builtin:<workload> for builtins
+func phaseKeyMatches(key, scriptName string) bool {
+	if key == scriptName || "builtin:"+key == scriptName || strings.HasPrefix(scriptName, "builtin:"+key+"/") {
+		return true
+	}
+	base := filepath.Base(scriptName)
+	return key == base || key == strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Results for the scripts run during one weight phase
+type PhaseResult struct {
+	Name    string
+	Start   time.Duration
+	End     time.Duration
+	Scripts map[string]*ScriptResult
+	// Achieved vs configured script mix within the phase
+	Mix []MixEntry
+}
+
+// Completes the per-phase results in the given result, which Result#Add only collects counts and latencies for;
+// runtime is how long the run went on for, which may be less than the phases cover
+func (s *Scripts) CompletePhases(result *Result, runtime time.Duration, driftThreshold float64) {
+	phases := make([]PhaseResult, 0, len(s.Phases))
+	for _, phase := range s.Phases {
+		if phase.Start >= runtime {
+			continue
+		}
+		phaseResult := PhaseResult{Name: phase.Name, Start: phase.Start, End: phase.End, Scripts: make(map[string]*ScriptResult)}
+		for _, collected := range result.Phases {
+			if collected.Name == phase.Name {
+				phaseResult.Scripts = collected.Scripts
+			}
+		}
+
+		ran := phase.End
+		if runtime < ran {
+			ran = runtime
+		}
+		ran -= phase.Start
+		for _, script := range phaseResult.Scripts {
+			script.Rate = float64(script.Succeeded+script.Failed) / ran.Seconds()
+		}
+
+		names := make([]string, 0, len(phase.Weights))
+		for _, script := range s.Scripts {
+			if _, found := phase.Weights[script.Name]; found && !containsString(names, script.Name) {
+				names = append(names, script.Name)
+			}
+		}
+		if len(names) > 1 {
+			phaseResult.Mix = mix(names, phase.Weights, phaseResult.Scripts, driftThreshold)
+		}
+		phases = append(phases, phaseResult)
+	}
+	result.Phases = phases
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseWeightPhases(t *testing.T) {
+	scripts := []Script{
+		{Name: "/tmp/reads.script", Weight: 1},
+		{Name: "/tmp/writes.script", Weight: 1},
+		{Name: "builtin:ldbc-like/ic2", Weight: 1},
+		{Name: "builtin:ldbc-like/ic6", Weight: 3},
+	}
+
+	phases, err := ParseWeightPhases([]string{
+		"10m-20m:ldbc-like=8,writes=2",
+		"0-10m:reads=90,writes.script=10",
+	}, scripts)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(phases))
+	assert.Equal(t, "0-10m", phases[0].Name)
+	assert.Equal(t, time.Duration(0), phases[0].Start)
+	assert.Equal(t, 10*time.Minute, phases[0].End)
+	assert.Equal(t, map[string]float64{"/tmp/reads.script": 90, "/tmp/writes.script": 10}, phases[0].Weights)
+	// ldbc-like matches both its queries, which split its weight in proportion to their own weights
+	assert.Equal(t, map[string]float64{
+		"builtin:ldbc-like/ic2": 2,
+		"builtin:ldbc-like/ic6": 6,
+		"/tmp/writes.script":    2,
+	}, phases[1].Weights)
+}
+
+func TestParseWeightPhasesErrors(t *testing.T) {
+	scripts := []Script{{Name: "reads", Weight: 1}, {Name: "writes", Weight: 1}}
+	for _, spec := range [][]string{
+		{"0-10m"},
+		{"10m:reads=1"},
+		{"10m-5m:reads=1"},
+		{"0-10m:reads"},
+		{"0-10m:reads=-1"},
+		{"0-10m:nope=1"},
+		{"0-10m:reads=0"},
+		{"0-10m:reads=1", "5m-15m:writes=1"},
+	} {
+		_, err := ParseWeightPhases(spec, scripts)
+		assert.Error(t, err, "%v", spec)
+	}
+}
+
+func TestChooseAtUsesPhaseWeights(t *testing.T) {
+	reads, writes := Script{Name: "reads", Weight: 1}, Script{Name: "writes", Weight: 1}
+	scripts := NewScripts(reads, writes)
+	phases, err := ParseWeightPhases([]string{"1m-2m:writes=1"}, scripts.Scripts)
+	assert.NoError(t, err)
+	scripts.Phases = phases
+	r := rand.New(rand.NewSource(1337))
+
+	for i := 0; i < 100; i++ {
+		script, phase := scripts.ChooseAt(r, 90*time.Second)
+		assert.Equal(t, "writes", script.Name)
+		assert.Equal(t, "1m-2m", phase)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		script, phase := scripts.ChooseAt(r, 2*time.Minute)
+		assert.Equal(t, "", phase)
+		seen[script.Name] = true
+	}
+	assert.Equal(t, map[string]bool{"reads": true, "writes": true}, seen)
+}
+
+func TestCompletePhasesComputesRatesOverTimeRun(t *testing.T) {
+	scripts := NewScripts(Script{Name: "reads", Weight: 1}, Script{Name: "writes", Weight: 1})
+	phases, err := ParseWeightPhases([]string{"0-10s:reads=3,writes=1", "10s-30s:writes=1", "60s-90s:reads=1"}, scripts.Scripts)
+	assert.NoError(t, err)
+	scripts.Phases = phases
+
+	worker := NewWorkerResult(0)
+	for i := 0; i < 30; i++ {
+		assert.NoError(t, worker.record("reads", "0-10s", time.Millisecond, uowOutcome{succeeded: true}))
+	}
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, worker.record("writes", "0-10s", time.Millisecond, uowOutcome{succeeded: true}))
+		assert.NoError(t, worker.record("writes", "10s-30s", time.Millisecond, uowOutcome{succeeded: true}))
+	}
+	result := NewResult("", "")
+	result.Add(worker)
+
+	// The run was stopped 5s into the second phase, and never got to the third
+	scripts.CompletePhases(&result, 15*time.Second, 0.05)
+
+	assert.Equal(t, 2, len(result.Phases))
+	assert.Equal(t, 3.0, result.Phases[0].Scripts["reads"].Rate)
+	assert.Equal(t, 2.0, result.Phases[1].Scripts["writes"].Rate)
+	assert.Equal(t, []MixEntry{
+		{ScriptName: "reads", TargetShare: 0.75, AchievedShare: 0.75},
+		{ScriptName: "writes", TargetShare: 0.25, AchievedShare: 0.25},
+	}, result.Phases[0].Mix)
+	assert.Nil(t, result.Phases[1].Mix)
+}
@@ -0,0 +1,219 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracks the driver's connection pool, so the report can show when workers were waiting on the pool rather than
+// on the database. The driver has no API for pool statistics, so this listens to the log events the pool emits
+// instead; install it as the driver logger with Configure.
+type PoolMetrics struct {
+	// Logger the driver had before, log events are passed on to it; nil if logging is off
+	next log.Logger
+
+	mut sync.Mutex
+	// Connections the pool has open, busy or idle
+	open int64
+	// Connections borrowed and not yet returned
+	inUse     int64
+	peakInUse int64
+	// Borrows that had to wait for another worker to return a connection
+	queued int64
+	// Borrows that gave up waiting, see neo4j.Config#ConnectionAcquisitionTimeout
+	timedOut int64
+	samples  []PoolSample
+}
+
+// State of the pool at one point during the run
+type PoolSample struct {
+	// Since the benchmark started
+	Elapsed time.Duration
+	InUse   int64
+	Idle    int64
+}
+
+// Summary of the pool over the run; acquisition wait times are measured by the workers, see
+// Result#AcquisitionWaits
+type PoolResult struct {
+	// Sampled at each progress checkpoint
+	Samples   []PoolSample
+	PeakInUse int64
+	Queued    int64
+	TimedOut  int64
+}
+
+func NewPoolMetrics() *PoolMetrics {
+	return &PoolMetrics{}
+}
+
+// Installs these metrics as the logger of the driver config, passing log events on to any logger already set
+func (p *PoolMetrics) Configure(c *neo4j.Config) {
+	p.next = c.Log
+	c.Log = p
+}
+
+// Forgets the peak, the counts and the samples, keeping track of the connections that are open; call this when
+// the benchmark starts, so work done before it, like populating datasets, isn't counted
+func (p *PoolMetrics) ResetCounters() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.peakInUse = p.inUse
+	p.queued = 0
+	p.timedOut = 0
+	p.samples = nil
+}
+
+// Records the current state of the pool, call this periodically while the benchmark runs
+func (p *PoolMetrics) Sample(elapsed time.Duration) PoolSample {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	sample := PoolSample{Elapsed: elapsed, InUse: p.inUse, Idle: p.open - p.inUse}
+	if sample.Idle < 0 {
+		// Idle connections the pool closes for being too old aren't logged, so open can lag behind
+		sample.Idle = 0
+	}
+	p.samples = append(p.samples, sample)
+	return sample
+}
+
+func (p *PoolMetrics) Result() *PoolResult {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return &PoolResult{
+		Samples:   append([]PoolSample{}, p.samples...),
+		PeakInUse: p.peakInUse,
+		Queued:    p.queued,
+		TimedOut:  p.timedOut,
+	}
+}
+
+// Pool events, by the start of the message the pool logs them with
+func (p *PoolMetrics) observe(name, msg string) {
+	if name != log.Pool {
+		return
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	switch {
+	case strings.HasPrefix(msg, "Trying to borrow"):
+		p.inUse++
+		if p.inUse > p.peakInUse {
+			p.peakInUse = p.inUse
+		}
+	case strings.HasPrefix(msg, "Returning connection"):
+		p.inUse--
+	case strings.HasPrefix(msg, "Borrow queued"):
+		p.queued++
+	case strings.HasPrefix(msg, "Borrow time-out"):
+		p.timedOut++
+		p.inUse--
+	case strings.HasPrefix(msg, "No server connection available"):
+		p.inUse--
+	case strings.HasPrefix(msg, "Connecting to"):
+		p.open++
+	case strings.HasPrefix(msg, "Failed to connect"), strings.HasPrefix(msg, "Unregistering"):
+		p.open--
+	}
+}
+
+func (p *PoolMetrics) Error(name string, id string, err error) {
+	if p.next != nil {
+		p.next.Error(name, id, err)
+	}
+}
+
+func (p *PoolMetrics) Warnf(name string, id string, msg string, args ...interface{}) {
+	p.observe(name, msg)
+	if p.next != nil {
+		p.next.Warnf(name, id, msg, args...)
+	}
+}
+
+func (p *PoolMetrics) Infof(name string, id string, msg string, args ...interface{}) {
+	p.observe(name, msg)
+	if p.next != nil {
+		p.next.Infof(name, id, msg, args...)
+	}
+}
+
+func (p *PoolMetrics) Debugf(name string, id string, msg string, args ...interface{}) {
+	p.observe(name, msg)
+	if p.next != nil {
+		p.next.Debugf(name, id, msg, args...)
+	}
+}
+
+// Measures how long a worker waits for the driver to get it a connection. The driver logs each message it sends
+// on a connection to the session's bolt logger, so the time from asking the session for a transaction to the
+// first message sent is the time spent borrowing, or opening, a connection.
+type acquisitionTimer struct {
+	now func() time.Time
+	// When the worker last asked for a connection; zero once the first message after that has been sent
+	start time.Time
+	waits []time.Duration
+}
+
+// Call right before a session call that borrows a connection
+func (t *acquisitionTimer) begin() {
+	t.start = t.now()
+}
+
+// Returns the waits measured since the last call, and forgets them
+func (t *acquisitionTimer) take() []time.Duration {
+	waits := t.waits
+	t.waits = nil
+	t.start = time.Time{}
+	return waits
+}
+
+func (t *acquisitionTimer) LogClientMessage(context string, msg string, args ...interface{}) {
+	if t.start.IsZero() {
+		return
+	}
+	t.waits = append(t.waits, t.now().Sub(t.start))
+	t.start = time.Time{}
+}
+
+func (t *acquisitionTimer) LogServerMessage(context string, msg string, args ...interface{}) {
+}
+
+// Acquisition wait and how busy the pool was; only written when the pool was tracked
+func writePoolReport(result Result, s *strings.Builder) {
+	pool := result.Pool
+	if pool == nil {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Connection pool --\n\n"))
+	waits := result.AcquisitionWaits
+	if waits != nil && waits.TotalCount() > 0 {
+		unit := result.LatencyUnit
+		s.WriteString(fmt.Sprintf("  Acquisition wait: P50: %s, P99: %s, P99.9: %s, Max: %s\n",
+			unit.Format(float64(waits.ValueAtQuantile(50))), unit.Format(float64(waits.ValueAtQuantile(99))),
+			unit.Format(float64(waits.ValueAtQuantile(99.9))), unit.Format(float64(waits.Max()))))
+	}
+	s.WriteString(fmt.Sprintf("  Peak in use: %d connections\n", pool.PeakInUse))
+	if len(pool.Samples) > 0 {
+		var inUse, idle int64
+		minIdle := pool.Samples[0].Idle
+		for _, sample := range pool.Samples {
+			inUse += sample.InUse
+			idle += sample.Idle
+			if sample.Idle < minIdle {
+				minIdle = sample.Idle
+			}
+		}
+		n := float64(len(pool.Samples))
+		s.WriteString(fmt.Sprintf("  Mean in use: %.1f, mean idle: %.1f, least idle: %d; over %d progress reports\n",
+			float64(inUse)/n, float64(idle)/n, minIdle, len(pool.Samples)))
+	}
+	if pool.Queued > 0 {
+		s.WriteString(fmt.Sprintf("  %d transactions waited for a connection to free up, %d gave up waiting; the pool was the "+
+			"bottleneck, not the database, for those\n", pool.Queued, pool.TimedOut))
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,111 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolMetricsFollowsPoolEvents(t *testing.T) {
+	pool := NewPoolMetrics()
+
+	// Two workers open a connection each, one returns it
+	pool.Debugf(log.Pool, "1", "Trying to borrow connection from %s", []string{"localhost:7687"})
+	pool.Infof(log.Pool, "1", "Connecting to %s", "localhost:7687")
+	pool.Debugf(log.Pool, "1", "Trying to borrow connection from %s", []string{"localhost:7687"})
+	pool.Infof(log.Pool, "1", "Connecting to %s", "localhost:7687")
+	pool.Debugf(log.Pool, "1", "Returning connection to %s {alive:%t}", "localhost:7687", true)
+	assert.Equal(t, PoolSample{Elapsed: time.Second, InUse: 1, Idle: 1}, pool.Sample(time.Second))
+
+	// A third has to wait, and gives up
+	pool.Debugf(log.Pool, "1", "Trying to borrow connection from %s", []string{"localhost:7687"})
+	pool.Warnf(log.Pool, "1", "Borrow queued")
+	pool.Warnf(log.Pool, "1", "Borrow time-out")
+	// Events from other parts of the driver are ignored
+	pool.Infof(log.Session, "2", "Trying to borrow connection")
+	assert.Equal(t, PoolSample{Elapsed: 2 * time.Second, InUse: 1, Idle: 1}, pool.Sample(2*time.Second))
+
+	result := pool.Result()
+	assert.Equal(t, int64(2), result.PeakInUse)
+	assert.Equal(t, int64(1), result.Queued)
+	assert.Equal(t, int64(1), result.TimedOut)
+	assert.Len(t, result.Samples, 2)
+
+	pool.ResetCounters()
+	result = pool.Result()
+	assert.Equal(t, int64(1), result.PeakInUse)
+	assert.Equal(t, int64(0), result.Queued)
+	assert.Len(t, result.Samples, 0)
+}
+
+func TestPoolMetricsPassesLogsOn(t *testing.T) {
+	next := &countingLogger{}
+	config := &neo4j.Config{Log: next}
+	pool := NewPoolMetrics()
+	pool.Configure(config)
+	assert.Equal(t, pool, config.Log)
+
+	config.Log.Warnf(log.Pool, "1", "Borrow queued")
+	config.Log.Debugf(log.Pool, "1", "Trying to borrow connection from %s", []string{"localhost:7687"})
+	assert.Equal(t, 2, next.calls)
+	assert.Equal(t, int64(1), pool.Result().Queued)
+}
+
+func TestAcquisitionTimerMeasuresUntilFirstMessage(t *testing.T) {
+	now := time.Unix(0, 0)
+	timer := &acquisitionTimer{now: func() time.Time { return now }}
+
+	timer.begin()
+	now = now.Add(5 * time.Millisecond)
+	timer.LogClientMessage("bolt-1", "BEGIN %s", nil)
+	now = now.Add(time.Millisecond)
+	timer.LogClientMessage("bolt-1", "RUN %q %s %s", "RETURN 1", nil, nil)
+
+	// Messages without a borrow before them, like a COMMIT, aren't counted
+	timer.LogClientMessage("bolt-1", "COMMIT")
+
+	assert.Equal(t, []time.Duration{5 * time.Millisecond}, timer.take())
+	assert.Empty(t, timer.take())
+}
+
+func TestWritePoolReport(t *testing.T) {
+	result := NewResult("neo4j", "")
+	for _, wait := range []int64{100, 200, 30000} {
+		assert.NoError(t, result.AcquisitionWaits.RecordValue(wait))
+	}
+	result.Pool = &PoolResult{
+		Samples:   []PoolSample{{Elapsed: time.Second, InUse: 4, Idle: 0}, {Elapsed: 2 * time.Second, InUse: 2, Idle: 2}},
+		PeakInUse: 4,
+		Queued:    3,
+	}
+
+	s := strings.Builder{}
+	writePoolReport(result, &s)
+
+	assert.Equal(t, `-- Connection pool --
+
+  Acquisition wait: P50: 0.200ms, P99: 30.015ms, P99.9: 30.015ms, Max: 30.015ms
+  Peak in use: 4 connections
+  Mean in use: 3.0, mean idle: 1.0, least idle: 0; over 2 progress reports
+  3 transactions waited for a connection to free up, 0 gave up waiting; the pool was the bottleneck, not the database, for those
+
+`, s.String())
+}
+
+type countingLogger struct {
+	calls int
+}
+
+func (l *countingLogger) Error(name string, id string, err error) { l.calls++ }
+func (l *countingLogger) Warnf(name string, id string, msg string, args ...interface{}) {
+	l.calls++
+}
+func (l *countingLogger) Infof(name string, id string, msg string, args ...interface{}) {
+	l.calls++
+}
+func (l *countingLogger) Debugf(name string, id string, msg string, args ...interface{}) {
+	l.calls++
+}
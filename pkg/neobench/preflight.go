@@ -0,0 +1,174 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// What preflight found out about a script, see WorkloadPreflight
+type PreflightResult struct {
+	ScriptName string
+	// True if every statement in the script is read-only
+	Readonly bool
+	// Names of the parameters the script's statements are sent with, sorted
+	Parameters []string
+	// Rows the planner estimates each operator will handle, summed over all operators in all statements; a rough
+	// measure of how much work the script is, for spotting scripts that will dominate the run
+	EstimatedRows float64
+	// Operators in the statements' plans that scan all nodes or relationships, or all of a label or type
+	Scans []PlanScan
+}
+
+// An operator that goes through everything of some kind rather than looking things up, see planScans
+type PlanScan struct {
+	Query    string
+	Operator string
+	// What is scanned, like "n:Person", as far as the server says
+	Details       string
+	EstimatedRows float64
+}
+
+// Runs WorkloadPreflight on all the given scripts at once; results are in the same order as the scripts. Rather
+// than stopping at the first script that fails, this returns an error that lists every failure.
+func PreflightScripts(driver neo4j.Driver, dbName string, scripts []Script, vars map[string]interface{},
+	csvLoader *CsvLoader, ldbcIds *LDBCIdLoader) ([]PreflightResult, error) {
+	results := make([]PreflightResult, len(scripts))
+	errs := make([]error, len(scripts))
+	var wg sync.WaitGroup
+	for i, script := range scripts {
+		wg.Add(1)
+		go func(i int, script Script) {
+			defer wg.Done()
+			results[i], errs[i] = WorkloadPreflight(driver, dbName, script, vars, csvLoader, ldbcIds)
+		}(i, script)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 1 {
+		return results, failed[0]
+	}
+	if len(failed) > 1 {
+		messages := make([]string, 0, len(failed))
+		for _, err := range failed {
+			messages = append(messages, err.Error())
+		}
+		return results, fmt.Errorf("%d of %d scripts failed preflight checks:\n  %s", len(failed), len(scripts),
+			strings.Join(messages, "\n  "))
+	}
+	return results, nil
+}
+
+// Writes a table of the given preflight results, one script per row
+func WritePreflightSummary(results []PreflightResult, w io.Writer) error {
+	s := strings.Builder{}
+	s.WriteString("Preflight:\n")
+	for _, result := range results {
+		mode := "write"
+		if result.Readonly {
+			mode = "read"
+		}
+		params := "-"
+		if len(result.Parameters) > 0 {
+			params = strings.Join(result.Parameters, ", ")
+		}
+		s.WriteString(fmt.Sprintf("  [%s]: %s, estimated rows %.0f, parameters: %s\n", result.ScriptName, mode,
+			result.EstimatedRows, params))
+	}
+	_, err := fmt.Fprint(w, s.String())
+	return err
+}
+
+// Warns about statements whose plans scan at least minRows rows, which in a benchmark nearly always means an
+// index is missing; the results then measure the scan rather than the query. Writes nothing if there are none.
+func WriteScanWarnings(results []PreflightResult, minRows float64, w io.Writer) error {
+	s := strings.Builder{}
+	for _, result := range results {
+		for _, scan := range result.Scans {
+			if scan.EstimatedRows < minRows {
+				continue
+			}
+			details := ""
+			if scan.Details != "" {
+				details = fmt.Sprintf(" (%s)", scan.Details)
+			}
+			s.WriteString(fmt.Sprintf("  [%s]: %s over an estimated %.0f rows%s, in:\n    %s\n", result.ScriptName,
+				scan.Operator, scan.EstimatedRows, details, strings.ReplaceAll(strings.TrimSpace(scan.Query), "\n", "\n    ")))
+		}
+	}
+	if s.Len() == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "\n"+
+		"WARNING: these statements scan large parts of the graph, which usually means an index they need is missing:\n"+
+		"%s"+
+		"A benchmark of them mostly measures the scan. Create the indexes they need, or use --scan-warning-rows 0 if\n"+
+		"scanning is intended.\n\n", s.String())
+	return err
+}
+
+// Union of the parameter names sent with the given statements, sorted
+func statementParameters(statements []Statement) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, stmt := range statements {
+		for name := range stmt.Params {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sums the EstimatedRows argument of every operator in the plan; nil plans, like for queries the server doesn't
+// plan, count as 0
+func planEstimatedRows(plan neo4j.Plan) float64 {
+	if plan == nil {
+		return 0
+	}
+	rows := 0.0
+	if estimated, ok := plan.Arguments()["EstimatedRows"].(float64); ok {
+		rows = estimated
+	}
+	for _, child := range plan.Children() {
+		rows += planEstimatedRows(child)
+	}
+	return rows
+}
+
+// Operators that read every node or relationship, or every one with a label or type
+var scanOperators = []string{"AllNodesScan", "NodeByLabelScan", "AllRelationshipsScan", "RelationshipTypeScan"}
+
+// Finds the scan operators in the given plan of the given query
+func planScans(query string, plan neo4j.Plan) []PlanScan {
+	if plan == nil {
+		return nil
+	}
+	var scans []PlanScan
+	// Servers suffix operators with the database, as in NodeByLabelScan@neo4j, and some prefix them, as in
+	// DirectedAllRelationshipsScan or PartitionedAllNodesScan
+	operator := strings.Split(plan.Operator(), "@")[0]
+	for _, scan := range scanOperators {
+		if strings.HasSuffix(operator, scan) {
+			rows, _ := plan.Arguments()["EstimatedRows"].(float64)
+			details, _ := plan.Arguments()["Details"].(string)
+			scans = append(scans, PlanScan{Query: query, Operator: operator, Details: details, EstimatedRows: rows})
+		}
+	}
+	for _, child := range plan.Children() {
+		scans = append(scans, planScans(query, child)...)
+	}
+	return scans
+}
@@ -0,0 +1,133 @@
+package neobench
+
+import (
+	"bytes"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPreflightReportsAllFailures(t *testing.T) {
+	broken1, err := Parse("broken1", ":set a nosuchfunction()\nRETURN 1;", 1)
+	assert.NoError(t, err)
+	broken2, err := Parse("broken2", ":set a $nope\nRETURN 1;", 1)
+	assert.NoError(t, err)
+
+	_, err = PreflightScripts(&fakeDriver{}, "", []Script{broken1, broken2}, map[string]interface{}{}, NewCsvLoader(), nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "2 of 2 scripts failed preflight checks")
+	assert.Contains(t, err.Error(), "script 'broken1' failed")
+	assert.Contains(t, err.Error(), "script 'broken2' failed")
+}
+
+func TestWritePreflightSummary(t *testing.T) {
+	out := bytes.Buffer{}
+
+	err := WritePreflightSummary([]PreflightResult{
+		{ScriptName: "reads.script", Readonly: true, Parameters: []string{"id", "limit"}, EstimatedRows: 12.4},
+		{ScriptName: "-S #0"},
+	}, &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `Preflight:
+  [reads.script]: read, estimated rows 12, parameters: id, limit
+  [-S #0]: write, estimated rows 0, parameters: -
+`, out.String())
+}
+
+func TestPlanEstimatedRowsSumsOperators(t *testing.T) {
+	plan := &fakePlan{rows: 1, children: []neo4j.Plan{
+		&fakePlan{rows: 10, children: []neo4j.Plan{&fakePlan{rows: 100}}},
+		&fakePlan{rows: 1000},
+	}}
+	assert.Equal(t, 1111.0, planEstimatedRows(plan))
+	assert.Equal(t, 0.0, planEstimatedRows(nil))
+}
+
+func TestPlanScansFindsScanOperators(t *testing.T) {
+	plan := &fakePlan{operator: "ProduceResults@neo4j", rows: 1, children: []neo4j.Plan{
+		&fakePlan{operator: "Filter@neo4j", rows: 1, children: []neo4j.Plan{
+			&fakePlan{operator: "NodeByLabelScan@neo4j", rows: 50000, details: "n:Person"},
+		}},
+		&fakePlan{operator: "DirectedAllRelationshipsScan@neo4j", rows: 20},
+		&fakePlan{operator: "NodeIndexSeek@neo4j", rows: 1},
+	}}
+	assert.Equal(t, []PlanScan{
+		{Query: "q", Operator: "NodeByLabelScan", Details: "n:Person", EstimatedRows: 50000},
+		{Query: "q", Operator: "DirectedAllRelationshipsScan", EstimatedRows: 20},
+	}, planScans("q", plan))
+	assert.Empty(t, planScans("q", nil))
+}
+
+func TestWriteScanWarnings(t *testing.T) {
+	out := bytes.Buffer{}
+	results := []PreflightResult{{ScriptName: "lookup", Scans: []PlanScan{
+		{Query: "MATCH (n:Person {id: $id})\nRETURN n", Operator: "NodeByLabelScan", Details: "n:Person", EstimatedRows: 50000},
+		{Query: "MATCH (n:Tiny) RETURN n", Operator: "NodeByLabelScan", EstimatedRows: 10},
+	}}}
+
+	assert.NoError(t, WriteScanWarnings(results, 10000, &out))
+	assert.Equal(t, `
+WARNING: these statements scan large parts of the graph, which usually means an index they need is missing:
+  [lookup]: NodeByLabelScan over an estimated 50000 rows (n:Person), in:
+    MATCH (n:Person {id: $id})
+    RETURN n
+A benchmark of them mostly measures the scan. Create the indexes they need, or use --scan-warning-rows 0 if
+scanning is intended.
+
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, WriteScanWarnings(results, 100000, &out))
+	assert.Equal(t, "", out.String())
+}
+
+type fakePlan struct {
+	operator string
+	details  string
+	rows     float64
+	children []neo4j.Plan
+}
+
+func (p *fakePlan) Operator() string {
+	if p.operator == "" {
+		return "Fake"
+	}
+	return p.operator
+}
+func (p *fakePlan) Arguments() map[string]interface{} {
+	args := map[string]interface{}{"EstimatedRows": p.rows}
+	if p.details != "" {
+		args["Details"] = p.details
+	}
+	return args
+}
+func (p *fakePlan) Identifiers() []string  { return nil }
+func (p *fakePlan) Children() []neo4j.Plan { return p.children }
+
+func TestPreflightUsesReadSessions(t *testing.T) {
+	script, err := Parse("reads", "RETURN 1;", 1)
+	assert.NoError(t, err)
+	driver := &sessionRecordingDriver{fakeDriver: &fakeDriver{}}
+
+	_, err = PreflightScripts(driver, "replica", []Script{script}, map[string]interface{}{}, NewCsvLoader(), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []neo4j.SessionConfig{{AccessMode: neo4j.AccessModeRead, DatabaseName: "replica"}}, driver.sessions)
+}
+
+// Records the sessions opened, and runs no transactions
+type sessionRecordingDriver struct {
+	*fakeDriver
+	sessions []neo4j.SessionConfig
+}
+
+func (d *sessionRecordingDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	d.sessions = append(d.sessions, config)
+	return d
+}
+
+func (d *sessionRecordingDriver) ReadTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	return true, nil
+}
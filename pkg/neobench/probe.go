@@ -0,0 +1,148 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URL schemes to probe, in the order we'd recommend them; routing and certificate checks are preferred
+var probeSchemes = []string{"neo4j+s", "neo4j", "neo4j+ssc", "bolt+s", "bolt", "bolt+ssc"}
+
+// Ports to probe when the host has none; 7687 is the Bolt default, 7688 is what a second instance on the same
+// machine, or the routing port of some cluster setups, commonly uses
+var probePorts = []string{"7687", "7688"}
+
+// Outcome of trying to connect with one URL
+type ProbeResult struct {
+	URL string
+	// Connected, even if the credentials were then rejected
+	Connected bool
+	// The credentials were accepted too, so this URL works as is
+	Works bool
+	// Why it didn't work, empty if it did
+	Problem string
+}
+
+// Tries connecting to the given host with every combination of scheme and port, at once, to find which work;
+// for users who can't connect and don't know why. Results are in the order of probeSchemes, per port.
+func Probe(host, user, password string, timeout time.Duration) []ProbeResult {
+	urls := probeUrls(host)
+	results := make([]ProbeResult, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			results[i] = probeUrl(u, user, password, timeout)
+		}(i, u)
+	}
+	wg.Wait()
+	return results
+}
+
+func probeUrls(host string) []string {
+	host = strings.TrimSuffix(host, "/")
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	addresses := []string{host}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		addresses = addresses[:0]
+		for _, port := range probePorts {
+			addresses = append(addresses, net.JoinHostPort(host, port))
+		}
+	}
+	urls := make([]string, 0, len(addresses)*len(probeSchemes))
+	for _, address := range addresses {
+		for _, scheme := range probeSchemes {
+			urls = append(urls, fmt.Sprintf("%s://%s", scheme, address))
+		}
+	}
+	return urls
+}
+
+func probeUrl(u, user, password string, timeout time.Duration) ProbeResult {
+	result := ProbeResult{URL: u}
+	driver, err := neo4j.NewDriver(u, neo4j.BasicAuth(user, password, ""), func(c *neo4j.Config) {
+		c.SocketConnectTimeout = timeout
+		c.ConnectionAcquisitionTimeout = timeout
+	})
+	if err != nil {
+		result.Problem = err.Error()
+		return result
+	}
+	defer driver.Close()
+
+	err = checkConnectivity(driver, "")
+	if err == nil {
+		result.Connected, result.Works = true, true
+		return result
+	}
+	// Errors from the server, like rejected credentials, mean the connection itself worked
+	if _, result.Connected = err.(*neo4j.Neo4jError); result.Connected {
+		result.Problem = diagnoseConnectionError(err, u, user, "").Error()
+	} else {
+		result.Problem = strings.TrimPrefix(err.Error(), "ConnectivityError: ")
+	}
+	return result
+}
+
+// Writes which URLs worked and which didn't, and the neobench flags for the one we'd suggest
+func WriteProbeReport(results []ProbeResult, w io.Writer) error {
+	s := strings.Builder{}
+	var suggestion, direct, connected string
+	for _, result := range results {
+		status := "ok"
+		if !result.Works {
+			status = result.Problem
+		}
+		s.WriteString(fmt.Sprintf("  %-40s %s\n", result.URL, status))
+		if result.Works {
+			if flags, routed := probeFlags(result.URL); !routed && direct == "" {
+				direct = result.URL
+			} else if routed && suggestion == "" {
+				suggestion = flags
+			}
+		}
+		if result.Connected && connected == "" {
+			connected = result.URL
+		}
+	}
+	s.WriteString("\n")
+	switch {
+	case suggestion != "":
+		s.WriteString(fmt.Sprintf("Use %s\n", suggestion))
+	case direct != "":
+		s.WriteString(fmt.Sprintf("Only %s works, which connects to that one server without routing; neobench always connects "+
+			"with routing, so it can't connect this way. Check that the address is a server of the cluster, not a load "+
+			"balancer in front of it\n", direct))
+	case connected != "":
+		s.WriteString(fmt.Sprintf("%s connects, but the server rejected the request; see above for why\n", connected))
+	default:
+		s.WriteString("Nothing worked; check that the host is right, the server is running and that no firewall is in the way\n")
+	}
+	_, err := fmt.Fprint(w, s.String())
+	return err
+}
+
+// The neobench flags that connect the way the given URL does; neobench picks the scheme itself, from -e and
+// --no-check-certificates, see determineConnectionUrl, and always routes. False for bolt URLs, which connect to the
+// one server directly, and have no equivalent.
+func probeFlags(u string) (string, bool) {
+	parts := strings.SplitN(u, "://", 2)
+	address := fmt.Sprintf("neo4j://%s", parts[1])
+	switch parts[0] {
+	case "neo4j+s":
+		return fmt.Sprintf("--address %s -e true", address), true
+	case "neo4j+ssc":
+		return fmt.Sprintf("--address %s -e true --no-check-certificates", address), true
+	case "neo4j":
+		return fmt.Sprintf("--address %s -e false", address), true
+	}
+	return "", false
+}
@@ -0,0 +1,73 @@
+package neobench
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestProbeUrls(t *testing.T) {
+	assert.Equal(t, []string{
+		"neo4j+s://db:7687", "neo4j://db:7687", "neo4j+ssc://db:7687", "bolt+s://db:7687", "bolt://db:7687", "bolt+ssc://db:7687",
+		"neo4j+s://db:7688", "neo4j://db:7688", "neo4j+ssc://db:7688", "bolt+s://db:7688", "bolt://db:7688", "bolt+ssc://db:7688",
+	}, probeUrls("db"))
+	assert.Equal(t, []string{
+		"neo4j+s://db:9000", "neo4j://db:9000", "neo4j+ssc://db:9000", "bolt+s://db:9000", "bolt://db:9000", "bolt+ssc://db:9000",
+	}, probeUrls("neo4j://db:9000"))
+}
+
+func TestProbeReportsUnreachableHost(t *testing.T) {
+	// Find a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	results := Probe(address, "neo4j", "secret", time.Second)
+
+	assert.Equal(t, len(probeSchemes), len(results))
+	for _, result := range results {
+		assert.False(t, result.Connected, result.URL)
+		assert.Contains(t, result.Problem, "connection refused", result.URL)
+	}
+}
+
+func TestWriteProbeReportSuggestsFirstWorkingUrl(t *testing.T) {
+	out := bytes.Buffer{}
+
+	err := WriteProbeReport([]ProbeResult{
+		{URL: "neo4j+s://db:7687", Problem: "tls: first record does not look like a TLS handshake"},
+		{URL: "neo4j://db:7687", Connected: true, Works: true},
+		{URL: "bolt://db:7687", Connected: true, Works: true},
+	}, &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `  neo4j+s://db:7687                        tls: first record does not look like a TLS handshake
+  neo4j://db:7687                          ok
+  bolt://db:7687                           ok
+
+Use --address neo4j://db:7687 -e false
+`, out.String())
+}
+
+func TestWriteProbeReportGivesTheFlagsThatConnectTheSameWay(t *testing.T) {
+	out := bytes.Buffer{}
+	err := WriteProbeReport([]ProbeResult{
+		{URL: "neo4j+s://db:7687", Connected: true, Problem: "certificate signed by unknown authority"},
+		{URL: "neo4j+ssc://db:7687", Connected: true, Works: true},
+	}, &out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Use --address neo4j://db:7687 -e true --no-check-certificates\n")
+
+	// Without routing, there's nothing neobench can be told to do the same
+	out.Reset()
+	err = WriteProbeReport([]ProbeResult{
+		{URL: "neo4j://db:7687", Connected: true, Problem: "Unable to retrieve routing table"},
+		{URL: "bolt://db:7687", Connected: true, Works: true},
+	}, &out)
+	assert.NoError(t, err)
+	assert.NotContains(t, out.String(), "Use --address")
+	assert.Contains(t, out.String(), "Only bolt://db:7687 works, which connects to that one server without routing")
+}
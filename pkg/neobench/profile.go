@@ -0,0 +1,117 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"sort"
+	"strings"
+)
+
+// Server-side cost of the units of work of one script that ran with PROFILE, see --profile-sample
+type ProfileResult struct {
+	ScriptName string
+	// Number of units of work profiled
+	Units int64
+	// Totals over all profiled units, by operator
+	Operators map[string]*OperatorProfile
+}
+
+type OperatorProfile struct {
+	DbHits int64
+	Rows   int64
+}
+
+func newProfileResult(scriptName string) *ProfileResult {
+	return &ProfileResult{ScriptName: scriptName, Operators: make(map[string]*OperatorProfile)}
+}
+
+// Adds the operators of the given plan, of a statement of a profiled unit of work
+func (p *ProfileResult) addPlan(plan neo4j.ProfiledPlan) {
+	if plan == nil {
+		return
+	}
+	// Servers suffix operators with the database, as in NodeIndexSeek@neo4j
+	name := strings.Split(plan.Operator(), "@")[0]
+	operator, found := p.Operators[name]
+	if !found {
+		operator = &OperatorProfile{}
+		p.Operators[name] = operator
+	}
+	operator.DbHits += plan.DbHits()
+	operator.Rows += plan.Records()
+	for _, child := range plan.Children() {
+		p.addPlan(child)
+	}
+}
+
+func (p *ProfileResult) merge(other *ProfileResult) {
+	p.Units += other.Units
+	for name, operator := range other.Operators {
+		existing, found := p.Operators[name]
+		if !found {
+			existing = &OperatorProfile{}
+			p.Operators[name] = existing
+		}
+		existing.DbHits += operator.DbHits
+		existing.Rows += operator.Rows
+	}
+}
+
+// Mean db hits of the profiled units of work, over all their operators
+func (p *ProfileResult) DbHitsPerUnit() float64 {
+	if p.Units == 0 {
+		return 0
+	}
+	hits := int64(0)
+	for _, operator := range p.Operators {
+		hits += operator.DbHits
+	}
+	return float64(hits) / float64(p.Units)
+}
+
+// Prefixes the given query with PROFILE, unless it already says how it should run, which PROFILE can't be combined
+// with; those run as they are
+func profileQuery(query string) string {
+	trimmed := strings.ToUpper(strings.TrimSpace(query))
+	for _, prefix := range []string{"PROFILE", "EXPLAIN", "CYPHER", "USING"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return query
+		}
+	}
+	return "PROFILE " + query
+}
+
+// Db hits and rows per profiled unit of work, by operator, busiest first, for each script
+func writeProfileReport(result Result, s *strings.Builder) {
+	if len(result.Profiles) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.Profiles))
+	for name := range result.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.WriteString(fmt.Sprintf("-- Profile samples --\n\n"))
+	for _, name := range names {
+		profile := result.Profiles[name]
+		s.WriteString(fmt.Sprintf("  [%s]: %d units of work profiled, %.1f db hits per unit\n", name, profile.Units, profile.DbHitsPerUnit()))
+		operators := make([]string, 0, len(profile.Operators))
+		for operator := range profile.Operators {
+			operators = append(operators, operator)
+		}
+		sort.Slice(operators, func(i, j int) bool {
+			a, b := profile.Operators[operators[i]], profile.Operators[operators[j]]
+			if a.DbHits != b.DbHits {
+				return a.DbHits > b.DbHits
+			}
+			return operators[i] < operators[j]
+		})
+		units := float64(profile.Units)
+		for _, operator := range operators {
+			s.WriteString(fmt.Sprintf("    %s: %.1f db hits, %.1f rows per unit\n", operator,
+				float64(profile.Operators[operator].DbHits)/units, float64(profile.Operators[operator].Rows)/units))
+		}
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,63 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileQuery(t *testing.T) {
+	assert.Equal(t, "PROFILE MATCH (n) RETURN n", profileQuery("MATCH (n) RETURN n"))
+	// These can't take another PROFILE in front of them
+	assert.Equal(t, "CYPHER runtime=slotted MATCH (n) RETURN n", profileQuery("CYPHER runtime=slotted MATCH (n) RETURN n"))
+	assert.Equal(t, " explain RETURN 1", profileQuery(" explain RETURN 1"))
+}
+
+func TestWriteProfileReport(t *testing.T) {
+	plan := &fakeProfiledPlan{operator: "ProduceResults@neo4j", dbHits: 0, rows: 1, children: []neo4j.ProfiledPlan{
+		&fakeProfiledPlan{operator: "NodeIndexSeek@neo4j", dbHits: 4, rows: 1},
+	}}
+	profiled := uowOutcome{succeeded: true, profiled: true, statements: []statementOutcome{{summary: &fakeSummary{profile: plan}}}}
+
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("lookup", "", time.Millisecond, profiled))
+	assert.NoError(t, worker.record("lookup", "", time.Millisecond, profiled))
+	// Units of work that weren't profiled don't count
+	assert.NoError(t, worker.record("lookup", "", time.Millisecond, uowOutcome{succeeded: true}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+
+	s := strings.Builder{}
+	writeProfileReport(result, &s)
+	assert.Equal(t, `-- Profile samples --
+
+  [lookup]: 2 units of work profiled, 4.0 db hits per unit
+    NodeIndexSeek: 4.0 db hits, 1.0 rows per unit
+    ProduceResults: 0.0 db hits, 1.0 rows per unit
+
+`, s.String())
+}
+
+type fakeProfiledPlan struct {
+	operator string
+	dbHits   int64
+	rows     int64
+	children []neo4j.ProfiledPlan
+}
+
+func (p *fakeProfiledPlan) Operator() string                  { return p.operator }
+func (p *fakeProfiledPlan) Arguments() map[string]interface{} { return nil }
+func (p *fakeProfiledPlan) Identifiers() []string             { return nil }
+func (p *fakeProfiledPlan) DbHits() int64                     { return p.dbHits }
+func (p *fakeProfiledPlan) Records() int64                    { return p.rows }
+func (p *fakeProfiledPlan) Children() []neo4j.ProfiledPlan    { return p.children }
+
+// A result summary with only a profile
+type fakeSummary struct {
+	neo4j.ResultSummary
+	profile neo4j.ProfiledPlan
+}
+
+func (s *fakeSummary) Profile() neo4j.ProfiledPlan { return s.profile }
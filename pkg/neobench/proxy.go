@@ -0,0 +1,180 @@
+package neobench
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Returns a dial function that connects through the given proxy, either socks5://[user:pass@]host:port or
+// http://[user:pass@]host:port; HTTP proxies need to allow CONNECT to the database port
+func proxyDialer(proxyUrl string) (func(address string) (net.Conn, error), error) {
+	u, err := url.Parse(proxyUrl)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid --proxy '%s', expected socks5://host:port or http://host:port", proxyUrl)
+	}
+	switch u.Scheme {
+	case "socks5":
+		return func(address string) (net.Conn, error) {
+			return dialSocks5(u, address)
+		}, nil
+	case "http":
+		return func(address string) (net.Conn, error) {
+			return dialHttpConnect(u, address)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --proxy scheme '%s', use socks5 or http", u.Scheme)
+	}
+}
+
+// SOCKS5 CONNECT, as in RFC 1928, with username/password auth from RFC 1929 if the proxy URL has credentials
+func dialSocks5(proxy *url.URL, address string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid port in %s", address)
+	}
+	if len(host) > 255 {
+		return nil, fmt.Errorf("host name too long for SOCKS5: %s", host)
+	}
+
+	conn, err := dialTCP(proxy.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to proxy %s", proxy.Host)
+	}
+	fail := func(err error) (net.Conn, error) {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "SOCKS5 proxy %s failed to connect to %s", proxy.Host, address)
+	}
+
+	method := byte(0x00)
+	if proxy.User != nil {
+		method = 0x02
+	}
+	if _, err := conn.Write([]byte{0x05, 1, method}); err != nil {
+		return fail(err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fail(err)
+	}
+	if reply[0] != 0x05 || reply[1] != method {
+		return fail(fmt.Errorf("proxy does not accept %s", socks5MethodName(method)))
+	}
+	if method == 0x02 {
+		user := proxy.User.Username()
+		pass, _ := proxy.User.Password()
+		if len(user) > 255 || len(pass) > 255 {
+			return fail(fmt.Errorf("proxy username and password must be at most 255 bytes each"))
+		}
+		auth := []byte{0x01, byte(len(user))}
+		auth = append(auth, user...)
+		auth = append(auth, byte(len(pass)))
+		auth = append(auth, pass...)
+		if _, err := conn.Write(auth); err != nil {
+			return fail(err)
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return fail(err)
+		}
+		if reply[1] != 0x00 {
+			return fail(fmt.Errorf("proxy rejected the username and password"))
+		}
+	}
+
+	// CONNECT by domain name, so names are resolved on the proxy's side of the network
+	request := []byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}
+	request = append(request, host...)
+	request = append(request, 0, 0)
+	binary.BigEndian.PutUint16(request[len(request)-2:], uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return fail(err)
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fail(err)
+	}
+	if header[1] != 0x00 {
+		return fail(fmt.Errorf("proxy replied with error code %d", header[1]))
+	}
+	// Skip the address the proxy bound, which we have no use for
+	var boundLen int
+	switch header[3] {
+	case 0x01:
+		boundLen = net.IPv4len
+	case 0x04:
+		boundLen = net.IPv6len
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return fail(err)
+		}
+		boundLen = int(length[0])
+	default:
+		return fail(fmt.Errorf("proxy replied with unknown address type %d", header[3]))
+	}
+	if _, err := io.ReadFull(conn, make([]byte, boundLen+2)); err != nil {
+		return fail(err)
+	}
+	return conn, nil
+}
+
+func socks5MethodName(method byte) string {
+	if method == 0x02 {
+		return "username and password authentication"
+	}
+	return "connections without authentication"
+}
+
+func dialHttpConnect(proxy *url.URL, address string) (net.Conn, error) {
+	conn, err := dialTCP(proxy.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to proxy %s", proxy.Host)
+	}
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if proxy.User != nil {
+		pass, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + pass))
+		request += fmt.Sprintf("Proxy-Authorization: Basic %s\r\n", credentials)
+	}
+	request += "\r\n"
+	if _, err := io.WriteString(conn, request); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "HTTP proxy %s failed to connect to %s", proxy.Host, address)
+	}
+
+	// Read the response one byte at a time, so nothing the database sends after it ends up in a buffer
+	response, err := http.ReadResponse(bufio.NewReaderSize(&byteReader{conn}, 1), &http.Request{Method: "CONNECT"})
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "HTTP proxy %s failed to connect to %s", proxy.Host, address)
+	}
+	// The body of the response is the connection to the database, so it's left unread
+	if response.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("HTTP proxy %s failed to connect to %s: %s", proxy.Host, address, response.Status)
+	}
+	return conn, nil
+}
+
+// Reads at most one byte per call
+type byteReader struct {
+	r io.Reader
+}
+
+func (b *byteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return b.r.Read(p)
+}
@@ -0,0 +1,136 @@
+package neobench
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestProxyDialers(t *testing.T) {
+	echo := startEchoServer(t, nil)
+	socks := startFakeProxy(t, serveSocks5)
+	httpProxy := startFakeProxy(t, serveHttpConnect)
+
+	for _, proxyUrl := range []string{
+		fmt.Sprintf("socks5://%s", socks),
+		fmt.Sprintf("socks5://neo:secret@%s", socks),
+		fmt.Sprintf("http://%s", httpProxy),
+	} {
+		dial, err := proxyDialer(proxyUrl)
+		assert.NoError(t, err)
+		conn, err := dial(echo)
+		if !assert.NoError(t, err, proxyUrl) {
+			continue
+		}
+		_, err = conn.Write([]byte("hello"))
+		assert.NoError(t, err)
+		reply := make([]byte, 5)
+		_, err = io.ReadFull(conn, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(reply), proxyUrl)
+		_ = conn.Close()
+	}
+}
+
+func TestProxyDialerRejectsUnknownSchemes(t *testing.T) {
+	_, err := proxyDialer("https://proxy:3128")
+	assert.Error(t, err)
+	_, err = proxyDialer("bastion:1080")
+	assert.Error(t, err)
+}
+
+func startFakeProxy(t *testing.T, serve func(client net.Conn) (net.Conn, error)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			client, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer client.Close()
+				target, err := serve(client)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+				go func() { _, _ = io.Copy(target, client) }()
+				_, _ = io.Copy(client, target)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// Just enough of SOCKS5 to accept what dialSocks5 sends
+func serveSocks5(client net.Conn) (net.Conn, error) {
+	greeting := make([]byte, 3)
+	if _, err := io.ReadFull(client, greeting); err != nil {
+		return nil, err
+	}
+	if _, err := client.Write([]byte{0x05, greeting[2]}); err != nil {
+		return nil, err
+	}
+	if greeting[2] == 0x02 {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(client, header); err != nil {
+			return nil, err
+		}
+		user := make([]byte, header[1])
+		if _, err := io.ReadFull(client, user); err != nil {
+			return nil, err
+		}
+		passLen := make([]byte, 1)
+		if _, err := io.ReadFull(client, passLen); err != nil {
+			return nil, err
+		}
+		pass := make([]byte, passLen[0])
+		if _, err := io.ReadFull(client, pass); err != nil {
+			return nil, err
+		}
+		status := byte(0x00)
+		if string(user) != "neo" || string(pass) != "secret" {
+			status = 0x01
+		}
+		if _, err := client.Write([]byte{0x01, status}); err != nil {
+			return nil, err
+		}
+	}
+	request := make([]byte, 5)
+	if _, err := io.ReadFull(client, request); err != nil {
+		return nil, err
+	}
+	hostAndPort := make([]byte, int(request[4])+2)
+	if _, err := io.ReadFull(client, hostAndPort); err != nil {
+		return nil, err
+	}
+	host := string(hostAndPort[:request[4]])
+	port := binary.BigEndian.Uint16(hostAndPort[request[4]:])
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+	return target, err
+}
+
+func serveHttpConnect(client net.Conn) (net.Conn, error) {
+	request, err := http.ReadRequest(bufio.NewReader(client))
+	if err != nil {
+		return nil, err
+	}
+	target, err := net.Dial("tcp", request.Host)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+	return target, err
+}
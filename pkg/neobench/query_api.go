@@ -0,0 +1,381 @@
+package neobench
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Protocols workloads can run over; see ProtocolSchedule
+const (
+	ProtocolBolt = "bolt"
+	ProtocolHttp = "http"
+)
+
+// Runs units of work over the HTTP Query API, /db/{name}/query/v2, rather than over Bolt; used by --dual-protocol
+// to compare the two. Transactions are the explicit transactions of the Query API, opened with the first
+// statement, so, like with Bolt, a unit of work with one statement is one request plus a commit.
+type QueryApiClient struct {
+	// Ex: http://localhost:7474
+	baseUrl  string
+	user     string
+	password string
+	// Database to use for statements that don't name one; the Query API has no default database
+	DatabaseName string
+	client       *http.Client
+}
+
+func NewQueryApiClient(baseUrl, user, password, databaseName string, checkCertificates bool) (*QueryApiClient, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid --http-address '%s', expected http://host:port or https://host:port", baseUrl)
+	}
+	transport := &http.Transport{
+		// Each worker runs one request at a time, so it needs one connection; keeping it open, like Bolt does,
+		// keeps connection setup out of the comparison
+		MaxIdleConnsPerHost: 1024,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: !checkCertificates},
+	}
+	return &QueryApiClient{
+		baseUrl:      strings.TrimSuffix(baseUrl, "/"),
+		user:         user,
+		password:     password,
+		DatabaseName: databaseName,
+		client:       &http.Client{Transport: transport, Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Guesses the HTTP address of the server with the given Bolt URL, using the default HTTP and HTTPS ports
+func QueryApiAddress(boltUrl string) (string, error) {
+	u, err := url.Parse(boltUrl)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("can't tell the HTTP address from '%s', use --http-address", boltUrl)
+	}
+	if strings.HasSuffix(u.Scheme, "+s") || strings.HasSuffix(u.Scheme, "+ssc") {
+		return fmt.Sprintf("https://%s", net.JoinHostPort(u.Hostname(), "7473")), nil
+	}
+	return fmt.Sprintf("http://%s", net.JoinHostPort(u.Hostname(), "7474")), nil
+}
+
+// Runs a trivial query, so problems with the HTTP address or credentials are found before the benchmark starts
+func (c *QueryApiClient) VerifyConnectivity() error {
+	_, err := c.post(fmt.Sprintf("/db/%s/query/v2", url.PathEscape(c.DatabaseName)), "", &queryApiRequest{Statement: "RETURN 1"})
+	return errors.Wrapf(err, "failed to run a query over the HTTP Query API at %s", c.baseUrl)
+}
+
+// An open Query API transaction
+type queryApiTx struct {
+	path string
+	// Clustered servers route requests of a transaction to the server that has it by this header
+	affinity string
+}
+
+type queryApiRequest struct {
+	Statement  string                 `json:"statement"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	AccessMode string                 `json:"accessMode,omitempty"`
+}
+
+type queryApiResponse struct {
+	Transaction *struct {
+		Id string `json:"id"`
+	} `json:"transaction"`
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Opens a transaction by running its first statement
+func (c *QueryApiClient) begin(databaseName string, s Statement, readonly bool) (*queryApiTx, error) {
+	path := fmt.Sprintf("/db/%s/query/v2/tx", url.PathEscape(c.database(databaseName)))
+	res, err := c.post(path, "", c.request(s, readonly))
+	if err != nil {
+		return nil, err
+	}
+	if res.response.Transaction == nil || res.response.Transaction.Id == "" {
+		return nil, fmt.Errorf("the server did not open a transaction at %s%s", c.baseUrl, path)
+	}
+	return &queryApiTx{
+		path:     fmt.Sprintf("%s/%s", path, url.PathEscape(res.response.Transaction.Id)),
+		affinity: res.affinity,
+	}, nil
+}
+
+func (c *QueryApiClient) run(tx *queryApiTx, s Statement) error {
+	_, err := c.post(tx.path, tx.affinity, c.request(s, false))
+	return err
+}
+
+func (c *QueryApiClient) commit(tx *queryApiTx) error {
+	_, err := c.post(tx.path+"/commit", tx.affinity, nil)
+	return err
+}
+
+func (c *QueryApiClient) rollback(tx *queryApiTx) {
+	req, err := http.NewRequest(http.MethodDelete, c.baseUrl+tx.path, nil)
+	if err != nil {
+		return
+	}
+	c.authorize(req, tx.affinity)
+	if res, err := c.client.Do(req); err == nil {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+}
+
+// Runs the statement in a transaction of its own
+func (c *QueryApiClient) autocommit(databaseName string, s Statement, readonly bool) error {
+	_, err := c.post(fmt.Sprintf("/db/%s/query/v2", url.PathEscape(c.database(databaseName))), "", c.request(s, readonly))
+	return err
+}
+
+func (c *QueryApiClient) database(databaseName string) string {
+	if databaseName == "" {
+		return c.DatabaseName
+	}
+	return databaseName
+}
+
+func (c *QueryApiClient) request(s Statement, readonly bool) *queryApiRequest {
+	req := &queryApiRequest{Statement: s.Query, Parameters: s.Params}
+	if readonly {
+		req.AccessMode = "READ"
+	}
+	return req
+}
+
+type queryApiResult struct {
+	response queryApiResponse
+	affinity string
+}
+
+// Sends the request and reads the whole response; errors the server reports are returned as Neo4jErrors, so
+// they're grouped and shown the same way as errors over Bolt
+func (c *QueryApiClient) post(path, affinity string, body *queryApiRequest) (queryApiResult, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return queryApiResult{}, errors.Wrapf(err, "failed to encode parameters")
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseUrl+path, bytes.NewReader(payload))
+	if err != nil {
+		return queryApiResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req, affinity)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return queryApiResult{}, err
+	}
+	defer res.Body.Close()
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return queryApiResult{}, errors.Wrapf(err, "failed to read response from %s", req.URL)
+	}
+
+	result := queryApiResult{affinity: res.Header.Get("neo4j-cluster-affinity")}
+	if result.affinity == "" {
+		result.affinity = affinity
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &result.response); err != nil && res.StatusCode < 300 {
+			return result, errors.Wrapf(err, "unexpected response from %s", req.URL)
+		}
+	}
+	if len(result.response.Errors) > 0 {
+		first := result.response.Errors[0]
+		return result, &neo4j.Neo4jError{Code: first.Code, Msg: first.Message}
+	}
+	if res.StatusCode >= 300 {
+		return result, fmt.Errorf("%s returned %s", req.URL, res.Status)
+	}
+	return result, nil
+}
+
+func (c *QueryApiClient) authorize(req *http.Request, affinity string) {
+	req.SetBasicAuth(c.user, c.password)
+	if affinity != "" {
+		req.Header.Set("neo4j-cluster-affinity", affinity)
+	}
+}
+
+// Runs the unit of work over the Query API; like runUnit, failures of the unit of work are reported in the
+// outcome, and the returned error is only set if the script could not be evaluated. Statements run in
+// transactions the same way they do in segmented units of work over Bolt: a new transaction whenever the database
+// changes, statements marked `:autocommit` in transactions of their own.
+func (w *Worker) runUnitOverQueryApi(uow *UnitOfWork) (uowOutcome, error) {
+	client := w.queryApi
+	var statements []statementOutcome
+	var tx *queryApiTx
+	var txDatabase string
+	measure := func(s Statement, run func() error) error {
+		start := w.now()
+		w.injectLatency()
+		err := run()
+		statements = append(statements, statementOutcome{
+			databaseName: client.database(uow.database(s)),
+			latency:      w.now().Sub(start),
+			succeeded:    err == nil,
+		})
+		return err
+	}
+
+	err := uow.Run(func(s Statement) error {
+		// Read backs run like any other read; the Query API has no read sessions to check for stale reads with
+		autocommit := s.Autocommit || uow.Autocommit || s.Readback
+		database := uow.database(s)
+		if tx != nil && (autocommit || database != txDatabase) {
+			err := client.commit(tx)
+			tx = nil
+			if err != nil {
+				return err
+			}
+		}
+		return measure(s, func() error {
+			if autocommit {
+				return client.autocommit(database, s, uow.Readonly || s.Readback)
+			}
+			if tx == nil {
+				var err error
+				tx, err = client.begin(database, s, uow.Readonly)
+				txDatabase = database
+				return err
+			}
+			return client.run(tx, s)
+		})
+	})
+	if err == nil && tx != nil {
+		err = client.commit(tx)
+		tx = nil
+	}
+	if tx != nil {
+		client.rollback(tx)
+	}
+
+	if scriptErr, ok := err.(*ScriptError); ok {
+		return uowOutcome{}, errors.Wrapf(scriptErr.Err, "failed to evaluate script '%s'", uow.ScriptName)
+	}
+	if err != nil {
+		return uowOutcome{
+			succeeded:    false,
+			failureGroup: groupError(err),
+			err:          err,
+			statements:   statements,
+			protocol:     ProtocolHttp,
+		}, nil
+	}
+	return uowOutcome{succeeded: true, statements: statements, protocol: ProtocolHttp}, nil
+}
+
+// Alternates between Bolt and the Query API, in phases of equal length starting with Bolt, for --dual-protocol
+type ProtocolSchedule struct {
+	Start       time.Time
+	PhaseLength time.Duration
+}
+
+// The protocol to use at the given time
+func (s ProtocolSchedule) At(t time.Time) string {
+	if int64(t.Sub(s.Start)/s.PhaseLength)%2 == 0 {
+		return ProtocolBolt
+	}
+	return ProtocolHttp
+}
+
+// Time spent on each protocol in a run of the given length
+func (s ProtocolSchedule) Durations(elapsed time.Duration) map[string]time.Duration {
+	phases := int64(elapsed / s.PhaseLength)
+	remainder := elapsed - time.Duration(phases)*s.PhaseLength
+	bolt := time.Duration((phases+1)/2) * s.PhaseLength
+	http := time.Duration(phases/2) * s.PhaseLength
+	if phases%2 == 0 {
+		bolt += remainder
+	} else {
+		http += remainder
+	}
+	return map[string]time.Duration{ProtocolBolt: bolt, ProtocolHttp: http}
+}
+
+// Results of the transactions that ran over one protocol, see ProtocolSchedule
+type ProtocolResult struct {
+	Protocol string
+	// Time spent on this protocol, over all its phases
+	Duration time.Duration
+	Scripts  map[string]*ScriptResult
+}
+
+// Sets the duration and rates of each protocol, given how long the run was
+func (s ProtocolSchedule) Complete(result *Result, elapsed time.Duration) {
+	durations := s.Durations(elapsed)
+	for i := range result.Protocols {
+		protocol := &result.Protocols[i]
+		protocol.Duration = durations[protocol.Protocol]
+		for _, script := range protocol.Scripts {
+			if protocol.Duration > 0 {
+				script.Rate = float64(script.Succeeded+script.Failed) / protocol.Duration.Seconds()
+			}
+		}
+	}
+}
+
+// Bolt and Query API results side by side, per script, with how much slower the Query API was
+func writeProtocolReport(result Result, latencies bool, s *strings.Builder) {
+	if len(result.Protocols) == 0 {
+		return
+	}
+	byProtocol := make(map[string]ProtocolResult)
+	seen := make(map[string]bool)
+	var names []string
+	for _, protocol := range result.Protocols {
+		byProtocol[protocol.Protocol] = protocol
+		for name := range protocol.Scripts {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	s.WriteString(fmt.Sprintf("-- Protocols --\n\n"))
+	unit := result.LatencyUnit
+	for _, name := range names {
+		s.WriteString(fmt.Sprintf("  [%s]:\n", name))
+		var bolt *ScriptResult
+		if protocol, found := byProtocol[ProtocolBolt]; found {
+			bolt = protocol.Scripts[name]
+		}
+		for _, protocolName := range []string{ProtocolBolt, ProtocolHttp} {
+			script := byProtocol[protocolName].Scripts[name]
+			if script == nil {
+				continue
+			}
+			line := fmt.Sprintf("    %s: %.3f transactions per second, %d failed", protocolName, script.Rate, script.Failed)
+			if latencies && script.Succeeded > 0 {
+				line += fmt.Sprintf(", P50: %s, P99: %s", unit.Format(float64(script.Latencies.ValueAtQuantile(50))),
+					unit.Format(float64(script.Latencies.ValueAtQuantile(99))))
+				if protocolName == ProtocolHttp && bolt != nil && bolt.Succeeded > 0 {
+					line += fmt.Sprintf(" (%s, %s vs bolt)",
+						unit.FormatDelta(float64(script.Latencies.ValueAtQuantile(50)-bolt.Latencies.ValueAtQuantile(50))),
+						unit.FormatDelta(float64(script.Latencies.ValueAtQuantile(99)-bolt.Latencies.ValueAtQuantile(99))))
+				}
+			}
+			s.WriteString(line + "\n")
+		}
+	}
+	s.WriteString("\n")
+}
@@ -0,0 +1,156 @@
+package neobench
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueryApiAddress(t *testing.T) {
+	for boltUrl, expected := range map[string]string{
+		"neo4j://localhost:7687":        "http://localhost:7474",
+		"bolt://db.example.com":         "http://db.example.com:7474",
+		"neo4j+s://db.example.com:7687": "https://db.example.com:7473",
+		"bolt+ssc://[::1]:7687":         "https://[::1]:7473",
+	} {
+		address, err := QueryApiAddress(boltUrl)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, address, boltUrl)
+	}
+}
+
+func TestRunUnitOverQueryApi(t *testing.T) {
+	server := newFakeQueryApi()
+	defer server.Close()
+	client, err := NewQueryApiClient(server.URL, "neo4j", "secret", "neo4j", true)
+	assert.NoError(t, err)
+	worker := NewWorker(nil, 0)
+	worker.SetDualProtocol(client, &ProtocolSchedule{Start: time.Now(), PhaseLength: time.Second})
+
+	uow := UnitOfWork{
+		ScriptName: "test",
+		Statements: []Statement{
+			{Query: "CREATE (n {id: $id})", Params: map[string]interface{}{"id": int64(1)}},
+			{Query: "MATCH (n) RETURN n"},
+			{Query: "RETURN 1", Autocommit: true},
+			{Query: "RETURN 2", Database: "other"},
+		},
+		Segmented: true,
+	}
+	outcome, err := worker.runUnitOverQueryApi(&uow)
+	assert.NoError(t, err)
+	assert.True(t, outcome.succeeded)
+	assert.Equal(t, ProtocolHttp, outcome.protocol)
+	assert.Len(t, outcome.statements, 4)
+	assert.Equal(t, "other", outcome.statements[3].databaseName)
+	assert.Equal(t, []string{
+		"POST /db/neo4j/query/v2/tx CREATE (n {id: $id}) map[id:1]",
+		"POST /db/neo4j/query/v2/tx/tx1 MATCH (n) RETURN n map[]",
+		"POST /db/neo4j/query/v2/tx/tx1/commit",
+		"POST /db/neo4j/query/v2 RETURN 1 map[]",
+		"POST /db/other/query/v2/tx RETURN 2 map[]",
+		"POST /db/other/query/v2/tx/tx2/commit",
+	}, server.requests)
+}
+
+func TestRunUnitOverQueryApiReportsServerErrors(t *testing.T) {
+	server := newFakeQueryApi()
+	defer server.Close()
+	client, err := NewQueryApiClient(server.URL, "neo4j", "secret", "neo4j", true)
+	assert.NoError(t, err)
+	worker := NewWorker(nil, 0)
+	worker.SetDualProtocol(client, &ProtocolSchedule{Start: time.Now(), PhaseLength: time.Second})
+
+	uow := UnitOfWork{
+		ScriptName: "test",
+		Statements: []Statement{{Query: "CREATE ()"}, {Query: "FAIL"}},
+	}
+	outcome, err := worker.runUnitOverQueryApi(&uow)
+	assert.NoError(t, err)
+	assert.False(t, outcome.succeeded)
+	assert.Equal(t, &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "Invalid input 'FAIL'"}, outcome.err)
+	// The transaction is rolled back
+	assert.Equal(t, "DELETE /db/neo4j/query/v2/tx/tx1", server.requests[len(server.requests)-1])
+}
+
+func TestProtocolSchedule(t *testing.T) {
+	start := time.Unix(0, 0)
+	schedule := ProtocolSchedule{Start: start, PhaseLength: 10 * time.Second}
+	assert.Equal(t, ProtocolBolt, schedule.At(start))
+	assert.Equal(t, ProtocolBolt, schedule.At(start.Add(9*time.Second)))
+	assert.Equal(t, ProtocolHttp, schedule.At(start.Add(10*time.Second)))
+	assert.Equal(t, ProtocolBolt, schedule.At(start.Add(25*time.Second)))
+
+	assert.Equal(t, map[string]time.Duration{ProtocolBolt: 20 * time.Second, ProtocolHttp: 15 * time.Second},
+		schedule.Durations(35*time.Second))
+	assert.Equal(t, map[string]time.Duration{ProtocolBolt: 10 * time.Second, ProtocolHttp: 5 * time.Second},
+		schedule.Durations(15*time.Second))
+}
+
+func TestWriteProtocolReport(t *testing.T) {
+	result := NewResult("neo4j", "")
+	bolt := NewWorkerResult(0)
+	assert.NoError(t, bolt.record("test", "", time.Millisecond, uowOutcome{succeeded: true, protocol: ProtocolBolt}))
+	assert.NoError(t, bolt.record("test", "", 2*time.Millisecond, uowOutcome{succeeded: true, protocol: ProtocolHttp}))
+	result.Add(bolt)
+	ProtocolSchedule{PhaseLength: time.Second}.Complete(&result, 2*time.Second)
+
+	s := strings.Builder{}
+	writeProtocolReport(result, true, &s)
+	assert.Equal(t, `-- Protocols --
+
+  [test]:
+    bolt: 1.000 transactions per second, 0 failed, P50: 1.000ms, P99: 1.000ms
+    http: 1.000 transactions per second, 0 failed, P50: 2.000ms, P99: 2.000ms (+1.000ms, +1.000ms vs bolt)
+
+`, s.String())
+}
+
+type fakeQueryApi struct {
+	*httptest.Server
+	mut      sync.Mutex
+	requests []string
+	nextTx   int
+}
+
+// Just enough of the Query API to check what requests neobench sends; the statement FAIL fails
+func newFakeQueryApi() *fakeQueryApi {
+	api := &fakeQueryApi{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.mut.Lock()
+		defer api.mut.Unlock()
+		if user, pass, ok := r.BasicAuth(); !ok || user != "neo4j" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req queryApiRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		entry := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+		if req.Statement != "" {
+			entry += fmt.Sprintf(" %s %v", req.Statement, req.Parameters)
+		}
+		api.requests = append(api.requests, entry)
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.Statement == "FAIL" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors": [{"code": "Neo.ClientError.Statement.SyntaxError", "message": "Invalid input 'FAIL'"}]}`))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/tx") {
+			api.nextTx++
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = fmt.Fprintf(w, `{"data": {"fields": [], "values": []}, "transaction": {"id": "tx%d"}}`, api.nextTx)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"fields": [], "values": []}}`))
+	}))
+	return api
+}
@@ -0,0 +1,168 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Steps a ramp is split into unless it says otherwise
+const rampDefaultSteps = 8
+
+// A step where the added clients each brought less than this share of the throughput that each client before them
+// did marks the knee; throughput stopped growing with clients at the step before it
+const rampKneeEfficiency = 0.5
+
+// Grows the number of clients over the run, set with --ramp, ex: 0..64/5m, so the point where the server stops
+// turning more clients into more throughput is found in one run. The ramp goes up in equal steps, each running for
+// an equal share of the duration and reported as a phase of its own; all clients connect before the run starts,
+// and each stays idle until its step.
+type ClientRamp struct {
+	From int
+	To   int
+	// How long the whole ramp takes, which is how long the run goes on for
+	Duration time.Duration
+	// Clients added at each step
+	Step int
+}
+
+// Parses ramps like "0..64/5m" or "0..64/5m,step=4"
+func ParseClientRamp(spec string) (ClientRamp, error) {
+	options := strings.Split(spec, ",")
+	parts := strings.SplitN(options[0], "/", 2)
+	bounds := strings.SplitN(parts[0], "..", 2)
+	if len(parts) != 2 || len(bounds) != 2 {
+		return ClientRamp{}, fmt.Errorf("invalid --ramp '%s', expected clients to start and end with and a duration, like 0..64/5m", spec)
+	}
+	from, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil || from < 0 {
+		return ClientRamp{}, fmt.Errorf("invalid --ramp '%s', clients to start with must be 0 or more, got '%s'", spec, bounds[0])
+	}
+	to, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	if err != nil || to <= from {
+		return ClientRamp{}, fmt.Errorf("invalid --ramp '%s', clients to end with must be more than to start with, got '%s'", spec, bounds[1])
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || duration <= 0 {
+		return ClientRamp{}, fmt.Errorf("invalid --ramp '%s', expected a duration like 90s or 5m, got '%s'", spec, parts[1])
+	}
+	ramp := ClientRamp{From: from, To: to, Duration: duration, Step: (to - from + rampDefaultSteps - 1) / rampDefaultSteps}
+	for _, option := range options[1:] {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != "step" {
+			return ClientRamp{}, fmt.Errorf("invalid --ramp '%s', unknown option '%s', expected step=<clients>", spec, option)
+		}
+		step, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || step <= 0 {
+			return ClientRamp{}, fmt.Errorf("invalid --ramp '%s', step must be a positive number of clients, got '%s'", spec, kv[1])
+		}
+		ramp.Step = step
+	}
+	return ramp, nil
+}
+
+// Number of clients at each step, in order; a step with no clients is left out, since it has nothing to measure
+func (r ClientRamp) Levels() []int {
+	var levels []int
+	for clients := r.From; ; clients += r.Step {
+		if clients >= r.To {
+			return append(levels, r.To)
+		}
+		if clients > 0 {
+			levels = append(levels, clients)
+		}
+	}
+}
+
+func (r ClientRamp) StepDuration() time.Duration {
+	return r.Duration / time.Duration(len(r.Levels()))
+}
+
+// The steps as run phases, named after their number of clients, ex: 8-clients
+func (r ClientRamp) RunPhases() []RunPhase {
+	levels := r.Levels()
+	phases := make([]RunPhase, 0, len(levels))
+	for _, clients := range levels {
+		phases = append(phases, RunPhase{Name: rampPhaseName(clients), Duration: r.StepDuration()})
+	}
+	return phases
+}
+
+// How long into the run the given client, counting from 0, starts running units of work
+func (r ClientRamp) JoinAfter(clientId int) time.Duration {
+	for i, clients := range r.Levels() {
+		if clientId < clients {
+			return time.Duration(i) * r.StepDuration()
+		}
+	}
+	return r.Duration
+}
+
+func rampPhaseName(clients int) string {
+	return fmt.Sprintf("%d-clients", clients)
+}
+
+type RampResult struct {
+	Steps []RampStep
+	// Clients at the step throughput stopped growing at; 0 if it grew throughout
+	Knee int
+}
+
+type RampStep struct {
+	Clients int
+	// Transactions per second, over all scripts
+	Rate   float64
+	Failed int64
+	// Latencies of all scripts together, in microseconds
+	Latencies *hdrhistogram.Histogram
+}
+
+// Throughput and latency of each step, from the phase results, and where throughput stopped growing; call after
+// Scripts#CompletePhases, which sets the rates of the phases
+func (r ClientRamp) Complete(phases []PhaseResult) *RampResult {
+	result := &RampResult{}
+	for _, clients := range r.Levels() {
+		for _, phase := range phases {
+			if phase.Name != rampPhaseName(clients) {
+				continue
+			}
+			combined := combinePhaseScripts(phase)
+			result.Steps = append(result.Steps, RampStep{Clients: clients, Rate: combined.Rate, Failed: combined.Failed,
+				Latencies: combined.Latencies})
+		}
+	}
+	for i := 1; i < len(result.Steps) && result.Knee == 0; i++ {
+		before, step := result.Steps[i-1], result.Steps[i]
+		perClient := before.Rate / float64(before.Clients)
+		if step.Rate-before.Rate < perClient*float64(step.Clients-before.Clients)*rampKneeEfficiency {
+			result.Knee = before.Clients
+		}
+	}
+	return result
+}
+
+// Throughput and p99 at each step of the ramp, and the step throughput stopped growing at
+func writeRampReport(result Result, s *strings.Builder) {
+	ramp := result.Ramp
+	if ramp == nil || len(ramp.Steps) == 0 {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Ramp --\n\n"))
+	for _, step := range ramp.Steps {
+		line := fmt.Sprintf("  %d clients: %.3f transactions per second", step.Clients, step.Rate)
+		if step.Latencies.TotalCount() > 0 {
+			line += fmt.Sprintf(", p99 %s", result.LatencyUnit.Format(float64(step.Latencies.ValueAtQuantile(99))))
+		}
+		if step.Failed > 0 {
+			line += fmt.Sprintf(", %d failed", step.Failed)
+		}
+		s.WriteString(line + "\n")
+	}
+	if ramp.Knee > 0 {
+		s.WriteString(fmt.Sprintf("  Throughput stopped growing with clients at %d clients; the clients added after that mostly added latency\n\n", ramp.Knee))
+	} else {
+		s.WriteString(fmt.Sprintf("  Throughput was still growing at the last step; ramp to more clients to find where it stops\n\n"))
+	}
+}
@@ -0,0 +1,120 @@
+package neobench
+
+import (
+	"github.com/codahale/hdrhistogram"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClientRamp(t *testing.T) {
+	ramp, err := ParseClientRamp("0..64/5m")
+	assert.NoError(t, err)
+	assert.Equal(t, ClientRamp{From: 0, To: 64, Duration: 5 * time.Minute, Step: 8}, ramp)
+	assert.Equal(t, []int{8, 16, 24, 32, 40, 48, 56, 64}, ramp.Levels())
+	assert.Equal(t, []RunPhase{{Name: "8-clients", Duration: 37500 * time.Millisecond}, {Name: "16-clients", Duration: 37500 * time.Millisecond}},
+		ramp.RunPhases()[:2])
+
+	ramp, err = ParseClientRamp("4..10/1m,step=4")
+	assert.NoError(t, err)
+	// The last step is cut short at the number of clients to end with
+	assert.Equal(t, []int{4, 8, 10}, ramp.Levels())
+	assert.Equal(t, 20*time.Second, ramp.StepDuration())
+
+	for _, invalid := range []string{"64", "0..64", "64..0/5m", "0..64/soon", "-1..4/1m", "0..64/5m,step=0", "0..64/5m,clients=2"} {
+		_, err := ParseClientRamp(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestClientsJoinAtTheirStep(t *testing.T) {
+	ramp := ClientRamp{From: 0, To: 6, Duration: 30 * time.Second, Step: 2}
+
+	assert.Equal(t, time.Duration(0), ramp.JoinAfter(0))
+	assert.Equal(t, time.Duration(0), ramp.JoinAfter(1))
+	assert.Equal(t, 10*time.Second, ramp.JoinAfter(2))
+	assert.Equal(t, 20*time.Second, ramp.JoinAfter(5))
+}
+
+func TestWorkerIdlesUntilItJoins(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	start := time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)
+	clock := &fakeSpaceTimeContinuum{currentTime: start}
+	driver := &fakeDriver{clock: clock, r: r, minLatency: time.Second, maxLatency: time.Second}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetJoinAfter(10 * time.Second)
+	wrk := newTestWorkload(r)
+	wrk.Start = start
+
+	result := w.RunBenchmark(wrk, "", 0, Burst{}, 2, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, start.Add(12*time.Second), clock.now())
+	// Rates are over the whole run, idle time included, so they add up with those of the clients that ran throughout
+	for _, script := range result.Scripts {
+		assert.InDelta(t, float64(script.Succeeded)/12.0, script.Rate, 0.0001)
+	}
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	w.SetJoinAfter(time.Hour)
+	wrk.Start = clock.now()
+	result = w.RunBenchmark(wrk, "", 0, Burst{}, 2, stopCh, NewResultRecorder(0))
+	assert.Empty(t, result.Scripts)
+}
+
+func TestWorkerJoinsCountingFromTheEndOfTheWarmup(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	driver := &warmingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Second, maxLatency: time.Second}}
+	barrier := NewReadyBarrier(1)
+	barrier.SetWarmup(10 * time.Second)
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+	w.SetReadyBarrier(barrier)
+	w.SetJoinAfter(5 * time.Second)
+
+	results := make(chan WorkerResult)
+	go func() {
+		results <- w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 2, make(chan struct{}), NewResultRecorder(0))
+	}()
+	assert.True(t, barrier.AwaitReady(nil))
+	// Setting up took a while, which the warmup and the join don't count
+	clock.sleep(time.Minute)
+	released := clock.now()
+	barrier.Release(released)
+
+	assert.NoError(t, (<-results).Error)
+	assert.Equal(t, released.Add(17*time.Second), clock.now())
+}
+
+func TestRampFindsWhereThroughputStopsGrowing(t *testing.T) {
+	ramp := ClientRamp{From: 0, To: 32, Duration: 4 * time.Minute, Step: 8}
+	phase := func(clients int, rate float64) PhaseResult {
+		latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+		assert.NoError(t, latencies.RecordValue(int64(clients)*1000))
+		return PhaseResult{Name: rampPhaseName(clients), Scripts: map[string]*ScriptResult{
+			"reads": {ScriptName: "reads", Rate: rate, Succeeded: 1, Latencies: latencies},
+		}}
+	}
+
+	// Doubling the clients nearly doubles throughput, then 24 clients add only a little
+	result := Result{Ramp: ramp.Complete([]PhaseResult{phase(8, 100), phase(16, 190), phase(24, 210), phase(32, 212)})}
+
+	assert.Equal(t, 16, result.Ramp.Knee)
+	s := &strings.Builder{}
+	writeRampReport(result, s)
+	assert.Equal(t, `-- Ramp --
+
+  8 clients: 100.000 transactions per second, p99 8.003ms
+  16 clients: 190.000 transactions per second, p99 16.007ms
+  24 clients: 210.000 transactions per second, p99 24.015ms
+  32 clients: 212.000 transactions per second, p99 32.015ms
+  Throughput stopped growing with clients at 16 clients; the clients added after that mostly added latency
+
+`, s.String())
+
+	result.Ramp = ramp.Complete([]PhaseResult{phase(8, 100), phase(16, 200)})
+	assert.Equal(t, 0, result.Ramp.Knee)
+}
@@ -0,0 +1,122 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/codahale/hdrhistogram"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A step of a rate schedule fell behind when it ran less than this share of the rate it asked for
+const rateStepBehindShare = 0.95
+
+// Whether --rate is a schedule, like 100:60s,200:60s, rather than a single rate
+func IsRateSchedule(spec string) bool {
+	return strings.Contains(spec, ":")
+}
+
+// Parses a --rate schedule, like 100:60s,200:60s,400:60s, into a run phase per step, each at its total rate for its
+// duration, so latency mode steps through the rates in one run and each step is reported on its own. Steps are named
+// after their rate, ex: rate-200; a rate that comes back later gets its position too, ex: rate-100-3.
+func ParseRateSchedule(spec string) ([]RunPhase, error) {
+	steps := strings.Split(spec, ",")
+	phases := make([]RunPhase, 0, len(steps))
+	names := make(map[string]bool)
+	for i, step := range steps {
+		parts := strings.SplitN(step, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --rate '%s': expected steps of a rate and how long to hold it, like 100:60s,200:60s, got '%s'", spec, step)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid --rate '%s': rate must be a positive number of transactions per second, got '%s'", spec, parts[0])
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid --rate '%s': expected a duration like 60s or 5m, got '%s'", spec, parts[1])
+		}
+		name := fmt.Sprintf("rate-%g", rate)
+		if names[name] {
+			name = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		names[name] = true
+		phases = append(phases, RunPhase{Name: name, Duration: duration, Rate: rate})
+	}
+	return phases, nil
+}
+
+type RateScheduleResult struct {
+	Steps []RateStep
+}
+
+type RateStep struct {
+	Name string
+	// Total transactions per second the step asked for, and ran
+	Requested float64
+	Achieved  float64
+	Failed    int64
+	// Latencies of all scripts together, in microseconds
+	Latencies *hdrhistogram.Histogram
+}
+
+// Whether the step ran noticeably fewer transactions than it asked for, which in latency mode means the database
+// didn't keep up, and the backlog carried over into the next step
+func (s RateStep) FellBehind() bool {
+	return s.Achieved < s.Requested*rateStepBehindShare
+}
+
+// Requested and achieved rate and latency of each step, from the phase results; call after Scripts#CompletePhases,
+// which sets the rates of the phases
+func CompleteRateSchedule(steps []RunPhase, phases []PhaseResult) *RateScheduleResult {
+	result := &RateScheduleResult{}
+	for _, step := range steps {
+		for _, phase := range phases {
+			if phase.Name != step.Name {
+				continue
+			}
+			combined := combinePhaseScripts(phase)
+			result.Steps = append(result.Steps, RateStep{Name: step.Name, Requested: step.Rate, Achieved: combined.Rate,
+				Failed: combined.Failed, Latencies: combined.Latencies})
+		}
+	}
+	return result
+}
+
+// Rate, failures and latencies of all scripts of the given phase together
+func combinePhaseScripts(phase PhaseResult) *ScriptResult {
+	combined := &ScriptResult{ScriptName: phase.Name, Latencies: hdrhistogram.New(0, 60*60*1000000, 3)}
+	for _, script := range phase.Scripts {
+		combined.Rate += script.Rate
+		combined.Succeeded += script.Succeeded
+		combined.Failed += script.Failed
+		combined.Latencies.Merge(script.Latencies)
+	}
+	return combined
+}
+
+// Each step of the --rate schedule in a line, the rate it asked for against the rate it ran and the latency at it,
+// to read off the highest rate that held its latency target
+func writeRateScheduleReport(result Result, s *strings.Builder) {
+	schedule := result.RateSchedule
+	if schedule == nil || len(schedule.Steps) == 0 {
+		return
+	}
+	unit := result.LatencyUnit
+	s.WriteString(fmt.Sprintf("-- Rate schedule --\n\n"))
+	for _, step := range schedule.Steps {
+		line := fmt.Sprintf("  [%s]: %.3f of %.3f transactions per second", step.Name, step.Achieved, step.Requested)
+		if step.Latencies.TotalCount() > 0 {
+			line += fmt.Sprintf(", P50: %s, P99: %s, Max: %s", unit.Format(float64(step.Latencies.ValueAtQuantile(50))),
+				unit.Format(float64(step.Latencies.ValueAtQuantile(99))), unit.Format(float64(step.Latencies.Max())))
+		}
+		if step.Failed > 0 {
+			line += fmt.Sprintf(", %d failed", step.Failed)
+		}
+		if step.FellBehind() {
+			line += ", fell behind"
+		}
+		s.WriteString(line + "\n")
+	}
+	s.WriteString("\n")
+}
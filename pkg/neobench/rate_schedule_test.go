@@ -0,0 +1,69 @@
+package neobench
+
+import (
+	"github.com/codahale/hdrhistogram"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRateSchedule(t *testing.T) {
+	assert.True(t, IsRateSchedule("100:60s,200:60s"))
+	assert.False(t, IsRateSchedule("100"))
+
+	steps, err := ParseRateSchedule("100:60s,2.5:30s,100:1m")
+	assert.NoError(t, err)
+	assert.Equal(t, []RunPhase{
+		{Name: "rate-100", Duration: time.Minute, Rate: 100},
+		{Name: "rate-2.5", Duration: 30 * time.Second, Rate: 2.5},
+		{Name: "rate-100-3", Duration: time.Minute, Rate: 100},
+	}, steps)
+
+	for _, invalid := range []string{"100:60s,200", "fast:60s", "0:60s", "100:soon", "100:-1m"} {
+		_, err := ParseRateSchedule(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRateScheduleStepsThroughTheRates(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	steps, err := ParseRateSchedule("100:60s,200:60s,400:60s")
+	assert.NoError(t, err)
+	rates := NewPhaseRates(steps, steps[0].Rate, 4)
+	rates.now = clock.now
+	rates.Begin(clock.now())
+
+	assert.Equal(t, 100.0, rates.Rate())
+	clock.sleep(90 * time.Second)
+	assert.Equal(t, 200.0, rates.Rate())
+	clock.sleep(time.Minute)
+	assert.Equal(t, 400.0, rates.Rate())
+	assert.Equal(t, 10*time.Millisecond, rates.ClientInterval())
+}
+
+func TestRateScheduleReportPutsTheStepsSideBySide(t *testing.T) {
+	steps, err := ParseRateSchedule("100:60s,200:60s")
+	assert.NoError(t, err)
+	phase := func(name string, rate float64, latency int64, failed int64) PhaseResult {
+		latencies := hdrhistogram.New(0, 60*60*1000000, 3)
+		assert.NoError(t, latencies.RecordValue(latency))
+		return PhaseResult{Name: name, Scripts: map[string]*ScriptResult{
+			"reads":  {ScriptName: "reads", Rate: rate / 2, Succeeded: 1, Latencies: latencies},
+			"writes": {ScriptName: "writes", Rate: rate / 2, Succeeded: 1, Failed: failed, Latencies: latencies},
+		}}
+	}
+
+	result := Result{RateSchedule: CompleteRateSchedule(steps, []PhaseResult{phase("rate-100", 100, 1500, 0), phase("rate-200", 150, 900000, 3)})}
+
+	assert.False(t, result.RateSchedule.Steps[0].FellBehind())
+	assert.True(t, result.RateSchedule.Steps[1].FellBehind())
+	s := &strings.Builder{}
+	writeRateScheduleReport(result, s)
+	assert.Equal(t, `-- Rate schedule --
+
+  [rate-100]: 100.000 of 100.000 transactions per second, P50: 1.500ms, P99: 1.500ms, Max: 1.500ms
+  [rate-200]: 150.000 of 200.000 transactions per second, P50: 900.095ms, P99: 900.095ms, Max: 900.095ms, 3 failed, fell behind
+
+`, s.String())
+}
@@ -0,0 +1,88 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyScriptsRunInReadSessionsCarryingBookmarksOver(t *testing.T) {
+	driver, w := newRoutingWorker()
+	read, write := newRoutingScripts(t)
+	sessions := newWorkerSessions(driver, "", &acquisitionTimer{now: w.now})
+
+	for _, script := range []Script{write, read, read, write} {
+		uow := script.NewUnitOfWork(ScriptContext{Script: script, Rand: rand.New(rand.NewSource(1))})
+		outcome, err := w.runAttempt(sessions, &uow)
+		assert.NoError(t, err)
+		assert.True(t, outcome.succeeded)
+	}
+
+	assert.Equal(t, []neo4j.SessionConfig{
+		{AccessMode: neo4j.AccessModeWrite, FetchSize: neo4j.FetchAll, BoltLogger: sessions.acquisition},
+		{AccessMode: neo4j.AccessModeRead, Bookmarks: []string{"bookmark-1"}, FetchSize: neo4j.FetchAll, BoltLogger: sessions.acquisition},
+		{AccessMode: neo4j.AccessModeWrite, Bookmarks: []string{"bookmark-3"}, FetchSize: neo4j.FetchAll, BoltLogger: sessions.acquisition},
+	}, driver.sessions)
+	assert.Equal(t, []string{"write", "read", "read", "write"}, driver.transactions)
+}
+
+func TestReadsOnLeaderRunsReadOnlyScriptsAsWrites(t *testing.T) {
+	driver, w := newRoutingWorker()
+	w.SetReadsOnLeader(true)
+	read, _ := newRoutingScripts(t)
+	sessions := newWorkerSessions(driver, "", &acquisitionTimer{now: w.now})
+
+	for i := 0; i < 2; i++ {
+		uow := read.NewUnitOfWork(ScriptContext{Script: read, Rand: rand.New(rand.NewSource(1))})
+		_, err := w.runAttempt(sessions, &uow)
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, driver.sessions, 1)
+	assert.Equal(t, neo4j.AccessModeWrite, driver.sessions[0].AccessMode)
+	assert.Equal(t, []string{"write", "write"}, driver.transactions)
+}
+
+func newRoutingScripts(t *testing.T) (Script, Script) {
+	read, err := Parse("read", "MATCH (n) RETURN n;", 1)
+	assert.NoError(t, err)
+	read.Readonly = true
+	write, err := Parse("write", "CREATE (n);", 1)
+	assert.NoError(t, err)
+	return read, write
+}
+
+func newRoutingWorker() (*routingDriver, *Worker) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &routingDriver{fakeDriver: &fakeDriver{clock: clock, r: rand.New(rand.NewSource(1337)), minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	return driver, &Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+}
+
+// Records the sessions opened and the kind of each transaction, which each leave a bookmark behind
+type routingDriver struct {
+	*fakeDriver
+	sessions     []neo4j.SessionConfig
+	transactions []string
+}
+
+func (d *routingDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	d.sessions = append(d.sessions, config)
+	return d
+}
+
+func (d *routingDriver) LastBookmark() string {
+	return fmt.Sprintf("bookmark-%d", len(d.transactions))
+}
+
+func (d *routingDriver) ReadTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	d.transactions = append(d.transactions, "read")
+	return work(&fakeTransaction{clock: d.clock, latency: d.minLatency})
+}
+
+func (d *routingDriver) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	d.transactions = append(d.transactions, "write")
+	return work(&fakeTransaction{clock: d.clock, latency: d.minLatency})
+}
@@ -0,0 +1,47 @@
+package neobench
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Transaction metadata keys neobench sets on every transaction, see RunTag
+const (
+	ScenarioMetadataKey = "neobenchScenario"
+	RunMetadataKey      = "neobenchRun"
+)
+
+// Identifies a benchmark run to the server; this is sent as transaction metadata and as the driver user agent, so
+// entries in the query log can be joined back to the run - and to other runs of the same scenario - that caused them.
+type RunTag struct {
+	// Short stable hash of the scenario description; runs with identical settings get the same hash
+	ScenarioHash string
+	// Unique per invocation of neobench
+	RunId string
+}
+
+func NewRunTag(scenario string, now time.Time) RunTag {
+	return RunTag{
+		ScenarioHash: ScenarioHash(scenario),
+		RunId:        strconv.FormatInt(now.UnixNano(), 36),
+	}
+}
+
+func ScenarioHash(scenario string) string {
+	sum := sha256.Sum256([]byte(scenario))
+	return hex.EncodeToString(sum[:6])
+}
+
+func (t RunTag) UserAgent() string {
+	return fmt.Sprintf("neobench/%s (scenario=%s; run=%s)", Version, t.ScenarioHash, t.RunId)
+}
+
+func (t RunTag) TxMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		ScenarioMetadataKey: t.ScenarioHash,
+		RunMetadataKey:      t.RunId,
+	}
+}
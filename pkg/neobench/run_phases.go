@@ -0,0 +1,156 @@
+package neobench
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A named period of the run, set with --phase; phases run one after the other, and each is reported on its own,
+// so a warmup, a measurement and a spike can run in one invocation rather than one neobench each, ex: spike:1m,rate=2x
+type RunPhase struct {
+	Name     string
+	Duration time.Duration
+	// Total rate in latency mode during the phase; 0 keeps --rate
+	Rate float64
+	// Multiplies --rate during the phase, ex: 2 for rate=2x; 0 unless the rate was given like that
+	RateFactor float64
+}
+
+// Parses phases like "measure:10m" or "spike:1m,rate=2x", in the order they run
+func ParseRunPhases(specs []string) ([]RunPhase, error) {
+	phases := make([]RunPhase, 0, len(specs))
+	names := make(map[string]bool)
+	for _, spec := range specs {
+		phase, err := parseRunPhase(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --phase '%s': %s", spec, err)
+		}
+		if names[phase.Name] {
+			return nil, fmt.Errorf("invalid --phase '%s': there is already a phase named %s", spec, phase.Name)
+		}
+		names[phase.Name] = true
+		phases = append(phases, phase)
+	}
+	return phases, nil
+}
+
+func parseRunPhase(spec string) (RunPhase, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return RunPhase{}, fmt.Errorf("expected a name and a duration, like measure:10m or spike:1m,rate=2x")
+	}
+	options := strings.Split(parts[1], ",")
+	duration, err := time.ParseDuration(strings.TrimSpace(options[0]))
+	if err != nil || duration <= 0 {
+		return RunPhase{}, fmt.Errorf("expected a duration like 90s or 10m, got '%s'", options[0])
+	}
+	phase := RunPhase{Name: strings.TrimSpace(parts[0]), Duration: duration}
+	for _, option := range options[1:] {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != "rate" {
+			return RunPhase{}, fmt.Errorf("unknown option '%s', expected rate=<transactions per second> or rate=<factor>x", option)
+		}
+		raw := strings.TrimSpace(kv[1])
+		factor := strings.HasSuffix(raw, "x")
+		value, err := strconv.ParseFloat(strings.TrimSuffix(raw, "x"), 64)
+		if err != nil || value <= 0 {
+			return RunPhase{}, fmt.Errorf("rate must be a positive number, or a factor of --rate like 2x, got '%s'", raw)
+		}
+		if factor {
+			phase.RateFactor, phase.Rate = value, 0
+		} else {
+			phase.Rate, phase.RateFactor = value, 0
+		}
+	}
+	return phase, nil
+}
+
+// Whether any phase changes the rate, which only latency mode has
+func RunPhasesChangeRate(phases []RunPhase) bool {
+	for _, phase := range phases {
+		if phase.Rate > 0 || phase.RateFactor > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// How long all phases take together, which is how long the run goes on for
+func RunPhasesDuration(phases []RunPhase) time.Duration {
+	total := time.Duration(0)
+	for _, phase := range phases {
+		total += phase.Duration
+	}
+	return total
+}
+
+// The phases as weight phases, each with the scripts' own weights, so results are collected and reported per
+// phase the same way they are for --weight-phase
+func RunPhasesAsWeightPhases(phases []RunPhase, scripts []Script) []WeightPhase {
+	weights := make(map[string]float64)
+	lookup := &WeightedRandom{}
+	for _, script := range scripts {
+		weights[script.Name] += script.Weight
+		lookup.Add(script, int(script.Weight*10000))
+	}
+	out := make([]WeightPhase, 0, len(phases))
+	start := time.Duration(0)
+	for _, phase := range phases {
+		out = append(out, WeightPhase{Name: phase.Name, Start: start, End: start + phase.Duration, Weights: weights, lookup: lookup})
+		start += phase.Duration
+	}
+	return out
+}
+
+// The latency mode rate of each phase as the run goes through them; see RateSource
+type PhaseRates struct {
+	phases     []RunPhase
+	baseRate   float64
+	numClients int
+	start      time.Time
+	now        func() time.Time
+}
+
+// Phases that don't set a rate run at baseRate, the total rate given with --rate
+func NewPhaseRates(phases []RunPhase, baseRate float64, numClients int) *PhaseRates {
+	return &PhaseRates{phases: phases, baseRate: baseRate, numClients: numClients, now: time.Now}
+}
+
+// Sets when the first phase starts; call before any worker asks for a rate
+func (p *PhaseRates) Begin(start time.Time) {
+	p.start = start
+}
+
+// Total rate of the phase the run is in now; before the first phase and after the last, --rate
+func (p *PhaseRates) Rate() float64 {
+	elapsed := p.now().Sub(p.start)
+	end := time.Duration(0)
+	for _, phase := range p.phases {
+		start := end
+		end += phase.Duration
+		if elapsed < start || elapsed >= end {
+			continue
+		}
+		if phase.Rate > 0 {
+			return phase.Rate
+		}
+		if phase.RateFactor > 0 {
+			return p.baseRate * phase.RateFactor
+		}
+		break
+	}
+	return p.baseRate
+}
+
+func (p *PhaseRates) Phases() []RunPhase {
+	return p.phases
+}
+
+func (p *PhaseRates) ClientInterval() time.Duration {
+	return TotalRatePerSecondToDurationPerClient(p.numClients, math.Max(p.Rate(), minControlledRate))
+}
+
+var _ RateSource = &PhaseRates{}
@@ -0,0 +1,74 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseRunPhases(t *testing.T) {
+	phases, err := ParseRunPhases([]string{"warmup:2m", "measure:10m", "spike:1m,rate=2x", "flat:30s,rate=500"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []RunPhase{
+		{Name: "warmup", Duration: 2 * time.Minute},
+		{Name: "measure", Duration: 10 * time.Minute},
+		{Name: "spike", Duration: time.Minute, RateFactor: 2},
+		{Name: "flat", Duration: 30 * time.Second, Rate: 500},
+	}, phases)
+	assert.Equal(t, 13*time.Minute+30*time.Second, RunPhasesDuration(phases))
+	assert.True(t, RunPhasesChangeRate(phases))
+
+	for _, invalid := range []string{"10m", "measure:soon", "spike:1m,rate=fast", "spike:1m,clients=2"} {
+		_, err := ParseRunPhases([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+	_, err = ParseRunPhases([]string{"a:1m", "a:2m"})
+	assert.EqualError(t, err, "invalid --phase 'a:2m': there is already a phase named a")
+}
+
+func TestPhaseRatesFollowThePhases(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	phases, err := ParseRunPhases([]string{"measure:1m", "spike:1m,rate=2x", "flat:1m,rate=10"})
+	assert.NoError(t, err)
+	rates := NewPhaseRates(phases, 100, 4)
+	rates.now = clock.now
+	rates.Begin(clock.now().Add(time.Second))
+
+	// Before the first phase, as during --warmup
+	assert.Equal(t, 100.0, rates.Rate())
+	clock.sleep(30 * time.Second)
+	assert.Equal(t, 100.0, rates.Rate())
+	clock.sleep(time.Minute)
+	assert.Equal(t, 200.0, rates.Rate())
+	assert.Equal(t, 20*time.Millisecond, rates.ClientInterval())
+	clock.sleep(time.Minute)
+	assert.Equal(t, 10.0, rates.Rate())
+	clock.sleep(time.Minute)
+	assert.Equal(t, 100.0, rates.Rate())
+}
+
+func TestRunPhasesAreReportedAsPhases(t *testing.T) {
+	scripts := NewScripts(Script{Name: "reads", Weight: 1}, Script{Name: "writes", Weight: 1})
+	phases, err := ParseRunPhases([]string{"measure:10s", "spike:10s"})
+	assert.NoError(t, err)
+	scripts.Phases = RunPhasesAsWeightPhases(phases, scripts.Scripts)
+
+	_, phase := scripts.ChooseAt(rand.New(rand.NewSource(1)), 12*time.Second)
+	assert.Equal(t, "spike", phase)
+	_, phase = scripts.ChooseAt(rand.New(rand.NewSource(1)), -time.Second)
+	assert.Equal(t, "", phase)
+
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("reads", "measure", time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, worker.record("reads", "spike", time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, worker.record("reads", "spike", time.Millisecond, uowOutcome{succeeded: true}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+	scripts.CompletePhases(&result, 20*time.Second, 0.05)
+
+	assert.Len(t, result.Phases, 2)
+	assert.Equal(t, "spike", result.Phases[1].Name)
+	assert.Equal(t, 0.2, result.Phases[1].Scripts["reads"].Rate)
+}
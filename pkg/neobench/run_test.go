@@ -0,0 +1,25 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRunTagIdentifiesScenarioAndRun(t *testing.T) {
+	now := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+	a := NewRunTag(" -b tpcb-like -c 1", now)
+	b := NewRunTag(" -b tpcb-like -c 1", now.Add(time.Second))
+	c := NewRunTag(" -b tpcb-like -c 2", now)
+
+	assert.Equal(t, a.ScenarioHash, b.ScenarioHash)
+	assert.NotEqual(t, a.ScenarioHash, c.ScenarioHash)
+	assert.NotEqual(t, a.RunId, b.RunId)
+	assert.Len(t, a.ScenarioHash, 12)
+
+	assert.Equal(t, map[string]interface{}{
+		"neobenchScenario": a.ScenarioHash,
+		"neobenchRun":      a.RunId,
+	}, a.TxMetadata())
+	assert.Contains(t, a.UserAgent(), a.RunId)
+}
@@ -0,0 +1,150 @@
+package neobench
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Longest part of a query shown to tell statements apart in the script evaluation report
+const commandLabelQueryLength = 40
+
+// How long one command of a unit of work took to evaluate, with UnitOfWork#TimeCommands set
+type CommandCost struct {
+	// Position of the command in the script, and what it is, ex: :set ids or statement 2 (MATCH (n) ...)
+	Index    int
+	Label    string
+	Duration time.Duration
+}
+
+// Client-side cost of evaluating the commands of one script, with --profile-scripts. This is the time spent working
+// out `:set` expressions and preparing statements, which counts towards latency like the time the database takes.
+type ScriptEvalResult struct {
+	ScriptName string
+	// Units of work the script ran in; retried transactions evaluate the script again, so commands may run more often
+	Units int64
+	// Keyed by the position of the command in the script
+	Commands map[int]*CommandEvalCost
+}
+
+type CommandEvalCost struct {
+	Label string
+	Runs  int64
+	Total time.Duration
+	Max   time.Duration
+}
+
+func newScriptEvalResult(scriptName string) *ScriptEvalResult {
+	return &ScriptEvalResult{ScriptName: scriptName, Commands: make(map[int]*CommandEvalCost)}
+}
+
+// Records the commands evaluated by one unit of work
+func (r *ScriptEvalResult) record(costs []CommandCost) {
+	r.Units++
+	for _, cost := range costs {
+		command, found := r.Commands[cost.Index]
+		if !found {
+			command = &CommandEvalCost{Label: cost.Label}
+			r.Commands[cost.Index] = command
+		}
+		command.Runs++
+		command.Total += cost.Duration
+		if cost.Duration > command.Max {
+			command.Max = cost.Duration
+		}
+	}
+}
+
+func (r *ScriptEvalResult) merge(other *ScriptEvalResult) {
+	r.Units += other.Units
+	for index, command := range other.Commands {
+		existing, found := r.Commands[index]
+		if !found {
+			existing = &CommandEvalCost{Label: command.Label}
+			r.Commands[index] = existing
+		}
+		existing.Runs += command.Runs
+		existing.Total += command.Total
+		if command.Max > existing.Max {
+			existing.Max = command.Max
+		}
+	}
+}
+
+// Time spent evaluating all commands of the script, over all units of work
+func (r *ScriptEvalResult) Total() time.Duration {
+	total := time.Duration(0)
+	for _, command := range r.Commands {
+		total += command.Total
+	}
+	return total
+}
+
+// Names the given command for the script evaluation report; statement is the number of the statement the command
+// produces, counting from one, for query commands. Commands that aren't timed have no label.
+func commandLabel(cmd Command, statement int) string {
+	switch cmd := cmd.(type) {
+	case SetCommand:
+		return ":set " + cmd.VarName
+	case UserCommand:
+		return ":user " + cmd.VarName
+	case QueryCommand:
+		query := strings.Join(strings.Fields(cmd.Query), " ")
+		if len(query) > commandLabelQueryLength {
+			query = query[:commandLabelQueryLength] + "..."
+		}
+		return fmt.Sprintf("statement %d (%s)", statement, query)
+	}
+	return ""
+}
+
+// Time spent evaluating each script, and each of its commands, most expensive first
+func writeScriptEvalReport(result Result, s *strings.Builder) {
+	if len(result.ScriptEvals) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.ScriptEvals))
+	for name := range result.ScriptEvals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.WriteString(fmt.Sprintf("-- Script evaluation --\n\n"))
+	for _, name := range names {
+		eval := result.ScriptEvals[name]
+		if eval.Units == 0 {
+			continue
+		}
+		total := eval.Total()
+		perUnit := float64(total.Microseconds()) / float64(eval.Units)
+		s.WriteString(fmt.Sprintf("  [%s]: %d units of work, %s evaluating per unit", name, eval.Units, result.LatencyUnit.Format(perUnit)))
+		if script, found := result.Scripts[name]; found && script.Latencies != nil && script.Latencies.Mean() > 0 {
+			s.WriteString(fmt.Sprintf(", %.1f%% of mean latency", 100*perUnit/script.Latencies.Mean()))
+		}
+		s.WriteString("\n")
+
+		indexes := make([]int, 0, len(eval.Commands))
+		for index := range eval.Commands {
+			indexes = append(indexes, index)
+		}
+		sort.Slice(indexes, func(i, j int) bool {
+			a, b := eval.Commands[indexes[i]], eval.Commands[indexes[j]]
+			if a.Total != b.Total {
+				return a.Total > b.Total
+			}
+			return indexes[i] < indexes[j]
+		})
+		for _, index := range indexes {
+			command := eval.Commands[index]
+			share := 0.0
+			if total > 0 {
+				share = 100 * float64(command.Total) / float64(total)
+			}
+			s.WriteString(fmt.Sprintf("    %s: %d runs, mean %s, max %s, %.1f%% of evaluation\n", command.Label, command.Runs,
+				result.LatencyUnit.Format(float64(command.Total.Microseconds())/float64(command.Runs)),
+				result.LatencyUnit.FormatDuration(command.Max), share))
+		}
+	}
+	s.WriteString("\n")
+}
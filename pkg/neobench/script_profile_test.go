@@ -0,0 +1,70 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnitOfWorkTimesCommands(t *testing.T) {
+	script, err := Parse("timed", `
+:set ids range(1, 100)
+:use system
+MATCH   (n)
+  WHERE n.id IN $ids AND n.name = "something long enough to be cut" RETURN n;
+:sleep 1 ms
+RETURN 1;`, 1)
+	assert.NoError(t, err)
+
+	uow := script.NewUnitOfWork(ScriptContext{Vars: map[string]interface{}{}, Rand: rand.New(rand.NewSource(1337))})
+	assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+	assert.Nil(t, uow.CommandCosts)
+
+	uow.TimeCommands = true
+	assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+	// Retries evaluate the script again, which costs again
+	assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+
+	labels := make([]string, 0)
+	for _, cost := range uow.CommandCosts {
+		labels = append(labels, cost.Label)
+	}
+	assert.Equal(t, []string{
+		":set ids",
+		"statement 1 (MATCH (n) WHERE n.id IN $ids AND n.name ...)",
+		"statement 2 (RETURN 1)",
+		":set ids",
+		"statement 1 (MATCH (n) WHERE n.id IN $ids AND n.name ...)",
+		"statement 2 (RETURN 1)",
+	}, labels)
+	assert.Equal(t, []int{0, 2, 4}, []int{uow.CommandCosts[0].Index, uow.CommandCosts[1].Index, uow.CommandCosts[2].Index})
+}
+
+func TestWriteScriptEvalReport(t *testing.T) {
+	worker := NewWorkerResult(0)
+	costs := []CommandCost{
+		{Index: 0, Label: ":set ids", Duration: 3 * time.Millisecond},
+		{Index: 1, Label: "statement 1 (RETURN $ids)", Duration: time.Millisecond},
+	}
+	assert.NoError(t, worker.record("test", "", 8*time.Millisecond, uowOutcome{succeeded: true, commandCosts: costs}))
+	costs[0].Duration = 5 * time.Millisecond
+	// Evaluating the scripts of failed units of work took as long
+	assert.NoError(t, worker.record("test", "", 8*time.Millisecond, uowOutcome{succeeded: false, commandCosts: costs}))
+	// Not profiled
+	assert.NoError(t, worker.record("other", "", 8*time.Millisecond, uowOutcome{succeeded: true}))
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+	result.Add(NewWorkerResult(1))
+
+	s := strings.Builder{}
+	writeScriptEvalReport(result, &s)
+	assert.Equal(t, `-- Script evaluation --
+
+  [test]: 2 units of work, 5.000ms evaluating per unit, 62.5% of mean latency
+    :set ids: 2 runs, mean 4.000ms, max 5.000ms, 80.0% of evaluation
+    statement 1 (RETURN $ids): 2 runs, mean 1.000ms, max 1.000ms, 20.0% of evaluation
+
+`, s.String())
+}
@@ -3,6 +3,7 @@ package neobench
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"path/filepath"
@@ -14,6 +15,10 @@ import (
 )
 
 func Parse(filename, script string, weight float64) (Script, error) {
+	script, err := expandLoops(filename, script)
+	if err != nil {
+		return Script{}, err
+	}
 	c := newParseContext(script, filename)
 
 	var output = Script{
@@ -50,6 +55,11 @@ func Parse(filename, script string, weight float64) (Script, error) {
 func parseMetaCommand(s *Script, c *parseContext) {
 	expect(c, ':')
 	cmd := ident(c)
+	// Some meta commands have dashes in their names, like :on-error
+	for c.PeekToken() == '-' {
+		c.Next()
+		cmd += "-" + ident(c)
+	}
 
 	switch cmd {
 	case "opt":
@@ -61,6 +71,46 @@ func parseMetaCommand(s *Script, c *parseContext) {
 		default:
 			c.fail(fmt.Errorf("unexpected opt: '%s'", opt))
 		}
+	case "autocommit":
+		// Marks the statement that follows to run as an auto-commit transaction of its own, eg. for
+		// CALL { .. } IN TRANSACTIONS, which can't run inside an explicit transaction
+		for c.PeekToken() == '\n' {
+			c.Next()
+		}
+		if tok := c.PeekToken(); tok == ':' || tok == scanner.EOF {
+			c.fail(fmt.Errorf(":autocommit must be followed by a query"))
+			return
+		}
+		query := command(c).(QueryCommand)
+		query.Autocommit = true
+		s.Commands = append(s.Commands, query)
+	case "readback":
+		// Marks the statement that follows as reading back what the statements before it wrote, through a read
+		// session of its own; see Statement#Readback
+		for c.PeekToken() == '\n' {
+			c.Next()
+		}
+		if tok := c.PeekToken(); tok == ':' || tok == scanner.EOF {
+			c.fail(fmt.Errorf(":readback must be followed by a query"))
+			return
+		}
+		query := command(c).(QueryCommand)
+		query.Readback = true
+		s.Commands = append(s.Commands, query)
+	case "use":
+		s.Commands = append(s.Commands, UseCommand{Database: ident(c)})
+	case "on-error":
+		parseOnError(s, c)
+	case "consume":
+		parseConsume(s, c)
+	case "db", "fetch-size", "bookmark":
+		parseSessionOption(s, c, cmd)
+	case "metadata":
+		key := ident(c)
+		s.Metadata = append(s.Metadata, MetadataEntry{
+			Key:        key,
+			Expression: expr(c),
+		})
 	case "set":
 		varName := ident(c)
 		setExpr := expr(c)
@@ -68,13 +118,17 @@ func parseMetaCommand(s *Script, c *parseContext) {
 			VarName:    varName,
 			Expression: setExpr,
 		})
+	case "user":
+		varName := ident(c)
+		userExpr := expr(c)
+		s.Commands = append(s.Commands, UserCommand{
+			VarName:    varName,
+			Expression: userExpr,
+		})
 	case "sleep":
 		durationBase := expr(c)
 		unit := time.Second
-		switch c.PeekToken() {
-		case '\n', scanner.EOF:
-			break
-		default:
+		if tok, text := c.Peek(); tok != '\n' && tok != scanner.EOF && text != "in" {
 			_, unitStr := c.Next()
 			switch unitStr {
 			case "s":
@@ -87,9 +141,18 @@ func parseMetaCommand(s *Script, c *parseContext) {
 				c.fail(fmt.Errorf(":sleep command must use 'us', 'ms', or 's' unit argument - or none. got: %s", unitStr))
 			}
 		}
+		inTransaction := false
+		if _, text := c.Peek(); text == "in" {
+			c.Next()
+			if where := ident(c); where != "transaction" {
+				c.fail(fmt.Errorf(":sleep command can be followed by 'in transaction' - or nothing. got: in %s", where))
+			}
+			inTransaction = true
+		}
 		s.Commands = append(s.Commands, SleepCommand{
-			Duration: durationBase,
-			Unit:     unit,
+			Duration:      durationBase,
+			Unit:          unit,
+			InTransaction: inTransaction,
 		})
 	default:
 		c.fail(fmt.Errorf("unexpected meta command: '%s'", cmd))
@@ -260,24 +323,71 @@ func term(c *parseContext) Expression {
 					args: []Expression{lhs, rhs},
 				},
 			}
-		} else if tok == '[' {
+		} else {
+			return lhs
+		}
+	}
+}
+
+// A primary expression followed by any number of indexes, slices and property accesses, ex: $rows[0][2],
+// $m["k"], $m.k, $list[1..5]
+func factor(c *parseContext) Expression {
+	lhs := primary(c)
+	for !c.done {
+		tok := c.PeekToken()
+		if tok == '[' {
 			c.Next()
-			index := expr(c)
-			expect(c, ']')
-			lhs = Expression{
-				Kind: sliceExpr,
-				Payload: SliceExpr{
-					src: lhs,
-					i:   index,
-				},
+			lhs = Expression{Kind: sliceExpr, Payload: slice(c, lhs)}
+		} else if tok == '.' {
+			c.Next()
+			key, err := tryIdent(c)
+			if err != nil {
+				// Not a property access, but the start of a range, like the one in $list[$from..$to]
+				c.Push('.', ".")
+				return lhs
 			}
+			lhs = Expression{Kind: sliceExpr, Payload: SliceExpr{src: lhs, i: Expression{Kind: stringExpr, Payload: key}}}
 		} else {
 			return lhs
 		}
 	}
+	return lhs
 }
 
-func factor(c *parseContext) Expression {
+// Parses what follows '[' after an expression: an index or key, ex: [0], or a range, ex: [1..5], [..5], [1..]
+func slice(c *parseContext, src Expression) SliceExpr {
+	out := SliceExpr{src: src}
+	if c.PeekToken() != '.' {
+		out.i = expr(c)
+	}
+	for dots := 0; dots < 2; dots++ {
+		tok, text := c.Peek()
+		if tok == '.' {
+			c.Next()
+			continue
+		}
+		if dots == 1 && tok == scanner.Float && strings.HasPrefix(text, ".") {
+			// The scanner reads the end of 1..5 as the float .5
+			c.Next()
+			c.Push(scanner.Int, text[1:])
+			break
+		}
+		if dots == 1 {
+			c.fail(fmt.Errorf("expected '..' in range, got '.%s'", text))
+			return out
+		}
+		expect(c, ']')
+		return out
+	}
+	out.isRange = true
+	if c.PeekToken() != ']' {
+		out.to = expr(c)
+	}
+	expect(c, ']')
+	return out
+}
+
+func primary(c *parseContext) Expression {
 	tok, content := c.Next()
 	if tok == scanner.Ident {
 		funcName := content
@@ -307,6 +417,9 @@ func factor(c *parseContext) Expression {
 		}
 		return Expression{Kind: intExpr, Payload: int64(intVal)}
 	} else if tok == scanner.Float {
+		if intVal, err := strconv.Atoi(strings.TrimSuffix(content, ".")); err == nil && startsRange(c, content) {
+			return Expression{Kind: intExpr, Payload: int64(intVal)}
+		}
 		floatVal, err := strconv.ParseFloat(content, 64)
 		if err != nil {
 			c.fail(err)
@@ -329,6 +442,9 @@ func factor(c *parseContext) Expression {
 			}
 			return Expression{Kind: intExpr, Payload: int64(-1 * intVal)}
 		} else if tok == scanner.Float {
+			if intVal, err := strconv.Atoi(strings.TrimSuffix(content, ".")); err == nil && startsRange(c, content) {
+				return Expression{Kind: intExpr, Payload: int64(-1 * intVal)}
+			}
 			floatVal, err := strconv.ParseFloat(content, 64)
 			if err != nil {
 				c.fail(err)
@@ -394,6 +510,20 @@ func factor(c *parseContext) Expression {
 	}
 }
 
+// The scanner reads the start of 1..5 as the float 1.; if that's what the float token just read is, this puts the
+// dot it took back, so the range can be parsed
+func startsRange(c *parseContext, float string) bool {
+	if !strings.HasSuffix(float, ".") {
+		return false
+	}
+	tok, text := c.Peek()
+	if tok == '.' || (tok == scanner.Float && strings.HasPrefix(text, ".")) {
+		c.Push('.', ".")
+		return true
+	}
+	return false
+}
+
 func listComprehension(c *parseContext) Expression {
 	itemName := ident(c)
 	maybeIn := ident(c)
@@ -535,14 +665,29 @@ func (e Expression) String() string {
 	}
 }
 
-// Intended to be expanded into a richer slicing system, for now just simple indexing
+// Indexing, ex: $list[0], key or property access, ex: $m["k"] or $m.k, and ranges, ex: $list[1..5]. These work as
+// they do in Cypher: negative indexes count from the end, and indexes outside the list and missing keys give null.
 type SliceExpr struct {
 	src Expression
-	i   Expression
+	// Index or key; for ranges, the start of the range, unset if the range has no start, like [..5]
+	i       Expression
+	isRange bool
+	// End of the range, exclusive; unset if the range has no end, like [1..]
+	to Expression
 }
 
 func (s SliceExpr) String() string {
-	return fmt.Sprintf("%s[%s]", s.src.String(), s.i.String())
+	if !s.isRange {
+		return fmt.Sprintf("%s[%s]", s.src.String(), s.i.String())
+	}
+	from, to := "", ""
+	if s.i.Kind != nullExpr {
+		from = s.i.String()
+	}
+	if s.to.Kind != nullExpr {
+		to = s.to.String()
+	}
+	return fmt.Sprintf("%s[%s..%s]", s.src.String(), from, to)
 }
 
 func (s SliceExpr) Eval(ctx *ScriptContext) (interface{}, error) {
@@ -550,26 +695,84 @@ func (s SliceExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if srcRaw == nil {
+		return nil, nil
+	}
+	if m, ok := srcRaw.(map[string]interface{}); ok && !s.isRange {
+		keyRaw, err := s.i.Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", s.String())
+		}
+		key, ok := keyRaw.(string)
+		if !ok {
+			return nil, fmt.Errorf("maps can only be indexed with strings, got %v in %s", keyRaw, s.String())
+		}
+		return m[key], nil
+	}
 	src, ok := srcRaw.([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("slicing only work on lists, got %v", srcRaw)
+		return nil, fmt.Errorf("slicing only work on lists and maps, got %v in %s", srcRaw, s.String())
 	}
 
-	iRaw, err := s.i.Eval(ctx)
-	if err != nil {
-		return nil, errors.Wrapf(err, "in slice %s", s.String())
+	if !s.isRange {
+		i, err := s.index(s.i, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 {
+			i += int64(len(src))
+		}
+		if i < 0 || i >= int64(len(src)) {
+			return nil, nil
+		}
+		return src[i], nil
 	}
 
+	from, to := int64(0), int64(len(src))
+	if s.i.Kind != nullExpr {
+		if from, err = s.index(s.i, ctx); err != nil {
+			return nil, err
+		}
+	}
+	if s.to.Kind != nullExpr {
+		if to, err = s.index(s.to, ctx); err != nil {
+			return nil, err
+		}
+	}
+	from, to = clampIndex(from, len(src)), clampIndex(to, len(src))
+	if from >= to {
+		return []interface{}{}, nil
+	}
+	return src[from:to], nil
+}
+
+func (s SliceExpr) index(e Expression, ctx *ScriptContext) (int64, error) {
+	iRaw, err := e.Eval(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "in slice %s", s.String())
+	}
 	iNum, err := asNumber(iRaw)
 	if err != nil {
-		return nil, errors.Wrapf(err, "expected integer as slice argument in %s", s.String())
+		return 0, errors.Wrapf(err, "expected integer as slice argument in %s", s.String())
 	}
 	if iNum.isDouble {
-		return nil, fmt.Errorf("floats can't be used as indexes in slices, in %s", s.String())
+		return 0, fmt.Errorf("floats can't be used as indexes in slices, in %s", s.String())
 	}
-	i := iNum.iVal
+	return iNum.iVal, nil
+}
 
-	return src[i], nil
+// Negative indexes count from the end; the result is within 0 and length
+func clampIndex(i int64, length int) int64 {
+	if i < 0 {
+		i += int64(length)
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > int64(length) {
+		return int64(length)
+	}
+	return i
 }
 
 // [i in range(1,10) | i * 2]
@@ -597,11 +800,13 @@ func (s ListCompExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 
 	out := make([]interface{}, len(src))
 	innerCtx := ScriptContext{
-		Script:    ctx.Script,
-		Stderr:    ctx.Stderr,
-		Vars:      make(map[string]interface{}),
-		Rand:      ctx.Rand,
-		CsvLoader: ctx.CsvLoader,
+		Script:      ctx.Script,
+		Stderr:      ctx.Stderr,
+		Vars:        make(map[string]interface{}),
+		Rand:        ctx.Rand,
+		CsvLoader:   ctx.CsvLoader,
+		LDBCIds:     ctx.LDBCIds,
+		SharedState: ctx.SharedState,
 	}
 	for k, v := range ctx.Vars {
 		innerCtx.Vars[k] = v
@@ -640,6 +845,18 @@ func (f CallExpr) argAsNumber(i int, ctx *ScriptContext) (Number, error) {
 	return asNumber(value)
 }
 
+func (f CallExpr) argAsMap(i int, ctx *ScriptContext) (map[string]interface{}, error) {
+	raw, err := f.args[i].Eval(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "in %s", f.String())
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("argument %d to %s(..) needs to be a map, got %v, in %s", i+1, f.name, raw, f.String())
+	}
+	return m, nil
+}
+
 func (f CallExpr) argAsString(i int, ctx *ScriptContext) (string, error) {
 	if len(f.args) <= i {
 		return "", fmt.Errorf("expected at least %d arguments, got %d", i+1, len(f.args))
@@ -709,11 +926,117 @@ func (f CallExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "in %s", f.String())
 		}
+		switch src := rawSrc.(type) {
+		case []interface{}:
+			return int64(len(src)), nil
+		case map[string]interface{}:
+			return int64(len(src)), nil
+		case string:
+			return int64(len(src)), nil
+		}
+		return nil, fmt.Errorf("argument to len(..) needs to be a list, map or string, in %s", f.String())
+	case "map_merge":
+		if len(f.args) < 2 {
+			return nil, fmt.Errorf("map_merge(..) requires two or more maps, in %s", f.String())
+		}
+		out := make(map[string]interface{})
+		for i := range f.args {
+			m, err := f.argAsMap(i, ctx)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range m {
+				out[k] = v
+			}
+		}
+		return out, nil
+	case "keys", "values":
+		if len(f.args) != 1 {
+			return nil, fmt.Errorf("%s(..) requires a map argument, in %s", f.name, f.String())
+		}
+		m, err := f.argAsMap(0, ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]interface{}, 0, len(m))
+		for _, k := range keys {
+			if f.name == "keys" {
+				out = append(out, k)
+			} else {
+				out = append(out, m[k])
+			}
+		}
+		return out, nil
+	case "map_from":
+		if len(f.args) != 2 {
+			return nil, fmt.Errorf("map_from(..) requires a list of keys and a list of values, in %s", f.String())
+		}
+		rawKeys, err := f.args[0].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		rawValues, err := f.args[1].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		keys, keysOk := rawKeys.([]interface{})
+		values, valuesOk := rawValues.([]interface{})
+		if !keysOk || !valuesOk {
+			return nil, fmt.Errorf("map_from(..) requires a list of keys and a list of values, in %s", f.String())
+		}
+		if len(keys) != len(values) {
+			return nil, fmt.Errorf("map_from(..) got %d keys but %d values, in %s", len(keys), len(values), f.String())
+		}
+		out := make(map[string]interface{}, len(keys))
+		for i, rawKey := range keys {
+			key, err := toString(rawKey)
+			if err != nil {
+				return nil, errors.Wrapf(err, "in %s", f.String())
+			}
+			out[key] = values[i]
+		}
+		return out, nil
+	case "sample":
+		if len(f.args) != 2 {
+			return nil, fmt.Errorf("sample(..) requires a list and a number of entries to pick, in %s", f.String())
+		}
+		rawSrc, err := f.args[0].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
 		src, ok := rawSrc.([]interface{})
 		if !ok {
-			return nil, fmt.Errorf("argument to len(..) needs to be a list, in %s", f.String())
+			return nil, fmt.Errorf("first argument to sample(..) needs to be a list, in %s", f.String())
+		}
+		n, err := f.argAsNumber(1, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %s", f.String(), err)
 		}
-		return int64(len(src)), nil
+		if n.isDouble || n.iVal < 0 {
+			return nil, fmt.Errorf("number of entries to sample must be a non-negative integer, in %s", f.String())
+		}
+		if n.iVal > int64(len(src)) {
+			return nil, fmt.Errorf("can't sample %d entries without replacement from a list of %d, in %s", n.iVal, len(src), f.String())
+		}
+		return sample(ctx.Rand, src, int(n.iVal)), nil
+	case "shuffle":
+		if len(f.args) != 1 {
+			return nil, fmt.Errorf("shuffle(..) requires a list argument, in %s", f.String())
+		}
+		rawSrc, err := f.args[0].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		src, ok := rawSrc.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("argument to shuffle(..) needs to be a list, in %s", f.String())
+		}
+		return sample(ctx.Rand, src, len(src)), nil
 	case "double":
 		a, err := f.argAsNumber(0, ctx)
 		if err != nil {
@@ -789,6 +1112,39 @@ func (f CallExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 			return nil, fmt.Errorf("in %s: %s", f.String(), err)
 		}
 		return math.Sqrt(a.val), nil
+	case "hash":
+		if len(f.args) != 1 {
+			return nil, fmt.Errorf("hash(..) requires one argument, in %s", f.String())
+		}
+		v, err := f.args[0].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		h, err := hashValue(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		return h, nil
+	case "bucket":
+		if len(f.args) != 2 {
+			return nil, fmt.Errorf("bucket(..) requires a value and a number of buckets, in %s", f.String())
+		}
+		v, err := f.args[0].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		n, err := f.argAsNumber(1, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %s", f.String(), err)
+		}
+		if n.isDouble || n.iVal < 1 {
+			return nil, fmt.Errorf("number of buckets must be a positive integer, in %s", f.String())
+		}
+		h, err := hashValue(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		return h % n.iVal, nil
 	case "random":
 		lb, err := f.argAsNumber(0, ctx)
 		if err != nil {
@@ -856,6 +1212,28 @@ func (f CallExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 
 		min, max := lb.iVal, ub.iVal
 		return gaussianRand(ctx.Rand, min, max, param.val)
+	case "ldbc_random_person":
+		scale, err := f.argAsNumber(0, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %s", f.String(), err)
+		}
+		return uniformRand(ctx.Rand, 1, LDBCPeople(scale.val)+1), nil
+	case "ldbc_random_message":
+		scale, err := f.argAsNumber(0, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %s", f.String(), err)
+		}
+		if ctx.LDBCIds == nil {
+			return nil, fmt.Errorf("in %s: ldbc-like message ids are only available when running against a database", f.String())
+		}
+		ids, err := ctx.LDBCIds.Load()
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		if ids.Scale != scale.val {
+			return nil, fmt.Errorf("in %s: the ldbc-like dataset in the database was populated with --scale %g, not %g", f.String(), ids.Scale, scale.val)
+		}
+		return ids.RandomMessage(ctx.Rand)
 	case "range":
 		lb, err := f.argAsNumber(0, ctx)
 		if err != nil {
@@ -878,24 +1256,55 @@ func (f CallExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 			return nil, errors.Wrapf(err, "random_matrix numRows must be integer, in %s", f.String())
 		}
 
-		spec := make([][]int64, 0)
+		spec := make([]matrixColumn, 0)
 		for i := 1; i < len(f.args); i++ {
 			rawRowSpec, err := f.args[i].Eval(ctx)
 			if err != nil {
 				return nil, errors.Wrapf(err, "in %s at %s", f.String(), f.args[i].String())
 			}
-			rowSpec, ok := rawRowSpec.([]interface{})
-			if !ok || len(rowSpec) != 2 {
-				return nil, fmt.Errorf("random_matrix column specs should be 2-integer lists specifying the range in that column, like '[1,14]', got %s", f.args[i].String())
+			column, err := parseMatrixColumn(rawRowSpec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "random_matrix column spec %s", f.args[i].String())
 			}
-			min, minOk := rowSpec[0].(int64)
-			max, maxOk := rowSpec[1].(int64)
-			if !minOk || !maxOk {
-				return nil, fmt.Errorf("random_matrix column random range should be integers, like '[1,14]', got %s", f.args[i].String())
+			spec = append(spec, column)
+		}
+		return randomMatrix(ctx.Rand, numRows.iVal, spec)
+	case "remember":
+		if len(f.args) != 2 {
+			return nil, fmt.Errorf("remember(..) requires a collection name and a value, in %s", f.String())
+		}
+		name, err := f.argAsString(0, ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		value, err := f.args[1].Eval(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		if ctx.SharedState == nil {
+			return value, nil
+		}
+		if err := ctx.SharedState.Remember(name, value); err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		return value, nil
+	case "recall":
+		if len(f.args) != 1 && len(f.args) != 2 {
+			return nil, fmt.Errorf("recall(..) requires a collection name and optionally a default value, in %s", f.String())
+		}
+		name, err := f.argAsString(0, ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "in %s", f.String())
+		}
+		if ctx.SharedState != nil {
+			if value, found := ctx.SharedState.Recall(name, ctx.Rand); found {
+				return value, nil
 			}
-			spec = append(spec, []int64{min, max})
 		}
-		return randomMatrix(ctx.Rand, numRows.iVal, spec), nil
+		if len(f.args) == 2 {
+			return f.args[1].Eval(ctx)
+		}
+		return nil, nil
 	case "csv":
 		path, err := f.argAsString(0, ctx)
 		if err != nil {
@@ -906,6 +1315,34 @@ func (f CallExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 			return nil, errors.Wrapf(err, "failed resolving path %s relative to %s in %s", path, ctx.Script.Name, f.String())
 		}
 		return ctx.CsvLoader.Load(absPath)
+	case "csv_partition":
+		if len(f.args) != 3 {
+			return nil, fmt.Errorf("csv_partition(..) requires a path, a worker id and a worker count, like csv_partition(\"users.csv\", $nbWorkerId, $nbWorkers), in %s", f.String())
+		}
+		path, err := f.argAsString(0, ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "csv_partition(..) takes string as first argument")
+		}
+		worker, err := f.argAsNumber(1, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %s", f.String(), err)
+		}
+		workers, err := f.argAsNumber(2, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %s", f.String(), err)
+		}
+		if worker.isDouble || workers.isDouble || workers.iVal < 1 || worker.iVal < 0 || worker.iVal >= workers.iVal {
+			return nil, fmt.Errorf("csv_partition(..) needs an integer worker id from 0 up to the integer worker count, in %s", f.String())
+		}
+		absPath, err := absPath(ctx.Script.Name, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed resolving path %s relative to %s in %s", path, ctx.Script.Name, f.String())
+		}
+		rows, err := ctx.CsvLoader.Load(absPath)
+		if err != nil {
+			return nil, err
+		}
+		return csvPartition(rows, worker.iVal, workers.iVal), nil
 	case "*":
 		a, err := f.argAsNumber(0, ctx)
 		if err != nil {
@@ -961,6 +1398,20 @@ func (f CallExpr) Eval(ctx *ScriptContext) (interface{}, error) {
 			return nil, errors.Wrapf(err, "in %s", f.String())
 		}
 
+		// Like in Cypher, adding to a list gives a new list with the lists joined, or the value added at the end
+		aList, aIsList := a.([]interface{})
+		bList, bIsList := b.([]interface{})
+		if aIsList || bIsList {
+			if !aIsList {
+				aList = []interface{}{a}
+			}
+			if !bIsList {
+				bList = []interface{}{b}
+			}
+			out := make([]interface{}, 0, len(aList)+len(bList))
+			return append(append(out, aList...), bList...), nil
+		}
+
 		_, aIsString := a.(string)
 		_, bIsString := b.(string)
 
@@ -1028,6 +1479,25 @@ func toString(val interface{}) (string, error) {
 }
 
 // Range, inclusive on both bounds to match cypher
+// 64-bit FNV-1a of the text of v, without the sign bit, so it's stable across runs, machines and neobench versions,
+// and integers hash like their text, so an id hashes the same whether it comes from a CSV as 7 or as "7"
+func hashValue(v interface{}) (int64, error) {
+	var text string
+	switch v := v.(type) {
+	case float64:
+		text = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		s, err := toString(v)
+		if err != nil {
+			return 0, errors.Wrapf(err, "can only hash strings and numbers")
+		}
+		text = s
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(text))
+	return int64(h.Sum64() & math.MaxInt64), nil
+}
+
 func rangeFn(min, max int64) (interface{}, error) {
 	out := make([]interface{}, 0, max-min)
 	for i := min; i <= max; i++ {
@@ -1036,21 +1506,128 @@ func rangeFn(min, max int64) (interface{}, error) {
 	return out, nil
 }
 
+// How random_matrix fills a column: values from min to max, picked with the given distribution. Uniform columns
+// exclude max, like they always have; the others include it, like random_exponential and random_gaussian do.
+type matrixColumn struct {
+	min, max     int64
+	distribution string
+	parameter    float64
+}
+
+// Parses a column spec like [1,14] or [1,100,"zipf",1.1]
+func parseMatrixColumn(raw interface{}) (matrixColumn, error) {
+	spec, ok := raw.([]interface{})
+	if !ok || (len(spec) != 2 && len(spec) != 3 && len(spec) != 4) {
+		return matrixColumn{}, fmt.Errorf("should be a list with the range in that column and optionally a distribution, " +
+			"like '[1,14]' or '[1,100,\"zipf\",1.1]'")
+	}
+	min, minOk := spec[0].(int64)
+	max, maxOk := spec[1].(int64)
+	if !minOk || !maxOk {
+		return matrixColumn{}, fmt.Errorf("random range should be integers, like '[1,14]'")
+	}
+	if max <= min {
+		return matrixColumn{}, fmt.Errorf("random range should have max greater than min, like '[1,14]'")
+	}
+	column := matrixColumn{min: min, max: max, distribution: "uniform"}
+	if len(spec) == 2 {
+		return column, nil
+	}
+	distribution, ok := spec[2].(string)
+	if !ok {
+		return matrixColumn{}, fmt.Errorf("distribution should be a string, one of \"uniform\", \"exponential\", \"gaussian\" or \"zipf\"")
+	}
+	column.distribution = distribution
+	if distribution == "uniform" {
+		if len(spec) == 4 {
+			return matrixColumn{}, fmt.Errorf("the uniform distribution takes no parameter")
+		}
+		return column, nil
+	}
+	if distribution != "exponential" && distribution != "gaussian" && distribution != "zipf" {
+		return matrixColumn{}, fmt.Errorf("unknown distribution %q, use one of \"uniform\", \"exponential\", \"gaussian\" or \"zipf\"", distribution)
+	}
+	if len(spec) != 4 {
+		return matrixColumn{}, fmt.Errorf("the %s distribution needs a parameter, like '[1,100,\"%s\",2.5]'", distribution, distribution)
+	}
+	switch parameter := spec[3].(type) {
+	case int64:
+		column.parameter = float64(parameter)
+	case float64:
+		column.parameter = parameter
+	default:
+		return matrixColumn{}, fmt.Errorf("the %s distribution parameter should be a number", distribution)
+	}
+	if distribution == "zipf" && column.parameter <= 1 {
+		return matrixColumn{}, fmt.Errorf("the zipf distribution parameter must be greater than 1")
+	}
+	return column, nil
+}
+
+func (c matrixColumn) draw(random *rand.Rand) (int64, error) {
+	switch c.distribution {
+	case "exponential":
+		return ExponentialRand(random, c.min, c.max, c.parameter)
+	case "gaussian":
+		return gaussianRand(random, c.min, c.max, c.parameter)
+	case "zipf":
+		return zipfRand(random, c.min, c.max, c.parameter), nil
+	default:
+		return uniformRand(random, c.min, c.max), nil
+	}
+}
+
 // Generates a random matrix with the given number of rows.
-// Each cell has a random integer value within the range given by columnSpec; each entry in the spec a 2-tuple
-func randomMatrix(random *rand.Rand, numRows int64, columnSpec [][]int64) []interface{} {
+// Each cell has a random integer value within the range given by columnSpec; each entry in the spec a column
+func randomMatrix(random *rand.Rand, numRows int64, columnSpec []matrixColumn) ([]interface{}, error) {
 	out := make([]interface{}, 0, numRows)
 	for i := 0; i < int(numRows); i++ {
 		row := make([]interface{}, len(columnSpec))
 		for col := 0; col < len(columnSpec); col++ {
-			min, max := columnSpec[col][0], columnSpec[col][1]
-			row[col] = uniformRand(random, min, max)
+			value, err := columnSpec[col].draw(random)
+			if err != nil {
+				return nil, err
+			}
+			row[col] = value
 		}
 		out = append(out, row)
 	}
+	return out, nil
+}
+
+// Zipfian over min through max, inclusive; min is the most likely value, with the probability of each following
+// value falling off with the given exponent, which must be greater than 1
+func zipfRand(random *rand.Rand, min, max int64, exponent float64) int64 {
+	return min + int64(rand.NewZipf(random, exponent, 1, uint64(max-min)).Uint64())
+}
+
+// Picks n distinct entries of src in random order, without modifying src; lists like those from csv(..) are shared
+// between clients. This is a Fisher-Yates shuffle that stops after n steps, tracking swaps in a map rather than a
+// copy of src, so sampling a few entries from a large list is cheap.
+func sample(random *rand.Rand, src []interface{}, n int) []interface{} {
+	swapped := make(map[int]interface{}, n)
+	at := func(i int) interface{} {
+		if v, found := swapped[i]; found {
+			return v
+		}
+		return src[i]
+	}
+	out := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		j := i + random.Intn(len(src)-i)
+		out[i] = at(j)
+		swapped[j] = at(i)
+	}
 	return out
 }
 
+// The contiguous share of rows that belongs to the given worker; together the partitions of all workers cover each
+// row exactly once, and their sizes differ by at most one row
+func csvPartition(rows []interface{}, worker, workers int64) []interface{} {
+	n := int64(len(rows))
+	return rows[n*worker/workers : n*(worker+1)/workers]
+}
+
 func uniformRand(random *rand.Rand, min, max int64) int64 {
 	return min + random.Int63n(max-min)
 }
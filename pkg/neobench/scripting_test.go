@@ -41,6 +41,7 @@ RETURN 1;`, 1)
 func TestSleepDuration(t *testing.T) {
 	tests := map[string]struct {
 		expectSleepDuration time.Duration
+		expectInTransaction bool
 		expectError         error
 	}{
 		":sleep 10": {
@@ -58,6 +59,17 @@ func TestSleepDuration(t *testing.T) {
 		":sleep 10 us": {
 			expectSleepDuration: 10 * time.Microsecond,
 		},
+		":sleep 10 ms in transaction": {
+			expectSleepDuration: 10 * time.Millisecond,
+			expectInTransaction: true,
+		},
+		":sleep 10 in transaction": {
+			expectSleepDuration: 10 * time.Second,
+			expectInTransaction: true,
+		},
+		":sleep 10 ms in session": {
+			expectError: fmt.Errorf(":sleep command can be followed by 'in transaction' - or nothing. got: in session (at testSleep:':sleep 10 ms in session':1:24)"),
+		},
 		":sleep 10 days": {
 			expectError: fmt.Errorf(":sleep command must use 'us', 'ms', or 's' unit argument - or none. got: days (at testSleep:':sleep 10 days':1:15)"),
 		},
@@ -77,6 +89,7 @@ func TestSleepDuration(t *testing.T) {
 			cmd := script.Commands[0].(SleepCommand)
 			actualDurationBase, err := cmd.Duration.Eval(nil)
 			assert.Equal(t, tc.expectSleepDuration, time.Duration(actualDurationBase.(int64))*cmd.Unit)
+			assert.Equal(t, tc.expectInTransaction, cmd.InTransaction)
 		})
 	}
 }
@@ -139,6 +152,47 @@ func TestExpressions(t *testing.T) {
 		"[1,2][0]":             int64(1),
 		"[1,2][1]":             int64(2),
 		"range(1, 5)[abs(-1)]": int64(2),
+		"2 * [1,2][1]":         int64(4),
+		"[1,2][-1]":            int64(2),
+		"[1,2][2]":             nil,
+
+		// Nested indexing, maps and ranges
+		`csv("/data.csv")[1][0]`:   "row2",
+		`[[1, [2, 3]]][0][1][1]`:   int64(3),
+		`{a: 1, b: {c: "x"}}["a"]`: int64(1),
+		`{a: 1, b: {c: "x"}}.b.c`:  "x",
+		`{a: 1}.missing`:           nil,
+		"range(1, 10)[1..4]":       []interface{}{int64(2), int64(3), int64(4)},
+		"range(1, 10)[..2]":        []interface{}{int64(1), int64(2)},
+		"range(1, 10)[8..]":        []interface{}{int64(9), int64(10)},
+		"range(1, 10)[-2..]":       []interface{}{int64(9), int64(10)},
+		"range(1, 10)[5..100]":     []interface{}{int64(6), int64(7), int64(8), int64(9), int64(10)},
+		"range(1, 10)[4..2]":       []interface{}{},
+		"$somelist[0..$scale]":     []interface{}{int64(1)},
+		"$somelist[$scale..2]":     []interface{}{int64(2)},
+		"range(1, 10)[1+1..2+1]":   []interface{}{int64(3)},
+		"range(1, 10)[1..1 + 1]":   []interface{}{int64(2)},
+		"[1.5, 2][0]":              1.5,
+
+		// Lists and maps
+		"[1, 2] + [3]": []interface{}{int64(1), int64(2), int64(3)},
+		"[1, 2] + 3":   []interface{}{int64(1), int64(2), int64(3)},
+		`"a" + ["b"]`:  []interface{}{"a", "b"},
+		`map_merge({a: 1, b: 2}, {b: 3}, {c: [4]})`:                       map[string]interface{}{"a": int64(1), "b": int64(3), "c": []interface{}{int64(4)}},
+		`keys({b: 1, a: 2})`:                                              []interface{}{"a", "b"},
+		`values({b: 1, a: 2})`:                                            []interface{}{int64(2), int64(1)},
+		`map_from(["id", "name"], [7, "x"])`:                              map[string]interface{}{"id": int64(7), "name": "x"},
+		`map_merge(map_from(["name"], csv("/data.csv")[0][..1]), {n: 1})`: map[string]interface{}{"name": "row1", "n": int64(1)},
+		`len({a: 1})`: int64(1),
+		`len("abc")`:  int64(3),
+
+		// Hashing
+		`hash("user-42")`:      int64(3658848852250057419),
+		`hash(7)`:              int64(3414760188119455638),
+		`hash("7")`:            int64(3414760188119455638),
+		`bucket("user-42", 8)`: int64(3),
+		`bucket(7, 8)`:         int64(6),
+		`bucket(7, 1)`:         int64(0),
 
 		// List comprehension
 		"[ i in range(1,3) | $i ]": []interface{}{int64(1), int64(2), int64(3)},
@@ -149,6 +203,8 @@ func TestExpressions(t *testing.T) {
 		"csv(\"/data.csv\")": []interface{}{
 			[]interface{}{"row1", int64(1), 1.3},
 			[]interface{}{"row2", int64(2), 1.0}},
+		"csv_partition(\"/data.csv\", 1, 2)": []interface{}{
+			[]interface{}{"row2", int64(2), 1.0}},
 		"double(5432)":                   float64(5432),
 		"double(5432.0)":                 float64(5432),
 		"greatest(5, 4, 3, 2)":           int64(5),
@@ -157,6 +213,8 @@ func TestExpressions(t *testing.T) {
 		"least(5, 4, 3, 2)":              int64(2),
 		"least(5, 4, 3, 2.0, 8)":         2.0,
 		"least(-5, -4, -3, -2)":          int64(-5),
+		"ldbc_random_person(1)":          int64(459),
+		"ldbc_random_message($scale)":    int64(2<<32 | 3),
 		"len([1,2,3])":                   int64(3),
 		"len([])":                        int64(0),
 		"int(5.4 + 3.8)":                 int64(9),
@@ -169,7 +227,12 @@ func TestExpressions(t *testing.T) {
 		"random_matrix(2, [1,5], [5,8])": []interface{}{
 			[]interface{}{int64(3), int64(5)},
 			[]interface{}{int64(1), int64(5)}},
-		"sqrt(2.0)": 1.414213562,
+		`random_matrix(2, [1,100,"zipf",1.1], [1,10,"uniform"])`: []interface{}{
+			[]interface{}{int64(3), int64(1)},
+			[]interface{}{int64(8), int64(7)}},
+		"sqrt(2.0)":               1.414213562,
+		"sample(range(1, 10), 3)": []interface{}{int64(9), int64(10), int64(2)},
+		"shuffle([1, 2, 3])":      []interface{}{int64(2), int64(1), int64(3)},
 	}
 
 	for expr, expected := range tc {
@@ -190,6 +253,9 @@ RETURN {v};`, expr), 1)
 					"/data.csv": `row1, 1, 1.3
 "row2", 2, 1.0`,
 				}),
+				LDBCIds: NewLDBCIdLoader(func() (*LDBCIds, error) {
+					return NewLDBCIds(1, []int64{0, 3, 5}), nil
+				}),
 			})
 			assert.NoError(t, err, "%+v", err)
 			actual := uow.Statements[0].Params["v"]
@@ -202,6 +268,117 @@ RETURN {v};`, expr), 1)
 	}
 }
 
+func TestRandomMatrixColumnDistributions(t *testing.T) {
+	script, err := Parse("test:random_matrix", `:set m random_matrix(1000, [1,1000,"zipf",1.5], [1,1000], [1,1000,"exponential",5.0])
+RETURN $m;`, 1)
+	assert.NoError(t, err)
+	uow, err := script.Eval(ScriptContext{
+		Vars: map[string]interface{}{},
+		Rand: rand.New(rand.NewSource(1337)),
+	})
+	assert.NoError(t, err)
+
+	lows := make([]int, 3)
+	for _, row := range uow.Statements[0].Params["m"].([]interface{}) {
+		for col, value := range row.([]interface{}) {
+			assert.GreaterOrEqual(t, value.(int64), int64(1))
+			assert.LessOrEqual(t, value.(int64), int64(1000))
+			if value.(int64) <= 10 {
+				lows[col] += 1
+			}
+		}
+	}
+	// Uniform puts about 1% of values in 1..10, the skewed distributions the majority
+	assert.Greater(t, lows[0], 500)
+	assert.Less(t, lows[1], 50)
+	assert.Greater(t, lows[2], 30)
+}
+
+func TestRandomMatrixColumnSpecErrors(t *testing.T) {
+	tc := map[string]string{
+		`[1,100,"pareto",2]`:  `unknown distribution "pareto"`,
+		`[1,100,"zipf"]`:      "the zipf distribution needs a parameter",
+		`[1,100,"zipf",1]`:    "the zipf distribution parameter must be greater than 1",
+		`[1,100,"uniform",2]`: "the uniform distribution takes no parameter",
+		`[100,1]`:             "should have max greater than min",
+	}
+	for spec, expected := range tc {
+		script, err := Parse("test:random_matrix", fmt.Sprintf(":set m random_matrix(2, %s)\nRETURN $m;", spec), 1)
+		assert.NoError(t, err)
+		_, err = script.Eval(ScriptContext{
+			Vars: map[string]interface{}{},
+			Rand: rand.New(rand.NewSource(1337)),
+		})
+		if assert.Error(t, err, spec) {
+			assert.Contains(t, err.Error(), expected)
+		}
+	}
+}
+
+func TestMapAndHashFunctionErrors(t *testing.T) {
+	tc := map[string]string{
+		`map_merge({a: 1})`:         "map_merge(..) requires two or more maps",
+		`map_merge({a: 1}, [1])`:    "argument 2 to map_merge(..) needs to be a map",
+		`keys([1])`:                 "argument 1 to keys(..) needs to be a map",
+		`map_from(["a", "b"], [1])`: "map_from(..) got 2 keys but 1 values",
+		`map_from(["a"], 1)`:        "map_from(..) requires a list of keys and a list of values",
+		`map_from([["a"]], [1])`:    "don't know how to convert '[a]' to string",
+		`hash([1])`:                 "can only hash strings and numbers",
+		`bucket("a", 0)`:            "number of buckets must be a positive integer",
+		`bucket("a", 2.5)`:          "number of buckets must be a positive integer",
+	}
+	for expr, expected := range tc {
+		script, err := Parse("test:maps", fmt.Sprintf(":set m %s\nRETURN $m;", expr), 1)
+		assert.NoError(t, err)
+		_, err = script.Eval(ScriptContext{
+			Vars: map[string]interface{}{},
+			Rand: rand.New(rand.NewSource(1337)),
+		})
+		if assert.Error(t, err, expr) {
+			assert.Contains(t, err.Error(), expected)
+		}
+	}
+}
+
+func TestSampleIsWithoutReplacement(t *testing.T) {
+	random := rand.New(rand.NewSource(1337))
+	src := make([]interface{}, 0, 100)
+	for i := int64(0); i < 100; i++ {
+		src = append(src, i)
+	}
+
+	for n := 0; n <= len(src); n += 10 {
+		out := sample(random, src, n)
+		assert.Len(t, out, n)
+		seen := make(map[interface{}]bool)
+		for _, v := range out {
+			assert.False(t, seen[v], "sampled %v twice", v)
+			seen[v] = true
+		}
+	}
+	// The source list is left alone, it may be shared with other clients
+	for i, v := range src {
+		assert.Equal(t, int64(i), v)
+	}
+}
+
+func TestCsvPartitionsAreDisjointAndComplete(t *testing.T) {
+	rows := make([]interface{}, 0, 103)
+	for i := int64(0); i < 103; i++ {
+		rows = append(rows, i)
+	}
+
+	for _, workers := range []int64{1, 4, 7, 200} {
+		var all []interface{}
+		for worker := int64(0); worker < workers; worker++ {
+			partition := csvPartition(rows, worker, workers)
+			assert.InDelta(t, float64(len(rows))/float64(workers), len(partition), 1)
+			all = append(all, partition...)
+		}
+		assert.Equal(t, rows, all, "%d workers", workers)
+	}
+}
+
 func TestDebugFunction(t *testing.T) {
 	vars := map[string]interface{}{"scale": int64(1)}
 	script, err := Parse("test:debug(..)", ":set blah debug(1337) * 10\nRETURN { blah };", 1)
@@ -322,3 +499,62 @@ MATCH (b);`, 1)
 		},
 	}, uow.Statements)
 }
+
+func TestAutocommitMarksFollowingStatement(t *testing.T) {
+	script, err := Parse("batching", `
+CREATE (:Marker);
+:autocommit
+LOAD CSV FROM "file:///x.csv" AS row
+CALL { WITH row CREATE (:Row {v: row[0]}) } IN TRANSACTIONS OF 1000 ROWS;
+MATCH (m:Marker) DELETE m;`, 1)
+	assert.NoError(t, err)
+	assert.Len(t, script.Commands, 3)
+	assert.False(t, script.Commands[0].(QueryCommand).Autocommit)
+	assert.True(t, script.Commands[1].(QueryCommand).Autocommit)
+	assert.Contains(t, script.Commands[1].(QueryCommand).Query, "IN TRANSACTIONS")
+	assert.False(t, script.Commands[2].(QueryCommand).Autocommit)
+
+	uow, err := script.Eval(ScriptContext{Vars: map[string]interface{}{}})
+	assert.NoError(t, err)
+	assert.True(t, uow.Segmented)
+	assert.True(t, uow.Statements[1].Autocommit)
+
+	_, err = Parse("dangling", `:autocommit`, 1)
+	assert.Error(t, err)
+}
+
+func TestReadbackMarksFollowingStatement(t *testing.T) {
+	script, err := Parse("ryw", `
+MERGE (n:Key {key: 1}) SET n.value = $value;
+:readback
+MATCH (n:Key {key: 1}) RETURN n.value = $value;`, 1)
+	assert.NoError(t, err)
+	assert.Len(t, script.Commands, 2)
+	assert.False(t, script.Commands[0].(QueryCommand).Readback)
+	assert.True(t, script.Commands[1].(QueryCommand).Readback)
+
+	uow, err := script.Eval(ScriptContext{Vars: map[string]interface{}{"value": int64(1)}})
+	assert.NoError(t, err)
+	assert.True(t, uow.Segmented)
+	assert.True(t, uow.Statements[1].Readback)
+
+	_, err = Parse("dangling", `:readback`, 1)
+	assert.Error(t, err)
+}
+
+func TestUseSwitchesDatabaseForFollowingStatements(t *testing.T) {
+	script, err := Parse("admin", `
+MATCH (n) RETURN count(n);
+:use system
+CREATE USER $name SET PASSWORD 'secret';
+:use `+"`tenant-1`"+`
+MATCH (n) RETURN count(n);`, 1)
+	assert.NoError(t, err)
+
+	uow, err := script.Eval(ScriptContext{Vars: map[string]interface{}{"name": "bob"}})
+	assert.NoError(t, err)
+	assert.True(t, uow.Segmented)
+	assert.Equal(t, "", uow.Statements[0].Database)
+	assert.Equal(t, "system", uow.Statements[1].Database)
+	assert.Equal(t, "tenant-1", uow.Statements[2].Database)
+}
@@ -0,0 +1,254 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A case for `neobench test`, which evaluates a script the way a worker would, but without a database, and checks
+// the statements it produces; so teams can unit test the logic of their workloads in CI. Cases are read from YAML,
+// see docs/scripts.md for an example.
+type ScriptTestCase struct {
+	Name string `yaml:"name"`
+	// Seed for the random functions of the script; the same seed produces the same statements
+	Seed int64 `yaml:"seed"`
+	// Variables, as with -D; scale defaults to 1 and nbWorkers to 1
+	Vars map[string]interface{} `yaml:"vars"`
+	// As nbWorkerId
+	Worker int64 `yaml:"worker"`
+	// Times to evaluate the script, each evaluation being checked; for random logic, more runs cover more draws
+	Runs int `yaml:"runs"`
+	// The statements the script must produce, in order; if set, the script must produce exactly this many
+	Expect []ExpectedStatement `yaml:"expect"`
+	// If set, evaluating the script must fail with an error containing this
+	Error string `yaml:"error"`
+}
+
+// What to check about one statement; only what is set is checked
+type ExpectedStatement struct {
+	// The query, exactly, after local $$params are substituted; surrounding whitespace and the ending ; are ignored
+	Query string `yaml:"query"`
+	// Part of the query
+	Contains string `yaml:"contains"`
+	// Parameter values, exactly; numbers compare by value, so 1 and 1.0 are equal
+	Params map[string]interface{} `yaml:"params"`
+	// Inclusive ranges numeric parameters must be within, as [min, max]
+	Ranges map[string][2]float64 `yaml:"ranges"`
+	// Database the statement runs against, as set by `:use`
+	Database string `yaml:"database"`
+	// Whether the statement runs in a transaction of its own, as with `:autocommit`
+	Autocommit *bool `yaml:"autocommit"`
+}
+
+// Reads test cases from a YAML file with a list of cases
+func LoadScriptTestCases(path string) ([]ScriptTestCase, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []ScriptTestCase
+	if err := yaml.Unmarshal(raw, &cases); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse test cases in %s", path)
+	}
+	for i, c := range cases {
+		if c.Name == "" {
+			cases[i].Name = fmt.Sprintf("#%d", i+1)
+		}
+	}
+	return cases, nil
+}
+
+// Runs each case against the given script, writing the outcome of each to out; returns the number that failed
+func RunScriptTests(script Script, cases []ScriptTestCase, csvLoader *CsvLoader, out io.Writer) int {
+	failed := 0
+	for _, c := range cases {
+		if err := runScriptTest(script, c, csvLoader); err != nil {
+			failed++
+			fmt.Fprintf(out, "FAIL %s: %s\n", c.Name, err)
+			continue
+		}
+		fmt.Fprintf(out, "ok   %s\n", c.Name)
+	}
+	fmt.Fprintf(out, "\n%d of %d cases passed for %s\n", len(cases)-failed, len(cases), script.Name)
+	return failed
+}
+
+func runScriptTest(script Script, c ScriptTestCase, csvLoader *CsvLoader) error {
+	vars := map[string]interface{}{"scale": int64(1), WorkersVar: int64(1), UsersVar: int64(1)}
+	for k, v := range c.Vars {
+		vars[k] = scriptTestValue(v)
+	}
+	random := rand.New(rand.NewSource(c.Seed))
+	sharedState := NewSharedState()
+	// The runs of a case are units of work of the same user, so `:user` variables carry over between them
+	user := NewUserState(c.Worker)
+	runs := c.Runs
+	if runs < 1 {
+		runs = 1
+	}
+	for run := 0; run < runs; run++ {
+		// Like preflights, tests don't send anything to a database, so there's no transaction to sleep in
+		uow, err := script.Eval(ScriptContext{
+			PreflightMode: true,
+			Script:        script,
+			Stderr:        ioutil.Discard,
+			Vars:          createVars(vars, c.Worker),
+			Rand:          random,
+			CsvLoader:     csvLoader,
+			SharedState:   sharedState,
+			User:          user,
+		})
+		if c.Error != "" {
+			if err == nil {
+				return fmt.Errorf("expected an error containing '%s', but the script evaluated fine", c.Error)
+			}
+			if !strings.Contains(err.Error(), c.Error) {
+				return fmt.Errorf("expected an error containing '%s', got: %s", c.Error, err)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkStatements(c.Expect, uow.Statements); err != nil {
+			if runs > 1 {
+				return fmt.Errorf("run %d of %d: %s", run+1, runs, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func checkStatements(expected []ExpectedStatement, actual []Statement) error {
+	if expected == nil {
+		return nil
+	}
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d statements, the script produced %d", len(expected), len(actual))
+	}
+	for i, e := range expected {
+		if err := e.check(actual[i]); err != nil {
+			return fmt.Errorf("statement %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (e ExpectedStatement) check(s Statement) error {
+	if e.Query != "" && normalizeQuery(e.Query) != normalizeQuery(s.Query) {
+		return fmt.Errorf("expected query '%s', got '%s'", normalizeQuery(e.Query), normalizeQuery(s.Query))
+	}
+	if e.Contains != "" && !strings.Contains(s.Query, e.Contains) {
+		return fmt.Errorf("expected query to contain '%s', got '%s'", e.Contains, strings.TrimSpace(s.Query))
+	}
+	for _, name := range sortedKeys(e.Params) {
+		actual, found := s.Params[name]
+		if !found {
+			return fmt.Errorf("expected parameter $%s, the statement has none", name)
+		}
+		if !scriptValuesEqual(scriptTestValue(e.Params[name]), actual) {
+			return fmt.Errorf("expected $%s to be %v, got %v", name, e.Params[name], actual)
+		}
+	}
+	for name, bounds := range e.Ranges {
+		actual, found := s.Params[name]
+		if !found {
+			return fmt.Errorf("expected parameter $%s, the statement has none", name)
+		}
+		n, err := asNumber(actual)
+		if err != nil {
+			return fmt.Errorf("expected $%s to be a number between %g and %g, got %v", name, bounds[0], bounds[1], actual)
+		}
+		if n.val < bounds[0] || n.val > bounds[1] {
+			return fmt.Errorf("expected $%s to be between %g and %g, got %v", name, bounds[0], bounds[1], actual)
+		}
+	}
+	if e.Database != "" && e.Database != s.Database {
+		return fmt.Errorf("expected the statement to run against database '%s', got '%s'", e.Database, s.Database)
+	}
+	if e.Autocommit != nil && *e.Autocommit != s.Autocommit {
+		return fmt.Errorf("expected autocommit to be %t, got %t", *e.Autocommit, s.Autocommit)
+	}
+	return nil
+}
+
+func normalizeQuery(query string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Converts values read from YAML into the types scripts work with: int64, float64, string, lists and maps of these
+func scriptTestValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = scriptTestValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = scriptTestValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// Compares values the way a test author would expect: numbers by value, lists and maps item by item
+func scriptValuesEqual(expected, actual interface{}) bool {
+	if e, err := asNumber(expected); err == nil {
+		a, err := asNumber(actual)
+		if err != nil {
+			return false
+		}
+		if !e.isDouble && !a.isDouble {
+			return e.iVal == a.iVal
+		}
+		return e.val == a.val
+	}
+	switch e := expected.(type) {
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !scriptValuesEqual(e[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for k, v := range e {
+			if !scriptValuesEqual(v, a[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(expected, actual)
+}
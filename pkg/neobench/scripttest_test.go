@@ -0,0 +1,81 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScriptTestCases(t *testing.T) {
+	script, err := Parse("transfer.script", `
+:set from random(1, 100 * $scale)
+:set amount 10
+MATCH (a:Account {aid: $from}) SET a.balance = a.balance - $amount;
+:use audit
+CREATE (:Transfer {amount: $amount, tags: ["a", 1]});
+`, 1)
+	assert.NoError(t, err)
+	var cases []ScriptTestCase
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+- name: passes
+  vars: {scale: 2}
+  runs: 20
+  expect:
+    - contains: "MATCH (a:Account"
+      params: {amount: 10.0}
+      ranges: {from: [1, 200]}
+    - query: "CREATE (:Transfer {amount: $amount, tags: [\"a\", 1]})"
+      database: audit
+      autocommit: false
+- name: out of range
+  runs: 50
+  expect:
+    - ranges: {from: [1, 10]}
+    - {}
+- name: wrong count
+  expect:
+    - {}
+- name: wrong param
+  expect:
+    - params: {amount: 11}
+    - {}
+`), &cases))
+
+	out := strings.Builder{}
+	failed := RunScriptTests(script, cases, NewCsvLoader(), &out)
+
+	assert.Equal(t, 3, failed, out.String())
+	assert.Contains(t, out.String(), "ok   passes\n")
+	assert.Contains(t, out.String(), "FAIL out of range: run ")
+	assert.Contains(t, out.String(), "FAIL wrong count: expected 1 statements, the script produced 2\n")
+	assert.Contains(t, out.String(), "FAIL wrong param: statement 1: expected $amount to be 11, got 10\n")
+	assert.Contains(t, out.String(), "1 of 4 cases passed for transfer.script")
+}
+
+func TestScriptTestsDontSleepInTransactions(t *testing.T) {
+	script, err := Parse("locks.script", "RETURN 1;\n:sleep 60 s in transaction\nRETURN 2;", 1)
+	assert.NoError(t, err)
+
+	out := strings.Builder{}
+	start := time.Now()
+	failed := RunScriptTests(script, []ScriptTestCase{{Name: "holds locks", Runs: 5, Expect: []ExpectedStatement{{}, {}}}}, NewCsvLoader(), &out)
+
+	assert.Equal(t, 0, failed, out.String())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestScriptTestExpectedErrors(t *testing.T) {
+	script, err := Parse("broken.script", ":set x $missing\nRETURN $x;", 1)
+	assert.NoError(t, err)
+
+	out := strings.Builder{}
+	failed := RunScriptTests(script, []ScriptTestCase{
+		{Name: "expects the error", Error: "missing"},
+		{Name: "expects another error", Error: "something else"},
+	}, NewCsvLoader(), &out)
+
+	assert.Equal(t, 1, failed, out.String())
+	assert.Contains(t, out.String(), "ok   expects the error\n")
+}
@@ -0,0 +1,143 @@
+package neobench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How `neobench selftest` runs each builtin: in a neobench process of its own, like a user would, so the flags,
+// the dataset generator, the workload and the report are all covered
+type SelfTest struct {
+	// The neobench binary to test
+	Executable string
+	// Database to run against, and credentials for it
+	Url, User, Password string
+	// Builtin workloads to run, ex: tpcb-like
+	Builtins []string
+	// Scale of the datasets; tiny, so the whole test takes minutes
+	Scale float64
+	// How long to run each workload for
+	Duration time.Duration
+}
+
+// Inits and runs each builtin in turn, writing the outcome of each to out, along with the output of the runs that
+// failed; returns the number of builtins that failed
+func (s SelfTest) Run(out io.Writer) int {
+	failed := 0
+	for _, name := range s.Builtins {
+		start := time.Now()
+		args := []string{
+			"--address", s.Url, "--user", s.User, "--password", s.Password,
+			"--init", "--builtin", name, "--scale", strconv.FormatFloat(s.Scale, 'f', -1, 64),
+			"--duration", s.Duration.String(), "--progress", s.Duration.String(), "--output", "csv",
+		}
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(s.Executable, args...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		if err == nil {
+			err = checkSelfTestReport(stdout.String())
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "FAIL %s: %s\n", name, err)
+			fmt.Fprintf(out, "  $ %s %s\n", s.Executable, strings.Join(redactPassword(args), " "))
+			for _, line := range strings.Split(strings.TrimSpace(stderr.String()+stdout.String()), "\n") {
+				fmt.Fprintf(out, "  %s\n", line)
+			}
+			continue
+		}
+		fmt.Fprintf(out, "ok   %s (%s)\n", name, time.Since(start).Round(time.Second))
+	}
+	fmt.Fprintf(out, "\n%d of %d builtins passed\n", len(s.Builtins)-failed, len(s.Builtins))
+	return failed
+}
+
+// The given arguments with the value of --password left out, so the command of a failed run can go in CI logs
+func redactPassword(args []string) []string {
+	out := append([]string{}, args...)
+	for i := 0; i+1 < len(out); i++ {
+		if out[i] == "--password" {
+			out[i+1] = "<redacted>"
+		}
+	}
+	return out
+}
+
+// Checks the csv report of a throughput run: it must list at least one script, and every script must have had
+// transactions succeed
+func checkSelfTestReport(report string) error {
+	records, err := csv.NewReader(strings.NewReader(report)).ReadAll()
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse report")
+	}
+	if len(records) < 2 || len(records[0]) < 3 || records[0][0] != "script" {
+		return fmt.Errorf("expected a report with a row for each script, got: %q", report)
+	}
+	for _, record := range records[1:] {
+		succeeded, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return fmt.Errorf("expected the number of successful transactions of %s, got '%s'", record[0], record[1])
+		}
+		if succeeded == 0 {
+			return fmt.Errorf("no transactions of %s succeeded, %s failed", record[0], record[2])
+		}
+	}
+	return nil
+}
+
+// Starts a throwaway Neo4j container with docker, returning its bolt url and a function that removes it. The
+// container is started with the given password for the neo4j user, and with the enterprise license accepted, which
+// only matters for enterprise images.
+func StartNeo4jContainer(image, password string) (string, func(), error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", nil, fmt.Errorf("starting a Neo4j container needs docker: %s", err)
+	}
+	out, err := exec.Command("docker", "run", "--detach", "--rm", "--publish", "7687",
+		"--env", "NEO4J_AUTH=neo4j/"+password, "--env", "NEO4J_ACCEPT_LICENSE_AGREEMENT=yes", image).Output()
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to start %s container", image)
+	}
+	containerId := strings.TrimSpace(string(out))
+	stop := func() {
+		_ = exec.Command("docker", "rm", "--force", containerId).Run()
+	}
+
+	out, err = exec.Command("docker", "port", containerId, "7687").Output()
+	if err != nil {
+		stop()
+		return "", nil, errors.Wrapf(err, "failed to find bolt port of container %s", containerId)
+	}
+	// Output is one line per address the port is bound to, ex: 0.0.0.0:49153
+	hostPort := strings.Split(strings.TrimSpace(string(out)), "\n")[0]
+	port := hostPort[strings.LastIndex(hostPort, ":")+1:]
+	return fmt.Sprintf("neo4j://localhost:%s", port), stop, nil
+}
+
+// Waits for the database at url to accept connections, as freshly started containers take a while to
+func AwaitNeo4j(url, user, password string, timeout time.Duration) error {
+	driver, err := neo4j.NewDriver(url, neo4j.BasicAuth(user, password, ""))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create driver for %s", url)
+	}
+	defer driver.Close()
+	deadline := time.Now().Add(timeout)
+	for {
+		err = driver.VerifyConnectivity()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "gave up waiting for Neo4j at %s after %s", url, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,29 @@
+package neobench
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCheckSelfTestReport(t *testing.T) {
+	assert.NoError(t, checkSelfTestReport("script,succeeded,failed,transactions_per_second\n"+
+		"\"builtin:ldbc-like/ic2\",120.000,0.000,24.000\n\"builtin:ldbc-like/ic6\",410.000,0.000,82.000\n"))
+
+	err := checkSelfTestReport("script,succeeded,failed,transactions_per_second\n\"builtin:tpcp-like\",0.000,12.000,0.000\n")
+	assert.EqualError(t, err, "no transactions of builtin:tpcp-like succeeded, 12.000 failed")
+
+	assert.Error(t, checkSelfTestReport("script,succeeded,failed,transactions_per_second\n"))
+	assert.Error(t, checkSelfTestReport("== Results ==\n"))
+}
+
+func TestSelfTestDoesNotPrintThePassword(t *testing.T) {
+	out := bytes.Buffer{}
+	selfTest := SelfTest{Executable: "neobench-that-does-not-exist", Url: "neo4j://db:7687", User: "neo4j", Password: "hunter2",
+		Builtins: []string{"tpcb-like"}, Scale: 0.01, Duration: time.Second}
+
+	assert.Equal(t, 1, selfTest.Run(&out))
+	assert.Contains(t, out.String(), "--user neo4j --password <redacted> --init")
+	assert.NotContains(t, out.String(), "hunter2")
+}
@@ -0,0 +1,257 @@
+package neobench
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A server metric to follow over the run. Neo4j names its metrics differently by version and database, like
+// neo4j_page_cache_hit_ratio or neo4j_dbms_page_cache_hit_ratio, so metrics are matched on part of the name, and
+// every metric that matches counts.
+type serverMetric struct {
+	Name string
+	// Matches metrics whose name contains any of these
+	match []string
+	// Counters are reported as their increase since the previous sample, summed over the metrics that match;
+	// gauges as their value, averaged over them
+	counter bool
+	// Gauges that are summed rather than averaged, like sizes of the stores of each database
+	summed bool
+}
+
+// The server metrics included in the report
+var serverMetrics = []serverMetric{
+	{Name: "Page cache hit ratio", match: []string{"page_cache_hit_ratio"}},
+	{Name: "GC time (ms)", match: []string{"vm_gc_time"}, counter: true},
+	{Name: "Checkpoints", match: []string{"check_point_events", "checkpoint_events"}, counter: true},
+	{Name: pageFaultsMetric, match: []string{"page_cache_page_faults"}, counter: true},
+	{Name: StoreSizeMetric, match: []string{"store_size_total"}, summed: true},
+}
+
+// Scrapes the Prometheus metrics endpoint of the server at each progress checkpoint, see --server-metrics, so the
+// report can show what the server was doing alongside the latencies the workers saw: a page cache that stops
+// hitting, GC pauses, checkpoints.
+type ServerMetrics struct {
+	// Ex: http://localhost:2004/metrics
+	Url     string
+	client  *http.Client
+	start   time.Time
+	samples []ServerMetricsSample
+	// Counter values at the previous sample, by metric
+	previous map[string]float64
+}
+
+// Server metrics at one point during the run
+type ServerMetricsSample struct {
+	// Since the benchmark started
+	Elapsed time.Duration
+	// By serverMetric name; metrics the server didn't expose are missing
+	Values map[string]float64
+}
+
+type ServerMetricsResult struct {
+	Url     string
+	Samples []ServerMetricsSample
+}
+
+func NewServerMetrics(url string) *ServerMetrics {
+	return &ServerMetrics{
+		Url: url,
+		// Scrapes happen in between progress reports, they can't be allowed to take long
+		client: &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// Records where the counters are at when the benchmark starts; samples report their increase since then
+func (m *ServerMetrics) Begin(start time.Time) error {
+	m.start = start
+	raw, err := m.scrape()
+	if err != nil {
+		return err
+	}
+	m.previous = counterValues(raw)
+	return nil
+}
+
+// Scrapes the server and records how its metrics have changed since the last sample
+func (m *ServerMetrics) Sample(now time.Time) (ServerMetricsSample, error) {
+	raw, err := m.scrape()
+	if err != nil {
+		return ServerMetricsSample{}, err
+	}
+	sample := ServerMetricsSample{Elapsed: now.Sub(m.start), Values: make(map[string]float64)}
+	counters := counterValues(raw)
+	for _, metric := range serverMetrics {
+		values := metric.values(raw)
+		if len(values) == 0 {
+			continue
+		}
+		if metric.counter {
+			if previous, found := m.previous[metric.Name]; found && counters[metric.Name] >= previous {
+				sample.Values[metric.Name] = counters[metric.Name] - previous
+			}
+			continue
+		}
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		if metric.summed {
+			sample.Values[metric.Name] = sum
+		} else {
+			sample.Values[metric.Name] = sum / float64(len(values))
+		}
+	}
+	m.previous = counters
+	m.samples = append(m.samples, sample)
+	return sample, nil
+}
+
+func (m *ServerMetrics) Result() *ServerMetricsResult {
+	return &ServerMetricsResult{Url: m.Url, Samples: m.samples}
+}
+
+func (m *ServerMetrics) scrape() (map[string][]float64, error) {
+	res, err := m.client.Get(m.Url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scrape server metrics")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		return nil, fmt.Errorf("failed to scrape server metrics from %s: %s", m.Url, res.Status)
+	}
+	metrics, err := parsePrometheusText(res.Body)
+	return metrics, errors.Wrapf(err, "failed to read server metrics from %s", m.Url)
+}
+
+// Values of all metrics matching this one
+func (s serverMetric) values(raw map[string][]float64) []float64 {
+	values := make([]float64, 0)
+	for name, v := range raw {
+		if s.matches(name) {
+			values = append(values, v...)
+		}
+	}
+	return values
+}
+
+func (s serverMetric) matches(name string) bool {
+	for _, match := range s.match {
+		if strings.Contains(name, match) {
+			return true
+		}
+	}
+	return false
+}
+
+// Totals of the counters among serverMetrics, by name
+func counterValues(raw map[string][]float64) map[string]float64 {
+	counters := make(map[string]float64)
+	for _, metric := range serverMetrics {
+		if !metric.counter {
+			continue
+		}
+		values := metric.values(raw)
+		if len(values) == 0 {
+			continue
+		}
+		total := 0.0
+		for _, v := range values {
+			total += v
+		}
+		counters[metric.Name] = total
+	}
+	return counters
+}
+
+// Reads metrics in the Prometheus text format, by name; series of the same metric with different labels each add
+// a value. Comments, type hints and timestamps are ignored.
+func parsePrometheusText(r io.Reader) (map[string][]float64, error) {
+	metrics := make(map[string][]float64)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest := line, ""
+		if i := strings.IndexAny(line, "{ \t"); i >= 0 {
+			name, rest = line[:i], line[i:]
+		}
+		if strings.HasPrefix(rest, "{") {
+			end := labelsEnd(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated labels in '%s'", line)
+			}
+			rest = rest[end+1:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("missing value in '%s'", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in '%s'", line)
+		}
+		if math.IsNaN(value) {
+			continue
+		}
+		metrics[name] = append(metrics[name], value)
+	}
+	return metrics, scanner.Err()
+}
+
+// Index of the brace that closes the labels at the start of s, skipping over quoted label values
+func labelsEnd(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// Server metrics at each progress report, alongside the client side throughput in the rest of the report
+func writeServerMetricsReport(result Result, s *strings.Builder) {
+	metrics := result.ServerMetrics
+	if metrics == nil || len(metrics.Samples) == 0 {
+		return
+	}
+	s.WriteString(fmt.Sprintf("-- Server metrics --\n\n"))
+	s.WriteString(fmt.Sprintf("  Scraped from %s at each progress report; counters show their increase since the report before\n", metrics.Url))
+	for _, sample := range metrics.Samples {
+		values := make([]string, 0, len(serverMetrics))
+		for _, metric := range serverMetrics {
+			value, found := sample.Values[metric.Name]
+			if !found {
+				continue
+			}
+			if metric.counter {
+				values = append(values, fmt.Sprintf("%s: %.0f", metric.Name, value))
+			} else {
+				values = append(values, fmt.Sprintf("%s: %.3f", metric.Name, value))
+			}
+		}
+		if len(values) == 0 {
+			values = append(values, "none of the metrics neobench reports were exposed")
+		}
+		s.WriteString(fmt.Sprintf("    %s: %s\n", sample.Elapsed.Round(time.Second), strings.Join(values, ", ")))
+	}
+	s.WriteString("\n")
+}
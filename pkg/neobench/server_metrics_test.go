@@ -0,0 +1,72 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePrometheusText(t *testing.T) {
+	metrics, err := parsePrometheusText(strings.NewReader(`# HELP neo4j_dbms_page_cache_hit_ratio The ratio of hits
+# TYPE neo4j_dbms_page_cache_hit_ratio gauge
+neo4j_dbms_page_cache_hit_ratio 0.98
+neo4j_vm_gc_time_total{gc="G1 Young Generation"} 120 1625000000000
+neo4j_vm_gc_time_total{gc="odd } name, \" quoted"} 30
+neo4j_other NaN
+`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]float64{
+		"neo4j_dbms_page_cache_hit_ratio": {0.98},
+		"neo4j_vm_gc_time_total":          {120, 30},
+	}, metrics)
+
+	_, err = parsePrometheusText(strings.NewReader("neo4j_broken{a=\"b\" 1\n"))
+	assert.Error(t, err)
+}
+
+func TestServerMetricsReportsCounterIncreases(t *testing.T) {
+	scrapes := []string{
+		"neo4j_neo4j_check_point_events_total 4\nneo4j_vm_gc_time_g1_young_generation_total 100\n",
+		"neo4j_neo4j_check_point_events_total 5\nneo4j_vm_gc_time_g1_young_generation_total 130\nneo4j_page_cache_hit_ratio 0.5\n",
+		"neo4j_neo4j_check_point_events_total 5\nneo4j_vm_gc_time_g1_young_generation_total 180\nneo4j_page_cache_hit_ratio 0.9\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, scrapes[0])
+		scrapes = scrapes[1:]
+	}))
+	defer server.Close()
+
+	start := time.Unix(0, 0)
+	metrics := NewServerMetrics(server.URL)
+	assert.NoError(t, metrics.Begin(start))
+	_, err := metrics.Sample(start.Add(10 * time.Second))
+	assert.NoError(t, err)
+	_, err = metrics.Sample(start.Add(20 * time.Second))
+	assert.NoError(t, err)
+
+	s := strings.Builder{}
+	writeServerMetricsReport(Result{ServerMetrics: metrics.Result()}, &s)
+	assert.Equal(t, fmt.Sprintf(`-- Server metrics --
+
+  Scraped from %s at each progress report; counters show their increase since the report before
+    10s: Page cache hit ratio: 0.500, GC time (ms): 30, Checkpoints: 1
+    20s: Page cache hit ratio: 0.900, GC time (ms): 50, Checkpoints: 0
+
+`, server.URL), s.String())
+}
+
+func TestServerMetricsScrapeFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "metrics are disabled", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := NewServerMetrics(server.URL).Begin(time.Now())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "404 Not Found")
+}
@@ -0,0 +1,86 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"strconv"
+	"text/scanner"
+)
+
+// Settings for the sessions a script runs in, set with `:db`, `:fetch-size` and `:bookmark`, so scripts in a mix can
+// each be run the way the part of the application they emulate would run them
+type SessionOptions struct {
+	// Database the script runs against, unless it switches with `:use`; empty means the one the workload runs against
+	Database string
+	// Records to fetch at a time; 0 fetches all of them at once
+	FetchSize int
+	// Whether each unit of work runs in a fresh session, without the bookmarks of the units of work before it, so
+	// in a cluster it may read from a server that hasn't caught up with them yet
+	Unchained bool
+	// If set, each unit of work runs in a fresh session that starts from the bookmarks of every worker; set by the
+	// worker with --bookmarks shared, see Worker#SetBookmarks
+	Shared *SharedBookmarks
+	// Whether the sessions are opened for reading, so in a cluster they are routed to followers and read replicas;
+	// set by the worker for read-only units of work, see Worker#SetReadsOnLeader
+	Read bool
+}
+
+// Bookmark modes of `:bookmark`
+const (
+	// Units of work see what the ones before them on the same worker wrote, the default
+	BookmarkChain = "chain"
+	// Units of work don't wait for anything, see SessionOptions#Unchained
+	BookmarkNone = "none"
+)
+
+// Parses the rest of the `:db`, `:fetch-size` or `:bookmark` line
+func parseSessionOption(s *Script, c *parseContext, cmd string) {
+	switch cmd {
+	case "db":
+		s.Session.Database = ident(c)
+	case "fetch-size":
+		tok, text := c.Next()
+		size, err := strconv.Atoi(text)
+		if tok != scanner.Int || err != nil || size < 1 {
+			c.fail(fmt.Errorf(":fetch-size must be followed by the number of records to fetch at a time, ex: :fetch-size 1000"))
+			return
+		}
+		s.Session.FetchSize = size
+	case "bookmark":
+		switch mode := ident(c); mode {
+		case BookmarkChain:
+			s.Session.Unchained = false
+		case BookmarkNone:
+			s.Session.Unchained = true
+		default:
+			c.fail(fmt.Errorf("unexpected :bookmark '%s', expected %s or %s", mode, BookmarkChain, BookmarkNone))
+		}
+	}
+}
+
+// The key sessions with these options, against the given database, are kept under
+func (o SessionOptions) sessionKey(databaseName string) string {
+	return fmt.Sprintf("%s/%d", databaseName, o.FetchSize)
+}
+
+func (o SessionOptions) accessMode() neo4j.AccessMode {
+	if o.Read {
+		return neo4j.AccessModeRead
+	}
+	return neo4j.AccessModeWrite
+}
+
+func (o SessionOptions) fetchSize() int {
+	if o.FetchSize == 0 {
+		return neo4j.FetchAll
+	}
+	return o.FetchSize
+}
+
+// Database the given statement of the unit of work goes to; empty means the one the workload runs against
+func (u *UnitOfWork) database(s Statement) string {
+	if s.Database != "" {
+		return s.Database
+	}
+	return u.Session.Database
+}
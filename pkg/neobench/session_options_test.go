@@ -0,0 +1,85 @@
+package neobench
+
+import (
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseSessionOptions(t *testing.T) {
+	script, err := Parse("options", `:db reporting
+:fetch-size 500
+:bookmark none
+RETURN 1;`, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionOptions{Database: "reporting", FetchSize: 500, Unchained: true}, script.Session)
+	assert.Len(t, script.Commands, 1)
+
+	for _, invalid := range []string{":fetch-size", ":fetch-size 0", ":fetch-size all", ":bookmark sometimes"} {
+		_, err := Parse("invalid", invalid, 1)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSessionOptionsConfigureSessions(t *testing.T) {
+	driver, w := newSessionConfigWorker()
+	script, err := Parse("options", ":db reporting\n:fetch-size 500\nRETURN 1;", 1)
+	assert.NoError(t, err)
+	wrk := ClientWorkload{Scripts: NewScripts(script), Rand: rand.New(rand.NewSource(1337))}
+
+	result := w.RunBenchmark(wrk, "neo4j", 0, Burst{}, 3, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(3), result.Scripts["options"].Succeeded)
+	// Chained units of work share one session
+	assert.Len(t, driver.configs, 1)
+	assert.Equal(t, "reporting", driver.configs[0].DatabaseName)
+	assert.Equal(t, 500, driver.configs[0].FetchSize)
+}
+
+func TestSessionOptionsUnchainedGetsFreshSessions(t *testing.T) {
+	driver, w := newSessionConfigWorker()
+	script, err := Parse("options", ":bookmark none\nRETURN 1;", 1)
+	assert.NoError(t, err)
+	wrk := ClientWorkload{Scripts: NewScripts(script), Rand: rand.New(rand.NewSource(1337))}
+
+	result := w.RunBenchmark(wrk, "neo4j", 0, Burst{}, 3, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Len(t, driver.configs, 3)
+	for _, config := range driver.configs {
+		assert.Equal(t, "neo4j", config.DatabaseName)
+		assert.Equal(t, neo4j.FetchAll, config.FetchSize)
+	}
+	assert.Equal(t, 3, driver.closed)
+}
+
+func newSessionConfigWorker() (*sessionConfigDriver, *Worker) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &sessionConfigDriver{fakeDriver: &fakeDriver{clock: clock, r: rand.New(rand.NewSource(1337)), minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	return driver, &Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+}
+
+// Records the config of each session opened, and how many of them were closed
+type sessionConfigDriver struct {
+	*fakeDriver
+	configs []neo4j.SessionConfig
+	closed  int
+}
+
+func (d *sessionConfigDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	d.configs = append(d.configs, config)
+	return &sessionConfigSession{fakeDriver: d.fakeDriver, driver: d}
+}
+
+type sessionConfigSession struct {
+	*fakeDriver
+	driver *sessionConfigDriver
+}
+
+func (s *sessionConfigSession) Close() error {
+	s.driver.closed++
+	return nil
+}
@@ -0,0 +1,59 @@
+package neobench
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Values scripts have stored with remember(..), for any worker to read back with recall(..). This lets later
+// transactions in a run use entities earlier ones created, like reading orders back after placing them.
+// Safe for concurrent use.
+type SharedState struct {
+	m           sync.RWMutex
+	collections map[string]*rememberedSet
+}
+
+// One named collection of remembered values; values are kept once each, in the order first remembered
+type rememberedSet struct {
+	values []interface{}
+	seen   map[interface{}]struct{}
+}
+
+func NewSharedState() *SharedState {
+	return &SharedState{collections: make(map[string]*rememberedSet)}
+}
+
+// Adds value to the named collection, unless it's already in it
+func (s *SharedState) Remember(name string, value interface{}) error {
+	switch value.(type) {
+	case int64, float64, string, bool:
+	default:
+		return fmt.Errorf("only numbers, strings and booleans can be remembered, got %v", value)
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	set, found := s.collections[name]
+	if !found {
+		set = &rememberedSet{seen: make(map[interface{}]struct{})}
+		s.collections[name] = set
+	}
+	if _, seen := set.seen[value]; seen {
+		return nil
+	}
+	set.seen[value] = struct{}{}
+	set.values = append(set.values, value)
+	return nil
+}
+
+// Picks a uniformly random value from the named collection; false if nothing has been remembered in it yet
+func (s *SharedState) Recall(name string, random *rand.Rand) (interface{}, bool) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	set, found := s.collections[name]
+	if !found || len(set.values) == 0 {
+		return nil, false
+	}
+	return set.values[random.Intn(len(set.values))], true
+}
@@ -0,0 +1,58 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestRememberAndRecallAcrossClients(t *testing.T) {
+	wrk := Workload{
+		Variables:   map[string]interface{}{},
+		Rand:        rand.New(rand.NewSource(1337)),
+		SharedState: NewSharedState(),
+	}
+	create, err := Parse("create", `:set id random(1, 1000)
+CREATE (:Order {id: $id});
+:set created remember("orders", $id)`, 1)
+	assert.NoError(t, err)
+	read, err := Parse("read", `:set id recall("orders", -1)
+MATCH (o:Order {id: $id}) RETURN o;`, 1)
+	assert.NoError(t, err)
+
+	// Nothing remembered yet, so the default is used
+	reader := wrk.NewClient()
+	uow, err := read.Eval(ScriptContext{Vars: map[string]interface{}{}, Rand: reader.Rand, SharedState: reader.SharedState})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), uow.Statements[0].Params["id"])
+
+	writer := wrk.NewClient()
+	uow, err = create.Eval(ScriptContext{Vars: map[string]interface{}{}, Rand: writer.Rand, SharedState: writer.SharedState})
+	assert.NoError(t, err)
+	createdId := uow.Statements[0].Params["id"]
+
+	uow, err = read.Eval(ScriptContext{Vars: map[string]interface{}{}, Rand: reader.Rand, SharedState: reader.SharedState})
+	assert.NoError(t, err)
+	assert.Equal(t, createdId, uow.Statements[0].Params["id"])
+}
+
+func TestRememberKeepsValuesOnce(t *testing.T) {
+	state := NewSharedState()
+	assert.NoError(t, state.Remember("ids", int64(1)))
+	assert.NoError(t, state.Remember("ids", int64(1)))
+	assert.NoError(t, state.Remember("ids", int64(2)))
+	assert.Error(t, state.Remember("ids", []interface{}{int64(1)}))
+
+	random := rand.New(rand.NewSource(1337))
+	recalled := make(map[interface{}]int)
+	for i := 0; i < 1000; i++ {
+		value, found := state.Recall("ids", random)
+		assert.True(t, found)
+		recalled[value] += 1
+	}
+	assert.Len(t, recalled, 2)
+	assert.InDelta(t, 500, recalled[int64(1)], 100)
+
+	_, found := state.Recall("other", random)
+	assert.False(t, found)
+}
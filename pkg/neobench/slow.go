@@ -0,0 +1,102 @@
+package neobench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logs units of work that take longer than Threshold, along with the queries, parameters and driver summaries of
+// their statements, so there are concrete examples of the slow tail to look into rather than just a histogram.
+// Slow units of work tend to come in bunches, so at most one is logged per Interval; the rest are counted, and
+// the count is included with the next one logged.
+type SlowLog struct {
+	Threshold time.Duration
+	Interval  time.Duration
+	// Unit latencies are logged in, see --latency-unit
+	Unit LatencyUnit
+
+	mut        sync.Mutex
+	out        io.Writer
+	lastLogged time.Time
+	suppressed int64
+}
+
+func NewSlowLog(threshold time.Duration, out io.Writer) *SlowLog {
+	return &SlowLog{
+		Threshold: threshold,
+		Interval:  time.Second,
+		out:       out,
+	}
+}
+
+// Logs the given unit of work, if it was slow and nothing else was logged too recently; latency is how long it took
+// to run, excluding any time it spent waiting to start
+func (l *SlowLog) Observe(now time.Time, workerId int64, scriptName string, latency time.Duration, outcome uowOutcome) {
+	if latency < l.Threshold {
+		return
+	}
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	if !l.lastLogged.IsZero() && now.Sub(l.lastLogged) < l.Interval {
+		l.suppressed++
+		return
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Slow unit of work: [%s] took %s on worker %d", scriptName, l.Unit.FormatDuration(latency), workerId))
+	if l.suppressed > 0 {
+		s.WriteString(fmt.Sprintf(" (%d more over %s since the last one logged)", l.suppressed, l.Threshold))
+	}
+	s.WriteString("\n")
+	for i, statement := range outcome.statements {
+		s.WriteString(fmt.Sprintf("  Statement %d, %s against %s:\n", i+1,
+			l.Unit.FormatDuration(statement.latency), describeDatabaseName(statement.databaseName)))
+		s.WriteString(fmt.Sprintf("    %s\n", strings.ReplaceAll(strings.TrimSpace(statement.query), "\n", "\n    ")))
+		if len(statement.params) > 0 {
+			s.WriteString(fmt.Sprintf("    Parameters: %s\n", formatParams(statement.params)))
+		}
+		if statement.summary != nil {
+			s.WriteString(fmt.Sprintf("    %s\n", describeSummary(statement)))
+		}
+	}
+	if !outcome.succeeded {
+		s.WriteString(fmt.Sprintf("  Failed: %s\n", outcome.err))
+	}
+	_, _ = fmt.Fprint(l.out, s.String())
+
+	l.lastLogged = now
+	l.suppressed = 0
+}
+
+func describeDatabaseName(name string) string {
+	if name == "" {
+		return "<default>"
+	}
+	return name
+}
+
+func formatParams(params map[string]interface{}) string {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Sprintf("%v", params)
+	}
+	return string(encoded)
+}
+
+// What the driver reported about the given statement: where it ran, how the time was spent and what it changed
+func describeSummary(statement statementOutcome) string {
+	summary := statement.summary
+	description := fmt.Sprintf("Ran on %s, result available after %s, consumed after %s",
+		summary.Server().Address(), summary.ResultAvailableAfter(), summary.ResultConsumedAfter())
+	counters := summary.Counters()
+	if counters != nil && counters.ContainsUpdates() {
+		description += fmt.Sprintf("; %d nodes created, %d deleted, %d relationships created, %d deleted, %d properties set",
+			counters.NodesCreated(), counters.NodesDeleted(), counters.RelationshipsCreated(),
+			counters.RelationshipsDeleted(), counters.PropertiesSet())
+	}
+	return description
+}
@@ -0,0 +1,44 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowLogLogsSlowUnitsRateLimited(t *testing.T) {
+	out := strings.Builder{}
+	slow := NewSlowLog(500*time.Millisecond, &out)
+	now := time.Unix(0, 0)
+	outcome := uowOutcome{
+		succeeded: false,
+		err:       fmt.Errorf("boom"),
+		statements: []statementOutcome{{
+			databaseName: "neo4j",
+			latency:      600 * time.Millisecond,
+			query:        "MATCH (n {id: $id})\nRETURN n",
+			params:       map[string]interface{}{"id": int64(7)},
+		}},
+	}
+
+	// Fast enough, not logged
+	slow.Observe(now, 1, "test", 100*time.Millisecond, outcome)
+	assert.Equal(t, "", out.String())
+
+	slow.Observe(now, 1, "test", 600*time.Millisecond, outcome)
+	// These come too soon after, and are only counted
+	slow.Observe(now.Add(100*time.Millisecond), 2, "test", 700*time.Millisecond, outcome)
+	slow.Observe(now.Add(200*time.Millisecond), 2, "test", 700*time.Millisecond, outcome)
+	slow.Observe(now.Add(time.Second), 3, "test", 800*time.Millisecond, uowOutcome{succeeded: true})
+
+	assert.Equal(t, `Slow unit of work: [test] took 600.000ms on worker 1
+  Statement 1, 600.000ms against neo4j:
+    MATCH (n {id: $id})
+    RETURN n
+    Parameters: {"id":7}
+  Failed: boom
+Slow unit of work: [test] took 800.000ms on worker 3 (2 more over 500ms since the last one logged)
+`, out.String())
+}
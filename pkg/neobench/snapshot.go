@@ -0,0 +1,42 @@
+package neobench
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Requests for a report of the results so far, while the run goes on; for runs that go on for hours, where waiting
+// for the final report to see how things are going isn't an option. Requests come from SIGUSR2, see
+// NotifyOnSignal, or from POSTs to the control endpoint, see ServeHTTP.
+type SnapshotRequests struct {
+	ch chan struct{}
+}
+
+func NewSnapshotRequests() *SnapshotRequests {
+	return &SnapshotRequests{ch: make(chan struct{}, 1)}
+}
+
+// Asks for a snapshot; requests that come in before the previous one is taken are folded into it
+func (s *SnapshotRequests) Request() {
+	select {
+	case s.ch <- struct{}{}:
+	default:
+	}
+}
+
+// Receives once for each snapshot to take
+func (s *SnapshotRequests) C() <-chan struct{} {
+	return s.ch
+}
+
+// Control endpoint; POST to it to request a snapshot, which is written to the output of the run
+func (s *SnapshotRequests) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST to request a snapshot of the results so far", http.StatusMethodNotAllowed)
+		return
+	}
+	s.Request()
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = fmt.Fprintln(w, "snapshot requested")
+}
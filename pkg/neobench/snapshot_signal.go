@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package neobench
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Requests a snapshot whenever the process receives SIGUSR2
+func (s *SnapshotRequests) NotifyOnSignal() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR2)
+	go func() {
+		for range sigCh {
+			s.Request()
+		}
+	}()
+}
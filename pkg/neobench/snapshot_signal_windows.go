@@ -0,0 +1,5 @@
+package neobench
+
+// Windows has no SIGUSR2; snapshots can still be requested from the control endpoint
+func (s *SnapshotRequests) NotifyOnSignal() {
+}
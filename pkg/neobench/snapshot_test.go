@@ -0,0 +1,53 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSnapshotLeavesRecorderUntouched(t *testing.T) {
+	start := time.Unix(0, 0)
+	rec := NewResultRecorder(0)
+	rec.Begin(start)
+	assert.NoError(t, rec.record("test", "", 10*time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, rec.record("test", "", 20*time.Millisecond, uowOutcome{succeeded: true}))
+
+	snapshot := NewResult("", "")
+	rec.Snapshot(start.Add(time.Second), &snapshot)
+	assert.Equal(t, int64(2), snapshot.TotalSucceeded())
+	assert.InDelta(t, 2.0, snapshot.TotalRate(), 0.001)
+
+	// Recording goes on, and neither the snapshot nor the final result lose anything
+	assert.NoError(t, rec.record("test", "", 30*time.Millisecond, uowOutcome{succeeded: true}))
+	assert.Equal(t, int64(2), snapshot.TotalSucceeded())
+	assert.Equal(t, int64(2), snapshot.Scripts["test"].Latencies.TotalCount())
+
+	final := NewResult("", "")
+	final.Add(rec.Complete(start.Add(2 * time.Second)))
+	assert.Equal(t, int64(3), final.TotalSucceeded())
+}
+
+func TestSnapshotRequestsFoldWhilePending(t *testing.T) {
+	snapshots := NewSnapshotRequests()
+	snapshots.Request()
+	snapshots.Request()
+
+	assert.Len(t, snapshots.C(), 1)
+}
+
+func TestSnapshotRequestsFromControlEndpoint(t *testing.T) {
+	snapshots := NewSnapshotRequests()
+
+	get := httptest.NewRecorder()
+	snapshots.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, get.Code)
+	assert.Len(t, snapshots.C(), 0)
+
+	post := httptest.NewRecorder()
+	snapshots.ServeHTTP(post, httptest.NewRequest(http.MethodPost, "/snapshot", nil))
+	assert.Equal(t, http.StatusAccepted, post.Code)
+	assert.Len(t, snapshots.C(), 1)
+}
@@ -0,0 +1,148 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	SoakRotationInterval = "interval"
+	SoakRotationMemory   = "memory"
+)
+
+// Keeps memory use bounded in runs that go on for days, see --soak. Results are otherwise kept for the whole run, and
+// every script, phase, database and error group adds to them; instead, every Interval, or sooner if the heap grows
+// past MemoryLimit, what was recorded since the last rotation is written to a report file in Dir and dropped.
+type Soak struct {
+	Interval time.Duration
+	Dir      string
+	// Heap size in bytes that triggers an early rotation, 0 for no limit
+	MemoryLimit uint64
+	// Whether the run is in latency mode, which decides the kind of report written for each rotation
+	Latency bool
+
+	Rotations    []SoakRotation
+	lastRotation time.Time
+	heapSize     func() uint64
+	// Frees what's no longer referenced, like results dropped at the last rotation, see Due
+	collectGarbage func()
+}
+
+// Results of a run between two rotations, and where they were written
+type SoakRotation struct {
+	Path      string
+	Start     time.Time
+	End       time.Time
+	Succeeded int64
+	Failed    int64
+	// Why results were rotated, SoakRotationInterval or SoakRotationMemory
+	Reason string
+}
+
+func NewSoak(interval time.Duration, dir string, memoryLimit uint64, latency bool) *Soak {
+	return &Soak{
+		Interval:    interval,
+		Dir:         dir,
+		MemoryLimit: memoryLimit,
+		Latency:     latency,
+		heapSize: func() uint64 {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			return stats.HeapAlloc
+		},
+		collectGarbage: runtime.GC,
+	}
+}
+
+// Called when the run starts, the first rotation covers the time since then
+func (s *Soak) Begin(start time.Time) {
+	s.lastRotation = start
+}
+
+// Whether results should be rotated at the given time, and if so why; this reads memory statistics, and over the
+// memory limit collects garbage, which briefly pauses the program, so call it at progress reports rather than
+// continuously
+func (s *Soak) Due(now time.Time) (bool, string) {
+	if !now.Before(s.lastRotation.Add(s.Interval)) {
+		return true, SoakRotationInterval
+	}
+	if s.MemoryLimit > 0 && s.heapSize() > s.MemoryLimit {
+		// The heap counts results dropped at the last rotation until they're collected; without collecting first,
+		// every check after a rotation would rotate again, until the next collection
+		s.collectGarbage()
+		if s.heapSize() > s.MemoryLimit {
+			return true, SoakRotationMemory
+		}
+	}
+	return false, ""
+}
+
+// Writes the given result, which should be everything recorded since the previous rotation, to a new report file
+func (s *Soak) Rotate(now time.Time, result Result, reason string) (SoakRotation, error) {
+	rotation := SoakRotation{
+		Path:      filepath.Join(s.Dir, fmt.Sprintf("neobench-soak-%04d.txt", len(s.Rotations)+1)),
+		Start:     s.lastRotation,
+		End:       now,
+		Succeeded: result.TotalSucceeded(),
+		Failed:    result.TotalFailed(),
+		Reason:    reason,
+	}
+	file, err := os.Create(rotation.Path)
+	if err != nil {
+		return rotation, errors.Wrapf(err, "failed to write soak results")
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "Results from %s to %s, rotated because of %s\n\n",
+		rotation.Start.Format(time.RFC3339), rotation.End.Format(time.RFC3339), reason)
+	if err != nil {
+		return rotation, errors.Wrapf(err, "failed to write soak results to %s", rotation.Path)
+	}
+	out := &InteractiveOutput{OutStream: file, ErrStream: file}
+	if s.Latency {
+		out.ReportLatency(result)
+	} else {
+		out.ReportThroughput(result)
+	}
+
+	s.Rotations = append(s.Rotations, rotation)
+	s.lastRotation = now
+	return rotation, nil
+}
+
+func (s *Soak) Result() *SoakResult {
+	return &SoakResult{Rotations: s.Rotations, LastRotation: s.lastRotation}
+}
+
+type SoakResult struct {
+	Rotations []SoakRotation
+	// The rest of the result only covers the time since this
+	LastRotation time.Time
+}
+
+// Where the results of a soak run went; the result it's part of only covers the time since the last rotation
+func writeSoakReport(result Result, s *strings.Builder) {
+	soak := result.Soak
+	if soak == nil || len(soak.Rotations) == 0 {
+		return
+	}
+	succeeded, failed := result.TotalSucceeded(), result.TotalFailed()
+	s.WriteString(fmt.Sprintf("-- Soak --\n\n"))
+	s.WriteString(fmt.Sprintf("  These results only cover the time since %s; earlier results were written to:\n",
+		soak.LastRotation.Format(time.RFC3339)))
+	for _, rotation := range soak.Rotations {
+		succeeded += rotation.Succeeded
+		failed += rotation.Failed
+		note := ""
+		if rotation.Reason == SoakRotationMemory {
+			note = ", early because memory ran over --soak-memory-limit"
+		}
+		s.WriteString(fmt.Sprintf("    %s: %s to %s, %d successful transactions, %d failed%s\n", rotation.Path,
+			rotation.Start.Format(time.RFC3339), rotation.End.Format(time.RFC3339), rotation.Succeeded, rotation.Failed, note))
+	}
+	s.WriteString(fmt.Sprintf("  %d successful transactions, %d failed over the whole run\n\n", succeeded, failed))
+}
@@ -0,0 +1,106 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSoakRotatesOnIntervalAndMemory(t *testing.T) {
+	start := time.Unix(0, 0)
+	heap := uint64(100)
+	soak := NewSoak(time.Hour, "", 1000, true)
+	soak.heapSize = func() uint64 { return heap }
+	soak.collectGarbage = func() {}
+	soak.Begin(start)
+
+	due, _ := soak.Due(start.Add(59 * time.Minute))
+	assert.False(t, due)
+	due, reason := soak.Due(start.Add(time.Hour))
+	assert.True(t, due)
+	assert.Equal(t, SoakRotationInterval, reason)
+
+	heap = 2000
+	due, reason = soak.Due(start.Add(time.Minute))
+	assert.True(t, due)
+	assert.Equal(t, SoakRotationMemory, reason)
+}
+
+func TestSoakDoesNotRotateOnResultsItAlreadyDropped(t *testing.T) {
+	start := time.Unix(0, 0)
+	heap, collections := uint64(2000), 0
+	soak := NewSoak(time.Hour, "", 1000, true)
+	soak.heapSize = func() uint64 { return heap }
+	// What the last rotation dropped stays on the heap until it's collected
+	soak.collectGarbage = func() {
+		collections++
+		heap = 500
+	}
+	soak.Begin(start)
+
+	due, _ := soak.Due(start.Add(10 * time.Second))
+	assert.False(t, due)
+	assert.Equal(t, 1, collections)
+
+	// Still over the limit once collected, so the results the run holds really are too big
+	soak.collectGarbage = func() { collections++ }
+	heap = 2000
+	due, reason := soak.Due(start.Add(20 * time.Second))
+	assert.True(t, due)
+	assert.Equal(t, SoakRotationMemory, reason)
+	assert.Equal(t, 2, collections)
+}
+
+func TestSoakWritesRotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "neobench-soak")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	start := time.Unix(0, 0).UTC()
+	soak := NewSoak(time.Hour, dir, 0, false)
+	soak.Begin(start)
+
+	result := NewResult("neo4j", "")
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("test", "", time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, worker.record("test", "", time.Millisecond, uowOutcome{succeeded: false, failureGroup: "unknown"}))
+	result.Add(worker)
+
+	rotation, err := soak.Rotate(start.Add(time.Hour), result, SoakRotationInterval)
+	assert.NoError(t, err)
+	assert.Equal(t, SoakRotation{
+		Path:      filepath.Join(dir, "neobench-soak-0001.txt"),
+		Start:     start,
+		End:       start.Add(time.Hour),
+		Succeeded: 1,
+		Failed:    1,
+		Reason:    SoakRotationInterval,
+	}, rotation)
+	written, err := ioutil.ReadFile(rotation.Path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(written), "Results from 1970-01-01T00:00:00Z to 1970-01-01T01:00:00Z, rotated because of interval\n\n== Results ==\n"))
+
+	// The next rotation picks up where this one left off
+	rotation, err = soak.Rotate(start.Add(90*time.Minute), NewResult("neo4j", ""), SoakRotationMemory)
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(time.Hour), rotation.Start)
+	assert.Equal(t, filepath.Join(dir, "neobench-soak-0002.txt"), rotation.Path)
+
+	final := NewResult("neo4j", "")
+	final.Add(worker)
+	final.Soak = soak.Result()
+	s := strings.Builder{}
+	writeSoakReport(final, &s)
+	assert.Equal(t, `-- Soak --
+
+  These results only cover the time since 1970-01-01T01:30:00Z; earlier results were written to:
+    `+dir+`/neobench-soak-0001.txt: 1970-01-01T00:00:00Z to 1970-01-01T01:00:00Z, 1 successful transactions, 1 failed
+    `+dir+`/neobench-soak-0002.txt: 1970-01-01T01:00:00Z to 1970-01-01T01:30:00Z, 0 successful transactions, 0 failed, early because memory ran over --soak-memory-limit
+  2 successful transactions, 2 failed over the whole run
+
+`, s.String())
+}
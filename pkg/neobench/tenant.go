@@ -0,0 +1,248 @@
+package neobench
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// One of several workloads run side by side against the same database with --tenant, each with results of its own,
+// to measure how much tenants of a shared instance slow each other down. Each tenant runs as a neobench process of
+// its own, with the flags all tenants share plus its own workload flags, so nothing but the database is shared:
+// not the driver, its connection pool, nor the CPU time of the Go runtime scheduling the clients.
+type Tenant struct {
+	Name string
+	// Flags defining the workload of this tenant, ex: -f oltp.script -r 100
+	Args []string
+}
+
+// Parses "name: flags", ex: "a: -f oltp.script -r 100"; flags may be quoted like in a shell, ex: b: -S "RETURN 1;"
+func ParseTenant(spec string) (Tenant, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.ContainsAny(strings.TrimSpace(parts[0]), " \t") {
+		return Tenant{}, fmt.Errorf("invalid --tenant '%s', expected a name and the flags of its workload, ex: 'a: -f oltp.script -r 100'", spec)
+	}
+	args, err := splitArgs(parts[1])
+	if err != nil {
+		return Tenant{}, fmt.Errorf("invalid --tenant '%s': %s", spec, err)
+	}
+	if len(args) == 0 {
+		return Tenant{}, fmt.Errorf("invalid --tenant '%s', tenant %s has no workload flags, ex: 'a: -f oltp.script -r 100'", spec, strings.TrimSpace(parts[0]))
+	}
+	return Tenant{Name: strings.TrimSpace(parts[0]), Args: args}, nil
+}
+
+// Splits s into arguments on whitespace, keeping what's in single or double quotes together
+func splitArgs(s string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, c := range s {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// Ex: -S "RETURN 1;" -c 2
+func (t Tenant) describe() string {
+	args := make([]string, 0, len(t.Args))
+	for _, arg := range t.Args {
+		if strings.ContainsAny(arg, " \t\n") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// The given command line arguments without any --tenant flags
+func WithoutTenantArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--tenant":
+			i++
+		case strings.HasPrefix(args[i], "--tenant="):
+		default:
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// Flags naming files a run writes; tenants sharing them would overwrite each other's, so each gets a file of its own
+var tenantFileFlags = []string{"timeseries-file", "latency-histogram-file"}
+
+// The given shared arguments as this tenant runs with them: files the run writes are named after the tenant, ex:
+// --timeseries-file timeseries.csv becomes timeseries-a.csv for tenant a
+func (t Tenant) sharedArgs(shared []string) []string {
+	out := make([]string, 0, len(shared))
+	for i := 0; i < len(shared); i++ {
+		arg := shared[i]
+		for _, flag := range tenantFileFlags {
+			if arg == "--"+flag && i+1 < len(shared) {
+				out = append(out, arg)
+				i++
+				arg = t.filePath(shared[i])
+				break
+			}
+			if strings.HasPrefix(arg, "--"+flag+"=") {
+				arg = "--" + flag + "=" + t.filePath(strings.TrimPrefix(arg, "--"+flag+"="))
+				break
+			}
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// Ex: out.hgrm becomes out-a.hgrm for tenant a
+func (t Tenant) filePath(path string) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), t.Name, ext)
+}
+
+// Runs each tenant in a neobench process of its own, with the shared arguments followed by those of the tenant, all
+// at the same time; files the shared arguments name are named after each tenant, see Tenant#sharedArgs. Progress is passed on to stderr as it comes, each line prefixed with the tenant's name; the
+// results are written to stdout once all tenants are done, one tenant after the other. Interrupts are passed on to
+// the tenants, so they stop and report like a single run would.
+//
+// Returns the exit code to exit with: 0 if all tenants succeeded, otherwise the highest code any exited with.
+func RunTenants(executable string, shared []string, tenants []Tenant, stdout, stderr io.Writer) int {
+	var stderrMut sync.Mutex
+	results := make([]bytes.Buffer, len(tenants))
+	codes := make([]int, len(tenants))
+	cmds := make([]*exec.Cmd, len(tenants))
+	for i, tenant := range tenants {
+		cmd := exec.Command(executable, append(tenant.sharedArgs(shared), tenant.Args...)...)
+		cmd.Stdout = &results[i]
+		cmd.Stderr = &prefixWriter{prefix: fmt.Sprintf("[%s] ", tenant.Name), out: stderr, mut: &stderrMut}
+		cmds[i] = cmd
+	}
+
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	var wg sync.WaitGroup
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			fmt.Fprintf(stderr, "[%s] failed to start: %s\n", tenants[i].Name, err)
+			codes[i] = 1
+			cmds[i] = nil
+			continue
+		}
+		wg.Add(1)
+		go func(i int, cmd *exec.Cmd) {
+			defer wg.Done()
+			if err := cmd.Wait(); err != nil {
+				codes[i] = 1
+				if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+					codes[i] = exitErr.ExitCode()
+				}
+			}
+		}(i, cmd)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case sig := <-sigCh:
+			for _, cmd := range cmds {
+				if cmd != nil {
+					// Not all platforms can signal processes; there, only the terminal's interrupt reaches tenants
+					_ = cmd.Process.Signal(sig)
+				}
+			}
+		case <-done:
+			waiting = false
+		}
+	}
+	for _, cmd := range cmds {
+		if cmd != nil {
+			cmd.Stderr.(*prefixWriter).flush()
+		}
+	}
+
+	code := 0
+	for i, tenant := range tenants {
+		fmt.Fprintf(stdout, "== Tenant %s: %s ==\n\n", tenant.Name, tenant.describe())
+		_, _ = stdout.Write(results[i].Bytes())
+		fmt.Fprintln(stdout)
+		if codes[i] > code {
+			code = codes[i]
+		}
+	}
+	return code
+}
+
+// Writes complete lines to out, each starting with prefix; writers sharing a mutex don't interleave their lines
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mut    *sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Writes out what's left of the last line, if it didn't end in a newline
+func (w *prefixWriter) flush() {
+	if len(w.buf) > 0 {
+		_ = w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
@@ -0,0 +1,87 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseTenant(t *testing.T) {
+	tenant, err := ParseTenant("a: -f oltp.script -l -r 100")
+	assert.NoError(t, err)
+	assert.Equal(t, Tenant{Name: "a", Args: []string{"-f", "oltp.script", "-l", "-r", "100"}}, tenant)
+
+	tenant, err = ParseTenant(`b:-S "MATCH (n) RETURN count(n);" -c 2`)
+	assert.NoError(t, err)
+	assert.Equal(t, Tenant{Name: "b", Args: []string{"-S", "MATCH (n) RETURN count(n);", "-c", "2"}}, tenant)
+
+	for _, invalid := range []string{"-f oltp.script", ": -f oltp.script", "a:", "a b: -c 2", `a: -S "RETURN 1;`} {
+		_, err := ParseTenant(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWithoutTenantArgs(t *testing.T) {
+	args := []string{"-d", "1m", "--tenant", "a: -c 1", "--tenant=b: -c 2", "-a", "neo4j://db:7687", "mydb"}
+	assert.Equal(t, []string{"-d", "1m", "-a", "neo4j://db:7687", "mydb"}, WithoutTenantArgs(args))
+}
+
+func TestTenantsWriteTimeSeriesFilesOfTheirOwn(t *testing.T) {
+	tenant := Tenant{Name: "a", Args: []string{"-c", "1"}}
+	assert.Equal(t, []string{"-d", "1m", "--timeseries-file", "out/timeseries-a.csv", "-a", "neo4j://db:7687"},
+		tenant.sharedArgs([]string{"-d", "1m", "--timeseries-file", "out/timeseries.csv", "-a", "neo4j://db:7687"}))
+	assert.Equal(t, []string{"--timeseries-file=timeseries-a"}, tenant.sharedArgs([]string{"--timeseries-file=timeseries"}))
+}
+
+func TestTenantsWriteLatencyHistogramsOfTheirOwn(t *testing.T) {
+	shared := []string{"--latency-histogram-file", "out.hgrm", "-d", "1m"}
+	a, b := Tenant{Name: "a"}, Tenant{Name: "b"}
+	assert.Equal(t, []string{"--latency-histogram-file", "out-a.hgrm", "-d", "1m"}, a.sharedArgs(shared))
+	assert.Equal(t, []string{"--latency-histogram-file", "out-b.hgrm", "-d", "1m"}, b.sharedArgs(shared))
+	// The files of each script are named after the file of the whole run, so they too are the tenant's own
+	assert.Equal(t, []string{"--latency-histogram-file=/tmp/out-b.hgrm"}, b.sharedArgs([]string{"--latency-histogram-file=/tmp/out.hgrm"}))
+}
+
+func TestPrefixWriterWritesWholeLines(t *testing.T) {
+	out := strings.Builder{}
+	w := &prefixWriter{prefix: "[a] ", out: &out, mut: &sync.Mutex{}}
+
+	_, _ = w.Write([]byte("Starting\nProgress: 10"))
+	assert.Equal(t, "[a] Starting\n", out.String())
+	_, _ = w.Write([]byte("%\n"))
+	_, _ = w.Write([]byte("Done"))
+	w.flush()
+	assert.Equal(t, "[a] Starting\n[a] Progress: 10%\n[a] Done\n", out.String())
+}
+
+func TestRunTenants(t *testing.T) {
+	os.Setenv("NEOBENCH_TENANT_HELPER", "1")
+	defer os.Unsetenv("NEOBENCH_TENANT_HELPER")
+	shared := []string{"-test.run=TestTenantHelperProcess", "--"}
+	tenants := []Tenant{{Name: "a", Args: []string{"0"}}, {Name: "b", Args: []string{"1"}}}
+	stdout, stderr := strings.Builder{}, strings.Builder{}
+
+	code := RunTenants(os.Args[0], shared, tenants, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), "== Tenant a: 0 ==\n\nresults of 0\n\n== Tenant b: 1 ==\n\nresults of 1\n")
+	assert.Contains(t, stderr.String(), "[a] progress of 0\n")
+	assert.Contains(t, stderr.String(), "[b] progress of 1\n")
+}
+
+// Stands in for neobench in TestRunTenants: reports on its last argument and exits with it as the exit code
+func TestTenantHelperProcess(t *testing.T) {
+	if os.Getenv("NEOBENCH_TENANT_HELPER") != "1" {
+		return
+	}
+	arg := os.Args[len(os.Args)-1]
+	fmt.Fprintf(os.Stderr, "progress of %s\n", arg)
+	fmt.Fprintf(os.Stdout, "results of %s\n", arg)
+	if arg == "1" {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
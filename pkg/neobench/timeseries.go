@@ -0,0 +1,70 @@
+package neobench
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Writes a CSV row per script at each progress report to a file of its own, see --timeseries-file, so throughput
+// and latency can be graphed over the run, where stalls like checkpoints show up that the totals of the final
+// report average away. Runs alongside the main output, like PrometheusOutput.
+type TimeSeriesOutput struct {
+	OutStream io.Writer
+	// Unit of the latency columns, always a fixed one, see LatencyUnit.Fixed
+	Unit  LatencyUnit
+	start time.Time
+	now   func() time.Time
+}
+
+func timeSeriesColumns(unit LatencyUnit) []string {
+	return []string{"timestamp", "elapsed_seconds", "script", "transactions_per_second", "succeeded", "failed",
+		"p50_" + unit.Suffix(), "p99_" + unit.Suffix()}
+}
+
+// Creates the file at path, replacing any file already there, and writes the header row to it
+func NewTimeSeriesOutput(path string, unit LatencyUnit) (*TimeSeriesOutput, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create --timeseries-file")
+	}
+	o := &TimeSeriesOutput{OutStream: file, Unit: unit.Fixed(), now: time.Now}
+	if _, err := fmt.Fprintln(o.OutStream, strings.Join(timeSeriesColumns(o.Unit), ",")); err != nil {
+		return nil, errors.Wrapf(err, "failed to write to %s", path)
+	}
+	return o, nil
+}
+
+func (o *TimeSeriesOutput) BenchmarkStart(databaseName, url, scenario string, run RunTag) {
+	o.start = o.now()
+}
+
+func (o *TimeSeriesOutput) ReportInitProgress(report ProgressReport) {
+}
+
+func (o *TimeSeriesOutput) ReportWorkloadProgress(completeness float64, checkpoint Result) {
+	now := o.now()
+	s := strings.Builder{}
+	for _, script := range sortedScripts(checkpoint.Scripts) {
+		s.WriteString(fmt.Sprintf("%s,%.3f,%s,%.3f,%d,%d,%.3f,%.3f\n", now.UTC().Format(time.RFC3339Nano), now.Sub(o.start).Seconds(),
+			script.ScriptName, script.Rate, script.Succeeded, script.Failed,
+			o.Unit.Value(float64(script.Latencies.ValueAtQuantile(50))), o.Unit.Value(float64(script.Latencies.ValueAtQuantile(99)))))
+	}
+	if _, err := io.WriteString(o.OutStream, s.String()); err != nil {
+		panic(err)
+	}
+}
+
+func (o *TimeSeriesOutput) ReportThroughput(result Result) {
+}
+
+func (o *TimeSeriesOutput) ReportLatency(result Result) {
+}
+
+func (o *TimeSeriesOutput) Errorf(format string, a ...interface{}) {
+}
+
+var _ Output = &TimeSeriesOutput{}
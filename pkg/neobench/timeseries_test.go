@@ -0,0 +1,30 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesOutputWritesARowPerScriptPerInterval(t *testing.T) {
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	s := &strings.Builder{}
+	out := &TimeSeriesOutput{OutStream: s, now: clock.now}
+	out.BenchmarkStart("neo4j", "neo4j://localhost:7687", "", RunTag{})
+
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("writes", "", 4*time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, worker.record("reads", "", time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, worker.record("reads", "", time.Millisecond, uowOutcome{succeeded: false, failureGroup: "Neo.TransientError"}))
+	worker.calculateRate(10 * time.Second)
+	checkpoint := NewResult("neo4j", "")
+	checkpoint.Add(worker)
+
+	clock.sleep(10 * time.Second)
+	out.ReportWorkloadProgress(0.5, checkpoint)
+
+	assert.Equal(t, `2020-01-01T01:01:11Z,10.000,reads,0.200,1,1,1.000,1.000
+2020-01-01T01:01:11Z,10.000,writes,0.100,1,0,4.001,4.001
+`, s.String())
+}
@@ -0,0 +1,108 @@
+package neobench
+
+import (
+	"crypto/tls"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Forwards connections from a local port to the database, for network setups the driver has no hooks for. The
+// driver connects to the tunnel in plain text over loopback, and the tunnel does the dialing, and TLS if enabled,
+// the way neobench was asked to.
+type tunnel struct {
+	listener net.Listener
+	// Address of the database, host:port
+	target string
+	dial   func(address string) (net.Conn, error)
+	// Nil if the connection to the database is not encrypted
+	tlsConfig *tls.Config
+
+	wg sync.WaitGroup
+}
+
+// Starts forwarding to the given address; this connects once before returning, so problems reaching the
+// database, like certificates not matching, are reported here rather than as driver errors later on
+func startTunnel(target string, dial func(address string) (net.Conn, error), tlsConfig *tls.Config) (*tunnel, error) {
+	t := &tunnel{target: target, dial: dial, tlsConfig: tlsConfig}
+	conn, err := t.connect()
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.Close()
+
+	t.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen for driver connections to forward to %s", target)
+	}
+	t.wg.Add(1)
+	go t.accept()
+	return t, nil
+}
+
+// Address the driver should connect to
+func (t *tunnel) Address() string {
+	return t.listener.Addr().String()
+}
+
+func (t *tunnel) Close() error {
+	err := t.listener.Close()
+	t.wg.Wait()
+	return err
+}
+
+func (t *tunnel) connect() (net.Conn, error) {
+	conn, err := t.dial(t.target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", t.target)
+	}
+	if t.tlsConfig == nil {
+		return conn, nil
+	}
+	tlsConn := tls.Client(conn, t.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "TLS handshake with %s failed, expecting a certificate for %s", t.target, t.tlsConfig.ServerName)
+	}
+	return tlsConn, nil
+}
+
+func (t *tunnel) accept() {
+	defer t.wg.Done()
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			// The listener is closed
+			return
+		}
+		go t.forward(local)
+	}
+}
+
+// Copies bytes both ways until either side closes; failing to connect closes the driver's connection, which the
+// driver reports like any other connection failure
+func (t *tunnel) forward(local net.Conn) {
+	defer local.Close()
+	remote, err := t.connect()
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func dialTCP(address string) (net.Conn, error) {
+	return net.DialTimeout("tcp", address, 5*time.Second)
+}
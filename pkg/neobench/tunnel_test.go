@@ -0,0 +1,139 @@
+package neobench
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTunnelVerifiesCertificateAgainstServerName(t *testing.T) {
+	cert, pool := selfSignedCertificate(t, "db.example.com")
+	address := startEchoServer(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	tun, err := startTunnel(address, dialTCP, &tls.Config{ServerName: "db.example.com", RootCAs: pool})
+	assert.NoError(t, err)
+	defer tun.Close()
+
+	conn, err := net.Dial("tcp", tun.Address())
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	assert.NoError(t, err)
+	reply := make([]byte, 5)
+	_, err = io.ReadFull(conn, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(reply))
+
+	_, err = startTunnel(address, dialTCP, &tls.Config{ServerName: "lb.example.com", RootCAs: pool})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expecting a certificate for lb.example.com")
+}
+
+// Starts a server that echoes back what it is sent, with TLS if a config is given; returns its address
+func startEchoServer(t *testing.T, tlsConfig *tls.Config) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func selfSignedCertificate(t *testing.T, dnsName string) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	parsed, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestResolvingDialerOverridesAddresses(t *testing.T) {
+	var dialed []string
+	dial, err := resolvingDialer(map[string]string{
+		"core1":      "127.0.0.1",
+		"core2:7687": "127.0.0.1:17687",
+	}, func(address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	for _, address := range []string{"core1:7688", "core2:7687", "core2:7688", "core3:7687"} {
+		_, _ = dial(address)
+	}
+
+	assert.Equal(t, []string{"127.0.0.1:7688", "127.0.0.1:17687", "core2:7688", "core3:7687"}, dialed)
+
+	_, err = resolvingDialer(map[string]string{"core1": ""}, dialTCP)
+	assert.Error(t, err)
+}
+
+func TestResolverOnlyOverridesTheHostOfTheAddress(t *testing.T) {
+	// Stands in for the port forward
+	forward, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer forward.Close()
+	go func() {
+		for {
+			conn, err := forward.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	for _, from := range []string{"core1", "core1:7687"} {
+		driver, err := NewDriver("neo4j://core1:7687", "neo4j", "secret", EncryptionOff, false,
+			Transport{Resolve: map[string]string{from: forward.Addr().String()}})
+		assert.NoError(t, err, from)
+		if driver != nil {
+			assert.NoError(t, driver.Close())
+		}
+	}
+
+	// The tunnel never connects to other servers of the cluster, so overriding them would do nothing
+	_, err = NewDriver("neo4j://core1:7687", "neo4j", "secret", EncryptionOff, false,
+		Transport{Resolve: map[string]string{"core1": "127.0.0.1:17687", "core2": "127.0.0.1:17688"}})
+	assert.EqualError(t, err, "invalid --resolver 'core2': neobench connects only to --address core1:7687, without cluster routing, "+
+		"so only it can be resolved, ex: --resolver core1=127.0.0.1:17687")
+	_, err = NewDriver("neo4j://core1:7687", "neo4j", "secret", EncryptionOff, false,
+		Transport{Resolve: map[string]string{"core1:7688": "127.0.0.1:17687"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package neobench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parses counts like "10M", "1.5k" or "2000"; suffixes are decimal, k is thousands, M millions, G or B billions
+func ParseCount(s string) (float64, error) {
+	return parseWithSuffix(s, []unitSuffix{
+		{"k", 1e3},
+		{"K", 1e3},
+		{"M", 1e6},
+		{"G", 1e9},
+		{"B", 1e9},
+	})
+}
+
+// Parses byte sizes like "50GB", "500MB" or "1.5TB" into bytes; units are binary, so 1KB is 1024 bytes
+func ParseByteSize(s string) (float64, error) {
+	return parseWithSuffix(strings.ToUpper(s), []unitSuffix{
+		// Longest suffixes first, so "GB" is not mistaken for "B"
+		{"KIB", 1 << 10},
+		{"MIB", 1 << 20},
+		{"GIB", 1 << 30},
+		{"TIB", 1 << 40},
+		{"KB", 1 << 10},
+		{"MB", 1 << 20},
+		{"GB", 1 << 30},
+		{"TB", 1 << 40},
+		{"K", 1 << 10},
+		{"M", 1 << 20},
+		{"G", 1 << 30},
+		{"T", 1 << 40},
+		{"B", 1},
+	})
+}
+
+// Parses fractions like "0.1%" or "0.001" into a number between 0 and 1
+func ParseFraction(s string) (float64, error) {
+	trimmed := strings.TrimSpace(s)
+	multiplier := 1.0
+	if strings.HasSuffix(trimmed, "%") {
+		trimmed = strings.TrimSuffix(trimmed, "%")
+		multiplier = 0.01
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(trimmed), 64)
+	if err != nil || value < 0 || value*multiplier > 1 {
+		return 0, fmt.Errorf("'%s' is not a valid fraction, expected a percentage like 0.1%% or a number between 0 and 1", s)
+	}
+	return value * multiplier, nil
+}
+
+type unitSuffix struct {
+	suffix     string
+	multiplier float64
+}
+
+func parseWithSuffix(s string, suffixes []unitSuffix) (float64, error) {
+	s = strings.TrimSpace(s)
+	multiplier := 1.0
+	number := s
+	for _, unit := range suffixes {
+		if strings.HasSuffix(s, unit.suffix) {
+			number = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("'%s' is not a valid amount, expected a positive number optionally followed by a unit", s)
+	}
+	return value * multiplier, nil
+}
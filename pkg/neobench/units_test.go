@@ -0,0 +1,60 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseCountAndByteSize(t *testing.T) {
+	counts := map[string]float64{
+		"2000": 2000,
+		"10M":  10000000,
+		"1.5k": 1500,
+		"3B":   3000000000,
+	}
+	for given, expected := range counts {
+		actual, err := ParseCount(given)
+		assert.NoError(t, err, given)
+		assert.Equal(t, expected, actual, given)
+	}
+
+	sizes := map[string]float64{
+		"50GB":  50 * (1 << 30),
+		"512mb": 512 * (1 << 20),
+		"1.5TB": 1.5 * (1 << 40),
+		"100":   100,
+		"100B":  100,
+		"2GiB":  2 * (1 << 30),
+	}
+	for given, expected := range sizes {
+		actual, err := ParseByteSize(given)
+		assert.NoError(t, err, given)
+		assert.Equal(t, expected, actual, given)
+	}
+
+	_, err := ParseCount("lots")
+	assert.Error(t, err)
+	_, err = ParseByteSize("-1GB")
+	assert.Error(t, err)
+}
+
+func TestParseFraction(t *testing.T) {
+	fractions := map[string]float64{
+		"0.1%": 0.001,
+		"5%":   0.05,
+		"0.25": 0.25,
+		" 1% ": 0.01,
+		"100%": 1,
+		"0":    0,
+	}
+	for given, expected := range fractions {
+		actual, err := ParseFraction(given)
+		assert.NoError(t, err, given)
+		assert.InDelta(t, expected, actual, 1e-12, given)
+	}
+
+	for _, invalid := range []string{"150%", "2", "-1%", "some"} {
+		_, err := ParseFraction(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,41 @@
+package neobench
+
+// A logical user of the application being emulated, with the variables its scripts set with `:user`, like which
+// person it is, kept from one of its units of work to the next. With --virtual-users, there are more of these than
+// there are clients, and each client takes turns running as the users it was given.
+type UserState struct {
+	Id   int64
+	Vars map[string]interface{}
+}
+
+func NewUserState(id int64) *UserState {
+	return &UserState{Id: id, Vars: make(map[string]interface{})}
+}
+
+// Gives this client the users numbered client, client+clients, client+2*clients and so on, up to users; the client
+// runs as each of them in turn
+func (s *ClientWorkload) SetVirtualUsers(client, clients, users int) {
+	s.Users = nil
+	s.nextUser = 0
+	for id := client; id < users; id += clients {
+		s.Users = append(s.Users, NewUserState(int64(id)))
+	}
+}
+
+// The user the next unit of work runs as; without virtual users, the client is a user of its own, by its id
+func (s *ClientWorkload) takeUser(clientId int64) *UserState {
+	if len(s.Users) == 0 {
+		s.Users = []*UserState{NewUserState(clientId)}
+	}
+	user := s.Users[s.nextUser]
+	s.nextUser = (s.nextUser + 1) % len(s.Users)
+	return user
+}
+
+// Sets the variables of this user in vars, for a unit of work it runs
+func (u *UserState) apply(vars map[string]interface{}) {
+	vars[UserIdVar] = u.Id
+	for k, v := range u.Vars {
+		vars[k] = v
+	}
+}
@@ -0,0 +1,68 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestVirtualUsersKeepTheirVariables(t *testing.T) {
+	script, err := Parse("person", `:user personId random(1, 1000000)
+MATCH (p:Person {id: $personId}) RETURN p, $nbUserId;`, 1)
+	assert.NoError(t, err)
+	wrk := Workload{
+		Variables: map[string]interface{}{},
+		Scripts:   NewScripts(script),
+		Rand:      rand.New(rand.NewSource(1337)),
+	}
+	client := wrk.NewClient()
+	client.SetVirtualUsers(1, 3, 8)
+	assert.Len(t, client.Users, 3)
+
+	persons := make(map[int64]interface{})
+	for i := 0; i < 9; i++ {
+		uow, err := client.Next(1)
+		assert.NoError(t, err)
+		assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+		params := uow.Statements[0].Params
+		user := params[UserIdVar].(int64)
+		// The client runs as users 1, 4 and 7 in turn
+		assert.Equal(t, int64(1+3*(i%3)), user)
+		if person, seen := persons[user]; seen {
+			assert.Equal(t, person, params["personId"])
+		}
+		persons[user] = params["personId"]
+	}
+	assert.Len(t, persons, 3)
+}
+
+func TestClientIsAUserOfItsOwnByDefault(t *testing.T) {
+	script, err := Parse("counter", `:user start random(1, 1000000)
+RETURN $start, $nbUserId;`, 1)
+	assert.NoError(t, err)
+	wrk := Workload{
+		Variables: map[string]interface{}{},
+		Scripts:   NewScripts(script),
+		Rand:      rand.New(rand.NewSource(1337)),
+	}
+	client := wrk.NewClient()
+
+	var starts []interface{}
+	for i := 0; i < 2; i++ {
+		uow, err := client.Next(5)
+		assert.NoError(t, err)
+		assert.NoError(t, uow.Run(func(Statement) error { return nil }))
+		assert.Equal(t, int64(5), uow.Statements[0].Params[UserIdVar])
+		starts = append(starts, uow.Statements[0].Params["start"])
+	}
+	assert.Equal(t, starts[0], starts[1])
+}
+
+func TestUserCommandWithoutUserActsLikeSet(t *testing.T) {
+	script, err := Parse("preflight", `:user id 7
+RETURN $id;`, 1)
+	assert.NoError(t, err)
+	uow, err := script.Eval(ScriptContext{Vars: map[string]interface{}{}, Rand: rand.New(rand.NewSource(1))})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), uow.Statements[0].Params["id"])
+}
@@ -0,0 +1,41 @@
+package neobench
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time, see the Makefile, ex: go build -ldflags "-X neobench/pkg/neobench.Version=1.2.0"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+const driverModule = "github.com/neo4j/neo4j-go-driver/v4"
+
+// Which build of neobench produced a result, so numbers can be traced back to the code that measured them
+type BuildInfo struct {
+	Version       string
+	Commit        string
+	DriverVersion string
+	GoVersion     string
+}
+
+// The build of the running binary
+func CurrentBuild() BuildInfo {
+	build := BuildInfo{Version: Version, Commit: Commit, DriverVersion: "unknown", GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == driverModule {
+				build.DriverVersion = dep.Version
+			}
+		}
+	}
+	return build
+}
+
+// Ex: neobench 1.2.0 (commit 3f2a9c1, neo4j-go-driver v4.3.3, go1.14)
+func (b BuildInfo) String() string {
+	return fmt.Sprintf("neobench %s (commit %s, neo4j-go-driver %s, %s)", b.Version, b.Commit, b.DriverVersion, b.GoVersion)
+}
@@ -0,0 +1,15 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	build := CurrentBuild()
+	assert.Equal(t, Version, build.Version)
+	assert.NotEqual(t, "unknown", build.DriverVersion)
+
+	build = BuildInfo{Version: "1.2.0", Commit: "3f2a9c1", DriverVersion: "v4.3.3", GoVersion: "go1.14"}
+	assert.Equal(t, "neobench 1.2.0 (commit 3f2a9c1, neo4j-go-driver v4.3.3, go1.14)", build.String())
+}
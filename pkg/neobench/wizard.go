@@ -0,0 +1,220 @@
+package neobench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Asks a first-time user, one question at a time, for what `neobench wizard` needs to build a command line: where
+// the database is, how to log in, what workload to run and for how long. Answers that don't parse are asked again.
+type Wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	// Builtin workloads to offer, ex: tpcb-like
+	builtins []string
+}
+
+func NewWizard(in io.Reader, out io.Writer, builtins []string) *Wizard {
+	return &Wizard{in: bufio.NewReader(in), out: out, builtins: builtins}
+}
+
+// Asks the questions and returns the neobench arguments they add up to, and whether the user wants to run them now
+func (w *Wizard) Run() ([]string, bool, error) {
+	fmt.Fprintf(w.out, "This asks a few questions and prints the neobench command line that runs what you answered; press enter to take the default in brackets.\n\n")
+
+	address, err := w.ask("Address of the database", "neo4j://localhost:7687", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	user, err := w.ask("Username", "neo4j", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	password, err := w.ask("Password (shown as you type)", "neo4j", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	database, err := w.ask("Database, empty for the default database", "", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	args := []string{"--address", address, "--user", user, "--password", password}
+
+	options := append(append([]string{}, w.builtins...), "my own script file")
+	workload, err := w.choose("Workload to run", options, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	if workload < len(w.builtins) {
+		args = append(args, "--builtin", w.builtins[workload])
+		scale, err := w.ask("Scale of the dataset, see docs/builtin.md; may be fractional", "1", validFloat)
+		if err != nil {
+			return nil, false, err
+		}
+		args = append(args, "--scale", scale)
+		init, err := w.confirm("Populate the dataset before running, needed the first time", true)
+		if err != nil {
+			return nil, false, err
+		}
+		if init {
+			args = append(args, "--init")
+		}
+	} else {
+		path, err := w.ask("Path to the script file, see docs/scripts.md", "", nonEmpty)
+		if err != nil {
+			return nil, false, err
+		}
+		args = append(args, "--file", path)
+	}
+
+	clients, err := w.ask("Concurrent clients", "1", validPositiveInt)
+	if err != nil {
+		return nil, false, err
+	}
+	args = append(args, "--clients", clients)
+	latency, err := w.confirm("Measure latency at a fixed rate, rather than the highest throughput", false)
+	if err != nil {
+		return nil, false, err
+	}
+	if latency {
+		rate, err := w.ask("Transactions per second, over all clients", "10", validFloat)
+		if err != nil {
+			return nil, false, err
+		}
+		args = append(args, "--latency", "--rate", rate)
+	}
+	duration, err := w.ask("How long to run, ex: 30s, 5m, 1h", "1m", validDuration)
+	if err != nil {
+		return nil, false, err
+	}
+	args = append(args, "--duration", duration)
+	if database != "" {
+		args = append(args, database)
+	}
+
+	fmt.Fprintf(w.out, "\nThe command line for this is:\n\n  neobench %s\n\n", ShellQuote(args))
+	run, err := w.confirm("Run it now", false)
+	if err != nil {
+		return nil, false, err
+	}
+	return args, run, nil
+}
+
+// Asks for a line of text, returning the default for an empty line
+func (w *Wizard) ask(question, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", question, defaultValue)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", question)
+		}
+		line, err := w.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("no answer to '%s': %s", question, err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = defaultValue
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(w.out, "  %s\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// Asks to pick one of the options by number, returning its index
+func (w *Wizard) choose(question string, options []string, defaultOption int) (int, error) {
+	fmt.Fprintf(w.out, "%s:\n", question)
+	for i, option := range options {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, option)
+	}
+	answer, err := w.ask("Number", strconv.Itoa(defaultOption+1), func(answer string) error {
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(options) {
+			return fmt.Errorf("please answer with a number from 1 to %d", len(options))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, _ := strconv.Atoi(answer)
+	return n - 1, nil
+}
+
+// Asks a yes or no question
+func (w *Wizard) confirm(question string, defaultYes bool) (bool, error) {
+	defaultValue := "y/N"
+	if defaultYes {
+		defaultValue = "Y/n"
+	}
+	answer, err := w.ask(question, defaultValue, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "y/n", "y", "yes", "n", "no":
+			return nil
+		}
+		return fmt.Errorf("please answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return defaultYes, nil
+}
+
+func nonEmpty(answer string) error {
+	if answer == "" {
+		return fmt.Errorf("please give an answer")
+	}
+	return nil
+}
+
+func validFloat(answer string) error {
+	if v, err := strconv.ParseFloat(answer, 64); err != nil || v <= 0 {
+		return fmt.Errorf("please answer with a number above 0, ex: 0.5")
+	}
+	return nil
+}
+
+func validPositiveInt(answer string) error {
+	if v, err := strconv.Atoi(answer); err != nil || v < 1 {
+		return fmt.Errorf("please answer with a whole number above 0")
+	}
+	return nil
+}
+
+func validDuration(answer string) error {
+	if v, err := time.ParseDuration(answer); err != nil || v <= 0 {
+		return fmt.Errorf("please answer with a duration, ex: 30s, 5m, 1h")
+	}
+	return nil
+}
+
+// Joins args into a command line a POSIX shell splits back into the same args
+func ShellQuote(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && strings.IndexFunc(arg, func(c rune) bool {
+			return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./:=,@+%", c))
+		}) < 0 {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
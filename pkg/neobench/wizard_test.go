@@ -0,0 +1,46 @@
+package neobench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestWizardForBuiltin(t *testing.T) {
+	answers := strings.Join([]string{"", "", "secret", "", "2", "0.1", "n", "4", "", "5m", "y"}, "\n") + "\n"
+	out := strings.Builder{}
+	args, run, err := NewWizard(strings.NewReader(answers), &out, []string{"tpcb-like", "ldbc-like"}).Run()
+
+	assert.NoError(t, err)
+	assert.True(t, run)
+	assert.Equal(t, []string{"--address", "neo4j://localhost:7687", "--user", "neo4j", "--password", "secret",
+		"--builtin", "ldbc-like", "--scale", "0.1", "--clients", "4", "--duration", "5m"}, args)
+	assert.Contains(t, out.String(), "neobench --address neo4j://localhost:7687 --user neo4j --password secret --builtin ldbc-like")
+}
+
+func TestWizardForScriptAsksAgainOnInvalidAnswers(t *testing.T) {
+	answers := strings.Join([]string{"neo4j+s://db.example.com", "bench", "p4ss word", "mydb",
+		"7", "3", "", "./my workload.script", "zero", "2", "maybe", "y", "-1", "100", "1 hour", "1h", ""}, "\n") + "\n"
+	out := strings.Builder{}
+	args, run, err := NewWizard(strings.NewReader(answers), &out, []string{"tpcb-like", "ldbc-like"}).Run()
+
+	assert.NoError(t, err)
+	assert.False(t, run)
+	assert.Equal(t, []string{"--address", "neo4j+s://db.example.com", "--user", "bench", "--password", "p4ss word",
+		"--file", "./my workload.script", "--clients", "2", "--latency", "--rate", "100", "--duration", "1h", "mydb"}, args)
+	assert.Contains(t, out.String(), "please answer with a number from 1 to 3")
+	assert.Contains(t, out.String(), "please give an answer")
+	assert.Contains(t, out.String(), "please answer y or n")
+	assert.Contains(t, out.String(), "--password 'p4ss word' --file './my workload.script'")
+}
+
+func TestWizardStopsAtEndOfInput(t *testing.T) {
+	_, _, err := NewWizard(strings.NewReader("\n\n"), ioutil.Discard, []string{"tpcb-like"}).Run()
+	assert.EqualError(t, err, "no answer to 'Password (shown as you type)': EOF")
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `-S 'RETURN $x;' -p 'it'\''s' '' -D scale=1,x=2.5`,
+		ShellQuote([]string{"-S", "RETURN $x;", "-p", "it's", "", "-D", "scale=1,x=2.5"}))
+}
@@ -15,6 +15,156 @@ type Worker struct {
 	driver   neo4j.Driver
 	now      func() time.Time
 	sleep    func(duration time.Duration)
+	// If set, decides the time between transactions in latency mode instead of the fixed rate
+	rates RateSource
+	// If set, units of work alternate between Bolt and the Query API on this schedule
+	protocols *ProtocolSchedule
+	queryApi  *QueryApiClient
+	// If set, `:readback` statements don't wait for the writes before them, see SetReadbackBookmarks
+	readbackWithoutBookmarks bool
+	// If set, read-only units of work run as writes, see SetReadsOnLeader
+	readsOnLeader bool
+	// How units of work wait for the writes before them, see SetBookmarks; empty is BookmarkChain
+	bookmarks       string
+	sharedBookmarks *SharedBookmarks
+	// If set, the outcome of every unit of work is also reported here, see FailoverTracker
+	failover *FailoverTracker
+	// If set, the size of what units of work send and receive is measured, see PayloadResult
+	payloads bool
+	// If set, the time until the first record of each statement arrives is measured, see FirstRecordResult
+	firstRecords bool
+	// If set, units of work slower than its threshold are logged, see SlowLog
+	slowLog *SlowLog
+	// Fraction of units of work to run with PROFILE, see ProfileResult
+	profileSample float64
+	// What to do with units of work that are due too long ago, in latency mode
+	scheduleLimit ScheduleLimit
+	// Added to every statement, see InjectedLatency
+	injectedLatency InjectedLatency
+	// Fraction of write transactions to roll back and retry, see AbortResult
+	abortFraction float64
+	// If set, the time spent evaluating each command of the scripts is measured, see ScriptEvalResult
+	profileScripts bool
+	// If set, the size of the parameters units of work send is checked against its thresholds
+	paramGuard *ParamSizeGuard
+	// If set, the worker connects and waits for the other workers to do the same before it starts, see ReadyBarrier
+	barrier *ReadyBarrier
+	// If set, opening sessions is paced, and connecting timed, by this, see ConnectPacer
+	connectPacer *ConnectPacer
+	// With an interval, the worker reconnects that often, see ConnectionChurn
+	churn         ConnectionChurn
+	nextReconnect time.Time
+	// If set, the worker stays idle for this long after measuring starts, see ClientRamp
+	joinAfter time.Duration
+}
+
+// How often a worker waiting to join a --ramp, or sleeping in a transaction, checks whether the run was stopped
+const stopPollInterval = 100 * time.Millisecond
+
+// Let the given source adjust the rate of this worker as the benchmark runs; see RateController and PhaseRates
+func (w *Worker) SetRateSource(rates RateSource) {
+	w.rates = rates
+}
+
+// Alternate between running units of work over Bolt and over the given Query API client, on the given schedule;
+// results are then also recorded by protocol, see WorkerResult#Protocols
+func (w *Worker) SetDualProtocol(client *QueryApiClient, schedule *ProtocolSchedule) {
+	w.queryApi = client
+	w.protocols = schedule
+}
+
+// Whether `:readback` statements are sent with the bookmark of the writes before them, the default, which makes
+// the server wait until those writes are visible before reading; without, stale reads show how far behind readers are
+func (w *Worker) SetReadbackBookmarks(enabled bool) {
+	w.readbackWithoutBookmarks = !enabled
+}
+
+// Whether read-only units of work run in write sessions, so in a cluster they go to the leader along with the writes;
+// by default they run in read sessions, which the driver routes to followers and read replicas
+func (w *Worker) SetReadsOnLeader(enabled bool) {
+	w.readsOnLeader = enabled
+}
+
+// How units of work wait for the writes before them, one of BookmarkChain, BookmarkNone or BookmarkShared; scripts
+// with `:bookmark none` run unchained regardless. With BookmarkShared, shared is what all workers share.
+func (w *Worker) SetBookmarks(mode string, shared *SharedBookmarks) {
+	w.bookmarks = mode
+	w.sharedBookmarks = shared
+}
+
+// Report the outcome of each unit of work to the given tracker, to measure recovery from failures like leader switches
+func (w *Worker) SetFailoverTracker(t *FailoverTracker) {
+	w.failover = t
+}
+
+// Whether to measure how much data each unit of work sends and receives, to break latency down by it
+func (w *Worker) SetPayloadSizes(enabled bool) {
+	w.payloads = enabled
+}
+
+// Whether to measure how long each statement takes to return its first record, along with how long it takes to
+// return all of them
+func (w *Worker) SetFirstRecordLatency(enabled bool) {
+	w.firstRecords = enabled
+}
+
+// Log units of work that run slower than the threshold of the given log, with what they ran
+func (w *Worker) SetSlowLog(l *SlowLog) {
+	w.slowLog = l
+}
+
+// Run the given fraction of units of work with PROFILE, to measure the server-side cost of each script
+func (w *Worker) SetProfileSample(fraction float64) {
+	w.profileSample = fraction
+}
+
+// Skip units of work that are further behind schedule than the given limit allows, see ScheduleLimit
+func (w *Worker) SetScheduleLimit(limit ScheduleLimit) {
+	w.scheduleLimit = limit
+}
+
+// Add the given delay to every statement, emulating a database further away
+func (w *Worker) SetInjectedLatency(l InjectedLatency) {
+	w.injectedLatency = l
+}
+
+// Roll back the given fraction of write transactions once their statements have run, and retry them the way an
+// application would, to measure the rollback path; see AbortResult
+func (w *Worker) SetAbortFraction(fraction float64) {
+	w.abortFraction = fraction
+}
+
+// Whether to measure the time spent evaluating the `:set` expressions and statements of each script, to tell
+// whether the client rather than the database is the bottleneck
+func (w *Worker) SetScriptProfiling(enabled bool) {
+	w.profileScripts = enabled
+}
+
+// Check the size of the parameters each unit of work sends against the thresholds of the given guard
+func (w *Worker) SetParamSizeGuard(g *ParamSizeGuard) {
+	w.paramGuard = g
+}
+
+// Connect before starting, and wait for the other workers of the given barrier to do the same, so the run is measured
+// from when all of them are ready
+func (w *Worker) SetReadyBarrier(b *ReadyBarrier) {
+	w.barrier = b
+}
+
+// Take turns with the other workers of the given pacer to open sessions, and time how long connecting takes
+func (w *Worker) SetConnectPacer(p *ConnectPacer) {
+	w.connectPacer = p
+}
+
+// Drop the connection and open a new one at the interval of the given churn, measuring what that costs
+func (w *Worker) SetConnectionChurn(c ConnectionChurn) {
+	w.churn = c
+}
+
+// Connect along with the other workers, but don't start running units of work until this long after measuring
+// starts, so clients can be added as the run goes, see ClientRamp
+func (w *Worker) SetJoinAfter(after time.Duration) {
+	w.joinAfter = after
 }
 
 // transactionRate is Time between transactions; this defines the workload rate
@@ -24,43 +174,88 @@ type Worker struct {
 //
 // If transactionRate is 0, we go as fast as we can, this is used to measure throughput
 // If numTransactions is 0, we go until stopCh tells us to stop
-func (w *Worker) RunBenchmark(wrk ClientWorkload, databaseName string, transactionRate time.Duration,
+// If burst has a size, this worker runs that many extra transactions at the start of each burst interval,
+// on top of the transactionRate; bursts are ignored when measuring throughput
+func (w *Worker) RunBenchmark(wrk ClientWorkload, databaseName string, transactionRate time.Duration, burst Burst,
 	numTransactions uint64, stopCh <-chan struct{}, recorder *ResultRecorder) WorkerResult {
-	session := w.driver.NewSession(neo4j.SessionConfig{
-		AccessMode:   neo4j.AccessModeWrite,
-		DatabaseName: databaseName,
-		Bookmarks:    nil,
-		FetchSize:    neo4j.FetchAll,
-	})
-	defer session.Close()
+	sessions := newWorkerSessions(w.driver, databaseName, &acquisitionTimer{now: w.now})
+	defer sessions.close()
 
-	workStartTime := w.now()
-	recorder.totalStart = workStartTime
-	recorder.currentStart = workStartTime
+	workStartTime, started := w.awaitStart(sessions, stopCh)
+	recorder.Begin(workStartTime)
+	if !started {
+		return recorder.Complete(w.now())
+	}
+	if w.barrier != nil {
+		// Setting up took however long it took; phases count from the release, not from when this client was made
+		wrk.Start = w.barrier.MeasureStart()
+	}
+	if w.joinAfter > 0 {
+		if !w.awaitJoin(wrk.Start, stopCh) {
+			return recorder.Complete(w.now())
+		}
+		// The recorder still counts from when the run started, so the rates of clients that joined late add up
+		workStartTime = w.now()
+	}
 
 	nextStart := workStartTime
+	bursting := transactionRate > 0 && burst.Size > 0
+	nextBurst := workStartTime.Add(burst.Interval)
+	burstRan := int64(0)
 
 	transactionCounter := uint64(0)
 
 	for {
+		// Burst transactions are scheduled like the regular ones, so they too are measured from when they
+		// should have started; while a burst is due before the next regular transaction, it goes first
+		intendedStart, inBurst := nextStart, false
+		if bursting && !nextBurst.After(nextStart) {
+			intendedStart, inBurst = nextBurst, true
+			burstRan++
+			if burstRan == burst.Size {
+				burstRan = 0
+				nextBurst = nextBurst.Add(burst.Interval)
+			}
+		}
+		if transactionRate > 0 {
+			if wait := intendedStart.Sub(w.now()); wait > 0 {
+				w.sleep(wait)
+			}
+		}
+
 		select {
 		case <-stopCh:
 			return recorder.Complete(w.now())
 		default:
 		}
 
-		uow, err := wrk.Next(w.workerId)
-		if err != nil {
-			return WorkerResult{WorkerId: w.workerId, Error: err}
+		// Rather than run transactions that are hopelessly late, and report the wait as latency, they can be skipped
+		if transactionRate > 0 && w.scheduleLimit.MaxLag > 0 {
+			if lag := w.now().Sub(intendedStart); lag > w.scheduleLimit.MaxLag {
+				missed := int64(1)
+				if !inBurst {
+					interval := transactionRate
+					if w.rates != nil {
+						interval = w.rates.ClientInterval()
+					}
+					if w.scheduleLimit.Policy == LagPolicyShed {
+						// Give up on everything due by now, and carry on from the next transaction due
+						missed = int64(lag/interval) + 1
+					}
+					nextStart = nextStart.Add(time.Duration(missed) * interval)
+				}
+				recorder.recordMissed(missed)
+				continue
+			}
 		}
 
-		outcome := w.runUnit(session, uow)
-
-		uowLatency := w.now().Sub(nextStart)
-
-		if err = recorder.record(uow.ScriptName, uowLatency, outcome); err != nil {
+		stopped, err := w.runNext(sessions, wrk, w.workerId, intendedStart, transactionRate > 0, inBurst, stopCh, recorder)
+		if err != nil {
 			return WorkerResult{WorkerId: w.workerId, Error: err}
 		}
+		if stopped {
+			return recorder.Complete(w.now())
+		}
 
 		transactionCounter++
 		if numTransactions != 0 && transactionCounter >= numTransactions {
@@ -78,20 +273,166 @@ func (w *Worker) RunBenchmark(wrk ClientWorkload, databaseName string, transacti
 			// If the database isn't keeping up,
 			// then the latency numbers will grow extremely large, showing the actual wait time
 			// real users would see from when they ask the system to do something to when they get service.
-			if uowLatency < transactionRate {
-				w.sleep(transactionRate - uowLatency)
+			if !inBurst {
+				interval := transactionRate
+				if w.rates != nil {
+					interval = w.rates.ClientInterval()
+				}
+				nextStart = nextStart.Add(interval)
 			}
-			nextStart = nextStart.Add(transactionRate)
 		} else {
 			// No rate limit set, so just track when each transaction started; this effectively
 			// makes us coordinate with the database such that our workload rate exactly matches
 			// the databases ability to process - eg. this measures throughput, but makes the
 			// latencies useless
-			nextStart = time.Now()
+			nextStart = w.now()
 		}
 	}
 }
 
+// Sets up the connection this worker starts out with, then waits for the other workers to be ready too, if there is
+// a barrier; returns when the run started, or false if it was stopped first
+func (w *Worker) awaitStart(sessions *workerSessions, stopCh <-chan struct{}) (time.Time, bool) {
+	sessions.pacer, sessions.stopCh = w.connectPacer, stopCh
+	if w.barrier == nil {
+		return w.now(), true
+	}
+	if !w.barrier.AwaitTurn(stopCh) {
+		return time.Time{}, false
+	}
+	session := sessions.get("")
+	connectStart := w.now()
+	// Failing to connect isn't fatal here; the transactions that follow fail the same way, and are counted
+	if err := sessions.warm(session); err == nil && w.connectPacer != nil {
+		w.connectPacer.record(w.now().Sub(connectStart))
+	}
+	return w.barrier.Ready(stopCh)
+}
+
+// Waits until the time set with SetJoinAfter, counting from the given start; false if the run was stopped before then
+func (w *Worker) awaitJoin(start time.Time, stopCh <-chan struct{}) bool {
+	return w.sleepUntil(start.Add(w.joinAfter), stopCh)
+}
+
+// Sleeps until the given time on the clock of the worker; false if the run was stopped before then
+func (w *Worker) sleepUntil(until time.Time, stopCh <-chan struct{}) bool {
+	for {
+		wait := until.Sub(w.now())
+		if wait <= 0 {
+			return true
+		}
+		select {
+		case <-stopCh:
+			return false
+		default:
+		}
+		if wait > stopPollInterval {
+			wait = stopPollInterval
+		}
+		w.sleep(wait)
+	}
+}
+
+// Runs the next unit of work of the given client, which was due at intendedStart, and records it. Scheduled is
+// whether it was due at a set time, as in latency mode, rather than as soon as the one before was done. Stopped is
+// set if the unit of work failed after the run was asked to stop, in which case the client should stop too; the
+// returned error is only set if recording failed or the script could not be evaluated.
+func (w *Worker) runNext(sessions *workerSessions, wrk ClientWorkload, clientId int64, intendedStart time.Time, scheduled, inBurst bool,
+	stopCh <-chan struct{}, recorder *ResultRecorder) (stopped bool, err error) {
+	// Phases are measured on the clock of the worker
+	wrk.now = w.now
+	uow, err := wrk.Next(clientId)
+	if err != nil {
+		return false, err
+	}
+	// `:sleep ... in transaction` holds the transaction open, but not the run once it's asked to stop
+	uow.ctx.Sleep = func(duration time.Duration) {
+		w.sleepUntil(w.now().Add(duration), stopCh)
+	}
+	uow.TimeCommands = w.profileScripts
+	if w.paramGuard != nil {
+		uow.MeasureParams, uow.ParamLimit = true, w.paramGuard.Limit
+	}
+
+	reconnected, err := w.reconnectIfDue(sessions)
+	if err != nil {
+		return false, err
+	}
+
+	unitStart := w.now()
+	outcome, err := w.runWithPolicy(sessions, &uow, stopCh)
+	if err != nil {
+		return false, err
+	}
+	if w.paramGuard != nil && w.paramGuard.oversized(uow.ParamBytes) {
+		outcome.paramBytes = uow.ParamBytes
+		w.paramGuard.Observe(w.now(), clientId, uow.ScriptName, uow.ParamBytes, uow.Statements)
+	}
+	outcome.churn, outcome.reconnected = w.churn.Interval > 0, reconnected
+	if w.profileScripts {
+		outcome.commandCosts = append(make([]CommandCost, 0, len(uow.CommandCosts)), uow.CommandCosts...)
+	}
+	if scheduled && unitStart.After(intendedStart) {
+		outcome.scheduleLag = unitStart.Sub(intendedStart)
+	}
+
+	// Units of work that fail after the run was asked to stop are likely failing because of it, like when
+	// the interrupt reached the database too; they're counted separately rather than as errors of the workload
+	if !outcome.succeeded {
+		select {
+		case <-stopCh:
+			recorder.recordShutdownFailure()
+			return true, nil
+		default:
+		}
+	}
+
+	if w.slowLog != nil {
+		w.slowLog.Observe(w.now(), clientId, uow.ScriptName, w.now().Sub(unitStart), outcome)
+	}
+
+	// Scripts can ask for client-side sleeps, emulating application think time; this happens outside
+	// of the transaction, but still counts towards the latency of the unit of work
+	if uow.Sleep > 0 {
+		w.sleep(uow.Sleep)
+	}
+
+	unitEnd := w.now()
+	uowLatency := unitEnd.Sub(intendedStart)
+	if w.failover != nil {
+		w.failover.Record(unitStart, unitEnd, uowLatency, outcome.succeeded)
+	}
+
+	if err = recorder.record(uow.ScriptName, uow.Phase, uowLatency, outcome); err != nil {
+		return false, err
+	}
+	if inBurst {
+		if err = recorder.recordBurst(uow.ScriptName, uowLatency, outcome); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Runs the unit of work once, over Bolt or, with --dual-protocol, over whichever protocol is up
+func (w *Worker) runAttempt(sessions *workerSessions, uow *UnitOfWork) (uowOutcome, error) {
+	if w.readsOnLeader {
+		uow.Readonly = false
+	}
+	if w.protocols != nil && w.protocols.At(w.now()) == ProtocolHttp {
+		return w.runUnitOverQueryApi(uow)
+	}
+	outcome, err := w.runUnit(sessions, uow)
+	if sessions.options.Shared != nil {
+		// Other workers see these writes from their next unit of work on, rather than from this one's next
+		sessions.finish()
+	}
+	if w.protocols != nil {
+		outcome.protocol = ProtocolBolt
+	}
+	return outcome, err
+}
+
 func (w *Worker) gatherResults(workloadStats map[string]*ScriptResult, workStartTime time.Time) []ScriptResult {
 	workloadResults := make([]ScriptResult, 0, len(workloadStats))
 	for _, result := range workloadStats {
@@ -106,37 +447,100 @@ func (w *Worker) gatherResults(workloadStats map[string]*ScriptResult, workStart
 	return workloadResults
 }
 
-func (w *Worker) runUnit(session neo4j.Session, uow UnitOfWork) uowOutcome {
-	transaction := func(tx neo4j.Transaction) (interface{}, error) {
-		var lastResult neo4j.Result
+// Runs the given unit of work; failures of the unit of work are reported in the outcome, the returned error is
+// only set if the script itself could not be evaluated, in which case there is no point in carrying on
+func (w *Worker) runUnit(sessions *workerSessions, uow *UnitOfWork) (uowOutcome, error) {
+	options := uow.Session
+	options.Read = uow.Readonly
+	if w.bookmarks == BookmarkNone {
+		options.Unchained = true
+	} else if w.bookmarks == BookmarkShared && !options.Unchained {
+		options.Unchained = true
+		options.Shared = w.sharedBookmarks
+	}
+	sessions.configure(options)
+	metadata, err := uow.Metadata()
+	if err != nil {
+		return uowOutcome{}, errors.Wrapf(err, "failed to evaluate script '%s'", uow.ScriptName)
+	}
+	var txConfig []func(*neo4j.TransactionConfig)
+	if metadata != nil {
+		txConfig = append(txConfig, neo4j.WithTxMetadata(metadata))
+	}
 
-		for _, s := range uow.Statements {
-			res, err := tx.Run(s.Query, s.Params)
-			if err != nil {
-				return nil, err
-			}
-			_, err = res.(neo4j.Result).Consume()
-			if err != nil {
-				return nil, err
-			}
-			lastResult = res
+	// A small sample of units of work is profiled; their statements are kept along with their summaries
+	profiled := w.profileSample > 0 && rand.Float64() < w.profileSample
+	query := func(s Statement) string {
+		if profiled {
+			return profileQuery(s.Query)
+		}
+		return s.Query
+	}
+
+	// Times each statement, for the per-database breakdown
+	var statements []statementOutcome
+	var readbacks, staleReads int64
+	var size payloadSize
+	// Summary of the statement being measured, if it completed, and when its first record arrived
+	var lastSummary neo4j.ResultSummary
+	var first firstRecordTimer
+	measure := func(s Statement, run func() error) error {
+		if w.payloads {
+			size.sent += valueSize(s.Params)
+		}
+		lastSummary = nil
+		start := w.now()
+		first = firstRecordTimer{start: start}
+		w.injectLatency()
+		err := run()
+		statement := statementOutcome{
+			databaseName: sessions.resolve(s.Database),
+			latency:      w.now().Sub(start),
+			succeeded:    err == nil,
+		}
+		if first.seen {
+			statement.firstRecord = first.latency
+		}
+		if w.slowLog != nil || profiled {
+			statement.query, statement.params, statement.summary = s.Query, s.Params, lastSummary
 		}
-		return lastResult, nil
+		statements = append(statements, statement)
+		return err
 	}
 
-	autocommitTransaction := func(session neo4j.Session) (interface{}, error) {
-		var lastResult neo4j.Result
+	// Sessions borrow a connection for each transaction, and for each auto-commit statement
+	acquisition := sessions.acquisition
+
+	transaction := func(tx neo4j.Transaction) (interface{}, error) {
+		err := uow.Run(func(s Statement) error {
+			return measure(s, func() error {
+				res, err := tx.Run(query(s), s.Params)
+				if err != nil {
+					return err
+				}
+				lastSummary, err = w.consume(res, uow.Consume, &size, &first)
+				return err
+			})
+		})
+		return nil, err
+	}
+
+	autocommitTransaction := func() error {
 		var retries = 20
-		var res interface{}
-		var err error
 
-		for _, s := range uow.Statements {
+		return uow.Run(func(s Statement) error {
+			var err error
 			var retriesThisTime = retries
 			for i := 0; i < retriesThisTime; i++ {
-				res, err = session.Run(s.Query, s.Params)
-				if err == nil {
-					_, err = res.(neo4j.Result).Consume()
-				}
+				err = measure(s, func() error {
+					acquisition.begin()
+					res, err := sessions.get(s.Database).Run(query(s), s.Params, txConfig...)
+					if err != nil {
+						return err
+					}
+					lastSummary, err = w.consume(res, uow.Consume, &size, &first)
+					return err
+				})
 				if err == nil {
 					break
 				}
@@ -144,36 +548,323 @@ func (w *Worker) runUnit(session neo4j.Session, uow UnitOfWork) uowOutcome {
 				w.sleep(time.Duration(i*10+jitter) * time.Millisecond)
 				retries = retries - 1
 			}
+			return err
+		})
+	}
 
-			if err != nil {
-				return nil, err
-			}
+	// Runs an attempt at the unit of work in an explicit transaction and rolls it back, with --abort-fraction; the
+	// caller then retries it
+	var rollbacks []time.Duration
+	abortedTransaction := func() error {
+		acquisition.begin()
+		tx, err := sessions.get("").BeginTransaction(txConfig...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = tx.Close()
+		}()
+		if _, err := transaction(tx); err != nil {
+			return err
+		}
+		start := w.now()
+		err = tx.Rollback()
+		rollbacks = append(rollbacks, w.now().Sub(start))
+		return err
+	}
 
-			lastResult = res.(neo4j.Result)
+	// Some statements run as auto-commit transactions or against other databases; the rest run in explicit
+	// transactions in between them, a new transaction starting whenever the database changes. These are not
+	// retried, since earlier transactions may have committed part of the work already.
+	segmentedTransaction := func() error {
+		var tx neo4j.Transaction
+		var txDatabase string
+		defer func() {
+			if tx != nil {
+				_ = tx.Close()
+			}
+		}()
+		err := uow.Run(func(s Statement) error {
+			var err error
+			if tx != nil && (s.Autocommit || s.Readback || s.Database != txDatabase) {
+				err = tx.Commit()
+				_ = tx.Close()
+				tx = nil
+				if err != nil {
+					return err
+				}
+			}
+			if !s.Autocommit && !s.Readback && tx == nil {
+				acquisition.begin()
+				tx, err = sessions.get(s.Database).BeginTransaction(txConfig...)
+				if err != nil {
+					return err
+				}
+				txDatabase = s.Database
+			}
+			return measure(s, func() error {
+				if s.Readback {
+					stale, err := w.readback(sessions, s)
+					if err != nil {
+						return err
+					}
+					readbacks++
+					if stale {
+						staleReads++
+					}
+					return nil
+				}
+				var res neo4j.Result
+				var err error
+				if s.Autocommit {
+					acquisition.begin()
+					res, err = sessions.get(s.Database).Run(query(s), s.Params, txConfig...)
+				} else {
+					res, err = tx.Run(query(s), s.Params)
+				}
+				if err != nil {
+					return err
+				}
+				lastSummary, err = w.consume(res, uow.Consume, &size, &first)
+				return err
+			})
+		})
+		if err != nil || tx == nil {
+			return err
 		}
-		return lastResult, nil
+		return tx.Commit()
 	}
 
-	var err error
-	if uow.Readonly {
-		_, err = session.ReadTransaction(transaction)
+	// Read-only units of work run in read sessions, see SessionOptions#Read, so auto-commit and segmented ones are
+	// routed like the rest
+	if uow.Autocommit {
+		err = autocommitTransaction()
+	} else if uow.Segmented {
+		err = segmentedTransaction()
+	} else if uow.Readonly {
+		acquisition.begin()
+		_, err = sessions.get("").ReadTransaction(transaction, txConfig...)
 	} else {
-		if uow.Autocommit {
-			_, err = autocommitTransaction(session)
-		} else {
-			_, err = session.WriteTransaction(transaction)
+		for err == nil && w.abortNext(len(rollbacks)) {
+			err = abortedTransaction()
+		}
+		if err == nil {
+			acquisition.begin()
+			_, err = sessions.get("").WriteTransaction(transaction, txConfig...)
 		}
 	}
+	abortable := w.abortFraction > 0 && !uow.Readonly && !uow.Autocommit && !uow.Segmented
+
+	if scriptErr, ok := err.(*ScriptError); ok {
+		return uowOutcome{}, errors.Wrapf(scriptErr.Err, "failed to evaluate script '%s'", uow.ScriptName)
+	}
+
+	var payload *payloadSize
+	if w.payloads {
+		payload = &size
+	}
 
 	if err != nil {
 		return uowOutcome{
-			succeeded:    false,
-			failureGroup: groupError(err),
-			err:          err,
+			succeeded:        false,
+			failureGroup:     groupError(err),
+			err:              err,
+			statements:       statements,
+			acquisitionWaits: acquisition.take(),
+			readbacks:        readbacks,
+			staleReads:       staleReads,
+			abortable:        abortable,
+			rollbacks:        rollbacks,
+		}, nil
+	}
+
+	return uowOutcome{
+		succeeded:        true,
+		statements:       statements,
+		acquisitionWaits: acquisition.take(),
+		readbacks:        readbacks,
+		staleReads:       staleReads,
+		payload:          payload,
+		profiled:         profiled,
+		abortable:        abortable,
+		rollbacks:        rollbacks,
+	}, nil
+}
+
+// Runs a `:readback` statement in a read session of its own, so that in a cluster it may go to another server
+// than the writes before it did; returns true if the read was stale, see Statement#Readback
+func (w *Worker) readback(sessions *workerSessions, s Statement) (bool, error) {
+	var bookmarks []string
+	if !w.readbackWithoutBookmarks {
+		if bookmark := sessions.get(s.Database).LastBookmark(); bookmark != "" {
+			bookmarks = []string{bookmark}
 		}
 	}
+	session := sessions.driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		DatabaseName: sessions.resolve(s.Database),
+		Bookmarks:    bookmarks,
+		FetchSize:    sessions.options.fetchSize(),
+		BoltLogger:   sessions.acquisition,
+	})
+	defer session.Close()
 
-	return uowOutcome{succeeded: true}
+	sessions.acquisition.begin()
+	res, err := session.Run(s.Query, s.Params)
+	if err != nil {
+		return false, err
+	}
+	records, err := res.Collect()
+	if err != nil {
+		return false, err
+	}
+	if len(records) != 1 || len(records[0].Values) == 0 {
+		return true, nil
+	}
+	fresh, _ := records[0].Values[0].(bool)
+	return !fresh, nil
+}
+
+// Sessions used by one worker; one for the database the workload runs against, and one for each other
+// database scripts switch to with `:use`. Sessions are created as they are first needed.
+type workerSessions struct {
+	driver       neo4j.Driver
+	databaseName string
+	sessions     map[string]neo4j.Session
+	// Whether each of the sessions reads or writes, see SessionOptions#Read
+	modes map[string]neo4j.AccessMode
+	// Bolt logger of all the sessions, timing how long it takes them to get a connection
+	acquisition *acquisitionTimer
+	// If set, new sessions wait their turn with this, unless stopCh closes first
+	pacer  *ConnectPacer
+	stopCh <-chan struct{}
+	// Whether the driver belongs to these sessions, and is closed with them, see replaceDriver
+	ownDriver bool
+	// Options of the unit of work being run, see configure; sessions of units of work that don't chain bookmarks
+	// are kept apart, and closed when the next unit of work starts
+	options   SessionOptions
+	unchained map[string]neo4j.Session
+	// Database of each of the unchained sessions, which their bookmarks are shared for, see SessionOptions#Shared
+	unchainedDatabases map[string]string
+}
+
+func newWorkerSessions(driver neo4j.Driver, databaseName string, acquisition *acquisitionTimer) *workerSessions {
+	return &workerSessions{
+		driver:             driver,
+		databaseName:       databaseName,
+		sessions:           make(map[string]neo4j.Session),
+		modes:              make(map[string]neo4j.AccessMode),
+		unchained:          make(map[string]neo4j.Session),
+		acquisition:        acquisition,
+		unchainedDatabases: make(map[string]string),
+	}
+}
+
+// Sets up the sessions for a unit of work with the given options, closing any the unit of work before had to itself
+func (s *workerSessions) configure(options SessionOptions) {
+	s.finish()
+	s.options = options
+}
+
+// Closes the sessions the unit of work had to itself, sharing where they got to first with SessionOptions#Shared
+func (s *workerSessions) finish() {
+	for key, session := range s.unchained {
+		if s.options.Shared != nil {
+			s.options.Shared.publish(s, s.unchainedDatabases[key], session.LastBookmark())
+		}
+		_ = session.Close()
+		delete(s.unchained, key)
+		delete(s.unchainedDatabases, key)
+	}
+}
+
+// Get the session for the given database; empty string means the database the unit of work runs against, see
+// SessionOptions#Database, or else the one the workload runs against
+func (s *workerSessions) get(databaseName string) neo4j.Session {
+	databaseName = s.resolve(databaseName)
+	sessions := s.sessions
+	if s.options.Unchained {
+		sessions = s.unchained
+	}
+	key := s.options.sessionKey(databaseName)
+	session, found := sessions[key]
+	mode := s.options.accessMode()
+	// Sessions of units of work that don't chain bookmarks only last the one unit of work, so they never switch
+	if found && (s.options.Unchained || s.modes[key] == mode) {
+		return session
+	}
+	var bookmarks []string
+	if found {
+		// Going between reading and writing takes a session of the other kind; it starts from the bookmark of the
+		// one before, so units of work still see what the ones before them wrote, as they would in a single session
+		if bookmark := session.LastBookmark(); bookmark != "" {
+			bookmarks = []string{bookmark}
+		}
+		_ = session.Close()
+	} else if s.pacer != nil {
+		// Stopping while waiting goes on with the session anyway, its transactions fail and the worker stops
+		s.pacer.Await(s.stopCh)
+	}
+	if s.options.Shared != nil {
+		bookmarks = s.options.Shared.For(databaseName)
+	}
+	session = s.driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   mode,
+		DatabaseName: databaseName,
+		Bookmarks:    bookmarks,
+		FetchSize:    s.options.fetchSize(),
+		BoltLogger:   s.acquisition,
+	})
+	sessions[key] = session
+	if s.options.Unchained {
+		s.unchainedDatabases[key] = databaseName
+	} else {
+		s.modes[key] = mode
+	}
+	return session
+}
+
+// Opens a connection for the given session, so the first transaction doesn't have to
+func (s *workerSessions) warm(session neo4j.Session) error {
+	result, err := session.Run("RETURN 1", nil)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume()
+	return err
+}
+
+func (s *workerSessions) resolve(databaseName string) string {
+	if databaseName == "" {
+		databaseName = s.options.Database
+	}
+	if databaseName == "" {
+		return s.databaseName
+	}
+	return databaseName
+}
+
+func (s *workerSessions) close() {
+	for _, session := range s.sessions {
+		_ = session.Close()
+	}
+	for _, session := range s.unchained {
+		_ = session.Close()
+	}
+	if s.ownDriver {
+		_ = s.driver.Close()
+	}
+}
+
+// Closes the sessions, and the driver if it is theirs, and goes on with the given driver, which is closed with them
+func (s *workerSessions) replaceDriver(driver neo4j.Driver) {
+	s.close()
+	s.driver = driver
+	s.ownDriver = true
+	s.sessions = make(map[string]neo4j.Session)
+	s.modes = make(map[string]neo4j.AccessMode)
+	s.unchained = make(map[string]neo4j.Session)
+	s.unchainedDatabases = make(map[string]string)
 }
 
 // Converts a total target rate into a per-client "pacing" duration, used to slow down workers to match
@@ -204,14 +895,50 @@ func NewResultRecorder(workerId int64) *ResultRecorder {
 	}
 }
 
-func (t *ResultRecorder) record(scriptName string, latency time.Duration, outcome uowOutcome) error {
+func (t *ResultRecorder) record(scriptName, phase string, latency time.Duration, outcome uowOutcome) error {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if err := t.current.record(scriptName, phase, latency, outcome); err != nil {
+		return err
+	}
+	return t.total.record(scriptName, phase, latency, outcome)
+}
+
+func (t *ResultRecorder) recordBurst(scriptName string, latency time.Duration, outcome uowOutcome) error {
 	t.mut.Lock()
 	defer t.mut.Unlock()
 
-	if err := t.current.record(scriptName, latency, outcome); err != nil {
+	if err := recordScript(t.current.Bursts, scriptName, latency, outcome); err != nil {
 		return err
 	}
-	return t.total.record(scriptName, latency, outcome)
+	return recordScript(t.total.Bursts, scriptName, latency, outcome)
+}
+
+func (t *ResultRecorder) recordMissed(n int64) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.current.Missed += n
+	t.total.Missed += n
+}
+
+func (t *ResultRecorder) recordShutdownFailure() {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.current.ShutdownFailures++
+	t.total.ShutdownFailures++
+}
+
+// Counts results from the given time, when the workload started; progress is read from other threads as soon as
+// the run is released, which can be before the worker gets to call this
+func (t *ResultRecorder) Begin(start time.Time) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.currentStart = start
+	t.totalStart = start
 }
 
 // Reports progress since last time you called this function
@@ -230,6 +957,27 @@ func (t *ResultRecorder) ProgressReport(now time.Time) WorkerResult {
 	return out
 }
 
+// Throws away everything recorded so far, so results count from now on; used to leave --warmup out of them
+func (t *ResultRecorder) Discard(now time.Time) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.current = NewWorkerResult(t.current.WorkerId)
+	t.currentStart = now
+	t.total = NewWorkerResult(t.total.WorkerId)
+	t.totalStart = now
+}
+
+// Adds everything recorded since the workload started to the given result, without resetting anything; the
+// worker keeps recording into its own result, so this copies rather than hands it out like Complete does
+func (t *ResultRecorder) Snapshot(now time.Time, into *Result) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.total.calculateRate(now.Sub(t.totalStart))
+	into.Add(t.total)
+}
+
 func (t *ResultRecorder) Complete(now time.Time) WorkerResult {
 	t.mut.Lock()
 	defer t.mut.Unlock()
@@ -252,6 +1000,17 @@ func NewWorkerResult(workerId int64) WorkerResult {
 		WorkerId:           workerId,
 		Scripts:            make(map[string]*ScriptResult),
 		FailedByErrorGroup: make(map[string]FailureGroup),
+		Databases:          make(map[string]*DatabaseResult),
+		Phases:             make(map[string]map[string]*ScriptResult),
+		Bursts:             make(map[string]*ScriptResult),
+		AcquisitionWaits:   hdrhistogram.New(0, 60*60*1000000, 3),
+		Protocols:          make(map[string]map[string]*ScriptResult),
+		Payloads:           make(map[string]*PayloadResult),
+		FirstRecords:       make(map[string]*FirstRecordResult),
+		Profiles:           make(map[string]*ProfileResult),
+		ScriptEvals:        make(map[string]*ScriptEvalResult),
+		OversizedParams:    make(map[string]*OversizedParamsResult),
+		ErrorHandling:      make(map[string]*ErrorHandlingResult),
 	}
 }
 
@@ -267,6 +1026,59 @@ type WorkerResult struct {
 
 	// Failure counts by cause
 	FailedByErrorGroup map[string]FailureGroup
+
+	// Statement statistics grouped by the database the statements ran against
+	Databases map[string]*DatabaseResult
+
+	// Script statistics for each weight phase, see Scripts#Phases; these don't have rates set
+	Phases map[string]map[string]*ScriptResult
+
+	// Statistics for the transactions run as part of a --burst, by script; these are also counted in Scripts
+	Bursts map[string]*ScriptResult
+
+	// Time spent waiting for the driver to provide a connection, in microseconds, see acquisitionTimer
+	AcquisitionWaits *hdrhistogram.Histogram
+
+	// Script statistics by the protocol the scripts ran over, with --dual-protocol; these don't have rates set
+	Protocols map[string]map[string]*ScriptResult
+
+	// Number of `:readback` statements, see Statement#Readback, how many of them read stale data, and how many did
+	// per second
+	Readbacks     int64
+	StaleReads    int64
+	StaleReadRate float64
+
+	// Latency of successful units of work by how much data they moved, by script, with --payload-sizes
+	Payloads map[string]*PayloadResult
+	// Time to first record against time to all records, by script, with --first-record
+	FirstRecords map[string]*FirstRecordResult
+
+	// Server-side cost of the units of work that ran with PROFILE, by script, with --profile-sample
+	Profiles map[string]*ProfileResult
+
+	// Time spent evaluating script commands, by script, with --profile-scripts
+	ScriptEvals map[string]*ScriptEvalResult
+
+	// Units of work that sent more parameter data than --param-size-warn, by script
+	OversizedParams map[string]*OversizedParamsResult
+
+	// Units of work that failed after the run was asked to stop; these are not counted anywhere else
+	ShutdownFailures int64
+
+	// In latency mode, how long after it was due the last unit of work started, and the most any did
+	ScheduleLag    time.Duration
+	MaxScheduleLag time.Duration
+	// Units of work skipped for being too far behind schedule, see ScheduleLimit
+	Missed int64
+
+	// Transactions rolled back on purpose and retried, with --abort-fraction; nil otherwise
+	Aborts *AbortResult
+
+	// Units of work that reconnected against those that didn't, with --reconnect-every; nil otherwise
+	Churn *ChurnResult
+
+	// What the `:on-error` policies of the scripts did, by script
+	ErrorHandling map[string]*ErrorHandlingResult
 }
 
 func (r *WorkerResult) getOrCreateScriptResult(scriptName string) *ScriptResult {
@@ -282,35 +1094,182 @@ func (r *WorkerResult) getOrCreateScriptResult(scriptName string) *ScriptResult
 	return stats
 }
 
-func (r *WorkerResult) record(scriptName string, latency time.Duration, outcome uowOutcome) error {
-	stats, found := r.Scripts[scriptName]
-	if !found {
-		stats = &ScriptResult{
-			ScriptName: scriptName,
-			Latencies:  hdrhistogram.New(0, 60*60*1000000, 3),
+func (r *WorkerResult) record(scriptName, phase string, latency time.Duration, outcome uowOutcome) error {
+	for _, stmt := range outcome.statements {
+		if err := r.recordStatement(stmt); err != nil {
+			return err
+		}
+		if stmt.succeeded && stmt.firstRecord > 0 {
+			first, found := r.FirstRecords[scriptName]
+			if !found {
+				first = newFirstRecordResult(scriptName)
+				r.FirstRecords[scriptName] = first
+			}
+			if err := first.record(stmt); err != nil {
+				return err
+			}
+		}
+	}
+	r.Readbacks += outcome.readbacks
+	r.StaleReads += outcome.staleReads
+	r.ScheduleLag = outcome.scheduleLag
+	if outcome.scheduleLag > r.MaxScheduleLag {
+		r.MaxScheduleLag = outcome.scheduleLag
+	}
+	for _, wait := range outcome.acquisitionWaits {
+		if err := r.AcquisitionWaits.RecordValue(wait.Microseconds()); err != nil {
+			return errors.Wrapf(err, "failed to record connection acquisition wait: %s", wait)
 		}
-		r.Scripts[scriptName] = stats
 	}
 
-	if outcome.succeeded {
-		stats.Succeeded++
-		if err := stats.Latencies.RecordValue(latency.Microseconds()); err != nil {
+	if err := recordScript(r.Scripts, scriptName, latency, outcome); err != nil {
+		return err
+	}
+	if outcome.protocol != "" {
+		protocolScripts, found := r.Protocols[outcome.protocol]
+		if !found {
+			protocolScripts = make(map[string]*ScriptResult)
+			r.Protocols[outcome.protocol] = protocolScripts
+		}
+		if err := recordScript(protocolScripts, scriptName, latency, outcome); err != nil {
+			return err
+		}
+	}
+	if phase != "" {
+		phaseScripts, found := r.Phases[phase]
+		if !found {
+			phaseScripts = make(map[string]*ScriptResult)
+			r.Phases[phase] = phaseScripts
+		}
+		if err := recordScript(phaseScripts, scriptName, latency, outcome); err != nil {
+			return err
+		}
+	}
+
+	if outcome.abortable {
+		if r.Aborts == nil {
+			r.Aborts = newAbortResult()
+		}
+		if err := r.Aborts.record(latency, outcome); err != nil {
+			return err
+		}
+	}
+
+	if outcome.churn {
+		if r.Churn == nil {
+			r.Churn = newChurnResult()
+		}
+		if err := r.Churn.record(latency, outcome); err != nil {
+			return err
+		}
+	}
+
+	if outcome.succeeded && outcome.payload != nil {
+		payload, found := r.Payloads[scriptName]
+		if !found {
+			payload = newPayloadResult(scriptName)
+			r.Payloads[scriptName] = payload
+		}
+		if err := payload.record(*outcome.payload, latency.Microseconds()); err != nil {
 			return errors.Wrapf(err, "failed to record latency: %s", latency)
 		}
-	} else {
-		stats.Failed++
+	}
+
+	if outcome.succeeded && outcome.profiled {
+		profile, found := r.Profiles[scriptName]
+		if !found {
+			profile = newProfileResult(scriptName)
+			r.Profiles[scriptName] = profile
+		}
+		profile.Units++
+		for _, stmt := range outcome.statements {
+			if stmt.summary != nil {
+				profile.addPlan(stmt.summary.Profile())
+			}
+		}
+	}
+
+	if outcome.retries > 0 || outcome.handled != "" {
+		handling, found := r.ErrorHandling[scriptName]
+		if !found {
+			handling = &ErrorHandlingResult{ScriptName: scriptName}
+			r.ErrorHandling[scriptName] = handling
+		}
+		handling.record(outcome)
+	}
+
+	if outcome.paramBytes > 0 {
+		oversized, found := r.OversizedParams[scriptName]
+		if !found {
+			oversized = &OversizedParamsResult{ScriptName: scriptName}
+			r.OversizedParams[scriptName] = oversized
+		}
+		oversized.record(outcome.paramBytes)
+	}
+
+	// Failed units of work count too, evaluating their scripts took as long
+	if outcome.commandCosts != nil {
+		eval, found := r.ScriptEvals[scriptName]
+		if !found {
+			eval = newScriptEvalResult(scriptName)
+			r.ScriptEvals[scriptName] = eval
+		}
+		eval.record(outcome.commandCosts)
+	}
+
+	if !outcome.succeeded {
 		failedGroup, found := r.FailedByErrorGroup[outcome.failureGroup]
 		if !found {
-			r.FailedByErrorGroup[outcome.failureGroup] = FailureGroup{
-				Count:        1,
+			failedGroup = FailureGroup{
 				FirstFailure: outcome.err,
+				Latencies:    hdrhistogram.New(0, 60*60*1000000, 3),
 			}
-		} else {
-			r.FailedByErrorGroup[outcome.failureGroup] = FailureGroup{
-				Count:        failedGroup.Count + 1,
-				FirstFailure: failedGroup.FirstFailure,
-			}
 		}
+		failedGroup.Count++
+		if err := failedGroup.Latencies.RecordValue(latency.Microseconds()); err != nil {
+			return errors.Wrapf(err, "failed to record latency: %s", latency)
+		}
+		r.FailedByErrorGroup[outcome.failureGroup] = failedGroup
+	}
+	return nil
+}
+
+func recordScript(scripts map[string]*ScriptResult, scriptName string, latency time.Duration, outcome uowOutcome) error {
+	stats, found := scripts[scriptName]
+	if !found {
+		stats = &ScriptResult{
+			ScriptName: scriptName,
+			Latencies:  hdrhistogram.New(0, 60*60*1000000, 3),
+		}
+		scripts[scriptName] = stats
+	}
+	if !outcome.succeeded {
+		stats.Failed++
+		return nil
+	}
+	stats.Succeeded++
+	if err := stats.Latencies.RecordValue(latency.Microseconds()); err != nil {
+		return errors.Wrapf(err, "failed to record latency: %s", latency)
+	}
+	return nil
+}
+
+func (r *WorkerResult) recordStatement(stmt statementOutcome) error {
+	stats, found := r.Databases[stmt.databaseName]
+	if !found {
+		stats = &DatabaseResult{
+			DatabaseName: stmt.databaseName,
+			Latencies:    hdrhistogram.New(0, 60*60*1000000, 3),
+		}
+		r.Databases[stmt.databaseName] = stats
+	}
+	if !stmt.succeeded {
+		stats.Failed++
+		return nil
+	}
+	stats.Succeeded++
+	if err := stats.Latencies.RecordValue(stmt.latency.Microseconds()); err != nil {
+		return errors.Wrapf(err, "failed to record latency: %s", stmt.latency)
 	}
 	return nil
 }
@@ -321,15 +1280,27 @@ func (r *WorkerResult) calculateRate(delta time.Duration) {
 	for _, script := range r.Scripts {
 		script.Rate = (float64(script.Succeeded+script.Failed) / float64(delta.Microseconds())) * 1000 * 1000
 	}
+	for _, script := range r.Bursts {
+		script.Rate = (float64(script.Succeeded+script.Failed) / float64(delta.Microseconds())) * 1000 * 1000
+	}
+	r.StaleReadRate = (float64(r.StaleReads) / float64(delta.Microseconds())) * 1000 * 1000
 }
 
 // Combines the count with the last error we saw, to help users see what the errors were
 type FailureGroup struct {
 	Count        int64
 	FirstFailure error
+	// How long the failed units of work took to fail, in microseconds; timeouts make for slow failures
+	Latencies *hdrhistogram.Histogram
 }
 
+// Groups failures by the status code the server gave, ex: Neo.TransientError.Transaction.DeadlockDetected, or
+// "unknown" for failures that didn't come from the server, like connection errors
 func groupError(err error) string {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return neo4jErr.Code
+	}
 	msg := err.Error()
 	if strings.HasPrefix(msg, "Server error: [") {
 		return strings.Split(strings.Split(msg, "[")[1], "]")[0]
@@ -342,6 +1313,49 @@ type uowOutcome struct {
 	// An opaque string used to group errors; we track counts for each unique string
 	failureGroup string
 	err          error
+	// Each statement attempted while running the unit of work
+	statements []statementOutcome
+	// How long each connection the unit of work used took to acquire from the driver's pool
+	acquisitionWaits []time.Duration
+	// Protocol the unit of work ran over, only set with --dual-protocol
+	protocol string
+	// Number of `:readback` statements that ran, and how many of them read stale data
+	readbacks  int64
+	staleReads int64
+	// How much data the unit of work sent and received, only set with --payload-sizes
+	payload *payloadSize
+	// Whether the unit of work ran with PROFILE, with --profile-sample; its statements then have summaries
+	profiled bool
+	// How long after it was due the unit of work started, in latency mode
+	scheduleLag time.Duration
+	// Whether the unit of work could have been rolled back with --abort-fraction, and how long each of the rollbacks
+	// it went through took
+	abortable bool
+	rollbacks []time.Duration
+	// How long each command of the script took to evaluate, only set with --profile-scripts
+	commandCosts []CommandCost
+	// Bytes of parameters the unit of work sent, only set if that is over --param-size-warn
+	paramBytes int64
+	// Whether the worker reconnects with --reconnect-every, and whether it did right before this unit of work
+	churn       bool
+	reconnected bool
+	// Times the unit of work was retried by its `:on-error` policy, and what the policy did once it failed for good,
+	// an OnError constant, or empty if nothing
+	retries        int
+	handled        string
+	fallbackFailed bool
+}
+
+type statementOutcome struct {
+	databaseName string
+	latency      time.Duration
+	succeeded    bool
+	// What ran and what the driver said about it, only kept with --slow-threshold, see SlowLog
+	query   string
+	params  map[string]interface{}
+	summary neo4j.ResultSummary
+	// How long until the first record arrived, only set with --first-record, for statements that returned records
+	firstRecord time.Duration
 }
 
 func NewWorker(driver neo4j.Driver, workerId int64) *Worker {
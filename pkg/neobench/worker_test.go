@@ -3,9 +3,11 @@ package neobench
 import (
 	"fmt"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -33,7 +35,7 @@ func TestMaintainsRateInFaceOfFailure(t *testing.T) {
 	targetRatePerSecond := float64(1)
 	txDuration := TotalRatePerSecondToDurationPerClient(1, targetRatePerSecond)
 
-	result := w.RunBenchmark(newTestWorkload(r), "", txDuration, 100, stopCh, rec)
+	result := w.RunBenchmark(newTestWorkload(r), "", txDuration, Burst{}, 100, stopCh, rec)
 
 	assert.NoError(t, result.Error)
 	sr := result.Scripts["workertest"]
@@ -125,3 +127,276 @@ func (d *fakeDriver) Run(cypher string, params map[string]interface{}, configure
 var _ neo4j.Driver = &fakeDriver{}
 
 var _ neo4j.Session = &fakeDriver{}
+
+func TestBreaksDownStatementsByDatabase(t *testing.T) {
+	res := NewWorkerResult(0)
+	err := res.record("fanout", "", 30*time.Millisecond, uowOutcome{succeeded: true, statements: []statementOutcome{
+		{databaseName: "", latency: 10 * time.Millisecond, succeeded: true},
+		{databaseName: "shard1", latency: 5 * time.Millisecond, succeeded: true},
+		{databaseName: "shard2", latency: 15 * time.Millisecond, succeeded: false},
+	}})
+	assert.NoError(t, err)
+
+	combined := NewResult("", "")
+	combined.Add(res)
+	combined.Add(res)
+
+	assert.Len(t, combined.Databases, 3)
+	assert.Equal(t, int64(2), combined.Databases[""].Succeeded)
+	assert.Equal(t, int64(2), combined.Databases["shard1"].Succeeded)
+	assert.Equal(t, int64(2), combined.Databases["shard2"].Failed)
+	assert.Equal(t, int64(0), combined.Databases["shard2"].Succeeded)
+	assert.Equal(t, int64(2), combined.Scripts["fanout"].Succeeded)
+}
+
+func TestWriteReadbackReport(t *testing.T) {
+	result := NewResult("neo4j", "")
+	worker := NewWorkerResult(0)
+	assert.NoError(t, worker.record("ryw", "", time.Millisecond, uowOutcome{succeeded: true, readbacks: 1, staleReads: 1}))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, worker.record("ryw", "", time.Millisecond, uowOutcome{succeeded: true, readbacks: 1}))
+	}
+	worker.calculateRate(2 * time.Second)
+	result.Add(worker)
+
+	s := strings.Builder{}
+	writeReadbackReport(result, &s)
+	assert.Equal(t, "-- Read your writes --\n\n  1 of 4 reads were stale (25.000%), 0.500 per second\n\n", s.String())
+}
+
+func TestRecordsLatencyOfFailuresByGroup(t *testing.T) {
+	worker := NewWorkerResult(0)
+	timeout := uowOutcome{succeeded: false, failureGroup: "Neo.TransientError.Transaction.Terminated", err: fmt.Errorf("terminated")}
+	assert.NoError(t, worker.record("slow", "", 30*time.Second, timeout))
+	assert.NoError(t, worker.record("slow", "", 10*time.Second, timeout))
+
+	result := NewResult("neo4j", "")
+	result.Add(worker)
+	result.Add(worker)
+
+	group := result.FailedByErrorGroup["Neo.TransientError.Transaction.Terminated"]
+	assert.Equal(t, int64(4), group.Count)
+	assert.Equal(t, int64(4), group.Latencies.TotalCount())
+	// Combining results doesn't change the worker's own
+	assert.Equal(t, int64(2), worker.FailedByErrorGroup["Neo.TransientError.Transaction.Terminated"].Latencies.TotalCount())
+
+	s := strings.Builder{}
+	writeErrorReport(result, &s)
+	assert.Equal(t, `Error stats:
+  Failed transactions: 4 (100.000 %)
+
+  Causes:
+    Neo.TransientError.Transaction.Terminated: 4 failures, taking P50: 10002.431ms, P99: 30015.487ms, Max: 30015.487ms
+      (ex: terminated)
+`, s.String())
+}
+
+func TestFailuresWhileStoppingAreCountedSeparately(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	stopCh := make(chan struct{})
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)}
+	driver := &stoppingDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}, stop: stopCh, after: 3}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 0, stopCh, NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(3), result.Scripts["workertest"].Succeeded)
+	assert.Equal(t, int64(0), result.Scripts["workertest"].Failed)
+	assert.Empty(t, result.FailedByErrorGroup)
+	assert.Equal(t, int64(1), result.ShutdownFailures)
+
+	combined := NewResult("", "")
+	combined.Add(result)
+	s := strings.Builder{}
+	writeErrorReport(combined, &s)
+	assert.Equal(t, `Error stats:
+  No errors!
+  1 more failed while the run was stopping; these are left out, as stopping may be what failed them
+`, s.String())
+}
+
+// Asks the run to stop while running a transaction, after some have succeeded, and then fails that transaction
+func TestFailuresAreGroupedByTheServerStatusCode(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	failure := &neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed", Msg: "Node(0) already exists"}
+	driver := &serverErrorDriver{fakeDriver: &fakeDriver{clock: clock, r: r}, err: errors.Wrap(failure, "failed to commit")}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+
+	result := w.RunBenchmark(newTestWorkload(r), "", 0, Burst{}, 3, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Len(t, result.FailedByErrorGroup, 1)
+	group := result.FailedByErrorGroup["Neo.ClientError.Schema.ConstraintValidationFailed"]
+	assert.Equal(t, int64(3), group.Count)
+	assert.Equal(t, "unknown", groupError(fmt.Errorf("connection reset by peer")))
+}
+
+// Fails every transaction with err
+type serverErrorDriver struct {
+	*fakeDriver
+	err error
+}
+
+func (d *serverErrorDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d
+}
+
+func (d *serverErrorDriver) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	return nil, d.err
+}
+
+type stoppingDriver struct {
+	*fakeDriver
+	stop  chan struct{}
+	after int
+}
+
+func (d *stoppingDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d
+}
+
+func (d *stoppingDriver) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	if d.after == 0 {
+		close(d.stop)
+		return nil, fmt.Errorf("connection closed")
+	}
+	d.after--
+	return d.fakeDriver.WriteTransaction(work, configurers...)
+}
+
+// Runs transaction functions against a fakeTransaction, so that statements go through the worker the way they do
+// against a server
+type statementDriver struct {
+	*fakeDriver
+	transactions []*fakeTransaction
+}
+
+func (d *statementDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d
+}
+
+func (d *statementDriver) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (interface{}, error) {
+	tx := &fakeTransaction{clock: d.clock, latency: d.minLatency}
+	d.transactions = append(d.transactions, tx)
+	return work(tx)
+}
+
+func (d *statementDriver) BeginTransaction(configurers ...func(*neo4j.TransactionConfig)) (neo4j.Transaction, error) {
+	tx := &fakeTransaction{clock: d.clock, latency: d.minLatency}
+	d.transactions = append(d.transactions, tx)
+	return tx, nil
+}
+
+type fakeTransaction struct {
+	clock      *fakeSpaceTimeContinuum
+	latency    time.Duration
+	statements int
+	rolledBack bool
+}
+
+func (tx *fakeTransaction) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	tx.clock.sleep(tx.latency)
+	tx.statements++
+	return &fakeResult{}, nil
+}
+
+func (tx *fakeTransaction) Commit() error {
+	return nil
+}
+
+func (tx *fakeTransaction) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func (tx *fakeTransaction) Close() error {
+	return nil
+}
+
+// A result without records or summary
+type fakeResult struct{}
+
+func (r *fakeResult) Keys() ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeResult) Next() bool {
+	return false
+}
+
+func (r *fakeResult) NextRecord(record **neo4j.Record) bool {
+	return false
+}
+
+func (r *fakeResult) Err() error {
+	return nil
+}
+
+func (r *fakeResult) Record() *neo4j.Record {
+	return nil
+}
+
+func (r *fakeResult) Collect() ([]*neo4j.Record, error) {
+	return nil, nil
+}
+
+func (r *fakeResult) Single() (*neo4j.Record, error) {
+	return nil, fmt.Errorf("no records")
+}
+
+func (r *fakeResult) Consume() (neo4j.ResultSummary, error) {
+	return nil, nil
+}
+
+func TestDiscardLeavesWarmupOutOfResults(t *testing.T) {
+	start := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+	recorder := NewResultRecorder(0)
+	recorder.Begin(start)
+	assert.NoError(t, recorder.record("warm", "", time.Second, uowOutcome{succeeded: true}))
+
+	recorder.Discard(start.Add(30 * time.Second))
+	assert.NoError(t, recorder.record("warm", "", time.Millisecond, uowOutcome{succeeded: true}))
+	assert.NoError(t, recorder.record("warm", "", time.Millisecond, uowOutcome{succeeded: true}))
+
+	result := recorder.Complete(start.Add(40 * time.Second))
+	assert.Equal(t, int64(2), result.Scripts["warm"].Succeeded)
+	assert.Equal(t, int64(1), result.Scripts["warm"].Latencies.Max()/1000)
+	// Rates are over the time since warmup ended
+	assert.InDelta(t, 0.2, result.Scripts["warm"].Rate, 0.001)
+}
+
+func TestSleepInTransactionFollowsTheWorkerClockAndEndsWhenStopped(t *testing.T) {
+	script, err := Parse("locks", `
+RETURN 1;
+:sleep 60 s in transaction
+RETURN 2;`, 1)
+	assert.NoError(t, err)
+	start := time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)
+	clock := &fakeSpaceTimeContinuum{currentTime: start}
+	r := rand.New(rand.NewSource(1337))
+	driver := &statementDriver{fakeDriver: &fakeDriver{clock: clock, r: r, minLatency: time.Millisecond, maxLatency: time.Millisecond}}
+	w := Worker{workerId: 0, driver: driver, now: clock.now, sleep: clock.sleep}
+
+	result := w.RunBenchmark(ClientWorkload{Scripts: NewScripts(script), Rand: r}, "", 0, Burst{}, 1, make(chan struct{}), NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, start.Add(60*time.Second+2*time.Millisecond), clock.now())
+
+	// Asked to stop while sleeping, the sleep ends at the next check rather than holding the run up for a minute
+	stopCh := make(chan struct{})
+	w.sleep = func(duration time.Duration) {
+		clock.sleep(duration)
+		select {
+		case <-stopCh:
+		default:
+			close(stopCh)
+		}
+	}
+	sleepStart := clock.now()
+	result = w.RunBenchmark(ClientWorkload{Scripts: NewScripts(script), Rand: r}, "", 0, Burst{}, 0, stopCh, NewResultRecorder(0))
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, sleepStart.Add(stopPollInterval+2*time.Millisecond), clock.now())
+}
@@ -3,6 +3,7 @@ package neobench
 import (
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"os"
 	"sort"
@@ -16,6 +17,13 @@ import (
 // Useful for creating sharded workloads or other logic that tie in session-esque concepts
 const WorkerIdVar = "nbWorkerId"
 
+// Number of workers running the workload, so scripts can divide work between them, see WorkerIdVar
+const WorkersVar = "nbWorkers"
+
+// The virtual user a unit of work runs as, and how many there are, see UserState
+const UserIdVar = "nbUserId"
+const UsersVar = "nbUsers"
+
 type Workload struct {
 	// set on command line and built in
 	Variables map[string]interface{}
@@ -24,6 +32,14 @@ type Workload struct {
 
 	Rand      *rand.Rand
 	CsvLoader *CsvLoader
+	// When the run started, for picking scripts by the phase the run is in, see Scripts#Phases
+	Start time.Time
+	// Ids in the ldbc-like dataset, for ldbc_random_message; nil when not running against a database
+	LDBCIds *LDBCIdLoader
+	// Values scripts remember(..) and recall(..), shared by all clients
+	SharedState *SharedState
+	// Attached to every transaction the workload runs, eg. from --tx-metadata
+	TxMetadata map[string]interface{}
 }
 
 // Scripts in a workload, and utilities to draw a weighted random script
@@ -33,6 +49,10 @@ type Scripts struct {
 	// Lookup table for choice of scripts; one entry for each script, each entry records the cumulative
 	// weight of that script and all scripts before it in the array. See Choose() for details
 	WeightedLookup *WeightedRandom
+	// Periods of the run with their own script weights, in order; outside of these the weights above apply
+	Phases []WeightPhase
+	// Groups of scripts to report on together, see Lane
+	Lanes []Lane
 }
 
 func NewScripts(scripts ...Script) Scripts {
@@ -51,6 +71,71 @@ func (s *Scripts) Choose(r *rand.Rand) Script {
 	return s.WeightedLookup.Draw(r).(Script)
 }
 
+// Like Choose, but using the weights of the phase the run is in after the given time; also returns the name of
+// that phase, empty if the run is not in any phase
+func (s *Scripts) ChooseAt(r *rand.Rand, elapsed time.Duration) (Script, string) {
+	for _, phase := range s.Phases {
+		if elapsed >= phase.Start && elapsed < phase.End {
+			return phase.lookup.Draw(r).(Script), phase.Name
+		}
+	}
+	return s.Choose(r), ""
+}
+
+// Compares the share of executions each script got in the given result to the share its weight asked for.
+// Scripts whose achieved share is more than driftThreshold away from the target share are marked as drifted;
+// this happens eg. in throughput mode when one slow script starves the others.
+func (s *Scripts) Mix(result Result, driftThreshold float64) []MixEntry {
+	weights := make(map[string]float64)
+	names := make([]string, 0, len(s.Scripts))
+	for _, script := range s.Scripts {
+		if _, found := weights[script.Name]; !found {
+			names = append(names, script.Name)
+		}
+		weights[script.Name] += script.Weight
+	}
+	return mix(names, weights, result.Scripts, driftThreshold)
+}
+
+// Compares achieved shares in the given script results to the shares the given weights ask for, see Scripts#Mix
+func mix(names []string, weights map[string]float64, scripts map[string]*ScriptResult, driftThreshold float64) []MixEntry {
+	totalWeight := 0.0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	totalExecuted := int64(0)
+	for _, script := range scripts {
+		totalExecuted += script.Succeeded + script.Failed
+	}
+	if totalWeight == 0 || totalExecuted == 0 {
+		return nil
+	}
+
+	out := make([]MixEntry, 0, len(names))
+	for _, name := range names {
+		executed := int64(0)
+		if scriptResult, found := scripts[name]; found {
+			executed = scriptResult.Succeeded + scriptResult.Failed
+		}
+		entry := MixEntry{
+			ScriptName:    name,
+			TargetShare:   weights[name] / totalWeight,
+			AchievedShare: float64(executed) / float64(totalExecuted),
+		}
+		entry.Drifted = math.Abs(entry.AchievedShare-entry.TargetShare) > driftThreshold
+		out = append(out, entry)
+	}
+	return out
+}
+
+// Target vs achieved share of all executed transactions for one script, see Scripts#Mix
+type MixEntry struct {
+	ScriptName    string
+	TargetShare   float64
+	AchievedShare float64
+	Drifted       bool
+}
+
 // List of items that can be randomly drawn from; each item has a weight determining its probability to be drawn
 type WeightedRandom struct {
 	// See draw(..)
@@ -108,6 +193,20 @@ type Script struct {
 	Weight     float64
 	Commands   []Command
 	Autocommit bool
+	// Transaction metadata set with `:metadata`; these are evaluated before the transaction starts,
+	// since the metadata is sent when the transaction begins
+	Metadata []MetadataEntry
+	// What to do when a unit of work of this script fails, set with `:on-error`
+	OnError ErrorPolicy
+	// How the sessions the script runs in are set up, see SessionOptions
+	Session SessionOptions
+	// How much of the results of its statements the script reads, one of the Consume constants, set with `:consume`
+	Consume string
+}
+
+type MetadataEntry struct {
+	Key        string
+	Expression Expression
 }
 
 // Context that scripts are executed in; these are not thread safe, and are re-created on each script
@@ -120,68 +219,271 @@ type ScriptContext struct {
 	Vars          map[string]interface{}
 	Rand          *rand.Rand
 	CsvLoader     *CsvLoader
+	LDBCIds       *LDBCIdLoader
+	// Nil in preflights, which must not remember values, since their statements don't run
+	SharedState *SharedState
+	// Database statements are sent to, changed by `:use`; empty means the database the workload runs against
+	Database string
+	// Metadata attached to every transaction, in addition to what the script sets with `:metadata`
+	TxMetadata map[string]interface{}
+	// Variables kept across the units of work of the virtual user this runs as, see `:user`; nil in preflights
+	User *UserState
+	// Carries out `:sleep ... in transaction`; workers set this so the sleep follows their clock and ends early when
+	// the run is stopped. Nil sleeps on the wall clock.
+	Sleep func(duration time.Duration)
 }
 
-// Evaluate this script in the given context
+// Evaluate this script in the given context, all commands are evaluated up front
 func (s *Script) Eval(ctx ScriptContext) (UnitOfWork, error) {
-	uow := UnitOfWork{
+	uow := s.NewUnitOfWork(ctx)
+	err := uow.Run(func(Statement) error { return nil })
+	uow.script = nil
+	if scriptErr, ok := err.(*ScriptError); ok {
+		err = scriptErr.Err
+	}
+	return uow, err
+}
+
+// Create a unit of work that evaluates this script lazily; nothing is evaluated until you call Run on it
+func (s *Script) NewUnitOfWork(ctx ScriptContext) UnitOfWork {
+	return UnitOfWork{
 		ScriptName: s.Name,
 		Readonly:   s.Readonly,
 		Autocommit: s.Autocommit,
+		Segmented:  s.isSegmented(),
+		OnError:    s.OnError,
+		Session:    s.Session,
+		Consume:    s.Consume,
 		Statements: nil,
+		script:     s,
+		ctx:        ctx,
 	}
+}
 
+// True if the statements in this script can't all run in one transaction; see UnitOfWork#Segmented
+func (s *Script) isSegmented() bool {
 	for _, cmd := range s.Commands {
-		if err := cmd.Execute(&ctx, &uow); err != nil {
-			return uow, err
+		switch cmd := cmd.(type) {
+		case QueryCommand:
+			if cmd.Autocommit || cmd.Readback {
+				return true
+			}
+		case UseCommand:
+			return true
 		}
 	}
-
-	return uow, nil
+	return false
 }
 
 func (s *Workload) NewClient() ClientWorkload {
 	return ClientWorkload{
-		Variables: s.Variables,
-		Scripts:   s.Scripts,
-		Rand:      rand.New(rand.NewSource(s.Rand.Int63())),
-		Stderr:    os.Stderr,
-		CsvLoader: s.CsvLoader,
+		Start:       s.Start,
+		Variables:   s.Variables,
+		Scripts:     s.Scripts,
+		Rand:        rand.New(rand.NewSource(s.Rand.Int63())),
+		Stderr:      os.Stderr,
+		CsvLoader:   s.CsvLoader,
+		LDBCIds:     s.LDBCIds,
+		SharedState: s.SharedState,
+		TxMetadata:  s.TxMetadata,
 	}
 }
 
 type ClientWorkload struct {
 	Readonly bool
+	// When the run started, see Scripts#Phases
+	Start time.Time
 	// variables set on command line and built-in
-	Variables map[string]interface{}
-	Scripts   Scripts
-	Rand      *rand.Rand
-	Stderr    io.Writer
-	CsvLoader *CsvLoader
+	Variables   map[string]interface{}
+	Scripts     Scripts
+	Rand        *rand.Rand
+	Stderr      io.Writer
+	CsvLoader   *CsvLoader
+	LDBCIds     *LDBCIdLoader
+	SharedState *SharedState
+	TxMetadata  map[string]interface{}
+	// Virtual users this client takes turns running as, see SetVirtualUsers; without, the client is a user of its own
+	Users    []*UserState
+	nextUser int
+	// Clock phases are measured on; workers set this to theirs, nil is the wall clock
+	now func() time.Time
 }
 
+// Picks the next script to run; the returned unit of work is lazy, the script is evaluated as you call Run on it
 func (s *ClientWorkload) Next(workerId int64) (UnitOfWork, error) {
-	script := s.Scripts.Choose(s.Rand)
-	return script.Eval(ScriptContext{
-		Script:    script,
-		Stderr:    s.Stderr,
-		Vars:      createVars(s.Variables, workerId),
-		Rand:      s.Rand,
-		CsvLoader: s.CsvLoader,
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	script, phase := s.Scripts.ChooseAt(s.Rand, now().Sub(s.Start))
+	user := s.takeUser(workerId)
+	vars := createVars(s.Variables, workerId)
+	user.apply(vars)
+	uow := script.NewUnitOfWork(ScriptContext{
+		Script:      script,
+		Stderr:      s.Stderr,
+		Vars:        vars,
+		Rand:        s.Rand,
+		CsvLoader:   s.CsvLoader,
+		LDBCIds:     s.LDBCIds,
+		SharedState: s.SharedState,
+		TxMetadata:  s.TxMetadata,
+		User:        user,
 	})
+	uow.Phase = phase
+	return uow, nil
 }
 
 type UnitOfWork struct {
 	// Path to user-provided script, or builtin:<name>
 	ScriptName string
-	Readonly   bool
+	// Name of the weight phase the script was picked in, empty if none; see Scripts#Phases
+	Phase    string
+	Readonly bool
+	// Statements produced by the most recent evaluation of the script
 	Statements []Statement
 	Autocommit bool
+	// Set if the statements can't all run in one transaction, because some are marked with `:autocommit` or
+	// target other databases via `:use`. The statements are then run in a series of transactions instead.
+	Segmented bool
+	// Client-side sleep requested by :sleep commands in the most recent evaluation; this is
+	// carried out by the worker, outside of the transaction
+	Sleep time.Duration
+	// If set, Run times each `:set` and statement it evaluates, adding them to CommandCosts; see --profile-scripts
+	TimeCommands bool
+	// How long each command took to evaluate, over every call to Run, with TimeCommands set
+	CommandCosts []CommandCost
+	// If set, Run adds up the approximate size of the parameters of the statements it evaluates in ParamBytes, and
+	// fails once they add up to more than ParamLimit, if that is set; see ParamSizeGuard
+	MeasureParams bool
+	ParamLimit    int64
+	ParamBytes    int64
+	// What to do if the unit of work fails, see ErrorPolicy
+	OnError ErrorPolicy
+	// How the sessions the unit of work runs in are set up
+	Session SessionOptions
+	// How much of the results of its statements the unit of work reads, see Script#Consume
+	Consume string
+
+	// Set if this unit of work is lazily evaluated, see Run
+	script *Script
+	ctx    ScriptContext
+	// Variables as the most recent call to Run left them, for the fallback statement, see FallbackStatement
+	lastVars map[string]interface{}
+}
+
+// Evaluates the unit of work, calling onStatement for each statement in order. Commands are evaluated one
+// at a time as this runs, so `:set` commands placed between two statements are evaluated after the first
+// statement has executed and right before the second one does. Each call starts over from the top of the
+// script with fresh variables, so this can be called again if a transaction is retried.
+//
+// If onStatement returns an error, evaluation stops and that error is returned.
+func (u *UnitOfWork) Run(onStatement func(Statement) error) error {
+	if u.script == nil {
+		// Already evaluated, eg. via Script#Eval
+		for _, stmt := range u.Statements {
+			if err := onStatement(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx := u.ctx
+	ctx.Vars = make(map[string]interface{}, len(u.ctx.Vars))
+	for k, v := range u.ctx.Vars {
+		ctx.Vars[k] = v
+	}
+	u.lastVars = ctx.Vars
+	u.Statements = u.Statements[:0]
+	u.Sleep = 0
+	u.ParamBytes = 0
+
+	for index, cmd := range u.script.Commands {
+		produced := len(u.Statements)
+		start := time.Time{}
+		if u.TimeCommands {
+			start = time.Now()
+		}
+		if err := cmd.Execute(&ctx, u); err != nil {
+			return &ScriptError{Err: err}
+		}
+		if u.TimeCommands {
+			if label := commandLabel(cmd, len(u.Statements)); label != "" {
+				u.CommandCosts = append(u.CommandCosts, CommandCost{Index: index, Label: label, Duration: time.Since(start)})
+			}
+		}
+		for _, stmt := range u.Statements[produced:] {
+			if u.MeasureParams {
+				u.ParamBytes += valueSize(stmt.Params)
+				if u.ParamLimit > 0 && u.ParamBytes > u.ParamLimit {
+					return &ScriptError{Err: fmt.Errorf("parameters add up to %s by statement %d, over the --param-size-limit of %s",
+						formatBytes(u.ParamBytes), len(u.Statements), formatBytes(u.ParamLimit))}
+				}
+			}
+			if err := onStatement(stmt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluates the transaction metadata for this unit of work; this is the workload-wide metadata combined with
+// anything the script sets via `:metadata`, the latter taking precedence. Returns nil if there is no metadata.
+func (u *UnitOfWork) Metadata() (map[string]interface{}, error) {
+	var entries []MetadataEntry
+	if u.script != nil {
+		entries = u.script.Metadata
+	}
+	if len(u.ctx.TxMetadata) == 0 && len(entries) == 0 {
+		return nil, nil
+	}
+
+	out := make(map[string]interface{}, len(u.ctx.TxMetadata)+len(entries))
+	for k, v := range u.ctx.TxMetadata {
+		out[k] = v
+	}
+	ctx := u.ctx
+	ctx.Vars = make(map[string]interface{}, len(u.ctx.Vars))
+	for k, v := range u.ctx.Vars {
+		ctx.Vars[k] = v
+	}
+	for _, entry := range entries {
+		value, err := entry.Expression.Eval(&ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to evaluate :metadata %s", entry.Key)
+		}
+		out[entry.Key] = value
+	}
+	return out, nil
+}
+
+// Returned by UnitOfWork#Run when the script itself failed to evaluate, as opposed to a statement failing
+type ScriptError struct {
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
 }
 
 type Statement struct {
 	Query  string
 	Params map[string]interface{}
+	// If set, this statement runs as an auto-commit transaction of its own, outside of the
+	// transaction the other statements in the script run in
+	Autocommit bool
+	// Database to run this statement against; empty means the database the workload runs against
+	Database string
+	// If set, this statement checks that the writes before it are visible to readers: it runs after those are
+	// committed, in a read session of its own, and must return a single row with true in its first column.
+	// Anything else counts as a stale read.
+	Readback bool
 }
 
 type Command interface {
@@ -194,6 +496,10 @@ type QueryCommand struct {
 	RemoteParams []string
 	// Locally substituted parameters
 	LocalParams []string
+	// Run as an auto-commit transaction of its own, see `:autocommit`
+	Autocommit bool
+	// Read back what was written, see `:readback`
+	Readback bool
 }
 
 func (c QueryCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
@@ -212,8 +518,11 @@ func (c QueryCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
 		}
 	}
 	uow.Statements = append(uow.Statements, Statement{
-		Query:  query,
-		Params: params,
+		Query:      query,
+		Params:     params,
+		Autocommit: c.Autocommit,
+		Readback:   c.Readback,
+		Database:   ctx.Database,
 	})
 	return nil
 }
@@ -266,9 +575,47 @@ func (c SetCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
 	return nil
 }
 
+// Like SetCommand, but only evaluated the first time the virtual user gets to it; the value is kept, and every
+// later unit of work of that user starts out with it
+type UserCommand struct {
+	VarName    string
+	Expression Expression
+}
+
+func (c UserCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
+	if ctx.User != nil {
+		if value, found := ctx.User.Vars[c.VarName]; found {
+			ctx.Vars[c.VarName] = value
+			return nil
+		}
+	}
+	value, err := c.Expression.Eval(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Vars[c.VarName] = value
+	if ctx.User != nil {
+		ctx.User.Vars[c.VarName] = value
+	}
+	return nil
+}
+
+// Switches the database the statements that follow are sent to
+type UseCommand struct {
+	Database string
+}
+
+func (c UseCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
+	ctx.Database = c.Database
+	return nil
+}
+
 type SleepCommand struct {
 	Duration Expression
 	Unit     time.Duration
+	// Sleep right away, while the transaction is open, rather than after it completes. This is
+	// used to simulate application processing time while holding locks.
+	InTransaction bool
 }
 
 func (c SleepCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
@@ -285,35 +632,60 @@ func (c SleepCommand) Execute(ctx *ScriptContext, uow *UnitOfWork) error {
 		return nil
 	}
 
-	time.Sleep(time.Duration(sleepInt) * c.Unit)
+	if c.InTransaction {
+		sleep := ctx.Sleep
+		if sleep == nil {
+			sleep = time.Sleep
+		}
+		sleep(time.Duration(sleepInt) * c.Unit)
+		return nil
+	}
+	uow.Sleep += time.Duration(sleepInt) * c.Unit
 	return nil
 }
 
-// Validates that a workload doesn't have syntax errors etc, and tells us if it is read-only
+// Validates that a workload doesn't have syntax errors etc, and tells us if it is read-only, what parameters it
+// sends and what the planner estimates it'll cost. This only runs EXPLAIN, so it uses read sessions, which lets it
+// run against read replicas and analytics endpoints that won't take writes.
 func WorkloadPreflight(driver neo4j.Driver, dbName string, script Script, vars map[string]interface{},
-	csvLoader *CsvLoader) (readonly bool, err error) {
+	csvLoader *CsvLoader, ldbcIds *LDBCIdLoader) (PreflightResult, error) {
+	if script.Session.Database != "" {
+		// Scripts that pick their database with `:db` are checked against that instead
+		dbName = script.Session.Database
+	}
 	session := driver.NewSession(neo4j.SessionConfig{
-		AccessMode:   neo4j.AccessModeWrite,
+		AccessMode:   neo4j.AccessModeRead,
 		DatabaseName: dbName,
 	})
 	defer session.Close()
 
 	r := rand.New(rand.NewSource(1337))
 
-	unitOfWork, err := script.Eval(ScriptContext{
+	ctx := ScriptContext{
 		PreflightMode: true,
 		Script:        script,
 		Stderr:        os.Stderr,
 		Vars:          createVars(vars, 0),
 		Rand:          r,
 		CsvLoader:     csvLoader,
-	})
+		LDBCIds:       ldbcIds,
+	}
+	result := PreflightResult{ScriptName: script.Name}
+	uow := script.NewUnitOfWork(ctx)
+	if _, err := uow.Metadata(); err != nil {
+		return result, errors.Wrapf(err, "script '%s' failed preflight checks", script.Name)
+	}
+	unitOfWork, err := script.Eval(ctx)
 	if err != nil {
-		return false, err
+		return result, errors.Wrapf(err, "script '%s' failed preflight checks", script.Name)
 	}
+	result.Parameters = statementParameters(unitOfWork.Statements)
 	readonlyRaw, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
 		readonly := true
 		for _, stmt := range unitOfWork.Statements {
+			if stmt.Autocommit || stmt.Database != "" {
+				continue
+			}
 			res, err := tx.Run(fmt.Sprintf("EXPLAIN %s", stmt.Query), stmt.Params)
 			if err != nil {
 				return false, err
@@ -323,20 +695,70 @@ func WorkloadPreflight(driver neo4j.Driver, dbName string, script Script, vars m
 				return false, err
 			}
 			readonly = summary.StatementType() == neo4j.StatementTypeReadOnly && readonly
+			result.EstimatedRows += planEstimatedRows(summary.Plan())
+			result.Scans = append(result.Scans, planScans(stmt.Query, summary.Plan())...)
 		}
 
 		return readonly, nil
 	})
 	if err != nil {
-		return false, errors.Wrapf(err, "script '%s' failed preflight checks", script.Name)
+		return result, errors.Wrapf(err, "script '%s' failed preflight checks", script.Name)
+	}
+	result.Readonly = readonlyRaw.(bool)
+	for _, stmt := range unitOfWork.Statements {
+		if stmt.Readback {
+			// Reading back happens in sessions of its own, after the rest of the script has committed
+			result.Readonly = false
+		}
+	}
+
+	// Statements that run on their own or against other databases can't share a read transaction with the rest
+	// of the script. Queries like CALL { .. } IN TRANSACTIONS are rejected in explicit transactions, even with
+	// EXPLAIN, so these are explained as auto-commit queries, each in the database it targets.
+	for _, stmt := range unitOfWork.Statements {
+		if !stmt.Autocommit && stmt.Database == "" {
+			continue
+		}
+		result.Readonly = false
+		if stmt.Database == "system" {
+			// Administration commands can't generally be explained
+			continue
+		}
+		plan, err := explainAutocommit(driver, dbName, stmt)
+		if err != nil {
+			return result, errors.Wrapf(err, "script '%s' failed preflight checks", script.Name)
+		}
+		result.EstimatedRows += planEstimatedRows(plan)
+		result.Scans = append(result.Scans, planScans(stmt.Query, plan)...)
+	}
+	return result, nil
+}
+
+func explainAutocommit(driver neo4j.Driver, dbName string, stmt Statement) (neo4j.Plan, error) {
+	if stmt.Database != "" {
+		dbName = stmt.Database
+	}
+	session := driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		DatabaseName: dbName,
+	})
+	defer session.Close()
+	res, err := session.Run(fmt.Sprintf("EXPLAIN %s", stmt.Query), stmt.Params)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := res.Consume()
+	if err != nil {
+		return nil, err
 	}
-	readonly = readonlyRaw.(bool)
-	return
+	return summary.Plan(), nil
 }
 
 func createVars(globalVars map[string]interface{}, workerId int64) map[string]interface{} {
 	vars := make(map[string]interface{})
 	vars[WorkerIdVar] = workerId
+	// Each worker is a user of its own, unless there are virtual users, see ClientWorkload#SetVirtualUsers
+	vars[UserIdVar] = workerId
 	for k, v := range globalVars {
 		vars[k] = v
 	}
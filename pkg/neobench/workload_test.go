@@ -1,6 +1,7 @@
 package neobench
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
 	"testing"
@@ -48,3 +49,109 @@ func TestChooseWeightedWorkload(t *testing.T) {
 	assert.InDelta(t, b.Weight, bNorm, maxDiffOnB, "seed=%d", seed)
 	assert.InDelta(t, c.Weight, cNorm, maxDiffOnC, "seed=%d", seed)
 }
+
+func TestMixDetectsDrift(t *testing.T) {
+	scripts := NewScripts(
+		Script{Name: "a", Weight: 1},
+		Script{Name: "b", Weight: 3},
+	)
+	result := NewResult("", "")
+	result.Scripts["a"] = &ScriptResult{ScriptName: "a", Succeeded: 45, Failed: 5}
+	result.Scripts["b"] = &ScriptResult{ScriptName: "b", Succeeded: 50}
+
+	mix := scripts.Mix(result, 0.05)
+
+	assert.Equal(t, []MixEntry{
+		{ScriptName: "a", TargetShare: 0.25, AchievedShare: 0.5, Drifted: true},
+		{ScriptName: "b", TargetShare: 0.75, AchievedShare: 0.5, Drifted: true},
+	}, mix)
+	assert.False(t, scripts.Mix(result, 0.3)[0].Drifted)
+}
+
+func TestUnitOfWorkEvaluatesCommandsBetweenStatements(t *testing.T) {
+	script, err := Parse("lazy", `
+:set x debug(1)
+RETURN $x;
+:set x debug(2)
+:sleep 3 ms
+RETURN $x;`, 1)
+	assert.NoError(t, err)
+
+	trace := bytes.NewBuffer(nil)
+	uow := script.NewUnitOfWork(ScriptContext{
+		Stderr: trace,
+		Vars:   map[string]interface{}{},
+		Rand:   rand.New(rand.NewSource(1337)),
+	})
+
+	// Nothing is evaluated until the unit of work runs
+	assert.Equal(t, "", trace.String())
+
+	var params []interface{}
+	err = uow.Run(func(s Statement) error {
+		trace.WriteString("stmt\n")
+		params = append(params, s.Params["x"])
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "1\nstmt\n2\nstmt\n", trace.String())
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, params)
+	assert.Equal(t, 3*time.Millisecond, uow.Sleep)
+
+	// Running again, eg. on transaction retry, starts over from the top
+	err = uow.Run(func(s Statement) error { return nil })
+	assert.NoError(t, err)
+	assert.Len(t, uow.Statements, 2)
+	assert.Equal(t, 3*time.Millisecond, uow.Sleep)
+}
+
+func TestSleepInTransactionHappensBetweenStatements(t *testing.T) {
+	script, err := Parse("locks", `
+RETURN 1;
+:sleep 20 ms in transaction
+RETURN 2;`, 1)
+	assert.NoError(t, err)
+
+	clock := &fakeSpaceTimeContinuum{currentTime: time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC)}
+	uow := script.NewUnitOfWork(ScriptContext{
+		Stderr: bytes.NewBuffer(nil),
+		Vars:   map[string]interface{}{},
+		Rand:   rand.New(rand.NewSource(1337)),
+		Sleep:  clock.sleep,
+	})
+
+	var stmtTimes []time.Time
+	err = uow.Run(func(s Statement) error {
+		stmtTimes = append(stmtTimes, clock.now())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, stmtTimes, 2)
+	assert.Equal(t, 20*time.Millisecond, stmtTimes[1].Sub(stmtTimes[0]))
+	// Nothing is left to sleep after the transaction
+	assert.Equal(t, time.Duration(0), uow.Sleep)
+}
+
+func TestMetadataCombinesWorkloadAndScriptMetadata(t *testing.T) {
+	script, err := Parse("tagged", `
+:metadata app "neobench"
+:metadata accountId $aid * 10
+RETURN 1;`, 1)
+	assert.NoError(t, err)
+	assert.Len(t, script.Commands, 1)
+
+	uow := script.NewUnitOfWork(ScriptContext{
+		Stderr:     bytes.NewBuffer(nil),
+		Vars:       map[string]interface{}{"aid": int64(7)},
+		Rand:       rand.New(rand.NewSource(1337)),
+		TxMetadata: map[string]interface{}{"app": "overridden", "team": "perf"},
+	})
+
+	metadata, err := uow.Metadata()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"app":       "neobench",
+		"team":      "perf",
+		"accountId": int64(70),
+	}, metadata)
+}